@@ -38,14 +38,26 @@ const (
 // Symlinks and other special file types such as devices and sockets will cause
 // the function to return [ErrUnsupportedFileType]. If creation fails, the
 // partially written archive is removed.
-func Create(src, dest string) (err error) {
+func Create(src, dest string) error {
+	return CreateWithOptions(src, dest, nil)
+}
+
+// Creates a zstd-compressed tar archive from a directory using options.
+//
+// Same behavior as [Create], relaxed by the given options. When
+// [Options.AllowSymlinks] is set, symlinks are stored as links and their
+// targets must resolve inside src, otherwise [ErrInvalidPath] is returned.
+// When [Options.PreserveExecutable] is set, executable files are stored with
+// [ExecutableFileMode]. Options can be nil, in which case the strict defaults
+// of [Create] apply.
+func CreateWithOptions(src, dest string, options *Options) (err error) {
 	file, err := os.Create(dest)
 	if err != nil {
 		return helpers.Wrap(ErrCreateFailed, err)
 	}
 	defer file.Close()
 
-	zw, err := zstd.NewWriter(file)
+	zw, err := newCompressor(file, options)
 	if err != nil {
 		os.Remove(dest)
 		return helpers.Wrap(ErrCreateFailed, err)
@@ -60,13 +72,24 @@ func Create(src, dest string) (err error) {
 	tw := tar.NewWriter(zw)
 	defer tw.Close()
 
-	if err = writeTar(tw, src); err != nil {
+	if err = writeTar(tw, src, options); err != nil {
 		return helpers.Wrap(ErrCreateFailed, err)
 	}
 
 	return nil
 }
 
+// Creates the zstd writer for an archive.
+//
+// Returns a seekable writer when requested by options, and a standard
+// single-frame zstd writer otherwise.
+func newCompressor(w io.Writer, options *Options) (io.WriteCloser, error) {
+	if options.seekable() {
+		return newSeekableWriter(w)
+	}
+	return zstd.NewWriter(w)
+}
+
 // Extracts a zstd-compressed tar archive to a directory.
 //
 // Files are extracted with [paths.DefaultFileMode] and directories with
@@ -76,19 +99,38 @@ func Create(src, dest string) (err error) {
 // attempts (e.g., "../etc/passwd") return [ErrInvalidPath]. If extraction fails,
 // the destination directory and its contents are removed.
 func Extract(src, dest string) error {
+	return ExtractWithOptions(src, dest, nil)
+}
+
+// Extracts a zstd-compressed tar archive to a directory using options.
+//
+// Same behavior as [Extract], relaxed by the given options. When
+// [Options.AllowSymlinks] is set, symlink entries are recreated if their
+// targets resolve inside dest, and no entry may be written through a link.
+// When [Options.PreserveExecutable] is set, entries with an executable bit are
+// extracted with [ExecutableFileMode]. Options can be nil.
+func ExtractWithOptions(src, dest string, options *Options) error {
 	file, err := os.Open(src)
 	if err != nil {
 		return helpers.Wrap(ErrExtractFailed, err)
 	}
 	defer file.Close()
 
-	return ExtractFromReader(file, dest)
+	return ExtractFromReaderWithOptions(file, dest, options)
 }
 
 // Extracts a zstd-compressed tar archive from a reader to a directory.
 //
 // Same behavior as [Extract] but reads from an [io.Reader] instead of a file.
 func ExtractFromReader(r io.Reader, dest string) error {
+	return ExtractFromReaderWithOptions(r, dest, nil)
+}
+
+// Extracts a zstd-compressed tar archive from a reader using options.
+//
+// Same behavior as [ExtractWithOptions] but reads from an [io.Reader] instead
+// of a file.
+func ExtractFromReaderWithOptions(r io.Reader, dest string, options *Options) error {
 	if _, statErr := os.Stat(dest); statErr == nil {
 		return helpers.Wrap(ErrExtractFailed, os.ErrExist)
 	}
@@ -99,7 +141,7 @@ func ExtractFromReader(r io.Reader, dest string) error {
 	}
 	defer zr.Close()
 
-	err = extractToDirectory(tar.NewReader(zr), dest)
+	err = extractToDirectory(tar.NewReader(zr), dest, options)
 	if err != nil {
 		return helpers.Wrap(ErrExtractFailed, err)
 	}
@@ -111,7 +153,7 @@ func ExtractFromReader(r io.Reader, dest string) error {
 //
 // Creates dest if it doesn't exist. If any error occurs during extraction,
 // dest and all extracted contents are removed.
-func extractToDirectory(tr *tar.Reader, dest string) (err error) {
+func extractToDirectory(tr *tar.Reader, dest string, options *Options) (err error) {
 	if err = os.MkdirAll(dest, DirMode); err != nil {
 		return err
 	}
@@ -121,7 +163,7 @@ func extractToDirectory(tr *tar.Reader, dest string) (err error) {
 		}
 	}()
 
-	if err = readTar(tr, dest); err != nil {
+	if err = readTar(tr, dest, options); err != nil {
 		return err
 	}
 
@@ -131,8 +173,9 @@ func extractToDirectory(tr *tar.Reader, dest string) (err error) {
 // Writes directory contents to a tar writer.
 //
 // Walks src directory recursively and writes each entry to tw. Paths in the
-// archive are relative to src and use forward slashes.
-func writeTar(tw *tar.Writer, src string) error {
+// archive are relative to src and use forward slashes. Symlinks are not
+// followed.
+func writeTar(tw *tar.Writer, src string, options *Options) error {
 	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -147,7 +190,7 @@ func writeTar(tw *tar.Writer, src string) error {
 			return nil
 		}
 
-		return writeEntry(tw, path, relPath, d)
+		return writeEntry(tw, src, path, relPath, d, options)
 	})
 }
 
@@ -155,8 +198,8 @@ func writeTar(tw *tar.Writer, src string) error {
 //
 // Validates file type, creates tar header with normalized path and permissions,
 // and writes file contents for regular files. Returns [ErrUnsupportedFileType]
-// for symlinks and special files.
-func writeEntry(tw *tar.Writer, path, relPath string, d fs.DirEntry) error {
+// for special files, and for symlinks unless allowed by options.
+func writeEntry(tw *tar.Writer, src, path, relPath string, d fs.DirEntry, options *Options) error {
 
 	info, err := d.Info()
 	if err != nil {
@@ -165,7 +208,14 @@ func writeEntry(tw *tar.Writer, path, relPath string, d fs.DirEntry) error {
 
 	mode := info.Mode()
 
-	if mode&os.ModeSymlink != 0 || (!mode.IsRegular() && !mode.IsDir()) {
+	if mode&os.ModeSymlink != 0 {
+		if !options.allowSymlinks() {
+			return ErrUnsupportedFileType
+		}
+		return writeSymlink(tw, src, path, relPath, info)
+	}
+
+	if !mode.IsRegular() && !mode.IsDir() {
 		return ErrUnsupportedFileType
 	}
 
@@ -176,7 +226,7 @@ func writeEntry(tw *tar.Writer, path, relPath string, d fs.DirEntry) error {
 
 	// Override name and mode
 	header.Name = filepath.ToSlash(relPath)
-	header.Mode = int64(FileMode)
+	header.Mode = int64(options.fileMode(mode.Perm()))
 	if info.IsDir() {
 		header.Mode = int64(DirMode)
 	}
@@ -192,6 +242,40 @@ func writeEntry(tw *tar.Writer, path, relPath string, d fs.DirEntry) error {
 	return nil
 }
 
+// Writes a symlink entry to the tar writer.
+//
+// The link target is stored with forward slashes and must resolve inside src,
+// otherwise [ErrInvalidPath] is returned.
+func writeSymlink(tw *tar.Writer, src, path, relPath string, info fs.FileInfo) error {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.ToSlash(relPath)
+	linkname := filepath.ToSlash(target)
+
+	resolved, err := linkDestination(name, linkname)
+	if err != nil {
+		return err
+	}
+
+	if err := checkContained(src, resolved); err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, linkname)
+	if err != nil {
+		return err
+	}
+
+	header.Name = name
+	header.Linkname = linkname
+	header.Mode = int64(ExecutableFileMode)
+
+	return tw.WriteHeader(header)
+}
+
 // Copies file contents from path to w.
 func copyFile(w io.Writer, path string) error {
 
@@ -207,13 +291,18 @@ func copyFile(w io.Writer, path string) error {
 
 // Reads tar entries and extracts them to dest.
 //
-// Validates each entry path for security before extraction. Returns the first
-// error encountered or nil on successful completion.
-func readTar(tr *tar.Reader, dest string) error {
+// Validates each entry path for security before extraction. When symlinks are
+// allowed, entries may not be written through a previously extracted link, and
+// every extracted link is checked again once all entries are in place, since
+// its target may have been created after it. Returns the first error
+// encountered or nil on successful completion.
+func readTar(tr *tar.Reader, dest string, options *Options) error {
+	var links []string
+
 	for {
 		header, err := tr.Next()
 		if errors.Is(err, io.EOF) {
-			return nil
+			break
 		}
 		if err != nil {
 			return err
@@ -224,10 +313,36 @@ func readTar(tr *tar.Reader, dest string) error {
 			return err
 		}
 
-		if err := extractEntry(header, tr, target); err != nil {
+		if options.allowSymlinks() {
+			rel, err := filepath.Rel(dest, target)
+			if err != nil {
+				return err
+			}
+			if err := checkNoSymlinks(dest, rel); err != nil {
+				return err
+			}
+		}
+
+		if err := extractEntry(header, tr, target, options); err != nil {
 			return err
 		}
+
+		if header.Typeflag == tar.TypeSymlink {
+			link, err := linkDestination(header.Name, header.Linkname)
+			if err != nil {
+				return err
+			}
+			links = append(links, link)
+		}
 	}
+
+	for _, link := range links {
+		if err := checkContained(dest, link); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Validates and joins an archive path with the destination directory.
@@ -252,15 +367,21 @@ func validateAndJoinPath(dest, name string) (string, error) {
 
 // Extracts a single tar entry to target.
 //
-// Handles directories and regular files. Returns [ErrUnsupportedFileType]
-// for all other entry types including symlinks.
-func extractEntry(header *tar.Header, tr *tar.Reader, target string) error {
+// Handles directories and regular files, and symlinks when allowed by options.
+// Returns [ErrUnsupportedFileType] for all other entry types.
+func extractEntry(header *tar.Header, tr *tar.Reader, target string, options *Options) error {
 	switch header.Typeflag {
 	case tar.TypeDir:
 		return extractDirectory(target)
 
 	case tar.TypeReg:
-		return extractFile(tr, target)
+		return extractFile(tr, target, options.fileMode(os.FileMode(header.Mode).Perm()))
+
+	case tar.TypeSymlink:
+		if !options.allowSymlinks() {
+			return ErrUnsupportedFileType
+		}
+		return extractSymlink(header, target)
 
 	default:
 		return ErrUnsupportedFileType
@@ -279,9 +400,9 @@ func extractDirectory(target string) error {
 
 // Extracts a regular file from r to target.
 //
-// Creates parent directories as needed, then writes file contents with
-// [paths.DefaultFileMode].
-func extractFile(r io.Reader, target string) error {
+// Creates parent directories as needed, then writes file contents with the
+// given mode, which is either [FileMode] or [ExecutableFileMode].
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
 
 	// Ensure parent directory exists
 	if err := extractDirectory(filepath.Dir(target)); err != nil {
@@ -289,7 +410,7 @@ func extractFile(r io.Reader, target string) error {
 	}
 
 	// Create file
-	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, FileMode)
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
 	if err != nil {
 		return err
 	}
@@ -302,6 +423,24 @@ func extractFile(r io.Reader, target string) error {
 	return nil
 }
 
+// Creates a symlink at target from a tar header.
+//
+// The link target must lexically stay inside the archive root, otherwise
+// [ErrInvalidPath] is returned. Resolution through other links is checked by
+// [readTar] after all entries have been extracted.
+func extractSymlink(header *tar.Header, target string) error {
+	if _, err := linkDestination(header.Name, header.Linkname); err != nil {
+		return err
+	}
+
+	// Ensure parent directory exists
+	if err := extractDirectory(filepath.Dir(target)); err != nil {
+		return err
+	}
+
+	return os.Symlink(filepath.FromSlash(header.Linkname), target)
+}
+
 // FindInTar finds and reads a file from a tar archive.
 //
 // Returns nil if the file is not found. The tar reader is consumed
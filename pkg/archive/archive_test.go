@@ -228,6 +228,231 @@ func TestCreateNestedDirectories(t *testing.T) {
 	assertFileContent(t, filepath.Join(destDir, "a", "b", "c", "deep.txt"), "deep")
 }
 
+func TestCreateDefaultNormalizesExecutable(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "run.sh"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+	if err := Create(srcDir, archivePath); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	destDir := filepath.Join(t.TempDir(), "extracted")
+	if err := ExtractWithOptions(archivePath, destDir, &Options{PreserveExecutable: true}); err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	assertFileMode(t, filepath.Join(destDir, "run.sh"), FileMode)
+}
+
+func TestCreateWithOptionsPreserveExecutable(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "run.sh"), []byte("#!/bin/sh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "data.txt"), []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	options := &Options{PreserveExecutable: true}
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+	if err := CreateWithOptions(srcDir, archivePath, options); err != nil {
+		t.Fatalf("CreateWithOptions failed: %v", err)
+	}
+
+	destDir := filepath.Join(t.TempDir(), "extracted")
+	if err := ExtractWithOptions(archivePath, destDir, options); err != nil {
+		t.Fatalf("ExtractWithOptions failed: %v", err)
+	}
+
+	assertFileMode(t, filepath.Join(destDir, "run.sh"), ExecutableFileMode)
+	assertFileMode(t, filepath.Join(destDir, "data.txt"), FileMode)
+}
+
+func TestExtractDefaultDropsExecutable(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "run.sh"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+	if err := CreateWithOptions(srcDir, archivePath, &Options{PreserveExecutable: true}); err != nil {
+		t.Fatalf("CreateWithOptions failed: %v", err)
+	}
+
+	destDir := filepath.Join(t.TempDir(), "extracted")
+	if err := Extract(archivePath, destDir); err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	assertFileMode(t, filepath.Join(destDir, "run.sh"), FileMode)
+}
+
+func TestCreateWithOptionsRelativeSymlink(t *testing.T) {
+	srcDir := t.TempDir()
+	createTestFiles(t, srcDir)
+
+	if err := os.Symlink("../file.txt", filepath.Join(srcDir, "subdir", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("subdir", filepath.Join(srcDir, "dirlink")); err != nil {
+		t.Fatal(err)
+	}
+
+	options := &Options{AllowSymlinks: true}
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+	if err := CreateWithOptions(srcDir, archivePath, options); err != nil {
+		t.Fatalf("CreateWithOptions failed: %v", err)
+	}
+
+	destDir := filepath.Join(t.TempDir(), "extracted")
+	if err := ExtractWithOptions(archivePath, destDir, options); err != nil {
+		t.Fatalf("ExtractWithOptions failed: %v", err)
+	}
+
+	target, err := os.Readlink(filepath.Join(destDir, "subdir", "link"))
+	if err != nil {
+		t.Fatalf("Readlink failed: %v", err)
+	}
+	if target != "../file.txt" {
+		t.Fatalf("expected link target %q, got %q", "../file.txt", target)
+	}
+
+	assertFileContent(t, filepath.Join(destDir, "subdir", "link"), "hello")
+	assertFileContent(t, filepath.Join(destDir, "dirlink", "nested.txt"), "nested")
+}
+
+func TestCreateWithOptionsEscapingSymlink(t *testing.T) {
+	srcDir := t.TempDir()
+
+	if err := os.Symlink("../outside", filepath.Join(srcDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+	err := CreateWithOptions(srcDir, archivePath, &Options{AllowSymlinks: true})
+
+	if !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("expected ErrInvalidPath, got: %v", err)
+	}
+
+	if _, statErr := os.Stat(archivePath); statErr == nil {
+		t.Fatal("archive should be removed on failure")
+	}
+}
+
+func TestCreateWithOptionsAbsoluteSymlink(t *testing.T) {
+	srcDir := t.TempDir()
+
+	target := filepath.Join(srcDir, "target.txt")
+	if err := os.WriteFile(target, []byte("target"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(srcDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+	err := CreateWithOptions(srcDir, archivePath, &Options{AllowSymlinks: true})
+
+	if !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("expected ErrInvalidPath, got: %v", err)
+	}
+}
+
+func TestCreateWithOptionsChainedSymlinkEscape(t *testing.T) {
+	srcDir := t.TempDir()
+
+	// Each link is lexically local, but together they resolve outside src
+	if err := os.Symlink(".", filepath.Join(srcDir, "self")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(srcDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../self/../outside", filepath.Join(srcDir, "sub", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../sub", filepath.Join(srcDir, "sub", "up")); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+	err := CreateWithOptions(srcDir, archivePath, &Options{AllowSymlinks: true})
+
+	if !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("expected ErrInvalidPath, got: %v", err)
+	}
+}
+
+func TestExtractSymlinkRejectedByDefault(t *testing.T) {
+	destDir := filepath.Join(t.TempDir(), "extracted")
+	data := createCraftedArchive(t, []*tar.Header{
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "file.txt", Mode: 0777},
+	})
+
+	err := ExtractFromReader(data, destDir)
+	if !errors.Is(err, ErrUnsupportedFileType) {
+		t.Fatalf("expected ErrUnsupportedFileType, got: %v", err)
+	}
+}
+
+func TestExtractWithOptionsEscapingSymlink(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+	}{
+		{
+			name: "parent traversal",
+			headers: []*tar.Header{
+				{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../outside", Mode: 0777},
+			},
+		},
+		{
+			name: "absolute target",
+			headers: []*tar.Header{
+				{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd", Mode: 0777},
+			},
+		},
+		{
+			name: "chained links",
+			headers: []*tar.Header{
+				{Name: "self", Typeflag: tar.TypeSymlink, Linkname: ".", Mode: 0777},
+				{Name: "sub/", Typeflag: tar.TypeDir, Mode: 0755},
+				{Name: "sub/link", Typeflag: tar.TypeSymlink, Linkname: "../self/../outside", Mode: 0777},
+				{Name: "sub/up", Typeflag: tar.TypeSymlink, Linkname: "../sub", Mode: 0777},
+			},
+		},
+		{
+			name: "write through link",
+			headers: []*tar.Header{
+				{Name: "dir", Typeflag: tar.TypeSymlink, Linkname: "real", Mode: 0777},
+				{Name: "dir/file.txt", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destDir := filepath.Join(t.TempDir(), "extracted")
+			data := createCraftedArchive(t, tt.headers)
+
+			err := ExtractFromReaderWithOptions(data, destDir, &Options{AllowSymlinks: true})
+			if !errors.Is(err, ErrInvalidPath) {
+				t.Fatalf("expected ErrInvalidPath, got: %v", err)
+			}
+
+			if _, statErr := os.Lstat(destDir); statErr == nil {
+				t.Fatal("destination should not exist after failed extraction")
+			}
+		})
+	}
+}
+
 func createTestFiles(t *testing.T, dir string) {
 	t.Helper()
 
@@ -313,3 +538,44 @@ func createMaliciousArchive(t *testing.T, maliciousPath string) *bytes.Reader {
 
 	return bytes.NewReader(buf.Bytes())
 }
+
+func assertFileMode(t *testing.T, path string, expected os.FileMode) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat %s: %v", path, err)
+	}
+
+	if info.Mode().Perm() != expected {
+		t.Fatalf("expected mode %v, got %v", expected, info.Mode().Perm())
+	}
+}
+
+func createCraftedArchive(t *testing.T, headers []*tar.Header) *bytes.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tw := tar.NewWriter(zw)
+
+	for _, header := range headers {
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(buf.Bytes())
+}
@@ -1,9 +1,23 @@
 // Package archive provides functions for creating and extracting zstd-compressed
 // tar archive.
 //
-// Archives are compressed using zstd. Only regular files and directories are
-// supported; symlinks and special files (devices, sockets, named pipes) are
-// rejected with [ErrUnsupportedFileType].
+// Archives are compressed using zstd. By default, only regular files and
+// directories are supported; symlinks and special files (devices, sockets,
+// named pipes) are rejected with [ErrUnsupportedFileType], and permissions are
+// normalized to [FileMode] and [DirMode].
+//
+// The strict defaults can be relaxed with [Options], passed to
+// [CreateWithOptions], [ExtractWithOptions] and [ExtractFromReaderWithOptions].
+// [Options.PreserveExecutable] keeps the executable bit on files, and
+// [Options.AllowSymlinks] accepts relative symlinks whose targets resolve inside
+// the archive root. Both checks apply on creation and on extraction, so an
+// archive built elsewhere cannot bypass them. Special files are always
+// rejected.
+//
+// Archives can be inspected without extraction through [Reader], which
+// implements [io/fs.FS] on top of a compressed archive. Archives written with
+// [Options.Seekable] use the zstd seekable format, so the reader decompresses
+// only the frames holding the requested file.
 //
 // Example:
 //
@@ -26,4 +40,19 @@
 //	if err != nil {
 //		log.Fatal(err)
 //	}
+//
+//	// Keep executable scripts and contained symlinks
+//	opts := &archive.Options{PreserveExecutable: true, AllowSymlinks: true}
+//	err = archive.CreateWithOptions("mydir", "output.tar.zst", opts)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	// Read a single file without extracting
+//	r, err := archive.OpenReader("output.tar.zst")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer r.Close()
+//	data, err := fs.ReadFile(r, "dist/index.js")
 package archive
@@ -0,0 +1,72 @@
+package archive
+
+import "os"
+
+const (
+
+	// Permission mode used for executable files when [Options.PreserveExecutable]
+	// is set.
+	//
+	// Files whose owner, group, or other executable bit is set are stored and
+	// extracted with this mode instead of [FileMode].
+	ExecutableFileMode os.FileMode = 0755
+)
+
+// Options for creating and extracting archives.
+//
+// The zero value (and a nil *Options) selects the strict default behavior:
+// every file is normalized to [FileMode], every directory to [DirMode], and
+// symlinks are rejected with [ErrUnsupportedFileType]. Each option relaxes one
+// of these rules and must be enabled explicitly by the caller.
+type Options struct {
+
+	// Keeps the executable bit on regular files.
+	//
+	// When set, files with any executable bit are normalized to
+	// [ExecutableFileMode] rather than [FileMode]. Other permission bits are
+	// still discarded, so the resulting mode is always one of the two.
+	PreserveExecutable bool
+
+	// Allows symbolic links whose targets stay inside the archive root.
+	//
+	// Link targets must be relative and must resolve, following any other
+	// links along the way, to a location inside the archive root. Absolute
+	// targets and targets that escape the root return [ErrInvalidPath]. The
+	// check is enforced both when creating and when extracting an archive.
+	AllowSymlinks bool
+
+	// Writes the archive in the zstd seekable format.
+	//
+	// The contents are compressed as independent frames of [SeekableFrameSize]
+	// bytes followed by a seek table, letting [Reader] read individual files
+	// without decompressing everything before them. Seekable archives remain
+	// valid zstd streams and are extracted like any other archive. Only
+	// affects creation.
+	Seekable bool
+}
+
+// Whether executable bits should be kept.
+func (o *Options) preserveExecutable() bool {
+	return o != nil && o.PreserveExecutable
+}
+
+// Whether the archive is written in the seekable format.
+func (o *Options) seekable() bool {
+	return o != nil && o.Seekable
+}
+
+// Whether contained symlinks are accepted.
+func (o *Options) allowSymlinks() bool {
+	return o != nil && o.AllowSymlinks
+}
+
+// Returns the normalized mode for a regular file.
+//
+// The perm parameter holds the original permission bits, either from the
+// filesystem when creating or from the tar header when extracting.
+func (o *Options) fileMode(perm os.FileMode) os.FileMode {
+	if o.preserveExecutable() && perm&0111 != 0 {
+		return ExecutableFileMode
+	}
+	return FileMode
+}
@@ -0,0 +1,560 @@
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Read-only view of a zstd-compressed tar archive.
+//
+// Implements [fs.FS], [fs.ReadDirFS], [fs.ReadFileFS] and [fs.StatFS], so an
+// archive can be inspected with the standard library (e.g., [fs.ReadFile],
+// [fs.WalkDir]) without extracting it. Opening a reader scans the archive once
+// to index its entries. Reading a file afterwards depends on how the archive
+// was written: archives created with [Options.Seekable] decompress only the
+// frames holding the file, while other archives are decompressed from the
+// start up to the file on every open. Directories missing from the archive
+// but implied by file paths are synthesized. Symlinks are followed when
+// opening or stating a path, as long as they resolve inside the archive.
+//
+// A Reader is safe for concurrent use. Files returned by [Reader.Open] are
+// not.
+type Reader struct {
+	src     io.ReaderAt       // Compressed archive contents.
+	size    int64             // Size of the compressed archive.
+	closer  io.Closer         // Underlying file, if opened by path.
+	entries map[string]*entry // Entries by cleaned path, including ".".
+	frames  *frameReader      // Random access reader, nil if not seekable.
+}
+
+// Indexed archive entry.
+type entry struct {
+	name     string      // Cleaned slash-separated path, "." for the root.
+	header   *tar.Header // Tar header, nil for synthesized directories.
+	offset   int64       // Offset of the contents in the decompressed stream.
+	children []*entry    // Directory children sorted by name.
+}
+
+// Opens an archive file for reading.
+//
+// The file stays open until [Reader.Close] is called. Returns [ErrReadFailed]
+// if the file cannot be opened or indexed.
+func OpenReader(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, helpers.Wrap(ErrReadFailed, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, helpers.Wrap(ErrReadFailed, err)
+	}
+
+	r, err := NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r.closer = f
+	return r, nil
+}
+
+// Creates a reader for an archive of the given size.
+//
+// The archive is indexed before returning. Entries with invalid paths return
+// [ErrInvalidPath] and entries other than regular files, directories and
+// symlinks return [ErrUnsupportedFileType], both wrapped in [ErrReadFailed].
+// The caller keeps ownership of r, which must remain readable until the
+// reader is no longer used.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	frames, err := readSeekTable(r, size)
+	if err != nil {
+		return nil, helpers.Wrap(ErrReadFailed, err)
+	}
+
+	reader := &Reader{
+		src:  r,
+		size: size,
+	}
+
+	if frames != nil {
+		if reader.frames, err = newFrameReader(r, frames); err != nil {
+			return nil, helpers.Wrap(ErrReadFailed, err)
+		}
+	}
+
+	if err := reader.index(); err != nil {
+		reader.Close()
+		return nil, helpers.Wrap(ErrReadFailed, err)
+	}
+
+	return reader, nil
+}
+
+// Whether the archive carries a seek table.
+//
+// Seekable archives support reading files without decompressing the entries
+// that precede them.
+func (r *Reader) Seekable() bool {
+	return r.frames != nil
+}
+
+// Releases resources held by the reader.
+//
+// Closes the underlying file when the reader was created with [OpenReader].
+func (r *Reader) Close() error {
+	if r.frames != nil {
+		r.frames.Close()
+	}
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// Opens the named file or directory.
+//
+// Implements [fs.FS]. Returned directories implement [fs.ReadDirFile].
+// Returned regular files also implement [io.Seeker] and [io.ReaderAt], which
+// are efficient only for seekable archives.
+func (r *Reader) Open(name string) (fs.File, error) {
+	e, err := r.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := newFileInfo(path.Base(name), e)
+	if info.IsDir() {
+		return &openDir{info: info, entries: r.dirEntries(e)}, nil
+	}
+
+	return &openFile{
+		reader: r,
+		name:   name,
+		info:   info,
+		offset: e.offset,
+	}, nil
+}
+
+// Reads the named directory.
+//
+// Implements [fs.ReadDirFS]. Entries are sorted by name. Symlinks are listed
+// as links and not followed.
+func (r *Reader) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := r.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isDirEntry(e) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	return r.dirEntries(e), nil
+}
+
+// Reads the named file.
+//
+// Implements [fs.ReadFileFS].
+func (r *Reader) ReadFile(name string) ([]byte, error) {
+	f, err := r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+
+	data := make([]byte, info.Size())
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+
+	return data, nil
+}
+
+// Returns information about the named file.
+//
+// Implements [fs.StatFS]. Symlinks are followed.
+func (r *Reader) Stat(name string) (fs.FileInfo, error) {
+	e, err := r.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return newFileInfo(path.Base(name), e), nil
+}
+
+// Scans the archive and builds the entry index.
+func (r *Reader) index() error {
+	zr, err := zstd.NewReader(io.NewSectionReader(r.src, 0, r.size))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	counter := &countingReader{r: zr}
+	tr := tar.NewReader(counter)
+
+	r.entries = map[string]*entry{".": {name: "."}}
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeReg, tar.TypeSymlink:
+		default:
+			return ErrUnsupportedFileType
+		}
+
+		name := path.Clean(strings.TrimSuffix(header.Name, "/"))
+		if !fs.ValidPath(name) || name == "." {
+			return ErrInvalidPath
+		}
+
+		e := r.ensure(name)
+		e.header = header
+		e.offset = counter.n // The tar reader stops right after the header
+	}
+
+	for _, e := range r.entries {
+		slices.SortFunc(e.children, func(a, b *entry) int {
+			return strings.Compare(a.name, b.name)
+		})
+	}
+
+	return nil
+}
+
+// Returns the entry for name, creating it and its parents if needed.
+func (r *Reader) ensure(name string) *entry {
+	if e, ok := r.entries[name]; ok {
+		return e
+	}
+
+	parent := r.ensure(path.Dir(name))
+	e := &entry{name: name}
+	parent.children = append(parent.children, e)
+	r.entries[name] = e
+
+	return e
+}
+
+// Resolves a path to an entry, following symlinks.
+//
+// Components are resolved one at a time, so links in the middle of a path
+// are followed too. Links that leave the archive root or form cycles resolve
+// to [fs.ErrNotExist]. Errors are returned as [fs.PathError] using op.
+func (r *Reader) resolve(op, name string) (*entry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	notExist := &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+
+	pending := strings.Split(name, "/")
+	var resolved []string
+	hops := 0
+
+	for len(pending) > 0 {
+		part := pending[0]
+		pending = pending[1:]
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return nil, notExist
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		resolved = append(resolved, part)
+		e, ok := r.entries[path.Join(resolved...)]
+		if !ok {
+			return nil, notExist
+		}
+
+		if e.header == nil || e.header.Typeflag != tar.TypeSymlink {
+			continue
+		}
+
+		hops++
+		if hops > maxSymlinkHops || path.IsAbs(e.header.Linkname) {
+			return nil, notExist
+		}
+
+		resolved = resolved[:len(resolved)-1]
+		pending = append(strings.Split(e.header.Linkname, "/"), pending...)
+	}
+
+	return r.entries[path.Join(append([]string{"."}, resolved...)...)], nil
+}
+
+// Returns the directory entries of a directory.
+func (r *Reader) dirEntries(e *entry) []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(e.children))
+	for i, child := range e.children {
+		entries[i] = fs.FileInfoToDirEntry(newFileInfo(path.Base(child.name), child))
+	}
+	return entries
+}
+
+// Returns a reader for size bytes of decompressed contents starting at offset.
+//
+// Seekable archives read directly from the frames holding the contents. Other
+// archives are decompressed from the start, discarding everything up to
+// offset.
+func (r *Reader) section(offset, size int64) (io.Reader, io.Closer, error) {
+	if r.frames != nil {
+		return io.NewSectionReader(r.frames, offset, size), nil, nil
+	}
+
+	zr, err := zstd.NewReader(io.NewSectionReader(r.src, 0, r.size))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := io.CopyN(io.Discard, zr, offset); err != nil {
+		zr.Close()
+		return nil, nil, err
+	}
+
+	return io.LimitReader(zr, size), closerFunc(zr.Close), nil
+}
+
+// Whether an entry is a directory, including synthesized ones.
+func isDirEntry(e *entry) bool {
+	return e.header == nil || e.header.Typeflag == tar.TypeDir
+}
+
+// File information for an archive entry.
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	header  *tar.Header
+}
+
+// Creates file information for an entry, reported under name.
+func newFileInfo(name string, e *entry) *fileInfo {
+	if e.header == nil {
+		return &fileInfo{name: name, mode: fs.ModeDir | DirMode}
+	}
+
+	info := e.header.FileInfo()
+	return &fileInfo{
+		name:    name,
+		size:    info.Size(),
+		mode:    info.Mode(),
+		modTime: info.ModTime(),
+		header:  e.header,
+	}
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) Mode() fs.FileMode  { return i.mode }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *fileInfo) Sys() any           { return i.header }
+
+// Regular file opened from a [Reader].
+type openFile struct {
+	reader  *Reader
+	name    string
+	info    *fileInfo
+	offset  int64     // Offset of the contents in the decompressed stream.
+	pos     int64     // Current read position within the file.
+	current io.Reader // Sequential reader positioned at pos, nil until needed.
+	closer  io.Closer // Releases current, if needed.
+	closed  bool
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Reads from the current position.
+//
+// The underlying reader is created lazily, so opening a file only to stat it
+// never decompresses anything.
+func (f *openFile) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	if f.pos >= f.info.size {
+		return 0, io.EOF
+	}
+
+	if f.current == nil {
+		current, closer, err := f.reader.section(f.offset+f.pos, f.info.size-f.pos)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.name, Err: err}
+		}
+		f.current, f.closer = current, closer
+	}
+
+	n, err := f.current.Read(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+// Reads len(p) bytes starting at off within the file.
+func (f *openFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	if off < 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	if off >= f.info.size {
+		return 0, io.EOF
+	}
+
+	section, closer, err := f.reader.section(f.offset+off, f.info.size-off)
+	if err != nil {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: err}
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
+
+	n, err := io.ReadFull(section, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Sets the position of the next Read.
+func (f *openFile) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += f.info.size
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	if offset != f.pos {
+		f.release()
+		f.pos = offset
+	}
+
+	return offset, nil
+}
+
+func (f *openFile) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	f.release()
+	return nil
+}
+
+// Drops the sequential reader, if any.
+func (f *openFile) release() {
+	if f.closer != nil {
+		f.closer.Close()
+	}
+	f.current, f.closer = nil, nil
+}
+
+// Directory opened from a [Reader].
+type openDir struct {
+	info    *fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *openDir) Close() error {
+	return nil
+}
+
+// Reads directory entries following [fs.ReadDirFile] semantics.
+func (d *openDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return slices.Clone(remaining), nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(remaining))
+	d.offset += n
+	return slices.Clone(remaining[:n]), nil
+}
+
+// Counts bytes read through a reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Adapts a function to [io.Closer].
+type closerFunc func()
+
+func (f closerFunc) Close() error {
+	f()
+	return nil
+}
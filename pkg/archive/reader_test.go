@@ -0,0 +1,235 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReaderFS(t *testing.T) {
+	for _, seekable := range []bool{false, true} {
+		srcDir := t.TempDir()
+		createTestFiles(t, srcDir)
+
+		archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+		if err := CreateWithOptions(srcDir, archivePath, &Options{Seekable: seekable}); err != nil {
+			t.Fatalf("CreateWithOptions failed: %v", err)
+		}
+
+		r, err := OpenReader(archivePath)
+		if err != nil {
+			t.Fatalf("OpenReader failed: %v", err)
+		}
+
+		if r.Seekable() != seekable {
+			t.Errorf("Seekable() = %v, want %v", r.Seekable(), seekable)
+		}
+
+		if err := fstest.TestFS(r, "file.txt", "subdir/nested.txt", "emptydir"); err != nil {
+			t.Errorf("seekable=%v: %v", seekable, err)
+		}
+
+		if err := r.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+}
+
+func TestReaderReadFile(t *testing.T) {
+	srcDir := t.TempDir()
+	createTestFiles(t, srcDir)
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+	if err := Create(srcDir, archivePath); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	r, err := OpenReader(archivePath)
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	data, err := fs.ReadFile(r, "subdir/nested.txt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "nested" {
+		t.Fatalf("expected %q, got %q", "nested", data)
+	}
+
+	if _, err := r.Stat("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected fs.ErrNotExist, got: %v", err)
+	}
+
+	if _, err := r.Open("../file.txt"); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("expected fs.ErrInvalid, got: %v", err)
+	}
+}
+
+func TestReaderSeekableRandomAccess(t *testing.T) {
+	srcDir := t.TempDir()
+
+	// Spread files across several frames
+	rng := rand.New(rand.NewSource(1))
+	large := make([]byte, 3*SeekableFrameSize+123)
+	rng.Read(large)
+
+	if err := os.WriteFile(filepath.Join(srcDir, "a.bin"), large, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "z.txt"), []byte("last"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+	if err := CreateWithOptions(srcDir, archivePath, &Options{Seekable: true}); err != nil {
+		t.Fatalf("CreateWithOptions failed: %v", err)
+	}
+
+	r, err := OpenReader(archivePath)
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	if !r.Seekable() {
+		t.Fatal("expected seekable archive")
+	}
+
+	data, err := r.ReadFile("z.txt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "last" {
+		t.Fatalf("expected %q, got %q", "last", data)
+	}
+
+	f, err := r.Open("a.bin")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ra, ok := f.(io.ReaderAt)
+	if !ok {
+		t.Fatal("expected file to implement io.ReaderAt")
+	}
+
+	// Read across a frame boundary
+	off := int64(2*SeekableFrameSize - 10)
+	buf := make([]byte, 64)
+	if _, err := ra.ReadAt(buf, off); err != nil {
+		t.Fatalf("ReadAt failed: %v", err)
+	}
+	if !bytes.Equal(buf, large[off:off+64]) {
+		t.Fatal("ReadAt returned wrong contents")
+	}
+
+	all, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !bytes.Equal(all, large) {
+		t.Fatal("ReadAll returned wrong contents")
+	}
+}
+
+func TestExtractSeekableArchive(t *testing.T) {
+	srcDir := t.TempDir()
+	createTestFiles(t, srcDir)
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.zst")
+	if err := CreateWithOptions(srcDir, archivePath, &Options{Seekable: true}); err != nil {
+		t.Fatalf("CreateWithOptions failed: %v", err)
+	}
+
+	destDir := filepath.Join(t.TempDir(), "extracted")
+	if err := Extract(archivePath, destDir); err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(destDir, "file.txt"), "hello")
+	assertFileContent(t, filepath.Join(destDir, "subdir", "nested.txt"), "nested")
+}
+
+func TestReaderSymlinks(t *testing.T) {
+	data := createCraftedArchive(t, []*tar.Header{
+		{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "../target", Mode: 0777},
+		{Name: "alias", Typeflag: tar.TypeSymlink, Linkname: "dir", Mode: 0777},
+		{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: "../outside", Mode: 0777},
+		{Name: "target/", Typeflag: tar.TypeDir, Mode: 0755},
+	})
+
+	r, err := NewReader(data, data.Size())
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer r.Close()
+
+	info, err := r.Stat("alias/link")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if !info.IsDir() {
+		t.Fatal("expected link to resolve to a directory")
+	}
+
+	entries, err := r.ReadDir("dir")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Type()&fs.ModeSymlink == 0 {
+		t.Fatalf("expected a single symlink entry, got %v", entries)
+	}
+
+	if _, err := r.Stat("escape"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected fs.ErrNotExist, got: %v", err)
+	}
+}
+
+func TestReaderImplicitDirectories(t *testing.T) {
+	data := createCraftedArchive(t, []*tar.Header{
+		{Name: "a/b/c.txt", Typeflag: tar.TypeReg, Mode: 0644},
+	})
+
+	r, err := NewReader(data, data.Size())
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer r.Close()
+
+	if err := fstest.TestFS(r, "a/b/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReaderInvalidPath(t *testing.T) {
+	data := createCraftedArchive(t, []*tar.Header{
+		{Name: "../escape.txt", Typeflag: tar.TypeReg, Mode: 0644},
+	})
+
+	_, err := NewReader(data, data.Size())
+	if !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("expected ErrInvalidPath, got: %v", err)
+	}
+	if !errors.Is(err, ErrReadFailed) {
+		t.Fatalf("expected ErrReadFailed, got: %v", err)
+	}
+}
+
+func TestReaderInvalidData(t *testing.T) {
+	data := bytes.NewReader([]byte("not a valid archive"))
+
+	if _, err := NewReader(data, data.Size()); !errors.Is(err, ErrReadFailed) {
+		t.Fatalf("expected ErrReadFailed, got: %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+package archive
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+
+	// Uncompressed size of each frame in a seekable archive.
+	//
+	// Smaller frames make random access cheaper at the cost of compression
+	// ratio. One mebibyte keeps the ratio close to a single-frame archive for
+	// typical resource contents.
+	SeekableFrameSize = 1 << 20
+
+	// Magic number of the skippable frame holding the seek table.
+	seekTableFrameMagic uint32 = 0x184D2A5E
+
+	// Magic number closing the seek table footer.
+	seekTableFooterMagic uint32 = 0x8F92EAB1
+
+	// Size of the seek table footer in bytes.
+	seekTableFooterSize = 9
+
+	// Size of the skippable frame header (magic and frame size) in bytes.
+	seekTableHeaderSize = 8
+
+	// Flag in the seek table descriptor signaling per-frame checksums.
+	seekTableChecksumFlag = 0x80
+)
+
+var (
+	errInvalidSeekTable = errors.New("invalid seek table")
+)
+
+// Location of a single zstd frame in a seekable archive.
+type frame struct {
+	compressedOffset   int64 // Offset of the frame in the compressed stream.
+	compressedSize     int64 // Size of the compressed frame.
+	decompressedOffset int64 // Offset of the frame contents in the decompressed stream.
+	decompressedSize   int64 // Size of the decompressed frame contents.
+}
+
+// Writes a zstd stream as independent frames followed by a seek table.
+//
+// The output follows the zstd seekable format: every [SeekableFrameSize] bytes
+// of input are compressed into their own frame, and a skippable frame holding
+// the compressed and decompressed size of each frame is appended on close.
+// Standard zstd decoders ignore the skippable frame and decode the frames as a
+// single stream, so seekable archives remain readable by [Extract].
+type seekableWriter struct {
+	w       io.Writer
+	encoder *zstd.Encoder
+	buf     []byte
+	frames  [][2]uint32 // Compressed and decompressed size of each frame.
+	closed  bool
+}
+
+// Creates a seekable writer on top of w.
+func newSeekableWriter(w io.Writer) (*seekableWriter, error) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &seekableWriter{
+		w:       w,
+		encoder: encoder,
+		buf:     make([]byte, 0, SeekableFrameSize),
+	}, nil
+}
+
+// Buffers p, flushing a frame whenever the buffer reaches the frame size.
+func (s *seekableWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(SeekableFrameSize-len(s.buf), len(p))
+		s.buf = append(s.buf, p[:n]...)
+		p = p[n:]
+		written += n
+
+		if len(s.buf) == SeekableFrameSize {
+			if err := s.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Compresses the buffered data into a frame and writes it.
+func (s *seekableWriter) flush() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+
+	compressed := s.encoder.EncodeAll(s.buf, nil)
+	if _, err := s.w.Write(compressed); err != nil {
+		return err
+	}
+
+	s.frames = append(s.frames, [2]uint32{uint32(len(compressed)), uint32(len(s.buf))})
+	s.buf = s.buf[:0]
+	return nil
+}
+
+// Flushes the last frame and writes the seek table.
+func (s *seekableWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	defer s.encoder.Close()
+
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	_, err := s.w.Write(encodeSeekTable(s.frames))
+	return err
+}
+
+// Encodes the seek table as a skippable frame.
+func encodeSeekTable(frames [][2]uint32) []byte {
+	payload := len(frames)*8 + seekTableFooterSize
+	table := make([]byte, 0, seekTableHeaderSize+payload)
+
+	table = binary.LittleEndian.AppendUint32(table, seekTableFrameMagic)
+	table = binary.LittleEndian.AppendUint32(table, uint32(payload))
+	for _, f := range frames {
+		table = binary.LittleEndian.AppendUint32(table, f[0])
+		table = binary.LittleEndian.AppendUint32(table, f[1])
+	}
+	table = binary.LittleEndian.AppendUint32(table, uint32(len(frames)))
+	table = append(table, 0) // Descriptor: no checksums
+	table = binary.LittleEndian.AppendUint32(table, seekTableFooterMagic)
+
+	return table
+}
+
+// Reads the seek table at the end of a seekable archive.
+//
+// Returns nil frames and no error if the archive does not end with a seek
+// table, and [errInvalidSeekTable] if it does but the table is malformed.
+func readSeekTable(r io.ReaderAt, size int64) ([]frame, error) {
+	if size < seekTableHeaderSize+seekTableFooterSize {
+		return nil, nil
+	}
+
+	footer := make([]byte, seekTableFooterSize)
+	if _, err := r.ReadAt(footer, size-seekTableFooterSize); err != nil {
+		return nil, err
+	}
+
+	if binary.LittleEndian.Uint32(footer[5:]) != seekTableFooterMagic {
+		return nil, nil
+	}
+
+	count := int64(binary.LittleEndian.Uint32(footer[0:4]))
+	entrySize := int64(8)
+	if footer[4]&seekTableChecksumFlag != 0 {
+		entrySize = 12
+	}
+
+	payload := count*entrySize + seekTableFooterSize
+	start := size - payload - seekTableHeaderSize
+	if start < 0 {
+		return nil, errInvalidSeekTable
+	}
+
+	table := make([]byte, seekTableHeaderSize+count*entrySize)
+	if _, err := r.ReadAt(table, start); err != nil {
+		return nil, err
+	}
+
+	if binary.LittleEndian.Uint32(table[0:4]) != seekTableFrameMagic ||
+		int64(binary.LittleEndian.Uint32(table[4:8])) != payload {
+		return nil, errInvalidSeekTable
+	}
+
+	frames := make([]frame, count)
+	var compressed, decompressed int64
+	for i := range frames {
+		entry := table[seekTableHeaderSize+int64(i)*entrySize:]
+		frames[i] = frame{
+			compressedOffset:   compressed,
+			compressedSize:     int64(binary.LittleEndian.Uint32(entry[0:4])),
+			decompressedOffset: decompressed,
+			decompressedSize:   int64(binary.LittleEndian.Uint32(entry[4:8])),
+		}
+		compressed += frames[i].compressedSize
+		decompressed += frames[i].decompressedSize
+	}
+
+	if compressed != start {
+		return nil, errInvalidSeekTable
+	}
+
+	return frames, nil
+}
+
+// Provides random access to the decompressed contents of a seekable archive.
+//
+// Only the frames overlapping a read are decompressed. The most recently
+// decoded frame is cached, so sequential reads through a file decode each
+// frame once. Safe for concurrent use.
+type frameReader struct {
+	src     io.ReaderAt
+	frames  []frame
+	decoder *zstd.Decoder
+	mu      sync.Mutex
+	cached  int    // Index of the cached frame, or -1.
+	cache   []byte // Decompressed contents of the cached frame.
+}
+
+// Creates a frame reader over src using the given frame index.
+func newFrameReader(src io.ReaderAt, frames []frame) (*frameReader, error) {
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &frameReader{
+		src:     src,
+		frames:  frames,
+		decoder: decoder,
+		cached:  -1,
+	}, nil
+}
+
+// Reads decompressed bytes starting at off.
+func (f *frameReader) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for n < len(p) {
+		i := sort.Search(len(f.frames), func(i int) bool {
+			fr := f.frames[i]
+			return fr.decompressedOffset+fr.decompressedSize > off
+		})
+		if i == len(f.frames) {
+			return n, io.EOF
+		}
+
+		data, err := f.frame(i)
+		if err != nil {
+			return n, err
+		}
+
+		copied := copy(p[n:], data[off-f.frames[i].decompressedOffset:])
+		n += copied
+		off += int64(copied)
+	}
+	return n, nil
+}
+
+// Returns the decompressed contents of frame i.
+//
+// Must be called with the mutex held.
+func (f *frameReader) frame(i int) ([]byte, error) {
+	if f.cached == i {
+		return f.cache, nil
+	}
+
+	fr := f.frames[i]
+	compressed := make([]byte, fr.compressedSize)
+	if _, err := f.src.ReadAt(compressed, fr.compressedOffset); err != nil {
+		return nil, err
+	}
+
+	// The cache buffer is reused, so invalidate it before decoding
+	f.cached = -1
+	data, err := f.decoder.DecodeAll(compressed, f.cache[:0])
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != fr.decompressedSize {
+		return nil, errInvalidSeekTable
+	}
+
+	f.cached = i
+	f.cache = data
+	return data, nil
+}
+
+// Releases the decoder.
+func (f *frameReader) Close() {
+	f.decoder.Close()
+}
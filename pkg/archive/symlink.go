@@ -0,0 +1,136 @@
+package archive
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+
+	// Maximum number of symlinks followed while resolving a single path.
+	//
+	// Matches the limit used by most operating systems and guards against
+	// link cycles inside the archive root.
+	maxSymlinkHops = 40
+)
+
+// Returns the archive path a symlink target points to.
+//
+// The name parameter is the slash-separated archive path of the link and
+// target is its slash-separated link target. Absolute targets and targets that
+// lexically escape the archive root return [ErrInvalidPath]. The returned path
+// is relative to the archive root and is deliberately left uncleaned, since
+// cleaning would collapse ".." across components that may themselves be links.
+// It has not been checked against other links; use [checkContained] for that.
+func linkDestination(name, target string) (string, error) {
+	if target == "" || path.IsAbs(target) || filepath.IsAbs(target) {
+		return "", ErrInvalidPath
+	}
+
+	dest := target
+	if dir := path.Dir(strings.TrimSuffix(name, "/")); dir != "." {
+		dest = dir + "/" + target
+	}
+
+	if clean := path.Clean(dest); clean != "." && !filepath.IsLocal(filepath.FromSlash(clean)) {
+		return "", ErrInvalidPath
+	}
+
+	return dest, nil
+}
+
+// Checks that a path resolves to a location inside root.
+//
+// The name parameter is a slash-separated path relative to root. Each component
+// is resolved in turn, following symlinks found on disk, so that a chain of
+// individually harmless links cannot be combined to leave the root. Components
+// that do not exist are resolved lexically. Returns [ErrInvalidPath] if the
+// path escapes root, a link has an absolute target, or too many links are
+// followed.
+func checkContained(root, name string) error {
+	pending := strings.Split(name, "/")
+	var resolved []string
+	hops := 0
+
+	for len(pending) > 0 {
+		part := pending[0]
+		pending = pending[1:]
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return ErrInvalidPath
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		resolved = append(resolved, part)
+		current := filepath.Join(root, filepath.FromSlash(path.Join(resolved...)))
+
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		hops++
+		if hops > maxSymlinkHops {
+			return ErrInvalidPath
+		}
+
+		target, err := os.Readlink(current)
+		if err != nil {
+			return err
+		}
+
+		target = filepath.ToSlash(target)
+		if path.IsAbs(target) || filepath.IsAbs(target) {
+			return ErrInvalidPath
+		}
+
+		// Replace the link with its target and keep resolving
+		resolved = resolved[:len(resolved)-1]
+		pending = append(strings.Split(target, "/"), pending...)
+	}
+
+	return nil
+}
+
+// Checks that no existing component of a path inside dest is a symlink.
+//
+// Prevents extraction from writing through a previously extracted link. The
+// localName parameter is an OS-specific path relative to dest, as returned by
+// [validateAndJoinPath]. The final component is checked as well, so an entry
+// can never replace or write through an existing link.
+func checkNoSymlinks(dest, localName string) error {
+	current := dest
+	for _, part := range strings.Split(localName, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return ErrInvalidPath
+		}
+	}
+	return nil
+}
@@ -17,6 +17,12 @@ const (
 )
 
 // Checks that a widget's dist/ directory contains required files.
+//
+// Only the canonical file name is checked; the entry point configured in the
+// manifest is ignored.
+//
+// Deprecated: Use [github.com/cruciblehq/protocol/pkg/build.Build], which
+// checks the entry point configured in the manifest and produces the archive.
 func ValidateWidgetStructure(distDir string, m *manifest.Widget) error {
 	widgetMain := filepath.Join(distDir, WidgetMainFile)
 	if _, err := os.Stat(widgetMain); os.IsNotExist(err) {
@@ -26,6 +32,12 @@ func ValidateWidgetStructure(distDir string, m *manifest.Widget) error {
 }
 
 // Checks that a service's dist/ directory contains required files.
+//
+// Only the canonical file name is checked; the image path configured in the
+// manifest is ignored and the image is not validated.
+//
+// Deprecated: Use [github.com/cruciblehq/protocol/pkg/build.Build], which
+// checks the image configured in the manifest and produces the archive.
 func ValidateServiceStructure(distDir string, m *manifest.Service) error {
 	serviceImage := filepath.Join(distDir, ServiceImageFile)
 	if _, err := os.Stat(serviceImage); os.IsNotExist(err) {
@@ -0,0 +1,286 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+	"github.com/cruciblehq/protocol/pkg/archive"
+	"github.com/cruciblehq/protocol/pkg/manifest"
+	"github.com/cruciblehq/protocol/pkg/oci"
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+const (
+
+	// Base name of the manifest file at the root of a package.
+	//
+	// The extension of the source manifest is kept, so a package built from a
+	// JSON manifest holds crucible.json.
+	ManifestFile = "crucible"
+)
+
+// Describes a package produced by [Build].
+type Package struct {
+	Path     string             // Path to the package archive.
+	Digest   *reference.Digest  // SHA-256 digest of the package archive.
+	Size     int64              // Size of the package archive in bytes.
+	Manifest *manifest.Manifest // Manifest the package was built from.
+}
+
+// Builds a package archive from a resource manifest.
+//
+// The manifestPath parameter is the path to the resource manifest. Entry points
+// configured in the manifest are resolved relative to the directory holding it
+// and must stay inside that directory, without going through symbolic links.
+// When the manifest leaves an entry point empty, the canonical location
+// ([manifest.WidgetDistMain] or [manifest.ServiceDistImage]) is used.
+//
+// For widgets, the entry point must be a regular file named
+// [archive.WidgetMainFile] inside a subdirectory of the resource, and that
+// whole subdirectory is packaged as dist/. For services, the image must be a
+// valid OCI image layout (see [oci.ValidateLayout]) supporting every required
+// platform, and is packaged as dist/image.tar. Required platforms come from
+// [Options.Platforms], then the manifest's Build.Platforms, then
+// [oci.RequiredPlatforms]. A missing platform returns an [*oci.PlatformError].
+//
+// The archive is written to dest. Returns [ErrMissingEntryPoint] if an entry
+// point does not exist, [ErrInvalidEntryPoint] if it is not usable, and
+// [ErrUnsupportedType] for resource types that cannot be packaged. All errors
+// are wrapped with [ErrBuildFailed]. If the build fails, no archive is left at
+// dest.
+func Build(manifestPath, dest string, options *Options) (*Package, error) {
+	m, err := manifest.Read(manifestPath)
+	if err != nil {
+		return nil, helpers.Wrap(ErrBuildFailed, err)
+	}
+
+	stage, err := os.MkdirTemp("", "crucible-build-*")
+	if err != nil {
+		return nil, helpers.Wrap(ErrBuildFailed, err)
+	}
+	defer os.RemoveAll(stage)
+
+	if err := layout(stage, manifestPath, m, options); err != nil {
+		return nil, helpers.Wrap(ErrBuildFailed, err)
+	}
+
+	if err := archive.CreateWithOptions(stage, dest, options.archive()); err != nil {
+		return nil, helpers.Wrap(ErrBuildFailed, err)
+	}
+
+	digest, size, err := digestFile(dest)
+	if err != nil {
+		os.Remove(dest)
+		return nil, helpers.Wrap(ErrBuildFailed, err)
+	}
+
+	return &Package{
+		Path:     dest,
+		Digest:   digest,
+		Size:     size,
+		Manifest: m,
+	}, nil
+}
+
+// Lays out the canonical package structure in stage.
+func layout(stage, manifestPath string, m *manifest.Manifest, options *Options) error {
+	root := filepath.Dir(manifestPath)
+
+	manifestName := ManifestFile + filepath.Ext(manifestPath)
+	if err := copyFile(manifestPath, filepath.Join(stage, manifestName)); err != nil {
+		return err
+	}
+
+	switch cfg := m.Config.(type) {
+	case *manifest.Widget:
+		return layoutWidget(stage, root, cfg)
+	case *manifest.Service:
+		return layoutService(stage, root, cfg, options.platforms(cfg.Build.Platforms))
+	default:
+		return helpers.Wrap(ErrUnsupportedType, fmt.Errorf("%q", m.Resource.Type))
+	}
+}
+
+// Copies the widget build output into stage/dist.
+func layoutWidget(stage, root string, cfg *manifest.Widget) error {
+	main := cfg.Build.Main
+	if main == "" {
+		main = manifest.WidgetDistMain
+	}
+
+	mainPath, err := entryPoint(root, main)
+	if err != nil {
+		return err
+	}
+
+	if filepath.Base(mainPath) != archive.WidgetMainFile {
+		return helpers.Wrap(ErrInvalidEntryPoint, fmt.Errorf("%s: must be named %s", main, archive.WidgetMainFile))
+	}
+
+	distDir := filepath.Dir(mainPath)
+	if distDir == root {
+		return helpers.Wrap(ErrInvalidEntryPoint, fmt.Errorf("%s: must be inside a build output directory", main))
+	}
+
+	return copyDir(distDir, filepath.Join(stage, manifest.WidgetDistDirectory))
+}
+
+// Validates the service image and copies it to stage/dist/image.tar.
+//
+// The platforms parameter lists the platforms the image must support, or is
+// empty for [oci.RequiredPlatforms].
+func layoutService(stage, root string, cfg *manifest.Service, platforms []string) error {
+	image := cfg.Build.Image
+	if image == "" {
+		image = manifest.ServiceDistImage
+	}
+
+	imagePath, err := entryPoint(root, image)
+	if err != nil {
+		return err
+	}
+
+	layout, err := oci.ReadLayout(imagePath)
+	if err != nil {
+		return err
+	}
+
+	if err := layout.Validate(); err != nil {
+		return err
+	}
+
+	if err := layout.ValidatePlatforms(platforms); err != nil {
+		return err
+	}
+
+	distDir := filepath.Join(stage, manifest.ServiceDistDirectory)
+	if err := os.MkdirAll(distDir, archive.DirMode); err != nil {
+		return err
+	}
+
+	return copyFile(imagePath, filepath.Join(stage, manifest.ServiceDistImage))
+}
+
+// Resolves an entry point configured in the manifest.
+//
+// The name parameter is a slash-separated path relative to root. Symbolic
+// links are not followed, since they could point outside root, so the entry
+// point and every directory leading to it must be real. Returns
+// [ErrInvalidEntryPoint] if the path leaves root, goes through a symbolic
+// link or is not a regular file, and [ErrMissingEntryPoint] if it does not
+// exist.
+func entryPoint(root, name string) (string, error) {
+	local := filepath.FromSlash(name)
+	if !filepath.IsLocal(local) {
+		return "", helpers.Wrap(ErrInvalidEntryPoint, fmt.Errorf("%s: must be a relative path inside the resource", name))
+	}
+
+	p := root
+	var info fs.FileInfo
+	for _, part := range strings.Split(filepath.Clean(local), string(filepath.Separator)) {
+		p = filepath.Join(p, part)
+
+		var err error
+		info, err = os.Lstat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", helpers.Wrap(ErrMissingEntryPoint, fmt.Errorf("%s", name))
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", helpers.Wrap(ErrInvalidEntryPoint, fmt.Errorf("%s: must not go through a symbolic link", name))
+		}
+	}
+
+	if !info.Mode().IsRegular() {
+		return "", helpers.Wrap(ErrInvalidEntryPoint, fmt.Errorf("%s: not a regular file", name))
+	}
+
+	return p, nil
+}
+
+// Copies a directory tree.
+//
+// Regular files keep their permission bits and symlinks are recreated as-is,
+// leaving their validation to [archive.CreateWithOptions]. Other file types
+// return [archive.ErrUnsupportedFileType].
+func copyDir(src, dest string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, archive.DirMode)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(p, target)
+		default:
+			return helpers.Wrap(archive.ErrUnsupportedFileType, fmt.Errorf("%s", p))
+		}
+	})
+}
+
+// Copies a regular file, keeping its permission bits.
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// Computes the digest and size of a file.
+func digestFile(p string) (*reference.Digest, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	digester, err := reference.NewDigester(reference.DefaultDigestAlgorithm, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := io.Copy(digester, f); err != nil {
+		return nil, 0, err
+	}
+
+	return digester.Digest(), digester.Size(), nil
+}
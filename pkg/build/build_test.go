@@ -0,0 +1,290 @@
+package build
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cruciblehq/protocol/pkg/archive"
+	"github.com/cruciblehq/protocol/pkg/oci"
+)
+
+func TestBuildWidget(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "crucible.yaml", "version: 0\nresource:\n  type: widget\n  version: 1.0.0\nbuild:\n  main: out/index.js\n")
+	writeFile(t, root, "out/index.js", "export default {}")
+	writeFile(t, root, "out/chunk.js", "chunk")
+	writeFile(t, root, "src/index.ts", "source")
+
+	dest := filepath.Join(t.TempDir(), "widget.tar.zst")
+	pkg, err := Build(filepath.Join(root, "crucible.yaml"), dest, nil)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if pkg.Digest == nil || pkg.Digest.Algorithm != "sha256" || len(pkg.Digest.Hash) != 64 {
+		t.Fatalf("unexpected digest: %v", pkg.Digest)
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Size != info.Size() {
+		t.Fatalf("Size = %d, want %d", pkg.Size, info.Size())
+	}
+
+	r, err := archive.OpenReader(dest)
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	for _, name := range []string{"crucible.yaml", "dist/index.js", "dist/chunk.js"} {
+		if _, err := r.Stat(name); err != nil {
+			t.Errorf("expected %s in package: %v", name, err)
+		}
+	}
+	if _, err := r.Stat("src"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected src to be excluded, got: %v", err)
+	}
+}
+
+func TestBuildWidgetMissingEntryPoint(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "crucible.yaml", "version: 0\nresource:\n  type: widget\n  version: 1.0.0\nbuild:\n  main: out/index.js\n")
+
+	dest := filepath.Join(t.TempDir(), "widget.tar.zst")
+	_, err := Build(filepath.Join(root, "crucible.yaml"), dest, nil)
+	if !errors.Is(err, ErrMissingEntryPoint) {
+		t.Fatalf("expected ErrMissingEntryPoint, got: %v", err)
+	}
+	if !errors.Is(err, ErrBuildFailed) {
+		t.Fatalf("expected ErrBuildFailed, got: %v", err)
+	}
+
+	if _, err := os.Stat(dest); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal("expected no archive to be written")
+	}
+}
+
+func TestBuildWidgetInvalidEntryPoint(t *testing.T) {
+	tests := []struct {
+		name string
+		main string
+	}{
+		{"escapes root", "../index.js"},
+		{"wrong name", "out/main.js"},
+		{"at root", "index.js"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFile(t, root, "crucible.yaml", "version: 0\nresource:\n  type: widget\n  version: 1.0.0\nbuild:\n  main: "+tt.main+"\n")
+			writeFile(t, root, "out/main.js", "main")
+			writeFile(t, root, "index.js", "main")
+
+			_, err := Build(filepath.Join(root, "crucible.yaml"), filepath.Join(t.TempDir(), "widget.tar.zst"), nil)
+			if !errors.Is(err, ErrInvalidEntryPoint) {
+				t.Fatalf("expected ErrInvalidEntryPoint, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildWidgetSymlinkedEntryPoint(t *testing.T) {
+	tests := []struct {
+		name string
+		link string // Path of the link inside the resource.
+		dest string // Path the link points to, inside an outside directory.
+	}{
+		{"main", "out/index.js", "index.js"},
+		{"dist directory", "out", "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			outside := t.TempDir()
+			writeFile(t, root, "crucible.yaml", "version: 0\nresource:\n  type: widget\n  version: 1.0.0\nbuild:\n  main: out/index.js\n")
+			writeFile(t, outside, "index.js", "export default {}")
+			writeSymlink(t, filepath.Join(outside, tt.dest), filepath.Join(root, tt.link))
+
+			_, err := Build(filepath.Join(root, "crucible.yaml"), filepath.Join(t.TempDir(), "widget.tar.zst"), nil)
+			if !errors.Is(err, ErrInvalidEntryPoint) {
+				t.Fatalf("expected ErrInvalidEntryPoint, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildService(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "crucible.json", `{"version": 0, "resource": {"type": "service", "version": "1.0.0"}, "build": {"image": "build/image.tar"}}`)
+	writeImage(t, filepath.Join(root, "build", "image.tar"), oci.RequiredPlatforms())
+
+	dest := filepath.Join(t.TempDir(), "service.tar.zst")
+	if _, err := Build(filepath.Join(root, "crucible.json"), dest, nil); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	r, err := archive.OpenReader(dest)
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	for _, name := range []string{"crucible.json", "dist/image.tar"} {
+		if _, err := r.Stat(name); err != nil {
+			t.Errorf("expected %s in package: %v", name, err)
+		}
+	}
+}
+
+func TestBuildServiceSymlinkedImage(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "image.tar")
+	writeFile(t, root, "crucible.json", `{"version": 0, "resource": {"type": "service", "version": "1.0.0"}, "build": {"image": "build/image.tar"}}`)
+	writeImage(t, outside, oci.RequiredPlatforms())
+	writeSymlink(t, outside, filepath.Join(root, "build", "image.tar"))
+
+	_, err := Build(filepath.Join(root, "crucible.json"), filepath.Join(t.TempDir(), "service.tar.zst"), nil)
+	if !errors.Is(err, ErrInvalidEntryPoint) {
+		t.Fatalf("expected ErrInvalidEntryPoint, got: %v", err)
+	}
+}
+
+func TestBuildServiceCorruptImage(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "crucible.yaml", "version: 0\nresource:\n  type: service\n  version: 1.0.0\n")
+	writeFile(t, root, "dist/image.tar", "not a tarball")
+
+	_, err := Build(filepath.Join(root, "crucible.yaml"), filepath.Join(t.TempDir(), "service.tar.zst"), nil)
+	if !errors.Is(err, oci.ErrInvalidImage) {
+		t.Fatalf("expected ErrInvalidImage, got: %v", err)
+	}
+}
+
+func TestBuildServiceSinglePlatform(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "crucible.yaml", "version: 0\nresource:\n  type: service\n  version: 1.0.0\n")
+	writeImage(t, filepath.Join(root, "dist", "image.tar"), []string{"linux/amd64"})
+
+	_, err := Build(filepath.Join(root, "crucible.yaml"), filepath.Join(t.TempDir(), "service.tar.zst"), nil)
+	if !errors.Is(err, oci.ErrSinglePlatform) {
+		t.Fatalf("expected ErrSinglePlatform, got: %v", err)
+	}
+}
+
+func TestBuildServiceDeclaredPlatforms(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "crucible.yaml", "version: 0\nresource:\n  type: service\n  version: 1.0.0\nbuild:\n  platforms:\n    - linux/arm/v7\n")
+	writeImage(t, filepath.Join(root, "dist", "image.tar"), []string{"linux/arm/v7"})
+
+	manifestPath := filepath.Join(root, "crucible.yaml")
+	if _, err := Build(manifestPath, filepath.Join(t.TempDir(), "service.tar.zst"), nil); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	// Options override the manifest
+	options := &Options{Platforms: []string{"linux/amd64"}}
+	_, err := Build(manifestPath, filepath.Join(t.TempDir(), "service.tar.zst"), options)
+
+	var platformErr *oci.PlatformError
+	if !errors.As(err, &platformErr) {
+		t.Fatalf("expected PlatformError, got: %v", err)
+	}
+	if !slices.Equal(platformErr.Missing, []string{"linux/amd64"}) {
+		t.Fatalf("Missing = %v", platformErr.Missing)
+	}
+}
+
+// Writes a file relative to root, creating parent directories.
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Creates a symbolic link, creating parent directories.
+func writeSymlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Writes an OCI image layout with one manifest per platform.
+func writeImage(t *testing.T, p string, platforms []string) {
+	t.Helper()
+
+	files := map[string][]byte{oci.OCILayoutFile: []byte(`{"imageLayoutVersion": "1.0.0"}`)}
+	addBlob := func(mediaType string, data []byte) oci.Descriptor {
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		files["blobs/sha256/"+hash] = data
+		return oci.Descriptor{MediaType: mediaType, Digest: "sha256:" + hash, Size: int64(len(data))}
+	}
+	marshal := func(v any) []byte {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	config := addBlob("application/vnd.oci.image.config.v1+json", []byte(`{}`))
+	layer := addBlob("application/vnd.oci.image.layer.v1.tar", []byte("layer"))
+
+	index := oci.Index{SchemaVersion: 2, MediaType: oci.OCIImageIndexMediaType}
+	for _, platform := range platforms {
+		manifest := oci.Manifest{SchemaVersion: 2, MediaType: oci.OCIImageManifestMediaType, Config: config, Layers: []oci.Descriptor{layer}}
+		d := addBlob(oci.OCIImageManifestMediaType, marshal(manifest))
+
+		p, err := oci.ParsePlatform(platform)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Platform = p
+		index.Manifests = append(index.Manifests, d)
+	}
+	files[oci.OCIIndexFile] = marshal(index)
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
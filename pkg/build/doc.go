@@ -0,0 +1,27 @@
+// Package build produces publishable resource packages from a manifest.
+//
+// A package is a zstd-compressed tar archive with a canonical layout, the same
+// for every resource of a given type. The manifest sits at the archive root as
+// crucible.<ext>, keeping the extension of the source file, and the build output
+// sits under dist/:
+//
+//	crucible.yaml
+//	dist/index.js    (widgets: the entry point and the files next to it)
+//	dist/image.tar   (services: the multi-platform OCI image)
+//
+// [Build] reads the manifest, checks that the entry points it configures exist
+// ([manifest.Widget] Build.Main or [manifest.Service] Build.Image), reads
+// service images with [oci.ReadLayout] and checks them with
+// [oci.Layout.Validate] and [oci.Layout.ValidatePlatforms] (see
+// [Options.Platforms]), lays out the canonical structure and writes
+// the archive. The returned [Package] carries the archive digest, ready to be
+// uploaded to a registry.
+//
+// Example:
+//
+//	pkg, err := build.Build("/path/to/resource/crucible.yaml", "widget.tar.zst", nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(pkg.Digest)
+package build
@@ -0,0 +1,10 @@
+package build
+
+import "errors"
+
+var (
+	ErrBuildFailed       = errors.New("package build failed")
+	ErrMissingEntryPoint = errors.New("entry point not found")
+	ErrInvalidEntryPoint = errors.New("invalid entry point")
+	ErrUnsupportedType   = errors.New("unsupported resource type")
+)
@@ -0,0 +1,41 @@
+package build
+
+import "github.com/cruciblehq/protocol/pkg/archive"
+
+// Options for building a package.
+//
+// The zero value (and a nil *Options) builds the package with the strict
+// archive defaults.
+type Options struct {
+
+	// Options used when writing the package archive.
+	//
+	// Lets callers preserve executable bits, accept contained symlinks in the
+	// build output, or write a seekable archive. See [archive.Options].
+	Archive *archive.Options
+
+	// Platforms a service image must support.
+	//
+	// Platforms are given in "os/arch" or "os/arch/variant" format. Overrides
+	// the platforms declared in the service manifest. When both are empty,
+	// the default platforms of the oci package apply. Ignored for widgets.
+	Platforms []string
+}
+
+// Returns the platforms required for a service.
+//
+// The declared parameter holds the platforms listed in the manifest.
+func (o *Options) platforms(declared []string) []string {
+	if o != nil && len(o.Platforms) > 0 {
+		return o.Platforms
+	}
+	return declared
+}
+
+// Returns the archive options, or nil for the defaults.
+func (o *Options) archive() *archive.Options {
+	if o == nil {
+		return nil
+	}
+	return o.Archive
+}
@@ -18,6 +18,7 @@ type Service struct {
 
 	// Holds build-related configuration for service resources.
 	Build struct {
-		Image string `field:"image"` // Path to pre-built OCI image tarball (e.g., "build/image.tar").
+		Image     string   `field:"image"`               // Path to pre-built OCI image tarball (e.g., "build/image.tar").
+		Platforms []string `field:"platforms,omitempty"` // Platforms the image must support (e.g., "linux/arm/v7"), or empty for the defaults.
 	} `field:"build"`
 }
@@ -0,0 +1,330 @@
+package oci
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+const (
+
+	// The media type for an OCI image configuration.
+	OCIImageConfigMediaType = "application/vnd.oci.image.config.v1+json"
+
+	// The media type for an uncompressed OCI layer.
+	OCIImageLayerMediaType = "application/vnd.oci.image.layer.v1.tar"
+
+	// The media type for a gzip-compressed OCI layer.
+	OCIImageLayerGzipMediaType = OCIImageLayerMediaType + "+gzip"
+
+	// The media type for a zstd-compressed OCI layer.
+	OCIImageLayerZstdMediaType = OCIImageLayerMediaType + "+zstd"
+
+	// Annotation holding the reference name of a manifest in an index.
+	OCIRefNameAnnotation = "org.opencontainers.image.ref.name"
+
+	// Path of the manifest written by docker save.
+	DockerManifestFile = "manifest.json"
+)
+
+// Describes one image in the manifest.json written by docker save.
+type dockerManifest struct {
+	Config   string   `json:"Config"`   // Path of the image configuration
+	RepoTags []string `json:"RepoTags"` // Tags the image was saved with
+	Layers   []string `json:"Layers"`   // Paths of the layer tarballs, base first
+}
+
+// A file found while scanning a docker save tarball.
+type dockerEntry struct {
+	digest    string // Digest of the file contents.
+	size      int64  // Size of the file contents.
+	mediaType string // Layer media type inferred from the file contents.
+	data      []byte // File contents, or nil if larger than maxMetadataBlobSize.
+}
+
+// Converts a docker save tarball into an OCI image layout tarball.
+//
+// The src parameter is a tarball written by docker save, holding a
+// manifest.json that lists the configuration and layers of each saved image.
+// Each image becomes an OCI manifest in the index written to dest, with the
+// platform taken from its configuration and the first repository tag recorded
+// in the [OCIRefNameAnnotation] annotation. Configurations and layers keep
+// their contents, so their digests are unchanged; layer media types reflect
+// whether the layer is uncompressed, gzip or zstd compressed. Layers stored
+// as symlinks to other layers are resolved.
+//
+// The source is read twice: once to hash its contents and once to copy them.
+// Returns [ErrInvalidImage] if src is not a valid docker save tarball and
+// [ErrConvertFailed] if conversion fails. If conversion fails, dest is removed.
+func ConvertDockerArchive(src, dest string) error {
+	entries, links, err := scanDockerArchive(src)
+	if err != nil {
+		return helpers.Wrap(ErrInvalidImage, err)
+	}
+
+	manifestEntry := entries[DockerManifestFile]
+	if manifestEntry == nil || manifestEntry.data == nil {
+		return helpers.Wrap(ErrInvalidImage, errors.New("manifest.json not found"))
+	}
+
+	var images []dockerManifest
+	if err := json.Unmarshal(manifestEntry.data, &images); err != nil {
+		return helpers.Wrap(ErrInvalidImage, err)
+	}
+	if len(images) == 0 {
+		return helpers.Wrap(ErrInvalidImage, errors.New("manifest.json lists no images"))
+	}
+
+	resolve := func(name string) (string, *dockerEntry, error) {
+		name = path.Clean(name)
+		for range maxIndexDepth {
+			target, ok := links[name]
+			if !ok {
+				break
+			}
+			name = target
+		}
+		entry := entries[name]
+		if entry == nil {
+			return "", nil, fmt.Errorf("%s not found", name)
+		}
+		return name, entry, nil
+	}
+
+	// Plan the manifests and the files to copy as blobs
+	type planned struct {
+		manifest Manifest
+		platform *Platform
+		tag      string
+	}
+	var plans []planned
+	needed := make(map[string]string) // Path of each file to copy, to its digest
+
+	for _, image := range images {
+		configName, config, err := resolve(image.Config)
+		if err != nil {
+			return helpers.Wrap(ErrInvalidImage, err)
+		}
+		if config.data == nil {
+			return helpers.Wrap(ErrInvalidImage, fmt.Errorf("%s too large", configName))
+		}
+
+		var platform Platform
+		if err := json.Unmarshal(config.data, &platform); err != nil {
+			return helpers.Wrap(ErrInvalidImage, fmt.Errorf("%s: %w", configName, err))
+		}
+		needed[configName] = config.digest
+
+		p := planned{
+			platform: &platform,
+			manifest: Manifest{
+				SchemaVersion: 2,
+				MediaType:     OCIImageManifestMediaType,
+				Config:        Descriptor{MediaType: OCIImageConfigMediaType, Digest: config.digest, Size: config.size},
+			},
+		}
+		if len(image.RepoTags) > 0 {
+			p.tag = image.RepoTags[0]
+		}
+
+		for _, layer := range image.Layers {
+			layerName, entry, err := resolve(layer)
+			if err != nil {
+				return helpers.Wrap(ErrInvalidImage, err)
+			}
+			needed[layerName] = entry.digest
+			p.manifest.Layers = append(p.manifest.Layers, Descriptor{
+				MediaType: entry.mediaType,
+				Digest:    entry.digest,
+				Size:      entry.size,
+			})
+		}
+
+		plans = append(plans, p)
+	}
+
+	w, err := newLayoutWriter(dest)
+	if err != nil {
+		return helpers.Wrap(ErrConvertFailed, err)
+	}
+
+	if err := copyDockerFiles(w, src, needed); err != nil {
+		w.abort()
+		return helpers.Wrap(ErrConvertFailed, err)
+	}
+
+	index := &Index{SchemaVersion: 2, MediaType: OCIImageIndexMediaType}
+	for _, p := range plans {
+		d, err := w.writeJSON(OCIImageManifestMediaType, p.manifest)
+		if err != nil {
+			w.abort()
+			return helpers.Wrap(ErrConvertFailed, err)
+		}
+		d.Platform = p.platform
+		if p.tag != "" {
+			d.Annotations = map[string]string{OCIRefNameAnnotation: p.tag}
+		}
+		index.Manifests = append(index.Manifests, d)
+	}
+
+	if err := w.close(index); err != nil {
+		w.abort()
+		return helpers.Wrap(ErrConvertFailed, err)
+	}
+
+	return nil
+}
+
+// Hashes every file in a docker save tarball.
+//
+// Returns the files by cleaned path and the symlinks by cleaned path to their
+// cleaned targets. Small files are kept in memory.
+func scanDockerArchive(src string) (map[string]*dockerEntry, map[string]string, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	entries := make(map[string]*dockerEntry)
+	links := make(map[string]string)
+
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		name := path.Clean(header.Name)
+
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			links[name] = path.Join(path.Dir(name), header.Linkname)
+		case tar.TypeLink:
+			links[name] = path.Clean(header.Linkname)
+		case tar.TypeReg:
+			entry, err := scanDockerEntry(tr, header.Size)
+			if err != nil {
+				return nil, nil, err
+			}
+			entries[name] = entry
+		}
+	}
+
+	return entries, links, nil
+}
+
+// Hashes a single file, sniffing its compression.
+func scanDockerEntry(r io.Reader, size int64) (*dockerEntry, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+
+	entry := &dockerEntry{mediaType: layerMediaType(magic)}
+
+	var w io.Writer = io.Discard
+	var buf *bytes.Buffer
+	if size <= maxMetadataBlobSize {
+		buf = bytes.NewBuffer(make([]byte, 0, size))
+		w = buf
+	}
+
+	digester, err := reference.NewDigester(reference.DefaultDigestAlgorithm, w)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(digester, br); err != nil {
+		return nil, err
+	}
+
+	entry.size = digester.Size()
+	entry.digest = digester.Digest().String()
+	if buf != nil {
+		entry.data = buf.Bytes()
+	}
+
+	return entry, nil
+}
+
+// Returns the layer media type matching the leading bytes of a layer.
+func layerMediaType(magic []byte) string {
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return OCIImageLayerGzipMediaType
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return OCIImageLayerZstdMediaType
+	default:
+		return OCIImageLayerMediaType
+	}
+}
+
+// Copies files from a docker save tarball into a layout as blobs.
+//
+// The needed parameter maps the cleaned path of each file to copy to the
+// digest computed while scanning. Files are verified against that digest again
+// as they are copied, in case the source changed between the two passes.
+func copyDockerFiles(w *layoutWriter, src string, needed map[string]string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		digest, ok := needed[path.Clean(header.Name)]
+		if !ok || w.has(digest) {
+			continue
+		}
+
+		expected, err := reference.ParseDigest(digest)
+		if err != nil {
+			return err
+		}
+		vr, err := reference.NewVerifyingReader(tr, expected)
+		if err != nil {
+			return err
+		}
+
+		if err := w.writeBlob(digest, header.Size, vr); err != nil {
+			return err
+		}
+
+		// Read to EOF so the digest is checked, in case the source changed
+		if _, err := io.Copy(io.Discard, vr); err != nil {
+			return fmt.Errorf("%s changed during conversion: %w", header.Name, err)
+		}
+	}
+
+	for name, digest := range needed {
+		if !w.has(digest) {
+			return fmt.Errorf("%s not found", name)
+		}
+	}
+
+	return nil
+}
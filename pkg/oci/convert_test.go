@@ -0,0 +1,192 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertDockerArchive(t *testing.T) {
+	src := writeDockerArchive(t, "linux", "amd64", "")
+	dest := filepath.Join(t.TempDir(), "image.tar")
+
+	if err := ConvertDockerArchive(src, dest); err != nil {
+		t.Fatalf("ConvertDockerArchive failed: %v", err)
+	}
+
+	layout, err := ReadLayout(dest)
+	if err != nil {
+		t.Fatalf("ReadLayout failed: %v", err)
+	}
+	if err := layout.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if len(layout.Index.Manifests) != 1 {
+		t.Fatalf("expected 1 manifest, got %d", len(layout.Index.Manifests))
+	}
+	d := layout.Index.Manifests[0]
+	if d.Platform == nil || d.Platform.String() != "linux/amd64" {
+		t.Fatalf("unexpected platform: %v", d.Platform)
+	}
+	if d.Annotations[OCIRefNameAnnotation] != "example:latest" {
+		t.Fatalf("unexpected annotations: %v", d.Annotations)
+	}
+
+	data, err := layout.blobData(d.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{OCIImageLayerMediaType, OCIImageLayerGzipMediaType, OCIImageLayerMediaType}
+	if len(manifest.Layers) != len(want) {
+		t.Fatalf("expected %d layers, got %d", len(want), len(manifest.Layers))
+	}
+	for i, layer := range manifest.Layers {
+		if layer.MediaType != want[i] {
+			t.Errorf("layer %d media type = %q, want %q", i, layer.MediaType, want[i])
+		}
+	}
+	if manifest.Layers[0].Digest != manifest.Layers[2].Digest {
+		t.Error("expected symlinked layer to resolve to the same blob")
+	}
+}
+
+func TestConvertDockerArchiveMissingManifest(t *testing.T) {
+	src := newTestImage(t, []string{"linux/amd64"}).write(t)
+	dest := filepath.Join(t.TempDir(), "image.tar")
+
+	if err := ConvertDockerArchive(src, dest); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("expected ErrInvalidImage, got: %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatal("expected no output to be written")
+	}
+}
+
+func TestMergeImages(t *testing.T) {
+	dir := t.TempDir()
+	amd64 := filepath.Join(dir, "amd64.tar")
+	arm64 := filepath.Join(dir, "arm64.tar")
+
+	if err := ConvertDockerArchive(writeDockerArchive(t, "linux", "amd64", ""), amd64); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConvertDockerArchive(writeDockerArchive(t, "linux", "arm64", "v8"), arm64); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(dir, "merged.tar")
+	if err := MergeImages(dest, amd64, arm64); err != nil {
+		t.Fatalf("MergeImages failed: %v", err)
+	}
+
+	if err := ValidateLayout(dest); err != nil {
+		t.Fatalf("ValidateLayout failed: %v", err)
+	}
+	if err := ValidateMultiPlatform(dest); err != nil {
+		t.Fatalf("ValidateMultiPlatform failed: %v", err)
+	}
+}
+
+func TestMergeImagesNested(t *testing.T) {
+	nested := newTestImage(t, []string{"linux/arm64"})
+	nested.nest(t)
+	arm64 := nested.write(t)
+	amd64 := newTestImage(t, []string{"linux/amd64"}).write(t)
+
+	dest := filepath.Join(t.TempDir(), "merged.tar")
+	if err := MergeImages(dest, amd64, arm64); err != nil {
+		t.Fatalf("MergeImages failed: %v", err)
+	}
+
+	if err := ValidateLayout(dest); err != nil {
+		t.Fatalf("ValidateLayout failed: %v", err)
+	}
+}
+
+func TestMergeImagesDuplicatePlatform(t *testing.T) {
+	a := newTestImage(t, []string{"linux/amd64"}).write(t)
+	b := newTestImage(t, []string{"linux/amd64", "linux/arm64"}).write(t)
+
+	dest := filepath.Join(t.TempDir(), "merged.tar")
+	err := MergeImages(dest, a, b)
+	if !errors.Is(err, ErrDuplicatePlatform) {
+		t.Fatalf("expected ErrDuplicatePlatform, got: %v", err)
+	}
+	if !errors.Is(err, ErrMergeFailed) {
+		t.Fatalf("expected ErrMergeFailed, got: %v", err)
+	}
+}
+
+// Writes a tarball in the legacy docker save format.
+//
+// The image has an uncompressed layer, a gzip-compressed layer, and a third
+// layer stored as a symlink to the first.
+func writeDockerArchive(t *testing.T, goos, arch, variant string) string {
+	t.Helper()
+
+	config, err := json.Marshal(Platform{OS: goos, Architecture: arch, Variant: variant})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("compressed layer " + arch))
+	zw.Close()
+
+	manifest, err := json.Marshal([]dockerManifest{{
+		Config:   "config.json",
+		RepoTags: []string{"example:latest"},
+		Layers:   []string{"aaa/layer.tar", "bbb/layer.tar", "ccc/layer.tar"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(t.TempDir(), "docker.tar")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"aaa/layer.tar", []byte("plain layer " + arch)},
+		{"bbb/layer.tar", gz.Bytes()},
+		{"config.json", config},
+		{DockerManifestFile, manifest},
+		{"repositories", []byte(`{}`)},
+	}
+	for _, file := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := &tar.Header{Name: "ccc/layer.tar", Typeflag: tar.TypeSymlink, Linkname: "../aaa/layer.tar", Mode: 0777}
+	if err := tw.WriteHeader(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
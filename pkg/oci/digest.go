@@ -0,0 +1,66 @@
+package oci
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+const (
+
+	// Directory holding blobs within an OCI tarball.
+	OCIBlobsDir = "blobs"
+)
+
+// Creates a descriptor for content with the given digest.
+func NewDescriptor(mediaType string, digest *reference.Digest, size int64) Descriptor {
+	return Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.String(),
+		Size:      size,
+	}
+}
+
+// Parses and validates the descriptor digest.
+//
+// Returns an error wrapping [ErrInvalidImage] and [reference.ErrInvalidDigest]
+// if the digest is malformed, uses an unsupported algorithm, or has a hash of
+// the wrong length.
+func (d *Descriptor) ParseDigest() (*reference.Digest, error) {
+	return parseDigest(d.Digest)
+}
+
+// Returns the path of a blob within an OCI tarball.
+//
+// The path follows the pattern: blobs/{algorithm}/{hash}
+func BlobPath(digest *reference.Digest) string {
+	return path.Join(OCIBlobsDir, digest.Algorithm, digest.Hash)
+}
+
+// Parses the digest of a blob from its path within an OCI tarball.
+//
+// The name parameter is a slash-separated path of the form
+// blobs/{algorithm}/{hash}, optionally prefixed with "./". Returns
+// [ErrInvalidImage] if the path is not a blob path or the digest is invalid.
+func ParseBlobPath(name string) (*reference.Digest, error) {
+	parts := strings.Split(path.Clean(strings.TrimPrefix(name, "./")), "/")
+	if len(parts) != 3 || parts[0] != OCIBlobsDir {
+		return nil, helpers.Wrap(ErrInvalidImage, fmt.Errorf("invalid blob path %q", name))
+	}
+	return parseDigest(parts[1] + ":" + parts[2])
+}
+
+// Parses and validates a digest string.
+func parseDigest(s string) (*reference.Digest, error) {
+	digest, err := reference.ParseDigest(s)
+	if err != nil {
+		return nil, helpers.Wrap(ErrInvalidImage, err)
+	}
+	if err := digest.Validate(); err != nil {
+		return nil, helpers.Wrap(ErrInvalidImage, err)
+	}
+	return digest, nil
+}
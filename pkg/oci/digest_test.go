@@ -0,0 +1,58 @@
+package oci
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+func TestBlobPathRoundTrip(t *testing.T) {
+	digest, err := reference.FromBytes(reference.SHA512, []byte("blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := BlobPath(digest)
+	if !strings.HasPrefix(p, "blobs/sha512/") {
+		t.Fatalf("BlobPath() = %q", p)
+	}
+
+	parsed, err := ParseBlobPath("./" + p)
+	if err != nil {
+		t.Fatalf("ParseBlobPath failed: %v", err)
+	}
+	if !parsed.Equal(digest) {
+		t.Fatalf("ParseBlobPath() = %s, want %s", parsed, digest)
+	}
+}
+
+func TestParseBlobPathInvalid(t *testing.T) {
+	for _, name := range []string{"index.json", "blobs/sha256", "blobs/sha256/abc", "blobs/md5/" + strings.Repeat("a", 32)} {
+		if _, err := ParseBlobPath(name); !errors.Is(err, ErrInvalidImage) {
+			t.Errorf("%s: expected ErrInvalidImage, got: %v", name, err)
+		}
+	}
+}
+
+func TestDescriptorParseDigest(t *testing.T) {
+	digest, err := reference.FromBytes(reference.SHA256, []byte("blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDescriptor(OCIImageLayerMediaType, digest, 4)
+	parsed, err := d.ParseDigest()
+	if err != nil {
+		t.Fatalf("ParseDigest failed: %v", err)
+	}
+	if !parsed.Equal(digest) {
+		t.Fatalf("ParseDigest() = %s, want %s", parsed, digest)
+	}
+
+	d.Digest = "sha256:short"
+	if _, err := d.ParseDigest(); !errors.Is(err, reference.ErrInvalidDigest) {
+		t.Fatalf("expected ErrInvalidDigest, got: %v", err)
+	}
+}
@@ -3,4 +3,41 @@
 // This package handles parsing and validation of OCI image tarballs, including
 // multi-platform manifest lists and nested index structures commonly produced
 // by tools like Docker Buildx.
+//
+// [ReadLayout] reads an image layout in a single streaming pass, hashing every
+// blob as it goes. [Layout.Validate] then walks the descriptor graph from
+// index.json through nested indexes, manifests, configs and layers, verifying
+// each blob's digest and size, and reports missing, mismatched and unreferenced
+// blobs in a [LayoutError].
+//
+// [ValidatePlatforms] checks that an image supports a set of platforms, given
+// as "os/arch" or "os/arch/variant", defaulting to [RequiredPlatforms]. Missing
+// platforms are reported in a [PlatformError] listing the required, found and
+// missing platforms.
+//
+// [Inspect] resolves, for each platform of an image, the [Manifest] with its
+// layers and their compressed sizes, and the [ImageConfig] holding the
+// entrypoint, environment, exposed ports, labels and user.
+//
+// Images produced by docker save can be rewritten as OCI image layouts with
+// [ConvertDockerArchive], and single-platform images built separately can be
+// combined into one multi-platform image with [MergeImages].
+//
+// Example:
+//
+//	// Combine per-platform docker save archives
+//	err := oci.ConvertDockerArchive("amd64-docker.tar", "amd64.tar")
+//	err = oci.ConvertDockerArchive("arm64-docker.tar", "arm64.tar")
+//	err = oci.MergeImages("image.tar", "amd64.tar", "arm64.tar")
+//
+//	layout, err := oci.ReadLayout("image.tar")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := layout.Validate(); err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := layout.ValidatePlatforms([]string{"linux/arm64", "linux/arm/v7"}); err != nil {
+//		log.Fatal(err)
+//	}
 package oci
@@ -3,6 +3,10 @@ package oci
 import "errors"
 
 var (
-	ErrInvalidImage   = errors.New("invalid OCI image")
-	ErrSinglePlatform = errors.New("single-platform image")
+	ErrInvalidImage      = errors.New("invalid OCI image")
+	ErrSinglePlatform    = errors.New("single-platform image")
+	ErrInvalidPlatform   = errors.New("invalid platform")
+	ErrDuplicatePlatform = errors.New("duplicate platform")
+	ErrConvertFailed     = errors.New("image conversion failed")
+	ErrMergeFailed       = errors.New("image merge failed")
 )
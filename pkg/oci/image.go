@@ -4,12 +4,12 @@ import (
 	"archive/tar"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"os"
-	"path"
+	"strings"
 
 	"github.com/cruciblehq/protocol/internal/helpers"
 	"github.com/cruciblehq/protocol/pkg/archive"
+	"github.com/cruciblehq/protocol/pkg/reference"
 )
 
 const (
@@ -18,7 +18,7 @@ const (
 	OCIImageIndexMediaType = "application/vnd.oci.image.index.v1+json"
 
 	// Directory path prefix for SHA256 blobs within an OCI tarball.
-	OCIBlobsSHA256Dir = "blobs/sha256"
+	OCIBlobsSHA256Dir = OCIBlobsDir + "/" + reference.SHA256
 )
 
 // Represents an OCI Image Index structure.
@@ -30,10 +30,11 @@ type Index struct {
 
 // Represents an OCI Content Descriptor.
 type Descriptor struct {
-	MediaType string    `json:"mediaType"`          // OCI media type
-	Digest    string    `json:"digest"`             // Content digest
-	Size      int64     `json:"size"`               // Size in bytes
-	Platform  *Platform `json:"platform,omitempty"` // Platform information
+	MediaType   string            `json:"mediaType"`             // OCI media type
+	Digest      string            `json:"digest"`                // Content digest
+	Size        int64             `json:"size"`                  // Size in bytes
+	Platform    *Platform         `json:"platform,omitempty"`    // Platform information
+	Annotations map[string]string `json:"annotations,omitempty"` // Arbitrary metadata
 }
 
 // Represents an OCI Platform structure.
@@ -47,7 +48,9 @@ type Platform struct {
 //
 // Images must support all these platforms to be considered universally
 // deployable within the Crucible ecosystem. The list may be updated in future
-// releases to include additional architectures as needed.
+// releases to include additional architectures as needed. It is the default
+// for [ValidatePlatforms] when no platforms are given; services targeting other
+// environments declare their own in the manifest.
 func RequiredPlatforms() []string {
 	return []string{
 		"linux/amd64", // x86_64 servers, most cloud providers
@@ -91,6 +94,9 @@ func IsNestedIndex(index *Index) bool {
 }
 
 // Reads a nested OCI index from the blobs directory.
+//
+// The digest parameter is a descriptor digest. A bare hash without an
+// algorithm is treated as sha256.
 func ReadNestedIndex(imagePath, digest string) (*Index, error) {
 	f, err := os.Open(imagePath)
 	if err != nil {
@@ -102,14 +108,17 @@ func ReadNestedIndex(imagePath, digest string) (*Index, error) {
 		return nil, helpers.Wrap(ErrInvalidImage, errors.New("manifest digest is empty"))
 	}
 
-	// Remove "sha256:" prefix if present
-	if len(digest) > 7 && digest[:7] == "sha256:" {
-		digest = digest[7:]
+	if !strings.Contains(digest, ":") {
+		digest = reference.SHA256 + ":" + digest
+	}
+
+	parsed, err := parseDigest(digest)
+	if err != nil {
+		return nil, err
 	}
 
 	tr := tar.NewReader(f)
-	blobPath := path.Join(OCIBlobsSHA256Dir, digest)
-	nestedData, err := archive.FindInTar(tr, blobPath)
+	nestedData, err := archive.FindInTar(tr, BlobPath(parsed))
 	if err != nil {
 		return nil, helpers.Wrap(ErrInvalidImage, err)
 	}
@@ -126,65 +135,53 @@ func ReadNestedIndex(imagePath, digest string) (*Index, error) {
 	return &index, nil
 }
 
+// Reads the index listing the platform manifests of an OCI tarball.
+//
+// Reads index.json and, for images from Docker Buildx, the nested index it
+// points to (see [IsNestedIndex]). Other blobs are neither read nor verified.
+func readPlatformIndex(imagePath string) (*Index, error) {
+	index, err := ReadIndex(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsNestedIndex(index) {
+		return ReadNestedIndex(imagePath, index.Manifests[0].Digest)
+	}
+	return index, nil
+}
+
 // Extracts valid platform identifiers from an OCI index.
 //
 // Excludes attestation manifests and descriptors with unknown os/architecture.
-// Returns a map where keys are platform strings in "os/arch" format.
+// Returns a map where keys are platform strings in "os/arch" format. Variants
+// are left out of the keys; use [IndexPlatforms] to tell them apart.
 func Platforms(index *Index) map[string]bool {
 	platforms := make(map[string]bool)
-	for _, manifest := range index.Manifests {
-		if manifest.Platform == nil {
-			continue
-		}
-		// Skip attestation manifests with unknown os/arch
-		if manifest.Platform.OS == "unknown" || manifest.Platform.Architecture == "unknown" {
-			continue
-		}
-		key := fmt.Sprintf("%s/%s", manifest.Platform.OS, manifest.Platform.Architecture)
-		platforms[key] = true
+	for _, p := range IndexPlatforms(index) {
+		platforms[p.OS+"/"+p.Architecture] = true
 	}
 	return platforms
 }
 
-// Validates that an OCI tarball contains multiple platforms.
+// Validates that an OCI tarball supports every platform in [RequiredPlatforms].
 //
-// Returns ErrSinglePlatform if the image supports only one platform or none
-// and ErrInvalidImage if the tarball structure is invalid.
+// Only index.json and the nested index it may point to are read, so the
+// tarball needs no oci-layout file and blobs are not verified; use
+// [ValidateLayout] for that. Returns a [*PlatformError], matching
+// ErrSinglePlatform, if a required platform is missing and ErrInvalidImage if
+// the index cannot be read. Use [ValidatePlatforms] to require a different set
+// of platforms.
 func ValidateMultiPlatform(imagePath string) error {
-	index, err := ReadIndex(imagePath)
-	if err != nil {
-		return err
-	}
-
-	// OCI images from buildx have a nested structure - resolve it if needed
-	if IsNestedIndex(index) {
-		index, err = ReadNestedIndex(imagePath, index.Manifests[0].Digest)
-		if err != nil {
-			return err
-		}
-	}
-
-	platforms := Platforms(index)
-
-	if len(platforms) == 0 {
-		return helpers.Wrap(ErrSinglePlatform, errors.New("image does not specify any platforms"))
-	}
-
-	// Check that all required platforms are present
-	var missing []string
-	for _, required := range RequiredPlatforms() {
-		if !platforms[required] {
-			missing = append(missing, required)
-		}
-	}
-
-	if len(missing) > 0 {
-		var supported []string
-		for p := range platforms {
-			supported = append(supported, p)
-		}
-		return ErrSinglePlatform
-	}
+	return ValidatePlatforms(imagePath, nil)
+}
 
-	return nil
+// Validates that the layout's image supports every platform in
+// [RequiredPlatforms].
+//
+// Same as [ValidateMultiPlatform], for a layout already read with
+// [ReadLayout]. Only the index is inspected; use [Layout.Validate] to check
+// the blobs.
+func (l *Layout) ValidateMultiPlatform() error {
+	return l.ValidatePlatforms(nil)
 }
@@ -0,0 +1,191 @@
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+)
+
+// Represents an OCI Image Configuration structure.
+//
+// Only the fields relevant to deploying an image are modeled. Docker image
+// configurations share this structure and decode the same way.
+type ImageConfig struct {
+	Architecture string          `json:"architecture"`      // CPU architecture
+	OS           string          `json:"os"`                // Operating system
+	Variant      string          `json:"variant,omitempty"` // CPU variant (optional)
+	Created      string          `json:"created,omitempty"` // Creation time in RFC 3339 format
+	Config       ContainerConfig `json:"config"`            // Execution parameters
+	RootFS       RootFS          `json:"rootfs"`            // Layer content digests
+}
+
+// Holds the execution parameters of an image.
+type ContainerConfig struct {
+	User         string              `json:"User,omitempty"`         // User or UID the process runs as
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"` // Ports in "port/protocol" format
+	Env          []string            `json:"Env,omitempty"`          // Environment in "KEY=value" format
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`   // Command run when the container starts
+	Cmd          []string            `json:"Cmd,omitempty"`          // Default arguments to the entrypoint
+	WorkingDir   string              `json:"WorkingDir,omitempty"`   // Working directory of the process
+	Labels       map[string]string   `json:"Labels,omitempty"`       // Arbitrary metadata
+	StopSignal   string              `json:"StopSignal,omitempty"`   // Signal sent to stop the container
+}
+
+// Lists the uncompressed digests of the image layers.
+type RootFS struct {
+	Type    string   `json:"type"`     // Always "layers"
+	DiffIDs []string `json:"diff_ids"` // Digests of the uncompressed layers, base first
+}
+
+// A port exposed by an image.
+type ExposedPort struct {
+	Port     uint16 // Port number
+	Protocol string // Protocol, "tcp" or "udp"
+}
+
+// Returns the exposed ports, sorted by port and protocol.
+//
+// Ports without a protocol default to "tcp". Returns [ErrInvalidImage] if a
+// port cannot be parsed.
+func (c *ImageConfig) Ports() ([]ExposedPort, error) {
+	ports := make([]ExposedPort, 0, len(c.Config.ExposedPorts))
+	for key := range c.Config.ExposedPorts {
+		number, protocol, _ := strings.Cut(key, "/")
+		if protocol == "" {
+			protocol = "tcp"
+		}
+
+		n, err := strconv.ParseUint(number, 10, 16)
+		if err != nil {
+			return nil, helpers.Wrap(ErrInvalidImage, fmt.Errorf("exposed port %q", key))
+		}
+
+		ports = append(ports, ExposedPort{Port: uint16(n), Protocol: protocol})
+	}
+
+	slices.SortFunc(ports, func(a, b ExposedPort) int {
+		if a.Port != b.Port {
+			return int(a.Port) - int(b.Port)
+		}
+		return strings.Compare(a.Protocol, b.Protocol)
+	})
+
+	return ports, nil
+}
+
+// Returns the environment as a map.
+//
+// Entries without "=" map to an empty value. Later entries win.
+func (c *ImageConfig) Environment() map[string]string {
+	env := make(map[string]string, len(c.Config.Env))
+	for _, entry := range c.Config.Env {
+		key, value, _ := strings.Cut(entry, "=")
+		env[key] = value
+	}
+	return env
+}
+
+// The image for a single platform of a possibly multi-platform image.
+type PlatformImage struct {
+	Platform   Platform     // Platform the image runs on
+	Descriptor Descriptor   // Descriptor of the manifest in the index
+	Manifest   *Manifest    // Resolved manifest, listing the layers with their compressed sizes
+	Config     *ImageConfig // Resolved image configuration
+}
+
+// Returns the compressed size of the image in bytes.
+//
+// Counts the manifest, the configuration and every layer, matching the bytes
+// a registry would transfer for this platform.
+func (p *PlatformImage) Size() int64 {
+	size := p.Descriptor.Size + p.Manifest.Config.Size
+	for _, layer := range p.Manifest.Layers {
+		size += layer.Size
+	}
+	return size
+}
+
+// Inspects every platform of an OCI image tarball.
+//
+// Shorthand for [ReadLayout] followed by [Layout.Inspect].
+func Inspect(imagePath string) ([]*PlatformImage, error) {
+	layout, err := ReadLayout(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	return layout.Inspect()
+}
+
+// Resolves the manifest and configuration of every platform in the layout.
+//
+// Platforms are listed in index order, after resolving nested indexes (see
+// [Layout.ResolveIndex]). Attestation manifests and descriptors that are not
+// image manifests are skipped. Descriptors without a platform take it from the
+// image configuration. Blob digests are not verified; use [Layout.Validate]
+// for that. Returns [ErrInvalidImage] if a manifest or configuration is
+// missing or cannot be parsed.
+func (l *Layout) Inspect() ([]*PlatformImage, error) {
+	index, err := l.ResolveIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var images []*PlatformImage
+	for _, d := range index.Manifests {
+		if d.MediaType != OCIImageManifestMediaType && d.MediaType != DockerManifestMediaType {
+			continue
+		}
+
+		// Skip attestation manifests with unknown os/arch
+		if d.Platform != nil && isUnknownPlatform(d.Platform) {
+			continue
+		}
+
+		var manifest Manifest
+		if err := l.decodeBlob(d.Digest, &manifest); err != nil {
+			return nil, err
+		}
+
+		var config ImageConfig
+		if err := l.decodeBlob(manifest.Config.Digest, &config); err != nil {
+			return nil, err
+		}
+
+		platform := Platform{OS: config.OS, Architecture: config.Architecture, Variant: config.Variant}
+		if d.Platform != nil {
+			platform = *d.Platform
+		}
+
+		if isUnknownPlatform(&platform) {
+			continue
+		}
+		if platform.OS == "" || platform.Architecture == "" {
+			return nil, helpers.Wrap(ErrInvalidImage, fmt.Errorf("manifest %s does not declare a platform", d.Digest))
+		}
+
+		images = append(images, &PlatformImage{
+			Platform:   platform,
+			Descriptor: d,
+			Manifest:   &manifest,
+			Config:     &config,
+		})
+	}
+
+	return images, nil
+}
+
+// Decodes a JSON blob kept in memory.
+func (l *Layout) decodeBlob(digest string, target any) error {
+	data, err := l.blobData(digest)
+	if err != nil {
+		return helpers.Wrap(ErrInvalidImage, err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return helpers.Wrap(ErrInvalidImage, fmt.Errorf("blob %s: %w", digest, err))
+	}
+	return nil
+}
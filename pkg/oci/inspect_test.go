@@ -0,0 +1,99 @@
+package oci
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	img := &testImage{files: map[string][]byte{
+		OCILayoutFile: []byte(`{"imageLayoutVersion": "1.0.0"}`),
+	}}
+
+	config := ImageConfig{
+		Architecture: "arm64",
+		OS:           "linux",
+		Config: ContainerConfig{
+			User:         "app",
+			ExposedPorts: map[string]struct{}{"8080/tcp": {}, "53/udp": {}, "443": {}},
+			Env:          []string{"PATH=/usr/bin", "MODE=prod"},
+			Entrypoint:   []string{"/app/server"},
+			Labels:       map[string]string{"team": "edge"},
+		},
+	}
+	layer := img.addBlob([]byte("layer contents"))
+	manifest := Manifest{
+		SchemaVersion: 2,
+		MediaType:     OCIImageManifestMediaType,
+		Config:        img.addJSON(t, OCIImageConfigMediaType, config),
+		Layers:        []Descriptor{img.descriptor(OCIImageLayerGzipMediaType, layer)},
+	}
+
+	// No platform in the descriptor, so it comes from the config
+	d := img.addJSON(t, OCIImageManifestMediaType, manifest)
+	img.index = Index{SchemaVersion: 2, MediaType: OCIImageIndexMediaType, Manifests: []Descriptor{d}}
+	img.nest(t)
+
+	images, err := Inspect(img.write(t))
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("expected 1 image, got %d", len(images))
+	}
+
+	image := images[0]
+	if image.Platform.String() != "linux/arm64" {
+		t.Errorf("Platform = %s", image.Platform.String())
+	}
+	if image.Config.Config.User != "app" {
+		t.Errorf("User = %q", image.Config.Config.User)
+	}
+	if !slices.Equal(image.Config.Config.Entrypoint, []string{"/app/server"}) {
+		t.Errorf("Entrypoint = %v", image.Config.Config.Entrypoint)
+	}
+	if image.Config.Config.Labels["team"] != "edge" {
+		t.Errorf("Labels = %v", image.Config.Config.Labels)
+	}
+	if env := image.Config.Environment(); env["MODE"] != "prod" {
+		t.Errorf("Environment = %v", env)
+	}
+
+	ports, err := image.Config.Ports()
+	if err != nil {
+		t.Fatalf("Ports failed: %v", err)
+	}
+	want := []ExposedPort{{53, "udp"}, {443, "tcp"}, {8080, "tcp"}}
+	if !slices.Equal(ports, want) {
+		t.Errorf("Ports = %v, want %v", ports, want)
+	}
+
+	if len(image.Manifest.Layers) != 1 || image.Manifest.Layers[0].Size != int64(len("layer contents")) {
+		t.Errorf("Layers = %v", image.Manifest.Layers)
+	}
+	wantSize := d.Size + manifest.Config.Size + int64(len("layer contents"))
+	if image.Size() != wantSize {
+		t.Errorf("Size() = %d, want %d", image.Size(), wantSize)
+	}
+}
+
+func TestInspectSkipsAttestations(t *testing.T) {
+	img := newTestImage(t, []string{"linux/amd64", "unknown/unknown"})
+
+	images, err := Inspect(img.write(t))
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if len(images) != 1 || images[0].Platform.String() != "linux/amd64" {
+		t.Fatalf("unexpected images: %v", images)
+	}
+}
+
+func TestImageConfigPortsInvalid(t *testing.T) {
+	config := &ImageConfig{Config: ContainerConfig{ExposedPorts: map[string]struct{}{"http/tcp": {}}}}
+
+	if _, err := config.Ports(); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("expected ErrInvalidImage, got: %v", err)
+	}
+}
@@ -0,0 +1,400 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+const (
+
+	// The media type for an OCI Image Manifest.
+	OCIImageManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	// The media type for a Docker manifest list.
+	DockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	// The media type for a Docker image manifest.
+	DockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// The image layout version written to the oci-layout file.
+	OCILayoutVersion = "1.0.0"
+
+	// Path of the image layout marker file within an OCI tarball.
+	OCILayoutFile = "oci-layout"
+
+	// Path of the top-level index within an OCI tarball.
+	OCIIndexFile = "index.json"
+
+	// Largest blob kept in memory while reading a layout.
+	//
+	// Indexes, manifests and configs are small JSON documents and are kept so
+	// the descriptor graph can be walked after the single pass over the tarball.
+	// Larger blobs, in practice layers, are hashed and discarded.
+	maxMetadataBlobSize = 4 << 20
+
+	// Maximum nesting of indexes followed while walking a layout.
+	maxIndexDepth = 8
+)
+
+// Represents an OCI Image Manifest structure.
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`       // OCI image manifest schema version
+	MediaType     string       `json:"mediaType,omitempty"` // OCI media type
+	Config        Descriptor   `json:"config"`              // Image configuration blob
+	Layers        []Descriptor `json:"layers"`              // Filesystem layer blobs
+}
+
+// An OCI image layout read from a tarball.
+//
+// Produced by [ReadLayout] in a single pass over the tarball. Holds the
+// top-level index, the oci-layout version, and the digest and size computed
+// for every blob. Small blobs are kept in memory so the descriptor graph can
+// be validated and resolved without reading the tarball again.
+type Layout struct {
+	Version string // Image layout version declared in oci-layout.
+	Index   *Index // Top-level index read from index.json.
+	blobs   map[string]*blob
+}
+
+// A blob found in the blobs directory of a layout.
+type blob struct {
+	computed string // Digest computed from the blob contents.
+	size     int64  // Size of the blob contents.
+	data     []byte // Blob contents, or nil if larger than maxMetadataBlobSize.
+}
+
+// Reports blobs that do not match the descriptors referencing them.
+//
+// Returned by [Layout.Validate] after walking the whole descriptor graph, so a
+// single error lists every problem found. Matches [ErrInvalidImage] with
+// [errors.Is].
+type LayoutError struct {
+	Missing      []string // Digests referenced by a descriptor but absent from the layout.
+	Mismatched   []string // Digests whose blob contents or size do not match the descriptor.
+	Unreferenced []string // Digests of blobs not reachable from index.json.
+}
+
+// Returns a summary of the problems found.
+func (e *LayoutError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing blobs: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Mismatched) > 0 {
+		parts = append(parts, "mismatched blobs: "+strings.Join(e.Mismatched, ", "))
+	}
+	if len(e.Unreferenced) > 0 {
+		parts = append(parts, "unreferenced blobs: "+strings.Join(e.Unreferenced, ", "))
+	}
+	return ErrInvalidImage.Error() + ": " + strings.Join(parts, "; ")
+}
+
+// Returns [ErrInvalidImage].
+func (e *LayoutError) Unwrap() error {
+	return ErrInvalidImage
+}
+
+// Validates the complete OCI image layout of a tarball.
+//
+// Shorthand for [ReadLayout] followed by [Layout.Validate].
+func ValidateLayout(imagePath string) error {
+	layout, err := ReadLayout(imagePath)
+	if err != nil {
+		return err
+	}
+	return layout.Validate()
+}
+
+// Reads an OCI image layout from a tarball in a single pass.
+//
+// Every entry under blobs/ is hashed as it streams by. The oci-layout and
+// index.json files are parsed, and other entries (such as the manifest.json
+// written by docker save) are ignored. Returns [ErrInvalidImage] if the tarball
+// cannot be read, if oci-layout or index.json is missing or malformed, or if a
+// blob path is not of the form blobs/<algorithm>/<hex> with a supported
+// algorithm (see [reference.IsSupportedDigestAlgorithm]).
+func ReadLayout(imagePath string) (*Layout, error) {
+	f, err := os.Open(imagePath)
+	if err != nil {
+		return nil, helpers.Wrap(ErrInvalidImage, err)
+	}
+	defer f.Close()
+
+	layout, err := readLayout(tar.NewReader(f))
+	if err != nil {
+		return nil, helpers.Wrap(ErrInvalidImage, err)
+	}
+
+	return layout, nil
+}
+
+// Reads the layout entries from a tar stream.
+func readLayout(tr *tar.Reader) (*Layout, error) {
+	layout := &Layout{blobs: make(map[string]*blob)}
+	var layoutData, indexData []byte
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+
+		switch {
+		case name == OCILayoutFile:
+			if layoutData, err = readSmall(tr, header.Size); err != nil {
+				return nil, err
+			}
+		case name == OCIIndexFile:
+			if indexData, err = readSmall(tr, header.Size); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(name, "blobs/"):
+			digest, b, err := readBlob(tr, name, header.Size)
+			if err != nil {
+				return nil, err
+			}
+			layout.blobs[digest] = b
+		}
+	}
+
+	if layoutData == nil {
+		return nil, errors.New("oci-layout not found")
+	}
+
+	var marker struct {
+		Version string `json:"imageLayoutVersion"`
+	}
+	if err := json.Unmarshal(layoutData, &marker); err != nil {
+		return nil, err
+	}
+	layout.Version = marker.Version
+
+	if indexData == nil {
+		return nil, errors.New("index.json not found")
+	}
+
+	if err := json.Unmarshal(indexData, &layout.Index); err != nil {
+		return nil, err
+	}
+	if layout.Index == nil {
+		return nil, errors.New("index.json is empty")
+	}
+
+	return layout, nil
+}
+
+// Reads a small file entry into memory.
+func readSmall(r io.Reader, size int64) ([]byte, error) {
+	if size > maxMetadataBlobSize {
+		return nil, fmt.Errorf("file too large (%d bytes)", size)
+	}
+	return io.ReadAll(r)
+}
+
+// Hashes a blob entry and keeps its contents if small enough.
+//
+// Returns the digest the blob is stored under, derived from its path.
+func readBlob(r io.Reader, name string, size int64) (string, *blob, error) {
+	digest, err := ParseBlobPath(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var w io.Writer = io.Discard
+	var buf *bytes.Buffer
+	if size <= maxMetadataBlobSize {
+		buf = bytes.NewBuffer(make([]byte, 0, size))
+		w = buf
+	}
+
+	digester, err := reference.NewDigester(digest.Algorithm, w)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := io.Copy(digester, r); err != nil {
+		return "", nil, err
+	}
+
+	b := &blob{
+		computed: digester.Digest().String(),
+		size:     digester.Size(),
+	}
+	if buf != nil {
+		b.data = buf.Bytes()
+	}
+
+	return digest.String(), b, nil
+}
+
+// Validates the descriptor graph of the layout.
+//
+// Walks index.json, nested indexes, manifests, configs and layers, checking
+// that every referenced blob exists and that its digest and size match
+// the descriptor. Blobs not reachable from index.json are reported as
+// unreferenced. Returns [ErrInvalidImage] for structural problems, such as an
+// unsupported layout version or a manifest that cannot be parsed, and a
+// [*LayoutError] listing every missing, mismatched or unreferenced blob.
+func (l *Layout) Validate() error {
+	if l.Version != OCILayoutVersion {
+		return helpers.Wrap(ErrInvalidImage, fmt.Errorf("unsupported image layout version %q", l.Version))
+	}
+
+	v := &validator{layout: l, visited: make(map[string]bool), problems: &LayoutError{}}
+	for _, d := range l.Index.Manifests {
+		if err := v.visit(d); err != nil {
+			return helpers.Wrap(ErrInvalidImage, err)
+		}
+	}
+
+	for digest := range l.blobs {
+		if !v.visited[digest] {
+			v.problems.Unreferenced = append(v.problems.Unreferenced, digest)
+		}
+	}
+
+	p := v.problems
+	if len(p.Missing) == 0 && len(p.Mismatched) == 0 && len(p.Unreferenced) == 0 {
+		return nil
+	}
+
+	slices.Sort(p.Missing)
+	slices.Sort(p.Mismatched)
+	slices.Sort(p.Unreferenced)
+	return p
+}
+
+// Resolves the index listing the platform manifests.
+//
+// Docker Buildx nests the platform manifests in an index referenced from
+// index.json (see [IsNestedIndex]). Nested indexes are followed using the
+// blobs read with the layout, without reading the tarball again.
+func (l *Layout) ResolveIndex() (*Index, error) {
+	index := l.Index
+	for depth := 0; IsNestedIndex(index); depth++ {
+		if depth == maxIndexDepth {
+			return nil, helpers.Wrap(ErrInvalidImage, errors.New("too many nested indexes"))
+		}
+
+		data, err := l.blobData(index.Manifests[0].Digest)
+		if err != nil {
+			return nil, helpers.Wrap(ErrInvalidImage, err)
+		}
+
+		var nested Index
+		if err := json.Unmarshal(data, &nested); err != nil {
+			return nil, helpers.Wrap(ErrInvalidImage, err)
+		}
+		index = &nested
+	}
+	return index, nil
+}
+
+// Returns the contents of a blob kept in memory.
+func (l *Layout) blobData(digest string) ([]byte, error) {
+	b, ok := l.blobs[digest]
+	if !ok {
+		return nil, fmt.Errorf("blob %s not found", digest)
+	}
+	if b.data == nil {
+		return nil, fmt.Errorf("blob %s too large (%d bytes)", digest, b.size)
+	}
+	return b.data, nil
+}
+
+// Walks the descriptor graph of a layout, collecting problems.
+type validator struct {
+	layout   *Layout
+	visited  map[string]bool
+	problems *LayoutError
+	depth    int
+}
+
+// Checks a descriptor and the descriptors it references.
+//
+// Returns an error only for structural problems; blob problems are recorded
+// in the validator and the walk continues.
+func (v *validator) visit(d Descriptor) error {
+	if v.visited[d.Digest] {
+		return nil
+	}
+	v.visited[d.Digest] = true
+
+	b, ok := v.layout.blobs[d.Digest]
+	if !ok {
+		v.problems.Missing = append(v.problems.Missing, d.Digest)
+		return nil
+	}
+	if b.computed != d.Digest || b.size != d.Size {
+		v.problems.Mismatched = append(v.problems.Mismatched, d.Digest)
+		return nil
+	}
+
+	switch d.MediaType {
+	case OCIImageIndexMediaType, DockerManifestListMediaType:
+		var index Index
+		if err := v.decode(d.Digest, &index); err != nil {
+			return err
+		}
+
+		if v.depth == maxIndexDepth {
+			return errors.New("too many nested indexes")
+		}
+		v.depth++
+		defer func() { v.depth-- }()
+
+		for _, m := range index.Manifests {
+			if err := v.visit(m); err != nil {
+				return err
+			}
+		}
+
+	case OCIImageManifestMediaType, DockerManifestMediaType:
+		var manifest Manifest
+		if err := v.decode(d.Digest, &manifest); err != nil {
+			return err
+		}
+
+		if err := v.visit(manifest.Config); err != nil {
+			return err
+		}
+		for _, layer := range manifest.Layers {
+			if err := v.visit(layer); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Decodes a JSON blob.
+func (v *validator) decode(digest string, target any) error {
+	data, err := v.layout.blobData(digest)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("blob %s: %w", digest, err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package oci
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestValidateLayout(t *testing.T) {
+	img := newTestImage(t, []string{"linux/amd64", "linux/arm64"})
+
+	if err := ValidateLayout(img.write(t)); err != nil {
+		t.Fatalf("ValidateLayout failed: %v", err)
+	}
+}
+
+func TestValidateLayoutNestedIndex(t *testing.T) {
+	img := newTestImage(t, []string{"linux/amd64", "linux/arm64"})
+	img.nest(t)
+
+	p := img.write(t)
+	if err := ValidateLayout(p); err != nil {
+		t.Fatalf("ValidateLayout failed: %v", err)
+	}
+	if err := ValidateMultiPlatform(p); err != nil {
+		t.Fatalf("ValidateMultiPlatform failed: %v", err)
+	}
+}
+
+func TestValidateLayoutMissingBlob(t *testing.T) {
+	img := newTestImage(t, []string{"linux/amd64"})
+	delete(img.files, "blobs/sha256/"+img.layer)
+
+	err := ValidateLayout(img.write(t))
+
+	var layoutErr *LayoutError
+	if !errors.As(err, &layoutErr) {
+		t.Fatalf("expected LayoutError, got: %v", err)
+	}
+	if !slices.Equal(layoutErr.Missing, []string{"sha256:" + img.layer}) {
+		t.Fatalf("Missing = %v", layoutErr.Missing)
+	}
+	if !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("expected ErrInvalidImage, got: %v", err)
+	}
+}
+
+func TestValidateLayoutMismatchedBlob(t *testing.T) {
+	img := newTestImage(t, []string{"linux/amd64"})
+	img.files["blobs/sha256/"+img.layer] = []byte("tampered")
+
+	err := ValidateLayout(img.write(t))
+
+	var layoutErr *LayoutError
+	if !errors.As(err, &layoutErr) {
+		t.Fatalf("expected LayoutError, got: %v", err)
+	}
+	if !slices.Equal(layoutErr.Mismatched, []string{"sha256:" + img.layer}) {
+		t.Fatalf("Mismatched = %v", layoutErr.Mismatched)
+	}
+}
+
+func TestValidateLayoutUnreferencedBlob(t *testing.T) {
+	img := newTestImage(t, []string{"linux/amd64"})
+	extra := img.addBlob([]byte("orphan"))
+
+	err := ValidateLayout(img.write(t))
+
+	var layoutErr *LayoutError
+	if !errors.As(err, &layoutErr) {
+		t.Fatalf("expected LayoutError, got: %v", err)
+	}
+	if !slices.Equal(layoutErr.Unreferenced, []string{"sha256:" + extra}) {
+		t.Fatalf("Unreferenced = %v", layoutErr.Unreferenced)
+	}
+}
+
+func TestReadLayoutMissingMarker(t *testing.T) {
+	img := newTestImage(t, []string{"linux/amd64"})
+	delete(img.files, OCILayoutFile)
+
+	if _, err := ReadLayout(img.write(t)); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("expected ErrInvalidImage, got: %v", err)
+	}
+}
+
+// An OCI image layout built in memory.
+type testImage struct {
+	files map[string][]byte
+	index Index
+	layer string // Hash of the layer shared by all manifests.
+}
+
+// Builds a layout with one manifest per platform sharing a config and layer.
+func newTestImage(t *testing.T, platforms []string) *testImage {
+	t.Helper()
+
+	img := &testImage{files: map[string][]byte{
+		OCILayoutFile: []byte(`{"imageLayoutVersion": "1.0.0"}`),
+	}}
+
+	img.layer = img.addBlob([]byte("layer contents"))
+	config := img.addBlob([]byte(`{"architecture": "amd64", "os": "linux"}`))
+
+	img.index = Index{SchemaVersion: 2, MediaType: OCIImageIndexMediaType}
+	for _, platform := range platforms {
+		p, err := ParsePlatform(platform)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		manifest := Manifest{
+			SchemaVersion: 2,
+			MediaType:     OCIImageManifestMediaType,
+			Config:        img.descriptor("application/vnd.oci.image.config.v1+json", config),
+			Layers:        []Descriptor{img.descriptor("application/vnd.oci.image.layer.v1.tar", img.layer)},
+		}
+
+		d := img.addJSON(t, OCIImageManifestMediaType, manifest)
+		d.Platform = p
+		img.index.Manifests = append(img.index.Manifests, d)
+	}
+
+	return img
+}
+
+// Moves the platform manifests into a nested index, as Docker Buildx does.
+func (img *testImage) nest(t *testing.T) {
+	t.Helper()
+	nested := img.addJSON(t, OCIImageIndexMediaType, img.index)
+	img.index = Index{SchemaVersion: 2, MediaType: OCIImageIndexMediaType, Manifests: []Descriptor{nested}}
+}
+
+// Adds a blob and returns its hash.
+func (img *testImage) addBlob(data []byte) string {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	img.files["blobs/sha256/"+hash] = data
+	return hash
+}
+
+// Adds a JSON blob and returns its descriptor.
+func (img *testImage) addJSON(t *testing.T, mediaType string, v any) Descriptor {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img.descriptor(mediaType, img.addBlob(data))
+}
+
+// Returns a descriptor for an existing blob.
+func (img *testImage) descriptor(mediaType, hash string) Descriptor {
+	return Descriptor{
+		MediaType: mediaType,
+		Digest:    "sha256:" + hash,
+		Size:      int64(len(img.files["blobs/sha256/"+hash])),
+	}
+}
+
+// Writes the layout as a tarball and returns its path.
+func (img *testImage) write(t *testing.T) string {
+	t.Helper()
+
+	data, err := json.Marshal(img.index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img.files[OCIIndexFile] = data
+
+	p := filepath.Join(t.TempDir(), "image.tar")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	names := make([]string, 0, len(img.files))
+	for name := range img.files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	tw := tar.NewWriter(f)
+	for _, name := range names {
+		content := img.files[name]
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
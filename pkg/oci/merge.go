@@ -0,0 +1,153 @@
+package oci
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+)
+
+// Merges several OCI image layout tarballs into one multi-platform image.
+//
+// Each source is validated with [Layout.Validate], and the platform manifests
+// of its resolved index (see [Layout.ResolveIndex]) are collected into a single
+// top-level index written to dest, together with the blobs they reference.
+// Manifests without a platform in their descriptor take it from their image
+// configuration. Attestation manifests and nested indexes are dropped. Blobs
+// shared between sources are written once.
+//
+// Returns [ErrDuplicatePlatform] if two sources provide the same platform,
+// [ErrInvalidImage] if a source is not a valid image, and [ErrMergeFailed] if
+// writing fails. All errors are wrapped with [ErrMergeFailed]. If merging
+// fails, dest is removed.
+func MergeImages(dest string, sources ...string) error {
+	if len(sources) == 0 {
+		return helpers.Wrap(ErrMergeFailed, errors.New("no images to merge"))
+	}
+
+	index := &Index{SchemaVersion: 2, MediaType: OCIImageIndexMediaType}
+	needed := make([]map[string]bool, len(sources))
+	seen := make(map[string]string) // Platform to the source providing it
+
+	for i, src := range sources {
+		manifests, blobs, err := collectManifests(src)
+		if err != nil {
+			return helpers.Wrap(ErrMergeFailed, err)
+		}
+
+		for _, d := range manifests {
+			platform := d.Platform.String()
+			if other, ok := seen[platform]; ok {
+				return helpers.Wrap(ErrMergeFailed, helpers.Wrap(ErrDuplicatePlatform, fmt.Errorf("%s in %s and %s", platform, other, src)))
+			}
+			seen[platform] = src
+			index.Manifests = append(index.Manifests, d)
+		}
+
+		needed[i] = blobs
+	}
+
+	w, err := newLayoutWriter(dest)
+	if err != nil {
+		return helpers.Wrap(ErrMergeFailed, err)
+	}
+
+	for i, src := range sources {
+		if err := copyLayoutBlobs(w, src, needed[i]); err != nil {
+			w.abort()
+			return helpers.Wrap(ErrMergeFailed, err)
+		}
+	}
+
+	if err := w.close(index); err != nil {
+		w.abort()
+		return helpers.Wrap(ErrMergeFailed, err)
+	}
+
+	return nil
+}
+
+// Collects the platform manifests of an image and the blobs they reference.
+//
+// Returns the manifest descriptors, each with its platform set, and the
+// digests of the manifests, configs and layers to copy.
+func collectManifests(src string) ([]Descriptor, map[string]bool, error) {
+	layout, err := ReadLayout(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := layout.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	images, err := layout.Inspect()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(images) == 0 {
+		return nil, nil, helpers.Wrap(ErrInvalidImage, fmt.Errorf("%s: no platform manifests", src))
+	}
+
+	manifests := make([]Descriptor, len(images))
+	blobs := make(map[string]bool)
+
+	for i, image := range images {
+		manifests[i] = image.Descriptor
+		manifests[i].Platform = &image.Platform
+
+		blobs[image.Descriptor.Digest] = true
+		blobs[image.Manifest.Config.Digest] = true
+		for _, layer := range image.Manifest.Layers {
+			blobs[layer.Digest] = true
+		}
+	}
+
+	return manifests, blobs, nil
+}
+
+// Copies blobs from an OCI layout tarball into a layout.
+//
+// Only blobs whose digest is in needed are copied. Blobs were verified when
+// the source was validated, so their names are trusted as digests.
+func copyLayoutBlobs(w *layoutWriter, src string, needed map[string]bool) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		parts := strings.Split(name, "/")
+		if len(parts) != 3 || parts[0] != "blobs" {
+			continue
+		}
+
+		digest := parts[1] + ":" + parts[2]
+		if !needed[digest] {
+			continue
+		}
+
+		if err := w.writeBlob(digest, header.Size, tr); err != nil {
+			return err
+		}
+	}
+}
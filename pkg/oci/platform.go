@@ -0,0 +1,168 @@
+package oci
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+)
+
+// Reports an image that does not support every required platform.
+//
+// Returned by [ValidatePlatforms] and [ValidateMultiPlatform] so callers can
+// tell users exactly which platforms to add to their build. Platforms are
+// formatted as "os/arch" or "os/arch/variant". Matches [ErrSinglePlatform]
+// with [errors.Is].
+type PlatformError struct {
+	Required []string // Platforms the image was required to support.
+	Found    []string // Platforms the image supports, sorted.
+	Missing  []string // Required platforms the image does not support.
+}
+
+// Returns a summary listing the missing and found platforms.
+func (e *PlatformError) Error() string {
+	found := "none"
+	if len(e.Found) > 0 {
+		found = strings.Join(e.Found, ", ")
+	}
+	return fmt.Sprintf("%s: missing %s (found %s)", ErrSinglePlatform, strings.Join(e.Missing, ", "), found)
+}
+
+// Returns [ErrSinglePlatform].
+func (e *PlatformError) Unwrap() error {
+	return ErrSinglePlatform
+}
+
+// Parses a platform string in the format "os/arch" or "os/arch/variant".
+//
+// Returns [ErrInvalidPlatform] if the string does not have two or three
+// non-empty components.
+func ParsePlatform(s string) (*Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return nil, helpers.Wrap(ErrInvalidPlatform, fmt.Errorf("%q", s))
+	}
+
+	p := &Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// Returns the platform in "os/arch" or "os/arch/variant" format.
+func (p *Platform) String() string {
+	if p.Variant == "" {
+		return p.OS + "/" + p.Architecture
+	}
+	return p.OS + "/" + p.Architecture + "/" + p.Variant
+}
+
+// Whether the platform satisfies a required platform.
+//
+// OS and architecture must be equal. A required platform without a variant is
+// satisfied by any variant, so "linux/arm64" accepts "linux/arm64/v8", while
+// "linux/arm/v7" only accepts the v7 variant.
+func (p *Platform) Satisfies(required *Platform) bool {
+	if p.OS != required.OS || p.Architecture != required.Architecture {
+		return false
+	}
+	return required.Variant == "" || p.Variant == required.Variant
+}
+
+// Validates that an OCI tarball supports the given platforms.
+//
+// The required parameter lists platforms in "os/arch" or "os/arch/variant"
+// format. When empty, [RequiredPlatforms] is used. Returns a [*PlatformError]
+// if any required platform is missing, [ErrInvalidPlatform] if a required
+// platform cannot be parsed, and [ErrInvalidImage] if the index cannot be
+// read. Like [ValidateMultiPlatform], only the index is read.
+func ValidatePlatforms(imagePath string, required []string) error {
+	index, err := readPlatformIndex(imagePath)
+	if err != nil {
+		return err
+	}
+	return validateIndexPlatforms(index, required)
+}
+
+// Validates that the layout's image supports the given platforms.
+//
+// Same as [ValidatePlatforms], for a layout already read with [ReadLayout].
+// Only the index is inspected; use [Layout.Validate] to check the blobs.
+func (l *Layout) ValidatePlatforms(required []string) error {
+
+	// OCI images from buildx have a nested structure - resolve it if needed
+	index, err := l.ResolveIndex()
+	if err != nil {
+		return err
+	}
+	return validateIndexPlatforms(index, required)
+}
+
+// Validates that the platform manifests of an index support the given
+// platforms, defaulting to [RequiredPlatforms].
+func validateIndexPlatforms(index *Index, required []string) error {
+	if len(required) == 0 {
+		required = RequiredPlatforms()
+	}
+
+	wanted := make([]*Platform, len(required))
+	for i, s := range required {
+		p, err := ParsePlatform(s)
+		if err != nil {
+			return err
+		}
+		wanted[i] = p
+	}
+
+	found := IndexPlatforms(index)
+
+	var missing []string
+	for i, w := range wanted {
+		if !slices.ContainsFunc(found, func(p *Platform) bool { return p.Satisfies(w) }) {
+			missing = append(missing, required[i])
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(found))
+	for i, p := range found {
+		names[i] = p.String()
+	}
+	slices.Sort(names)
+
+	return &PlatformError{
+		Required: slices.Clone(required),
+		Found:    names,
+		Missing:  missing,
+	}
+}
+
+// Returns the platforms of the manifests in an index, in index order.
+//
+// Unlike [Platforms], variants are kept, so the result can be matched against
+// required platforms with [Platform.Satisfies]. Excludes attestation
+// manifests and descriptors with unknown os/architecture.
+func IndexPlatforms(index *Index) []*Platform {
+	var platforms []*Platform
+	for _, manifest := range index.Manifests {
+		if manifest.Platform == nil {
+			continue
+		}
+		// Skip attestation manifests with unknown os/arch
+		if isUnknownPlatform(manifest.Platform) {
+			continue
+		}
+		platforms = append(platforms, manifest.Platform)
+	}
+	return platforms
+}
+
+// Whether a platform is the placeholder used by attestation manifests.
+func isUnknownPlatform(p *Platform) bool {
+	return p.OS == "unknown" || p.Architecture == "unknown"
+}
@@ -0,0 +1,146 @@
+package oci
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"linux/amd64", "linux/amd64", false},
+		{"linux/arm/v7", "linux/arm/v7", false},
+		{"linux", "", true},
+		{"linux/", "", true},
+		{"linux/arm/v7/extra", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p, err := ParsePlatform(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPlatform) {
+					t.Fatalf("expected ErrInvalidPlatform, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePlatform failed: %v", err)
+			}
+			if p.String() != tt.want {
+				t.Fatalf("String() = %q, want %q", p.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPlatformSatisfies(t *testing.T) {
+	tests := []struct {
+		platform string
+		required string
+		want     bool
+	}{
+		{"linux/arm64/v8", "linux/arm64", true},
+		{"linux/arm64", "linux/arm64", true},
+		{"linux/arm/v7", "linux/arm/v7", true},
+		{"linux/arm/v6", "linux/arm/v7", false},
+		{"linux/arm", "linux/arm/v7", false},
+		{"linux/amd64", "linux/arm64", false},
+	}
+
+	for _, tt := range tests {
+		p, _ := ParsePlatform(tt.platform)
+		required, _ := ParsePlatform(tt.required)
+		if got := p.Satisfies(required); got != tt.want {
+			t.Errorf("%s satisfies %s = %v, want %v", tt.platform, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePlatforms(t *testing.T) {
+	p := newTestImage(t, []string{"linux/arm64/v8", "linux/arm/v7"}).write(t)
+
+	if err := ValidatePlatforms(p, []string{"linux/arm64", "linux/arm/v7"}); err != nil {
+		t.Fatalf("ValidatePlatforms failed: %v", err)
+	}
+}
+
+func TestValidatePlatformsMissing(t *testing.T) {
+	p := newTestImage(t, []string{"linux/arm64", "linux/arm/v6"}).write(t)
+
+	err := ValidatePlatforms(p, []string{"linux/amd64", "linux/arm64", "linux/arm/v7"})
+
+	var platformErr *PlatformError
+	if !errors.As(err, &platformErr) {
+		t.Fatalf("expected PlatformError, got: %v", err)
+	}
+	if !errors.Is(err, ErrSinglePlatform) {
+		t.Fatalf("expected ErrSinglePlatform, got: %v", err)
+	}
+	if !slices.Equal(platformErr.Missing, []string{"linux/amd64", "linux/arm/v7"}) {
+		t.Errorf("Missing = %v", platformErr.Missing)
+	}
+	if !slices.Equal(platformErr.Found, []string{"linux/arm/v6", "linux/arm64"}) {
+		t.Errorf("Found = %v", platformErr.Found)
+	}
+	if len(platformErr.Required) != 3 {
+		t.Errorf("Required = %v", platformErr.Required)
+	}
+}
+
+func TestValidateMultiPlatformDefaults(t *testing.T) {
+	p := newTestImage(t, []string{"linux/amd64"}).write(t)
+
+	err := ValidateMultiPlatform(p)
+
+	var platformErr *PlatformError
+	if !errors.As(err, &platformErr) {
+		t.Fatalf("expected PlatformError, got: %v", err)
+	}
+	if !slices.Equal(platformErr.Required, RequiredPlatforms()) {
+		t.Errorf("Required = %v", platformErr.Required)
+	}
+	if !slices.Equal(platformErr.Missing, []string{"linux/arm64"}) {
+		t.Errorf("Missing = %v", platformErr.Missing)
+	}
+}
+
+func TestValidatePlatformsInvalid(t *testing.T) {
+	p := newTestImage(t, []string{"linux/amd64"}).write(t)
+
+	if err := ValidatePlatforms(p, []string{"amd64"}); !errors.Is(err, ErrInvalidPlatform) {
+		t.Fatalf("expected ErrInvalidPlatform, got: %v", err)
+	}
+}
+
+func TestValidateMultiPlatformIndexOnly(t *testing.T) {
+	img := newTestImage(t, []string{"linux/amd64", "linux/arm64"})
+	img.nest(t)
+	delete(img.files, OCILayoutFile)
+	img.files["blobs/sha256/"+img.layer] = []byte("tampered")
+
+	if err := ValidateMultiPlatform(img.write(t)); err != nil {
+		t.Fatalf("ValidateMultiPlatform failed: %v", err)
+	}
+}
+
+func TestPlatformsVariants(t *testing.T) {
+	img := newTestImage(t, []string{"linux/amd64", "linux/arm64/v8", "linux/arm/v7"})
+
+	platforms := Platforms(&img.index)
+	if len(platforms) != 3 || !platforms["linux/amd64"] || !platforms["linux/arm64"] || !platforms["linux/arm"] {
+		t.Errorf("Platforms() = %v", platforms)
+	}
+
+	var names []string
+	for _, p := range IndexPlatforms(&img.index) {
+		names = append(names, p.String())
+	}
+	if !slices.Equal(names, []string{"linux/amd64", "linux/arm64/v8", "linux/arm/v7"}) {
+		t.Errorf("IndexPlatforms() = %v", names)
+	}
+}
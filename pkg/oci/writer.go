@@ -0,0 +1,136 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+// Writes an OCI image layout tarball.
+//
+// Blobs are written once per digest, so callers can add the same blob from
+// several sources. The oci-layout file and index.json are written on close.
+type layoutWriter struct {
+	file    *os.File
+	tw      *tar.Writer
+	written map[string]bool
+}
+
+// Creates a layout writer for a new tarball at dest.
+func newLayoutWriter(dest string) (*layoutWriter, error) {
+	file, err := os.Create(dest)
+	if err != nil {
+		return nil, err
+	}
+	return &layoutWriter{
+		file:    file,
+		tw:      tar.NewWriter(file),
+		written: make(map[string]bool),
+	}, nil
+}
+
+// Whether a blob with the given digest has been written.
+func (w *layoutWriter) has(digest string) bool {
+	return w.written[digest]
+}
+
+// Writes a blob read from r.
+//
+// The digest must be in "algorithm:hex" format and size must be the exact
+// number of bytes in r. Blobs already written are skipped and r is not read.
+func (w *layoutWriter) writeBlob(digest string, size int64, r io.Reader) error {
+	if w.written[digest] {
+		return nil
+	}
+
+	parsed, err := parseDigest(digest)
+	if err != nil {
+		return err
+	}
+
+	header := &tar.Header{
+		Name:     BlobPath(parsed),
+		Mode:     0644,
+		Size:     size,
+		Typeflag: tar.TypeReg,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(w.tw, r, size); err != nil {
+		return err
+	}
+
+	w.written[digest] = true
+	return nil
+}
+
+// Writes a JSON blob and returns its descriptor.
+func (w *layoutWriter) writeJSON(mediaType string, v any) (Descriptor, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Descriptor{}, err
+	}
+
+	digest, err := reference.FromBytes(reference.DefaultDigestAlgorithm, data)
+	if err != nil {
+		return Descriptor{}, err
+	}
+
+	d := NewDescriptor(mediaType, digest, int64(len(data)))
+	if err := w.writeBlob(d.Digest, d.Size, bytes.NewReader(data)); err != nil {
+		return Descriptor{}, err
+	}
+	return d, nil
+}
+
+// Writes a regular file at the root of the layout.
+func (w *layoutWriter) writeFile(name string, data []byte) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+// Writes oci-layout and index.json and closes the tarball.
+func (w *layoutWriter) close(index *Index) error {
+	marker, err := json.Marshal(map[string]string{"imageLayoutVersion": OCILayoutVersion})
+	if err != nil {
+		return err
+	}
+	if err := w.writeFile(OCILayoutFile, marker); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := w.writeFile(OCIIndexFile, data); err != nil {
+		return err
+	}
+
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.file.Close()
+}
+
+// Closes the tarball and removes it.
+//
+// Used when writing fails part way through.
+func (w *layoutWriter) abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
@@ -1,19 +1,43 @@
 package reference
 
 import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
 	"strings"
 
 	"github.com/cruciblehq/protocol/internal/helpers"
 )
 
+const (
+
+	// The SHA-256 digest algorithm.
+	SHA256 = "sha256"
+
+	// The SHA-512 digest algorithm.
+	SHA512 = "sha512"
+
+	// Algorithm used when computing new digests.
+	DefaultDigestAlgorithm = SHA256
+)
+
+// Hash constructors for the supported digest algorithms.
+var digestAlgorithms = map[string]func() hash.Hash{
+	SHA256: sha256.New,
+	SHA512: sha512.New,
+}
+
 // Content-addressable digest for resource verification.
 //
 // Digests ensure immutability and integrity of referenced resources. When a
 // digest is present, the reference is considered "frozen" and always refers
 // to the exact same content.
 //
-// This type only validates the format (algorithm:hash). Actual hash validation
-// against file contents is performed at a higher layer.
+// Parsing only validates the format (algorithm:hash). [Digest.Validate] checks
+// that the algorithm is supported and the hash has the right length, and
+// [Digester] and [VerifyingReader] compute and verify digests of content.
 type Digest struct {
 	Algorithm string // Cryptographic hash algorithm (e.g., "sha256").
 	Hash      string // Hex-encoded hash value.
@@ -21,8 +45,8 @@ type Digest struct {
 
 // Parses a digest string in the format "algorithm:hash".
 //
-// Only validates the format, not the algorithm or hash length. Algorithm and
-// hash are normalized to lowercase.
+// Only validates the format, not the algorithm or hash length; use
+// [Digest.Validate] for that. Algorithm and hash are normalized to lowercase.
 func ParseDigest(s string) (*Digest, error) {
 	s = strings.TrimSpace(s)
 
@@ -60,3 +84,62 @@ func (d *Digest) Equal(other *Digest) bool {
 	}
 	return d.Algorithm == other.Algorithm && d.Hash == other.Hash
 }
+
+// Creates a digest from a raw hash sum.
+func NewDigest(algorithm string, sum []byte) *Digest {
+	return &Digest{
+		Algorithm: algorithm,
+		Hash:      hex.EncodeToString(sum),
+	}
+}
+
+// Computes the digest of data.
+//
+// Returns [ErrUnsupportedDigestAlgorithm] if the algorithm is not supported.
+func FromBytes(algorithm string, data []byte) (*Digest, error) {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(data)
+	return NewDigest(algorithm, h.Sum(nil)), nil
+}
+
+// Whether an algorithm can be used to compute and verify digests.
+func IsSupportedDigestAlgorithm(algorithm string) bool {
+	_, ok := digestAlgorithms[algorithm]
+	return ok
+}
+
+// Checks that the digest uses a supported algorithm and a well-formed hash.
+//
+// The hash must be lowercase hex of the length produced by the algorithm.
+// Returns [ErrUnsupportedDigestAlgorithm] or [ErrInvalidDigestHash], both
+// wrapped with [ErrInvalidDigest].
+func (d *Digest) Validate() error {
+	h, err := newHash(d.Algorithm)
+	if err != nil {
+		return helpers.Wrap(ErrInvalidDigest, err)
+	}
+
+	if len(d.Hash) != h.Size()*2 {
+		return helpers.Wrap(ErrInvalidDigest, helpers.Wrap(ErrInvalidDigestHash, fmt.Errorf("%s hash must be %d characters, got %d", d.Algorithm, h.Size()*2, len(d.Hash))))
+	}
+
+	for _, c := range d.Hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return helpers.Wrap(ErrInvalidDigest, helpers.Wrap(ErrInvalidDigestHash, fmt.Errorf("invalid character %q", c)))
+		}
+	}
+
+	return nil
+}
+
+// Returns a new hash for a supported algorithm.
+func newHash(algorithm string) (hash.Hash, error) {
+	constructor, ok := digestAlgorithms[algorithm]
+	if !ok {
+		return nil, helpers.Wrap(ErrUnsupportedDigestAlgorithm, fmt.Errorf("%q", algorithm))
+	}
+	return constructor(), nil
+}
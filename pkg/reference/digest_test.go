@@ -1,6 +1,10 @@
 package reference
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+)
 
 func mustParseDigest(t *testing.T, s string) *Digest {
 	t.Helper()
@@ -185,3 +189,73 @@ func TestDigest_Equal_RightNil(t *testing.T) {
 		t.Error("expected non-nil and nil digests to not be equal")
 	}
 }
+
+// Validate tests
+
+func TestDigest_Validate_SHA256(t *testing.T) {
+	d := mustParseDigest(t, "sha256:"+strings.Repeat("a", 64))
+	if err := d.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDigest_Validate_SHA512(t *testing.T) {
+	d := mustParseDigest(t, "sha512:"+strings.Repeat("b", 128))
+	if err := d.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDigest_Validate_WrongLength(t *testing.T) {
+	d := mustParseDigest(t, "sha256:abc123")
+	err := d.Validate()
+	if !errors.Is(err, ErrInvalidDigestHash) {
+		t.Errorf("expected ErrInvalidDigestHash, got: %v", err)
+	}
+	if !errors.Is(err, ErrInvalidDigest) {
+		t.Errorf("expected ErrInvalidDigest, got: %v", err)
+	}
+}
+
+func TestDigest_Validate_NonHex(t *testing.T) {
+	d := mustParseDigest(t, "sha256:"+strings.Repeat("g", 64))
+	if err := d.Validate(); !errors.Is(err, ErrInvalidDigestHash) {
+		t.Errorf("expected ErrInvalidDigestHash, got: %v", err)
+	}
+}
+
+func TestDigest_Validate_UnsupportedAlgorithm(t *testing.T) {
+	d := mustParseDigest(t, "blake2b:789abc")
+	if err := d.Validate(); !errors.Is(err, ErrUnsupportedDigestAlgorithm) {
+		t.Errorf("expected ErrUnsupportedDigestAlgorithm, got: %v", err)
+	}
+}
+
+// FromBytes tests
+
+func TestFromBytes_SHA256(t *testing.T) {
+	d, err := FromBytes(SHA256, []byte("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if d.String() != want {
+		t.Errorf("String() = %q, want %q", d.String(), want)
+	}
+}
+
+func TestFromBytes_SHA512(t *testing.T) {
+	d, err := FromBytes(SHA512, []byte("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFromBytes_UnsupportedAlgorithm(t *testing.T) {
+	if _, err := FromBytes("md5", []byte("hello")); !errors.Is(err, ErrUnsupportedDigestAlgorithm) {
+		t.Errorf("expected ErrUnsupportedDigestAlgorithm, got: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package reference
+
+import (
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+)
+
+// Computes the digest of content written through it.
+//
+// Wraps an optional [io.Writer] so content can be hashed while it is stored,
+// for example while copying an upload to disk. Bytes are hashed only after the
+// underlying writer accepts them, so the digest always matches what was
+// written.
+type Digester struct {
+	algorithm string
+	hash      hash.Hash
+	w         io.Writer
+	size      int64
+}
+
+// Creates a digester writing through to w.
+//
+// The w parameter may be nil, in which case content is only hashed. Returns
+// [ErrUnsupportedDigestAlgorithm] if the algorithm is not supported.
+func NewDigester(algorithm string, w io.Writer) (*Digester, error) {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &Digester{algorithm: algorithm, hash: h, w: w}, nil
+}
+
+// Writes p to the underlying writer and hashes the bytes written.
+func (d *Digester) Write(p []byte) (int, error) {
+	n := len(p)
+	var err error
+	if d.w != nil {
+		n, err = d.w.Write(p)
+	}
+	d.hash.Write(p[:n])
+	d.size += int64(n)
+	return n, err
+}
+
+// Returns the digest of the content written so far.
+func (d *Digester) Digest() *Digest {
+	return NewDigest(d.algorithm, d.hash.Sum(nil))
+}
+
+// Returns the number of bytes written so far.
+func (d *Digester) Size() int64 {
+	return d.size
+}
+
+// Verifies the digest of content read through it.
+//
+// Reads pass through unchanged. When the underlying reader reports [io.EOF],
+// the digest of everything read is compared to the expected digest, and
+// [ErrDigestMismatch] is returned instead of [io.EOF] if they differ. Callers
+// must therefore read until EOF before trusting the content.
+type VerifyingReader struct {
+	r        io.Reader
+	expected *Digest
+	hash     hash.Hash
+	size     int64
+	err      error
+}
+
+// Creates a reader verifying that r yields content matching expected.
+//
+// Returns an error wrapping [ErrInvalidDigest] if the expected digest is not
+// valid (see [Digest.Validate]).
+func NewVerifyingReader(r io.Reader, expected *Digest) (*VerifyingReader, error) {
+	if err := expected.Validate(); err != nil {
+		return nil, err
+	}
+	h, err := newHash(expected.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifyingReader{r: r, expected: expected, hash: h}, nil
+}
+
+// Reads from the underlying reader, verifying the digest at EOF.
+func (v *VerifyingReader) Read(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+
+	n, err := v.r.Read(p)
+	v.hash.Write(p[:n])
+	v.size += int64(n)
+
+	if err == io.EOF {
+		actual := NewDigest(v.expected.Algorithm, v.hash.Sum(nil))
+		if !actual.Equal(v.expected) {
+			err = helpers.Wrap(ErrDigestMismatch, fmt.Errorf("expected %s, got %s", v.expected, actual))
+		}
+		v.err = err
+	}
+
+	return n, err
+}
+
+// Returns the number of bytes read so far.
+func (v *VerifyingReader) Size() int64 {
+	return v.size
+}
+
+// Whether the content was read to EOF and matched the expected digest.
+func (v *VerifyingReader) Verified() bool {
+	return v.err == io.EOF
+}
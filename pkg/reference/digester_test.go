@@ -0,0 +1,124 @@
+package reference
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func mustFromBytes(t *testing.T, algorithm string, data []byte) *Digest {
+	t.Helper()
+	d, err := FromBytes(algorithm, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return d
+}
+
+// Digester tests
+
+func TestDigester_WritesThrough(t *testing.T) {
+	var buf bytes.Buffer
+	d, err := NewDigester(SHA256, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	io.WriteString(d, "hello ")
+	io.WriteString(d, "world")
+
+	if buf.String() != "hello world" {
+		t.Errorf("written = %q, want %q", buf.String(), "hello world")
+	}
+	if d.Size() != 11 {
+		t.Errorf("Size() = %d, want 11", d.Size())
+	}
+	if want := mustFromBytes(t, SHA256, []byte("hello world")); !d.Digest().Equal(want) {
+		t.Errorf("Digest() = %s, want %s", d.Digest(), want)
+	}
+}
+
+func TestDigester_NilWriter(t *testing.T) {
+	d, err := NewDigester(SHA512, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	io.WriteString(d, "hello")
+
+	if want := mustFromBytes(t, SHA512, []byte("hello")); !d.Digest().Equal(want) {
+		t.Errorf("Digest() = %s, want %s", d.Digest(), want)
+	}
+}
+
+func TestDigester_UnsupportedAlgorithm(t *testing.T) {
+	if _, err := NewDigester("md5", nil); !errors.Is(err, ErrUnsupportedDigestAlgorithm) {
+		t.Errorf("expected ErrUnsupportedDigestAlgorithm, got: %v", err)
+	}
+}
+
+// VerifyingReader tests
+
+func TestVerifyingReader_Match(t *testing.T) {
+	data := []byte("verified content")
+	r, err := NewVerifyingReader(iotest.OneByteReader(bytes.NewReader(data)), mustFromBytes(t, SHA256, data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("read = %q, want %q", got, data)
+	}
+	if !r.Verified() {
+		t.Error("expected reader to be verified")
+	}
+	if r.Size() != int64(len(data)) {
+		t.Errorf("Size() = %d, want %d", r.Size(), len(data))
+	}
+}
+
+func TestVerifyingReader_Mismatch(t *testing.T) {
+	r, err := NewVerifyingReader(strings.NewReader("tampered"), mustFromBytes(t, SHA256, []byte("original")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("expected ErrDigestMismatch, got: %v", err)
+	}
+	if r.Verified() {
+		t.Error("expected reader not to be verified")
+	}
+
+	// The mismatch is sticky
+	if _, err := r.Read(make([]byte, 1)); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("expected ErrDigestMismatch, got: %v", err)
+	}
+}
+
+func TestVerifyingReader_NotVerifiedBeforeEOF(t *testing.T) {
+	data := []byte("partial")
+	r, err := NewVerifyingReader(bytes.NewReader(data), mustFromBytes(t, SHA256, data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r.Read(make([]byte, 3))
+	if r.Verified() {
+		t.Error("expected reader not to be verified before EOF")
+	}
+}
+
+func TestVerifyingReader_InvalidDigest(t *testing.T) {
+	_, err := NewVerifyingReader(strings.NewReader(""), &Digest{Algorithm: SHA256, Hash: "abc"})
+	if !errors.Is(err, ErrInvalidDigest) {
+		t.Errorf("expected ErrInvalidDigest, got: %v", err)
+	}
+}
@@ -121,4 +121,10 @@
 // the individual components. The [Reference] struct provides access to the
 // parsed components and a [String] method to obtain the canonical string
 // representation of the reference.
+//
+// Content addressing is handled the same way everywhere through [Digest]. The
+// supported algorithms are [SHA256] and [SHA512]; [Digest.Validate] checks the
+// hash length for the algorithm. A [Digester] computes the digest of content
+// written through it, and a [VerifyingReader] returns [ErrDigestMismatch] at
+// EOF when content read through it does not match an expected digest.
 package reference
@@ -63,7 +63,10 @@ var (
 	ErrNegativePatchVersion     = errors.New("negative patch version")
 
 	// Specific digest errors
-	ErrMissingDigestColon   = errors.New("digest missing colon separator")
-	ErrEmptyDigestAlgorithm = errors.New("empty digest algorithm")
-	ErrEmptyDigestHash      = errors.New("empty digest hash")
+	ErrMissingDigestColon         = errors.New("digest missing colon separator")
+	ErrEmptyDigestAlgorithm       = errors.New("empty digest algorithm")
+	ErrEmptyDigestHash            = errors.New("empty digest hash")
+	ErrUnsupportedDigestAlgorithm = errors.New("unsupported digest algorithm")
+	ErrInvalidDigestHash          = errors.New("invalid digest hash")
+	ErrDigestMismatch             = errors.New("digest mismatch")
 )
@@ -0,0 +1,136 @@
+package registry
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+
+	// Lifetime of URLs built by [SignedURLBuilder] when its TTL is zero.
+	DefaultSignedURLTTL = 15 * time.Minute
+
+	// Query parameters of signed archive URLs.
+	signedURLDigest    = "digest"
+	signedURLExpires   = "expires"
+	signedURLSignature = "signature"
+)
+
+// Builds the client-facing URLs of archives.
+//
+// [SQLRegistry] records archives by private storage keys, relative to its
+// archive root, and never hands them out. [Version.Archive] holds the URL
+// built by the registry's builder instead, so the archive root can move and
+// archives can be served from elsewhere without clients noticing.
+type ArchiveURLBuilder interface {
+
+	// Returns the URL the archive of a version is downloaded from.
+	//
+	// The digest identifies the archive content, so a builder can make URLs
+	// that stop working once the archive is replaced.
+	ArchiveURL(ctx context.Context, namespace, resource, version, digest string) (string, error)
+}
+
+// Builds archive URLs pointing at the registry API.
+//
+// The zero value builds paths relative to the API root, such as
+// "/namespaces/ns/resources/res/versions/1.0.0/archive", which clients resolve
+// against the registry they asked.
+type APIURLBuilder struct {
+	BaseURL string // URL the paths are joined to, or empty for relative paths.
+}
+
+// Returns the API URL of the archive of a version.
+func (b APIURLBuilder) ArchiveURL(ctx context.Context, namespace, resource, version, digest string) (string, error) {
+	return joinArchivePath(b.BaseURL, namespace, resource, version)
+}
+
+// Builds archive URLs signed with a shared key.
+//
+// Suits archives served by a separate host, such as a CDN or an object store
+// front end, that does not authenticate clients itself. Each URL carries the
+// archive digest, an expiry time and an HMAC-SHA256 signature over both and
+// the path; the server checks them with [SignedURLBuilder.Verify].
+type SignedURLBuilder struct {
+	BaseURL string        // URL the archive paths are joined to.
+	Key     []byte        // Key shared with the server verifying the URLs.
+	TTL     time.Duration // Lifetime of the URLs, or zero for [DefaultSignedURLTTL].
+}
+
+// Returns a signed URL of the archive of a version.
+func (b *SignedURLBuilder) ArchiveURL(ctx context.Context, namespace, resource, version, digest string) (string, error) {
+	raw, err := joinArchivePath(b.BaseURL, namespace, resource, version)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	ttl := b.TTL
+	if ttl == 0 {
+		ttl = DefaultSignedURLTTL
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+
+	q := u.Query()
+	q.Set(signedURLDigest, digest)
+	q.Set(signedURLExpires, expires)
+	q.Set(signedURLSignature, b.sign(u.EscapedPath(), digest, expires))
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// Verifies a signed archive URL at time now.
+//
+// Returns [ErrURLSignature] if the URL was not signed with the key of the
+// builder or was altered, and [ErrURLExpired] if it is past its expiry time.
+func (b *SignedURLBuilder) Verify(u *url.URL, now time.Time) error {
+	q := u.Query()
+	digest := q.Get(signedURLDigest)
+	expires := q.Get(signedURLExpires)
+
+	signature, err := hex.DecodeString(q.Get(signedURLSignature))
+	if err != nil {
+		return ErrURLSignature
+	}
+	expected, _ := hex.DecodeString(b.sign(u.EscapedPath(), digest, expires))
+	if !hmac.Equal(signature, expected) {
+		return ErrURLSignature
+	}
+
+	deadline, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrURLSignature
+	}
+	if now.Unix() > deadline {
+		return ErrURLExpired
+	}
+	return nil
+}
+
+// Returns the hex-encoded signature of an archive URL.
+func (b *SignedURLBuilder) sign(path, digest, expires string) string {
+	mac := hmac.New(sha256.New, b.Key)
+	mac.Write([]byte(strings.Join([]string{path, digest, expires}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Joins the API path of the archive of a version to a base URL.
+//
+// Returns the path alone if base is empty.
+func joinArchivePath(base, namespace, resource, version string) (string, error) {
+	if base == "" {
+		base = "/"
+	}
+	return url.JoinPath(base, "namespaces", namespace, "resources", resource, "versions", version, "archive")
+}
@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAPIURLBuilder(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		want    string
+	}{
+		{
+			name: "relative",
+			want: "/namespaces/ns/resources/app/versions/1.0.0/archive",
+		},
+		{
+			name:    "base URL",
+			baseURL: "https://registry.example.com/api",
+			want:    "https://registry.example.com/api/namespaces/ns/resources/app/versions/1.0.0/archive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := APIURLBuilder{BaseURL: tt.baseURL}.ArchiveURL(context.Background(), "ns", "app", "1.0.0", "sha256:abc")
+			if err != nil {
+				t.Fatalf("ArchiveURL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ArchiveURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignedURLBuilder(t *testing.T) {
+	builder := &SignedURLBuilder{BaseURL: "https://cdn.example.com", Key: []byte("secret"), TTL: time.Minute}
+
+	raw, err := builder.ArchiveURL(context.Background(), "ns", "app", "1.0.0", "sha256:abc")
+	if err != nil {
+		t.Fatalf("ArchiveURL() error = %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Host != "cdn.example.com" || u.Path != "/namespaces/ns/resources/app/versions/1.0.0/archive" {
+		t.Errorf("unexpected URL %q", raw)
+	}
+	if u.Query().Get("digest") != "sha256:abc" {
+		t.Errorf("digest = %q, want %q", u.Query().Get("digest"), "sha256:abc")
+	}
+
+	if err := builder.Verify(u, time.Now()); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := builder.Verify(u, time.Now().Add(2*time.Minute)); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("Verify() after expiry error = %v, want %v", err, ErrURLExpired)
+	}
+
+	other := &SignedURLBuilder{Key: []byte("other")}
+	if err := other.Verify(u, time.Now()); !errors.Is(err, ErrURLSignature) {
+		t.Errorf("Verify() with another key error = %v, want %v", err, ErrURLSignature)
+	}
+}
+
+func TestSignedURLBuilder_Tampered(t *testing.T) {
+	builder := &SignedURLBuilder{BaseURL: "https://cdn.example.com", Key: []byte("secret")}
+
+	raw, _ := builder.ArchiveURL(context.Background(), "ns", "app", "1.0.0", "sha256:abc")
+
+	tests := []struct {
+		name   string
+		tamper func(u *url.URL)
+	}{
+		{"path", func(u *url.URL) { u.Path = "/namespaces/ns/resources/app/versions/2.0.0/archive" }},
+		{"digest", func(u *url.URL) { setQuery(u, "digest", "sha256:def") }},
+		{"expires", func(u *url.URL) { setQuery(u, "expires", "99999999999") }},
+		{"signature", func(u *url.URL) { setQuery(u, "signature", "zz") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(raw)
+			tt.tamper(u)
+			if err := builder.Verify(u, time.Now()); !errors.Is(err, ErrURLSignature) {
+				t.Errorf("Verify() error = %v, want %v", err, ErrURLSignature)
+			}
+		})
+	}
+}
+
+// Replaces a query parameter of a URL.
+func setQuery(u *url.URL, key, value string) {
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+}
@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/cruciblehq/protocol/pkg/codec"
+)
+
+const (
+
+	// Number of audit events returned when [AuditFilter.Limit] is zero.
+	DefaultAuditLimit = 100
+
+	// Maximum number of audit events returned by one query.
+	MaxAuditLimit = 1000
+)
+
+// Mutation recorded in the audit log.
+//
+// Audit actions follow the pattern {entity}:{operation}, like [Action], but
+// name the operation that was performed rather than the permission it needed.
+type AuditAction string
+
+const (
+	AuditNamespaceCreate AuditAction = "namespace:create" // Namespace created.
+	AuditNamespaceUpdate AuditAction = "namespace:update" // Namespace metadata changed.
+	AuditNamespaceDelete AuditAction = "namespace:delete" // Namespace deleted.
+	AuditMemberCreate    AuditAction = "member:create"    // Member added to a namespace.
+	AuditMemberUpdate    AuditAction = "member:update"    // Member role changed.
+	AuditMemberDelete    AuditAction = "member:delete"    // Member removed from a namespace.
+	AuditResourceCreate  AuditAction = "resource:create"  // Resource created.
+	AuditResourceUpdate  AuditAction = "resource:update"  // Resource metadata changed.
+	AuditResourceDelete  AuditAction = "resource:delete"  // Resource deleted.
+	AuditVersionCreate   AuditAction = "version:create"   // Version created.
+	AuditVersionUpdate   AuditAction = "version:update"   // Version metadata changed.
+	AuditVersionDelete   AuditAction = "version:delete"   // Version deleted.
+	AuditArchiveUpload   AuditAction = "archive:upload"   // Archive uploaded, in one request or by committing an upload session.
+	AuditChannelCreate   AuditAction = "channel:create"   // Channel created.
+	AuditChannelUpdate   AuditAction = "channel:update"   // Channel moved or its metadata changed.
+	AuditChannelDelete   AuditAction = "channel:delete"   // Channel deleted.
+	AuditChannelRollback AuditAction = "channel:rollback" // Channel pointed back at a previous version.
+)
+
+// Summary of an archive recorded for archive uploads.
+type archiveSummary struct {
+	Digest string `field:"digest"` // Archive digest.
+	Size   int64  `field:"size"`   // Archive size in bytes.
+}
+
+// Returns the archive summary of a version, or nil if it has no archive.
+func summarizeArchive(v *Version) any {
+	if v == nil || v.Digest == nil {
+		return nil
+	}
+	s := archiveSummary{Digest: *v.Digest}
+	if v.Size != nil {
+		s.Size = *v.Size
+	}
+	return s
+}
+
+// Encodes a summary of a target as JSON, using the field tags of its type.
+//
+// Returns an empty string for nil, so creations have no before summary and
+// deletions no after summary.
+func summarize(v any) string {
+	if v == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := codec.Encode(&buf, codec.ContentTypeJSON, "field", false, v); err != nil {
+		return ""
+	}
+	return string(bytes.TrimSpace(buf.Bytes()))
+}
+
+// Records a mutation in the audit log.
+//
+// The actor is the subject of the principal in ctx, and the time is now.
+// Called in the transaction of the mutation, so that both are committed or
+// neither is. Failures are logged and returned as [ErrorCodeInternalError].
+func (r *SQLRegistry) audit(ctx context.Context, event AuditEvent) error {
+	event.Actor = subjectFromContext(ctx)
+	event.CreatedAt = time.Now().Unix()
+
+	if err := r.insertAuditEvent(ctx, &event); err != nil {
+		return r.logAndReturnError(ErrorCodeInternalError, errMsgRecordAudit, err, "action", event.Action, "namespace", event.Namespace, "resource", event.Resource, "target", event.Target)
+	}
+	return nil
+}
+
+// Returns the audit summary of a namespace, or nil.
+func namespaceSummary(ns *Namespace) any {
+	if ns == nil {
+		return nil
+	}
+	return NamespaceInfo{Name: ns.Name, Description: ns.Description}
+}
+
+// Returns the audit summary of a member, or nil.
+func memberSummary(m *Member) any {
+	if m == nil {
+		return nil
+	}
+	return MemberInfo{Subject: m.Subject, Role: m.Role}
+}
+
+// Returns the audit summary of a resource, or nil.
+func resourceSummary(res *Resource) any {
+	if res == nil {
+		return nil
+	}
+	return ResourceInfo{Name: res.Name, Type: res.Type, Description: res.Description}
+}
+
+// Returns the audit summary of a version, or nil.
+func versionSummary(v *Version) any {
+	if v == nil {
+		return nil
+	}
+	return VersionInfo{String: v.String}
+}
+
+// Returns the audit summary of a channel, or nil.
+func channelSummary(c *Channel) any {
+	if c == nil {
+		return nil
+	}
+	return ChannelInfo{Name: c.Name, Version: c.Version.String, Description: c.Description}
+}
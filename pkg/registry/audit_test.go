@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAudit_ChannelMove(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	alice := WithPrincipal(ctx, &Principal{Subject: "alice"})
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "app", Type: "widget"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "1.0.0"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "1.1.0"})
+	_, _ = registry.UploadArchive(ctx, "test-ns", "app", "1.1.0", bytes.NewReader([]byte("archive")))
+	_, _ = registry.CreateChannel(ctx, "test-ns", "app", ChannelInfo{Name: "stable", Version: "1.0.0"})
+
+	if _, err := registry.UpdateChannel(alice, "test-ns", "app", "stable", ChannelInfo{Name: "stable", Version: "1.1.0"}, AnyRevision); err != nil {
+		t.Fatalf("UpdateChannel() error = %v", err)
+	}
+
+	list, err := registry.ListAuditEvents(ctx, AuditFilter{Namespace: "test-ns", Resource: "app", Target: "stable"})
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	if len(list.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(list.Events), list.Events)
+	}
+
+	moved := list.Events[0]
+	if moved.Action != AuditChannelUpdate || moved.Actor != "alice" {
+		t.Errorf("unexpected event: %+v", moved)
+	}
+	if moved.Before != `{"description":"","name":"stable","version":"1.0.0"}` {
+		t.Errorf("Before = %s", moved.Before)
+	}
+	if moved.After != `{"description":"","name":"stable","version":"1.1.0"}` {
+		t.Errorf("After = %s", moved.After)
+	}
+	if moved.Digest == nil {
+		t.Error("expected digest of the target version")
+	}
+
+	created := list.Events[1]
+	if created.Action != AuditChannelCreate || created.Actor != "" || created.Before != "" {
+		t.Errorf("unexpected event: %+v", created)
+	}
+}
+
+func TestAudit_Filters(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "app", Type: "widget"})
+	_ = registry.DeleteResource(ctx, "test-ns", "app", AnyRevision)
+	_ = registry.DeleteResource(ctx, "test-ns", "app", AnyRevision) // No event for a missing resource
+
+	all, err := registry.ListAuditEvents(ctx, AuditFilter{})
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	if len(all.Events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", all.Events)
+	}
+
+	deleted, err := registry.ListAuditEvents(ctx, AuditFilter{Action: AuditResourceDelete})
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	if len(deleted.Events) != 1 || deleted.Events[0].After != "" || deleted.Events[0].Before == "" {
+		t.Errorf("unexpected events: %+v", deleted.Events)
+	}
+
+	future := time.Now().Add(time.Hour).Unix()
+	later, err := registry.ListAuditEvents(ctx, AuditFilter{Since: future})
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	if len(later.Events) != 0 {
+		t.Errorf("expected no events, got %+v", later.Events)
+	}
+
+	limited, err := registry.ListAuditEvents(ctx, AuditFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	if len(limited.Events) != 1 || limited.Events[0].Action != AuditResourceDelete {
+		t.Errorf("unexpected events: %+v", limited.Events)
+	}
+}
+
+func TestAudit_InvalidFilter(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, filter := range []AuditFilter{
+		{Resource: "app"},
+		{Since: 10, Until: 5},
+		{Limit: MaxAuditLimit + 1},
+		{Namespace: "Bad Name"},
+	} {
+		_, err := registry.ListAuditEvents(context.Background(), filter)
+		assertErrorCode(t, err, ErrorCodeBadRequest)
+	}
+}
+
+func TestAudit_AppendOnly(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns"})
+
+	if _, err := registry.db.ExecContext(ctx, "UPDATE audit_log SET actor = 'mallory'"); err == nil {
+		t.Error("expected update of the audit log to fail")
+	}
+	if _, err := registry.db.ExecContext(ctx, "DELETE FROM audit_log"); err == nil {
+		t.Error("expected delete from the audit log to fail")
+	}
+}
+
+func TestClient_ListAuditEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audit" {
+			t.Errorf("expected /audit, got %s", r.URL.Path)
+		}
+		if got := r.URL.RawQuery; got != "limit=10&namespace=test&since=100&target=stable" {
+			t.Errorf("unexpected query: %s", got)
+		}
+		w.Header().Set("Content-Type", "application/vnd.crucible.audit-event-list.v0+json")
+		w.Write([]byte(`{"events":[{"id":1,"actor":"alice","action":"channel:update","namespace":"test","resource":"app","target":"stable","before":"","after":"","digest":null,"createdAt":100}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	list, err := client.ListAuditEvents(context.Background(), AuditFilter{Namespace: "test", Target: "stable", Since: 100, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Events) != 1 || list.Events[0].Actor != "alice" || list.Events[0].Action != AuditChannelUpdate {
+		t.Errorf("unexpected events: %+v", list.Events)
+	}
+}
@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"context"
+	"slices"
+)
+
+// Operation a principal may be authorized to perform on a namespace.
+//
+// Actions follow the pattern {entity}:{operation}. Reads are not authorized;
+// every action covers a family of mutations.
+type Action string
+
+const (
+	ActionNamespaceWrite  Action = "namespace:write"  // Create, update or delete a namespace.
+	ActionMemberWrite     Action = "member:write"     // Add, change or remove namespace members.
+	ActionResourceWrite   Action = "resource:write"   // Create, update or delete a resource.
+	ActionResourcePublish Action = "resource:publish" // Create, update or delete versions and upload archives.
+	ActionChannelUpdate   Action = "channel:update"   // Create, update or delete channels.
+)
+
+// Named set of actions granted to a principal.
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"     // All actions.
+	RolePublisher Role = "publisher" // Resource, version and channel changes, but not namespace changes.
+	RoleReader    Role = "reader"    // No mutations.
+)
+
+// Actions granted by each role.
+var roleActions = map[Role][]Action{
+	RoleAdmin:     {ActionNamespaceWrite, ActionMemberWrite, ActionResourceWrite, ActionResourcePublish, ActionChannelUpdate},
+	RolePublisher: {ActionResourceWrite, ActionResourcePublish, ActionChannelUpdate},
+	RoleReader:    {},
+}
+
+// Whether a role is one of the predefined roles.
+func IsValidRole(role Role) bool {
+	_, ok := roleActions[role]
+	return ok
+}
+
+// Whether a role grants an action.
+func (r Role) Grants(action Action) bool {
+	return slices.Contains(roleActions[r], action)
+}
+
+// Authenticated identity making a registry request.
+//
+// Roles are granted across all namespaces. [SQLRegistry] adds the role of the
+// principal's namespace membership (see [Member]) before consulting its
+// [Authorizer], so authorizers see the effective roles for the namespace.
+type Principal struct {
+	Subject string // Unique identifier of the user or service.
+	Roles   []Role // Roles granted across all namespaces.
+}
+
+// Whether any of the principal's roles grants an action.
+func (p *Principal) Grants(action Action) bool {
+	for _, role := range p.Roles {
+		if role.Grants(action) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a copy of the principal holding an additional role.
+func (p *Principal) withRole(role Role) *Principal {
+	if slices.Contains(p.Roles, role) {
+		return p
+	}
+	return &Principal{Subject: p.Subject, Roles: append(slices.Clone(p.Roles), role)}
+}
+
+// Decides whether the principal of a request may perform an action.
+//
+// The principal is carried by the context (see [WithPrincipal]). Consulted by
+// [SQLRegistry] before every mutation.
+type Authorizer interface {
+
+	// Authorizes an action on a namespace.
+	//
+	// Returns nil if the action is allowed. Returns an [*Error] with
+	// [ErrorCodeUnauthorized] if the context carries no principal, and with
+	// [ErrorCodeForbidden] if the principal may not perform the action.
+	Authorize(ctx context.Context, action Action, namespace string) error
+}
+
+// Context key for the request principal.
+type principalKey struct{}
+
+// Returns a copy of ctx carrying the principal making the request.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Returns the principal carried by ctx, or nil if there is none.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// Returns the subject of the principal carried by ctx, or an empty string if
+// there is none.
+func subjectFromContext(ctx context.Context) string {
+	if principal := PrincipalFromContext(ctx); principal != nil {
+		return principal.Subject
+	}
+	return ""
+}
+
+// Authorizes actions using the roles of the principal alone.
+//
+// The namespace is not considered; namespace memberships reach the authorizer
+// as roles of the principal.
+type RoleAuthorizer struct{}
+
+// Authorizes an action using the roles of the principal in ctx.
+func (RoleAuthorizer) Authorize(ctx context.Context, action Action, namespace string) error {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil {
+		return &Error{Code: ErrorCodeUnauthorized, Message: errMsgUnauthorized}
+	}
+	if !principal.Grants(action) {
+		return &Error{Code: ErrorCodeForbidden, Message: errMsgForbidden + ": " + string(action)}
+	}
+	return nil
+}
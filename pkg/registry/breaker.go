@@ -0,0 +1,136 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+)
+
+const (
+
+	// Default number of consecutive failures that opens the circuit.
+	DefaultBreakerThreshold = 5
+
+	// Default time the circuit stays open before a trial request.
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// Controls the circuit breaker of [Client].
+//
+// The breaker counts consecutive failed requests, after retries. A request
+// fails if it ends with a transport error or a 429 or 5xx response. Once the
+// threshold is reached the circuit opens, and requests return
+// [ErrCircuitOpen] without contacting the registry until the cooldown has
+// elapsed. A single trial request is then let through: if it succeeds the
+// circuit closes, otherwise it opens for another cooldown. The zero value
+// (and a nil *BreakerPolicy) uses the defaults.
+type BreakerPolicy struct {
+
+	// Number of consecutive failures that opens the circuit.
+	//
+	// Zero uses [DefaultBreakerThreshold]. Negative disables the breaker.
+	Threshold int
+
+	// Time the circuit stays open before a trial request.
+	//
+	// Zero uses [DefaultBreakerCooldown].
+	Cooldown time.Duration
+}
+
+// Returns the failure threshold, applying the default.
+func (p *BreakerPolicy) threshold() int {
+	if p == nil || p.Threshold == 0 {
+		return DefaultBreakerThreshold
+	}
+	return p.Threshold
+}
+
+// Returns the cooldown, applying the default.
+func (p *BreakerPolicy) cooldown() time.Duration {
+	if p == nil || p.Cooldown <= 0 {
+		return DefaultBreakerCooldown
+	}
+	return p.Cooldown
+}
+
+// Tracks the health of a registry across requests.
+type circuitBreaker struct {
+	threshold int              // Consecutive failures that open the circuit, or negative if disabled.
+	cooldown  time.Duration    // Time the circuit stays open.
+	now       func() time.Time // Clock, replaceable in tests.
+
+	mu       sync.Mutex
+	failures int       // Consecutive failures.
+	openedAt time.Time // When the circuit opened, or zero if closed.
+	trial    bool      // Whether a trial request is in flight.
+}
+
+// Creates a circuit breaker from a policy.
+func newCircuitBreaker(policy *BreakerPolicy) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: policy.threshold(),
+		cooldown:  policy.cooldown(),
+		now:       time.Now,
+	}
+}
+
+// Checks whether a request may be sent.
+//
+// Returns [ErrCircuitOpen] while the circuit is open, or while the trial
+// request after the cooldown is in flight.
+func (b *circuitBreaker) allow() error {
+	if b.threshold < 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return nil
+	}
+
+	remaining := b.cooldown - b.now().Sub(b.openedAt)
+	if remaining > 0 || b.trial {
+		return helpers.Wrap(ErrCircuitOpen, fmt.Errorf("%d consecutive failures, retry in %s", b.failures, max(remaining, 0).Round(time.Second)))
+	}
+
+	b.trial = true
+	return nil
+}
+
+// Records the outcome of a request let through by allow.
+func (b *circuitBreaker) record(resp *http.Response, err error) {
+	if b.threshold < 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+
+	if err == nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// Releases a request let through by allow without recording an outcome.
+//
+// Used when the caller gave up on the request, which says nothing about the
+// health of the registry.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	healthy := false
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if !healthy {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", string(MediaTypeNamespaceList)+"+json")
+		w.Write([]byte(`{"namespaces":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, &ClientOptions{
+		Retry:   &RetryPolicy{MaxAttempts: 1},
+		Breaker: &BreakerPolicy{Threshold: 2, Cooldown: time.Minute},
+	})
+
+	now := time.Now()
+	client.breaker.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for range 2 {
+		if _, err := client.ListNamespaces(ctx); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("circuit opened too early: %v", err)
+		}
+	}
+
+	if _, err := client.ListNamespaces(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got: %d", attempts)
+	}
+
+	// After the cooldown a trial request is let through and closes the circuit
+	now = now.Add(time.Minute)
+	healthy = true
+
+	if _, err := client.ListNamespaces(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.ListNamespaces(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCircuitBreaker_FailedTrialReopens(t *testing.T) {
+	b := newCircuitBreaker(&BreakerPolicy{Threshold: 1, Cooldown: time.Minute})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	failed := &http.Response{StatusCode: http.StatusServiceUnavailable}
+
+	b.allow()
+	b.record(failed, nil)
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got: %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected trial request, got: %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen during trial, got: %v", err)
+	}
+
+	b.record(failed, nil)
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen after failed trial, got: %v", err)
+	}
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	b := newCircuitBreaker(&BreakerPolicy{Threshold: -1})
+	for range 10 {
+		if err := b.allow(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b.record(nil, errors.New("connection refused"))
+	}
+}
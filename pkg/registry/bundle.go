@@ -0,0 +1,506 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+	"github.com/cruciblehq/protocol/pkg/archive"
+	"github.com/cruciblehq/protocol/pkg/codec"
+	"github.com/cruciblehq/protocol/pkg/plan"
+	"github.com/cruciblehq/protocol/pkg/reference"
+	"github.com/cruciblehq/protocol/pkg/resource"
+)
+
+const (
+
+	// Version of the bundle format written by [ExportBundle].
+	BundleFormatVersion = 1
+
+	// Name of the metadata file at the root of a bundle.
+	BundleIndexFile = "index.json"
+
+	// Directory of a bundle holding archives, stored as
+	// {BundleArchiveDir}/{algorithm}/{hash}.
+	BundleArchiveDir = "archives"
+)
+
+// Metadata of an offline bundle.
+//
+// Lists the registry records needed to recreate the bundled versions on
+// another registry. Records are listed parents first, so they can be created
+// in order. Encoded with [codec] as [BundleIndexFile].
+type BundleIndex struct {
+	Version    int              `field:"version"`    // Bundle format version (see [BundleFormatVersion]).
+	Namespaces []NamespaceInfo  `field:"namespaces"` // Namespaces of the bundled resources.
+	Resources  []BundleResource `field:"resources"`  // Resources of the bundled versions.
+	Versions   []BundleVersion  `field:"versions"`   // Bundled versions, each with an archive.
+	Channels   []BundleChannel  `field:"channels"`   // Channels referenced by the exported references.
+}
+
+// Resource record in a bundle.
+type BundleResource struct {
+	Namespace   string `field:"namespace"`   // Parent namespace.
+	Name        string `field:"name"`        // Resource name.
+	Type        string `field:"type"`        // Resource type.
+	Description string `field:"description"` // Human-readable description.
+}
+
+// Version record in a bundle.
+type BundleVersion struct {
+	Namespace string `field:"namespace"` // Parent namespace.
+	Resource  string `field:"resource"`  // Parent resource name.
+	String    string `field:"string"`    // Version string.
+	Digest    string `field:"digest"`    // Archive digest, naming the archive in the bundle.
+	Size      int64  `field:"size"`      // Archive size in bytes.
+}
+
+// Channel record in a bundle.
+type BundleChannel struct {
+	Namespace   string `field:"namespace"`   // Parent namespace.
+	Resource    string `field:"resource"`    // Parent resource name.
+	Name        string `field:"name"`        // Channel name.
+	Version     string `field:"version"`     // Version the channel points to.
+	Description string `field:"description"` // Human-readable description.
+}
+
+// Exports versions from a registry into an offline bundle.
+//
+// Each reference is resolved against the source: channel references to the
+// version the channel points to, and version constraints to the highest
+// matching version. Frozen references must match the digest of the resolved
+// version. The bundle, a zstd-compressed tar archive written to dest, holds
+// the metadata of the resolved versions, their resources, namespaces and
+// channels, and their archives stored by digest. Archives are verified as
+// they are downloaded.
+//
+// Returns the index of the bundle. All errors are wrapped with
+// [ErrExportFailed].
+func ExportBundle(ctx context.Context, source Registry, refs []*reference.Reference, dest string) (*BundleIndex, error) {
+	stage, err := os.MkdirTemp(filepath.Dir(dest), ".crucible-bundle-*")
+	if err != nil {
+		return nil, helpers.Wrap(ErrExportFailed, err)
+	}
+	defer os.RemoveAll(stage)
+
+	b := &bundleExport{
+		source:     source,
+		stage:      stage,
+		index:      &BundleIndex{Version: BundleFormatVersion},
+		namespaces: make(map[string]bool),
+		resources:  make(map[string]bool),
+		versions:   make(map[string]bool),
+		channels:   make(map[string]bool),
+	}
+
+	for _, ref := range refs {
+		if err := b.add(ctx, ref); err != nil {
+			return nil, helpers.Wrap(ErrExportFailed, fmt.Errorf("%s: %w", ref, err))
+		}
+	}
+
+	if err := codec.EncodeFile(filepath.Join(stage, BundleIndexFile), "field", true, b.index); err != nil {
+		return nil, helpers.Wrap(ErrExportFailed, err)
+	}
+
+	if err := archive.Create(stage, dest); err != nil {
+		return nil, helpers.Wrap(ErrExportFailed, err)
+	}
+
+	return b.index, nil
+}
+
+// Exports the services of a plan from a registry into an offline bundle.
+//
+// Service references are parsed as [resource.TypeService] references with
+// the given options and exported with [ExportBundle].
+func ExportPlanBundle(ctx context.Context, source Registry, p *plan.Plan, dest string, options *reference.IdentifierOptions) (*BundleIndex, error) {
+	refs := make([]*reference.Reference, 0, len(p.Services))
+	for _, svc := range p.Services {
+		ref, err := reference.Parse(svc.Reference, resource.TypeService, options)
+		if err != nil {
+			return nil, helpers.Wrap(ErrExportFailed, fmt.Errorf("service %s: %w", svc.ID, err))
+		}
+		refs = append(refs, ref)
+	}
+	return ExportBundle(ctx, source, refs, dest)
+}
+
+// Imports an offline bundle into a registry.
+//
+// Every archive in the bundle is verified against its digest before anything
+// is written. Namespaces, resources, versions and channels missing from the
+// target are then created, archives are uploaded to versions whose digest
+// differs, and channels are pointed at their bundled versions. Records
+// already present on the target are kept, so importing is safe to repeat.
+//
+// Returns the index of the bundle. All errors are wrapped with
+// [ErrImportFailed]; an archive that does not match its digest returns an
+// error wrapping [reference.ErrDigestMismatch].
+func ImportBundle(ctx context.Context, target Registry, src string) (*BundleIndex, error) {
+	tmp, err := os.MkdirTemp("", "crucible-bundle-*")
+	if err != nil {
+		return nil, helpers.Wrap(ErrImportFailed, err)
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, "bundle")
+	if err := archive.Extract(src, dir); err != nil {
+		return nil, helpers.Wrap(ErrImportFailed, err)
+	}
+
+	var index BundleIndex
+	if _, err := codec.DecodeFile(filepath.Join(dir, BundleIndexFile), "field", &index); err != nil {
+		return nil, helpers.Wrap(ErrImportFailed, err)
+	}
+	if index.Version != BundleFormatVersion {
+		return nil, helpers.Wrap(ErrImportFailed, fmt.Errorf("%w: %d", ErrUnsupportedBundle, index.Version))
+	}
+
+	for _, v := range index.Versions {
+		if err := verifyBundleArchive(dir, v.Digest); err != nil {
+			return nil, helpers.Wrap(ErrImportFailed, fmt.Errorf("%s/%s %s: %w", v.Namespace, v.Resource, v.String, err))
+		}
+	}
+
+	if err := importBundle(ctx, target, dir, &index); err != nil {
+		return nil, helpers.Wrap(ErrImportFailed, err)
+	}
+
+	return &index, nil
+}
+
+// State of a single [ExportBundle] run.
+//
+// The maps hold the keys of records already added to the index, so shared
+// namespaces, resources and archives are only exported once.
+type bundleExport struct {
+	source     Registry
+	stage      string
+	index      *BundleIndex
+	namespaces map[string]bool
+	resources  map[string]bool
+	versions   map[string]bool
+	channels   map[string]bool
+}
+
+// Resolves a reference and adds its records and archive to the bundle.
+func (b *bundleExport) add(ctx context.Context, ref *reference.Reference) error {
+	namespace, name, err := referenceLocation(ref)
+	if err != nil {
+		return err
+	}
+
+	if err := b.addResource(ctx, namespace, name, ref.Type()); err != nil {
+		return err
+	}
+
+	var version string
+	if ch := ref.Channel(); ch != nil {
+		channel, err := b.source.ReadChannel(ctx, namespace, name, *ch)
+		if err != nil {
+			return err
+		}
+		version = channel.Version.String
+
+		key := cacheKey("channel", namespace, name, *ch)
+		if !b.channels[key] {
+			b.channels[key] = true
+			b.index.Channels = append(b.index.Channels, BundleChannel{
+				Namespace:   namespace,
+				Resource:    name,
+				Name:        channel.Name,
+				Version:     version,
+				Description: channel.Description,
+			})
+		}
+	} else {
+		version, err = resolveVersion(ctx, b.source, namespace, name, ref.Version())
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.addVersion(ctx, namespace, name, version, ref.Digest())
+}
+
+// Adds a resource and its namespace to the bundle.
+//
+// The resource type must match the type of the reference.
+func (b *bundleExport) addResource(ctx context.Context, namespace, name string, typ resource.Type) error {
+	key := cacheKey("resource", namespace, name)
+	if b.resources[key] {
+		return nil
+	}
+
+	if !b.namespaces[namespace] {
+		ns, err := b.source.ReadNamespace(ctx, namespace)
+		if err != nil {
+			return err
+		}
+		b.namespaces[namespace] = true
+		b.index.Namespaces = append(b.index.Namespaces, NamespaceInfo{Name: ns.Name, Description: ns.Description})
+	}
+
+	res, err := b.source.ReadResource(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if res.Type != string(typ) {
+		return fmt.Errorf("%w: %s is a %s", reference.ErrTypeMismatch, res.Name, res.Type)
+	}
+
+	b.resources[key] = true
+	b.index.Resources = append(b.index.Resources, BundleResource{
+		Namespace:   namespace,
+		Name:        res.Name,
+		Type:        res.Type,
+		Description: res.Description,
+	})
+	return nil
+}
+
+// Adds a version to the bundle and downloads its archive.
+//
+// The version must have an archive. If expected is not nil, the archive
+// digest must equal it.
+func (b *bundleExport) addVersion(ctx context.Context, namespace, name, version string, expected *reference.Digest) error {
+	v, err := b.source.ReadVersion(ctx, namespace, name, version)
+	if err != nil {
+		return err
+	}
+	if v.Digest == nil {
+		return fmt.Errorf("%w: %s/%s %s", ErrMissingDigest, namespace, name, version)
+	}
+
+	digest, err := parseBundleDigest(*v.Digest)
+	if err != nil {
+		return err
+	}
+	if expected != nil && !expected.Equal(digest) {
+		return helpers.Wrap(reference.ErrDigestMismatch, fmt.Errorf("expected %s, registry has %s", expected, digest))
+	}
+
+	key := cacheKey("version", namespace, name, version)
+	if b.versions[key] {
+		return nil
+	}
+
+	path := bundleArchivePath(b.stage, digest)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := b.download(ctx, namespace, name, version, digest, path); err != nil {
+			return err
+		}
+	}
+
+	record := BundleVersion{Namespace: namespace, Resource: name, String: version, Digest: digest.String()}
+	if v.Size != nil {
+		record.Size = *v.Size
+	}
+
+	b.versions[key] = true
+	b.index.Versions = append(b.index.Versions, record)
+	return nil
+}
+
+// Downloads an archive into the bundle staging directory, verifying its
+// digest.
+func (b *bundleExport) download(ctx context.Context, namespace, name, version string, digest *reference.Digest, path string) error {
+	body, err := b.source.DownloadArchive(ctx, namespace, name, version)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	vr, err := reference.NewVerifyingReader(body, digest)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), archive.DirMode); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, vr); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+// Creates the records of a verified bundle on a registry.
+func importBundle(ctx context.Context, target Registry, dir string, index *BundleIndex) error {
+	for _, ns := range index.Namespaces {
+		if _, err := target.CreateNamespace(ctx, ns); err != nil && !hasErrorCode(err, ErrorCodeNamespaceExists) {
+			return fmt.Errorf("namespace %s: %w", ns.Name, err)
+		}
+	}
+
+	for _, res := range index.Resources {
+		info := ResourceInfo{Name: res.Name, Type: res.Type, Description: res.Description}
+		if _, err := target.CreateResource(ctx, res.Namespace, info); err != nil && !hasErrorCode(err, ErrorCodeResourceExists) {
+			return fmt.Errorf("resource %s/%s: %w", res.Namespace, res.Name, err)
+		}
+	}
+
+	for _, v := range index.Versions {
+		if err := importBundleVersion(ctx, target, dir, v); err != nil {
+			return fmt.Errorf("version %s/%s %s: %w", v.Namespace, v.Resource, v.String, err)
+		}
+	}
+
+	for _, ch := range index.Channels {
+		info := ChannelInfo{Name: ch.Name, Version: ch.Version, Description: ch.Description}
+		_, err := target.CreateChannel(ctx, ch.Namespace, ch.Resource, info)
+		if hasErrorCode(err, ErrorCodeChannelExists) {
+			_, err = target.UpdateChannel(ctx, ch.Namespace, ch.Resource, ch.Name, info, AnyRevision)
+		}
+		if err != nil {
+			return fmt.Errorf("channel %s/%s %s: %w", ch.Namespace, ch.Resource, ch.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Creates a bundled version on a registry and uploads its archive.
+//
+// The upload is skipped if the target already has the same archive.
+func importBundleVersion(ctx context.Context, target Registry, dir string, v BundleVersion) error {
+	existing, err := target.CreateVersion(ctx, v.Namespace, v.Resource, VersionInfo{String: v.String})
+	if hasErrorCode(err, ErrorCodeVersionExists) {
+		existing, err = target.ReadVersion(ctx, v.Namespace, v.Resource, v.String)
+	}
+	if err != nil {
+		return err
+	}
+	if existing.Digest != nil && *existing.Digest == v.Digest {
+		return nil
+	}
+
+	digest, err := parseBundleDigest(v.Digest)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(bundleArchivePath(dir, digest))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	uploaded, err := target.UploadArchive(ctx, v.Namespace, v.Resource, v.String, file)
+	if err != nil {
+		return err
+	}
+	if uploaded.Digest == nil || *uploaded.Digest != v.Digest {
+		return helpers.Wrap(reference.ErrDigestMismatch, fmt.Errorf("expected %s, target stored %v", v.Digest, uploaded.Digest))
+	}
+
+	return nil
+}
+
+// Checks that the archive of a bundle matches its digest.
+func verifyBundleArchive(dir, digest string) error {
+	expected, err := parseBundleDigest(digest)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(bundleArchivePath(dir, expected))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	vr, err := reference.NewVerifyingReader(file, expected)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, vr)
+	return err
+}
+
+// Parses and validates the digest of a bundle archive.
+//
+// Digests come from the source registry on export and from the bundle index
+// on import, and both are untrusted. Validation ensures the algorithm is
+// known and the hash is hex of the right length, so that neither can escape
+// the bundle directory once used as path elements by [bundleArchivePath].
+func parseBundleDigest(s string) (*reference.Digest, error) {
+	digest, err := reference.ParseDigest(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := digest.Validate(); err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// Returns the path of an archive in a bundle directory.
+//
+// The digest must have been validated by [parseBundleDigest], so that its
+// algorithm and hash are safe to use as path elements.
+func bundleArchivePath(dir string, digest *reference.Digest) string {
+	return filepath.Join(dir, BundleArchiveDir, digest.Algorithm, digest.Hash)
+}
+
+// Resolves a version constraint to the highest matching version of a
+// resource.
+//
+// Returns [ErrNoMatchingVersion] if no version matches.
+func resolveVersion(ctx context.Context, source Registry, namespace, name string, constraint *reference.VersionConstraint) (string, error) {
+	versions, err := source.ListVersions(ctx, namespace, name)
+	if err != nil {
+		return "", err
+	}
+
+	var best *reference.Version
+	for _, summary := range versions.Versions {
+		v, err := reference.ParseVersion(summary.String)
+		if err != nil {
+			continue
+		}
+		if ok, _ := constraint.MatchesVersion(v); !ok {
+			continue
+		}
+		if best == nil {
+			best = v
+			continue
+		}
+		if c, ok := v.Compare(best); ok && c > 0 {
+			best = v
+		}
+	}
+
+	if best == nil {
+		return "", fmt.Errorf("%w: %s/%s %s", ErrNoMatchingVersion, namespace, name, constraint)
+	}
+	return best.String(), nil
+}
+
+// Returns the namespace and name of the resource a reference points to.
+//
+// References to other registries store a path instead, which must have the
+// form namespace/name.
+func referenceLocation(ref *reference.Reference) (string, string, error) {
+	if ref.Name() != "" {
+		return ref.Namespace(), ref.Name(), nil
+	}
+
+	namespace, name, ok := strings.Cut(ref.Path(), "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("%w: %s", reference.ErrInvalidPath, ref.Path())
+	}
+	return namespace, name, nil
+}
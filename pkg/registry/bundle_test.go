@@ -0,0 +1,264 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cruciblehq/protocol/pkg/archive"
+	"github.com/cruciblehq/protocol/pkg/codec"
+	"github.com/cruciblehq/protocol/pkg/plan"
+	"github.com/cruciblehq/protocol/pkg/reference"
+	"github.com/cruciblehq/protocol/pkg/resource"
+)
+
+var testIdentifierOptions = &reference.IdentifierOptions{DefaultRegistry: "hub.example.com"}
+
+func setupTestBundle(t *testing.T) (*SQLRegistry, *SQLRegistry, func()) {
+	t.Helper()
+
+	source, cleanupSource := setupTestDB(t)
+	target, cleanupTarget := setupTestDB(t)
+
+	ctx := context.Background()
+	_, _ = source.CreateNamespace(ctx, NamespaceInfo{Name: "acme", Description: "Acme"})
+	_, _ = source.CreateResource(ctx, "acme", ResourceInfo{Name: "app", Type: "widget", Description: "App"})
+	_, _ = source.CreateResource(ctx, "acme", ResourceInfo{Name: "api", Type: "service", Description: "API"})
+	for _, v := range []string{"1.0.0", "1.1.0", "2.0.0"} {
+		_, _ = source.CreateVersion(ctx, "acme", "app", VersionInfo{String: v})
+		_, _ = source.UploadArchive(ctx, "acme", "app", v, bytes.NewReader([]byte("app "+v)))
+	}
+	_, _ = source.CreateVersion(ctx, "acme", "api", VersionInfo{String: "1.0.0"})
+	_, _ = source.UploadArchive(ctx, "acme", "api", "1.0.0", bytes.NewReader([]byte("api 1.0.0")))
+	_, _ = source.CreateChannel(ctx, "acme", "app", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Stable"})
+
+	return source, target, func() {
+		cleanupTarget()
+		cleanupSource()
+	}
+}
+
+func parseTestReferences(t *testing.T, typ resource.Type, refs ...string) []*reference.Reference {
+	t.Helper()
+
+	parsed := make([]*reference.Reference, len(refs))
+	for i, s := range refs {
+		ref, err := reference.Parse(s, typ, testIdentifierOptions)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", s, err)
+		}
+		parsed[i] = ref
+	}
+	return parsed
+}
+
+func TestBundle_RoundTrip(t *testing.T) {
+	source, target, cleanup := setupTestBundle(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	dest := filepath.Join(t.TempDir(), "bundle"+archive.ArchiveFileExtension)
+	refs := parseTestReferences(t, resource.TypeWidget, "acme/app ^1.0.0", "acme/app :stable")
+
+	index, err := ExportBundle(ctx, source, refs, dest)
+	if err != nil {
+		t.Fatalf("ExportBundle() error = %v", err)
+	}
+	if len(index.Namespaces) != 1 || len(index.Resources) != 1 || len(index.Versions) != 2 || len(index.Channels) != 1 {
+		t.Fatalf("unexpected index: %+v", index)
+	}
+	if index.Versions[0].String != "1.1.0" {
+		t.Errorf("resolved %q, want '1.1.0'", index.Versions[0].String)
+	}
+
+	if _, err := ImportBundle(ctx, target, dest); err != nil {
+		t.Fatalf("ImportBundle() error = %v", err)
+	}
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		src, _ := source.ReadVersion(ctx, "acme", "app", version)
+		dst, err := target.ReadVersion(ctx, "acme", "app", version)
+		if err != nil {
+			t.Fatalf("ReadVersion() error = %v", err)
+		}
+		if dst.Digest == nil || *dst.Digest != *src.Digest {
+			t.Errorf("digest of %s = %v, want %s", version, dst.Digest, *src.Digest)
+		}
+	}
+
+	ch, err := target.ReadChannel(ctx, "acme", "app", "stable")
+	if err != nil {
+		t.Fatalf("ReadChannel() error = %v", err)
+	}
+	if ch.Version.String != "1.0.0" || ch.Description != "Stable" {
+		t.Errorf("unexpected channel: %+v", ch)
+	}
+
+	// Importing again changes nothing
+	if _, err := ImportBundle(ctx, target, dest); err != nil {
+		t.Fatalf("ImportBundle() error = %v", err)
+	}
+}
+
+func TestExportPlanBundle(t *testing.T) {
+	source, _, cleanup := setupTestBundle(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	v, _ := source.ReadVersion(ctx, "acme", "api", "1.0.0")
+	p := &plan.Plan{Services: []plan.Service{{ID: "api", Reference: "acme/api 1.0.0 " + *v.Digest}}}
+
+	dest := filepath.Join(t.TempDir(), "plan"+archive.ArchiveFileExtension)
+	index, err := ExportPlanBundle(ctx, source, p, dest, testIdentifierOptions)
+	if err != nil {
+		t.Fatalf("ExportPlanBundle() error = %v", err)
+	}
+	if len(index.Versions) != 1 || index.Versions[0].Digest != *v.Digest {
+		t.Errorf("unexpected versions: %+v", index.Versions)
+	}
+}
+
+func TestExportBundle_Errors(t *testing.T) {
+	source, _, cleanup := setupTestBundle(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	dest := filepath.Join(t.TempDir(), "bundle"+archive.ArchiveFileExtension)
+	zero := "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+	tests := []struct {
+		name string
+		typ  resource.Type
+		ref  string
+		want error
+	}{
+		{"no match", resource.TypeWidget, "acme/app ^3.0.0", ErrNoMatchingVersion},
+		{"digest", resource.TypeWidget, "acme/app 1.0.0 " + zero, reference.ErrDigestMismatch},
+		{"type", resource.TypeService, "acme/app 1.0.0", reference.ErrTypeMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ExportBundle(ctx, source, parseTestReferences(t, tt.typ, tt.ref), dest)
+			if !errors.Is(err, ErrExportFailed) || !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, err)
+			}
+			if _, err := os.Stat(dest); !os.IsNotExist(err) {
+				t.Error("expected no bundle to be written")
+			}
+		})
+	}
+}
+
+func TestImportBundle_Tampered(t *testing.T) {
+	source, target, cleanup := setupTestBundle(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	dest := filepath.Join(t.TempDir(), "bundle"+archive.ArchiveFileExtension)
+	index, err := ExportBundle(ctx, source, parseTestReferences(t, resource.TypeWidget, "acme/app 1.0.0"), dest)
+	if err != nil {
+		t.Fatalf("ExportBundle() error = %v", err)
+	}
+
+	// Rebuild the bundle with a tampered archive
+	dir := filepath.Join(t.TempDir(), "bundle")
+	if err := archive.Extract(dest, dir); err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := reference.ParseDigest(index.Versions[0].Digest)
+	if err := os.WriteFile(bundleArchivePath(dir, digest), []byte("tampered"), archive.FileMode); err != nil {
+		t.Fatal(err)
+	}
+	tampered := filepath.Join(t.TempDir(), "tampered"+archive.ArchiveFileExtension)
+	if err := archive.Create(dir, tampered); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ImportBundle(ctx, target, tampered)
+	if !errors.Is(err, ErrImportFailed) || !errors.Is(err, reference.ErrDigestMismatch) {
+		t.Fatalf("expected ErrDigestMismatch, got: %v", err)
+	}
+
+	list, _ := target.ListNamespaces(ctx)
+	if len(list.Namespaces) != 0 {
+		t.Errorf("expected nothing to be imported, got %+v", list.Namespaces)
+	}
+}
+
+func TestImportBundle_UnsupportedVersion(t *testing.T) {
+	_, target, cleanup := setupTestBundle(t)
+	defer cleanup()
+
+	dir := filepath.Join(t.TempDir(), "bundle")
+	if err := os.MkdirAll(dir, archive.DirMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := codec.EncodeFile(filepath.Join(dir, BundleIndexFile), "field", false, BundleIndex{Version: 99}); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(t.TempDir(), "bundle"+archive.ArchiveFileExtension)
+	if err := archive.Create(dir, src); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ImportBundle(context.Background(), target, src)
+	if !errors.Is(err, ErrUnsupportedBundle) {
+		t.Errorf("expected ErrUnsupportedBundle, got: %v", err)
+	}
+}
+
+func TestImportBundle_InvalidDigest(t *testing.T) {
+	_, target, cleanup := setupTestBundle(t)
+	defer cleanup()
+
+	dir := filepath.Join(t.TempDir(), "bundle")
+	if err := os.MkdirAll(dir, archive.DirMode); err != nil {
+		t.Fatal(err)
+	}
+	index := BundleIndex{
+		Version:  BundleFormatVersion,
+		Versions: []BundleVersion{{Namespace: "acme", Resource: "app", String: "1.0.0", Digest: "sha256:../../../etc/passwd"}},
+	}
+	if err := codec.EncodeFile(filepath.Join(dir, BundleIndexFile), "field", false, index); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(t.TempDir(), "bundle"+archive.ArchiveFileExtension)
+	if err := archive.Create(dir, src); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ImportBundle(context.Background(), target, src)
+	if !errors.Is(err, ErrImportFailed) || !errors.Is(err, reference.ErrInvalidDigest) {
+		t.Errorf("expected ErrInvalidDigest, got: %v", err)
+	}
+}
+
+func TestReferenceLocation(t *testing.T) {
+	tests := []struct {
+		ref       string
+		namespace string
+		name      string
+		wantErr   bool
+	}{
+		{"acme/app 1.0.0", "acme", "app", false},
+		{"app 1.0.0", reference.DefaultNamespace, "app", false},
+		{"registry.example.com/acme/app 1.0.0", "acme", "app", false},
+		{"registry.example.com/a/b/c 1.0.0", "", "", true},
+	}
+
+	for _, tt := range tests {
+		ref := parseTestReferences(t, resource.TypeWidget, tt.ref)[0]
+		namespace, name, err := referenceLocation(ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("referenceLocation(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if namespace != tt.namespace || name != tt.name {
+			t.Errorf("referenceLocation(%q) = %q, %q, want %q, %q", tt.ref, namespace, name, tt.namespace, tt.name)
+		}
+	}
+}
@@ -0,0 +1,749 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+const (
+
+	// How long cached channels and metadata are served before being
+	// revalidated against the remote registry.
+	DefaultCacheTTL = 5 * time.Minute
+
+	// Cache error messages
+	errMsgCacheDigestMismatch = "downloaded archive does not match the remote digest"
+	errMsgEvictCache          = "unable to evict cached entry"
+	errMsgServeStale          = "remote registry unavailable, serving cached entry"
+)
+
+// Pull-through cache in front of a remote registry.
+//
+// Implements [Registry] by serving reads from a local [SQLRegistry] and its
+// archive store, and filling it from the remote registry on a miss. Versions
+// with an archive are immutable, so once cached they are served forever
+// without contacting the remote. Namespaces, resources and channels are
+// revalidated against the remote once their TTL has expired; if the remote
+// cannot be reached, the cached entry is served instead.
+//
+// Namespace and resource summaries only list what has been cached. Listings,
+// members, channel history, audit events and upload sessions describe the
+// remote registry and are always read from it. Mutations are sent to the
+// remote and evict the entries they affect.
+//
+// Cached entries carry the revision last seen in the remote registry, so they
+// can be passed back as the expected revision of a mutation. Entries cached by
+// a previous process carry [AnyRevision] until they are revalidated.
+//
+// The local registry is written to anonymously and should be created without
+// an authorizer. Thread-safe for concurrent access.
+type CachingRegistry struct {
+	remote    Registry             // Registry being cached, typically a [*Client]
+	local     *SQLRegistry         // Local store of cached entries
+	logger    *slog.Logger         // Logger for cache operations
+	ttl       time.Duration        // Lifetime of cached channels and metadata
+	mu        sync.Mutex           // Protects fetched, revisions and fills
+	fetched   map[string]time.Time // Last revalidation of channels and metadata, by cache key
+	revisions map[string]Revision  // Remote revisions of cached entries, by cache key
+	fills     map[string]*fillLock // Locks of versions being filled, by cache key
+}
+
+// Lock serializing the fills of one version, so its archive is downloaded
+// once.
+//
+// Dropped by the registry once no fill holds or waits for it.
+type fillLock struct {
+	sync.Mutex
+	waiters int // Fills holding or waiting for the lock
+}
+
+// Options for creating a [CachingRegistry].
+//
+// The zero value (and a nil *CachingRegistryOptions) revalidates cached
+// entries after [DefaultCacheTTL].
+type CachingRegistryOptions struct {
+
+	// How long cached channels and metadata are served without revalidation.
+	//
+	// Zero uses [DefaultCacheTTL]. A negative TTL revalidates on every read,
+	// still falling back to the cached entry if the remote is unavailable.
+	// Versions are not affected and are cached forever.
+	TTL time.Duration
+}
+
+// Returns the cache TTL, applying the default.
+func (o *CachingRegistryOptions) ttl() time.Duration {
+	if o == nil || o.TTL == 0 {
+		return DefaultCacheTTL
+	}
+	return o.TTL
+}
+
+// Creates a new pull-through cache in front of a remote registry.
+//
+// Cached entries are stored in local, which the caller creates and owns as
+// with [NewSQLRegistry]. Entries cached by a previous process are reused;
+// channels and metadata are revalidated once on first read.
+func NewCachingRegistry(remote Registry, local *SQLRegistry, logger *slog.Logger) *CachingRegistry {
+	return NewCachingRegistryWithOptions(remote, local, logger, nil)
+}
+
+// Creates a new pull-through cache in front of a remote registry using
+// options.
+//
+// Same as [NewCachingRegistry], configured by the given options. Options can
+// be nil.
+func NewCachingRegistryWithOptions(remote Registry, local *SQLRegistry, logger *slog.Logger, options *CachingRegistryOptions) *CachingRegistry {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &CachingRegistry{
+		remote:    remote,
+		local:     local,
+		logger:    logger,
+		ttl:       options.ttl(),
+		fetched:   make(map[string]time.Time),
+		revisions: make(map[string]Revision),
+		fills:     make(map[string]*fillLock),
+	}
+}
+
+// Creates a namespace in the remote registry.
+func (c *CachingRegistry) CreateNamespace(ctx context.Context, info NamespaceInfo) (*Namespace, error) {
+	return c.remote.CreateNamespace(ctx, info)
+}
+
+// Retrieves a namespace, revalidating the cached copy once its TTL expires.
+//
+// Resource summaries only list cached resources.
+func (c *CachingRegistry) ReadNamespace(ctx context.Context, namespace string) (*Namespace, error) {
+	key := cacheKey("namespace", namespace)
+	if c.fresh(key) {
+		if ns, err := c.local.ReadNamespace(ctx, namespace); err == nil {
+			ns.Revision = c.revision(key)
+			return ns, nil
+		}
+	}
+
+	if err := c.cacheNamespace(ctx, namespace); err != nil {
+		if c.serveStale(err, key) {
+			if cached, lerr := c.local.ReadNamespace(ctx, namespace); lerr == nil {
+				cached.Revision = c.revision(key)
+				return cached, nil
+			}
+		}
+		return nil, err
+	}
+
+	ns, err := c.local.ReadNamespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	ns.Revision = c.revision(key)
+	return ns, nil
+}
+
+// Updates a namespace in the remote registry and evicts the cached copy.
+func (c *CachingRegistry) UpdateNamespace(ctx context.Context, namespace string, info NamespaceInfo, expected Revision) (*Namespace, error) {
+	ns, err := c.remote.UpdateNamespace(ctx, namespace, info, expected)
+	if err == nil {
+		c.forget(cacheKey("namespace", namespace))
+	}
+	return ns, err
+}
+
+// Deletes a namespace from the remote registry and from the cache.
+func (c *CachingRegistry) DeleteNamespace(ctx context.Context, namespace string, expected Revision) error {
+	if err := c.remote.DeleteNamespace(ctx, namespace, expected); err != nil {
+		return err
+	}
+
+	key := cacheKey("namespace", namespace)
+	c.forget(key)
+	c.evict(key, c.local.DeleteNamespace(cacheContext(ctx), namespace, AnyRevision))
+	return nil
+}
+
+// Lists the namespaces of the remote registry.
+func (c *CachingRegistry) ListNamespaces(ctx context.Context) (*NamespaceList, error) {
+	return c.remote.ListNamespaces(ctx)
+}
+
+// Adds a member to a namespace in the remote registry.
+func (c *CachingRegistry) CreateMember(ctx context.Context, namespace string, info MemberInfo) (*Member, error) {
+	return c.remote.CreateMember(ctx, namespace, info)
+}
+
+// Retrieves a namespace member from the remote registry.
+func (c *CachingRegistry) ReadMember(ctx context.Context, namespace string, subject string) (*Member, error) {
+	return c.remote.ReadMember(ctx, namespace, subject)
+}
+
+// Changes the role of a namespace member in the remote registry.
+func (c *CachingRegistry) UpdateMember(ctx context.Context, namespace string, subject string, info MemberInfo, expected Revision) (*Member, error) {
+	return c.remote.UpdateMember(ctx, namespace, subject, info, expected)
+}
+
+// Removes a member from a namespace in the remote registry.
+func (c *CachingRegistry) DeleteMember(ctx context.Context, namespace string, subject string, expected Revision) error {
+	return c.remote.DeleteMember(ctx, namespace, subject, expected)
+}
+
+// Lists the members of a namespace in the remote registry.
+func (c *CachingRegistry) ListMembers(ctx context.Context, namespace string) (*MemberList, error) {
+	return c.remote.ListMembers(ctx, namespace)
+}
+
+// Creates a resource in the remote registry.
+func (c *CachingRegistry) CreateResource(ctx context.Context, namespace string, info ResourceInfo) (*Resource, error) {
+	return c.remote.CreateResource(ctx, namespace, info)
+}
+
+// Retrieves a resource, revalidating the cached copy once its TTL expires.
+//
+// Version and channel summaries only list cached versions and channels.
+func (c *CachingRegistry) ReadResource(ctx context.Context, namespace string, resource string) (*Resource, error) {
+	key := cacheKey("resource", namespace, resource)
+	if c.fresh(key) {
+		if res, err := c.local.ReadResource(ctx, namespace, resource); err == nil {
+			res.Revision = c.revision(key)
+			return res, nil
+		}
+	}
+
+	if err := c.cacheResource(ctx, namespace, resource); err != nil {
+		if c.serveStale(err, key) {
+			if cached, lerr := c.local.ReadResource(ctx, namespace, resource); lerr == nil {
+				cached.Revision = c.revision(key)
+				return cached, nil
+			}
+		}
+		return nil, err
+	}
+
+	res, err := c.local.ReadResource(ctx, namespace, resource)
+	if err != nil {
+		return nil, err
+	}
+	res.Revision = c.revision(key)
+	return res, nil
+}
+
+// Updates a resource in the remote registry and evicts the cached copy.
+func (c *CachingRegistry) UpdateResource(ctx context.Context, namespace string, resource string, info ResourceInfo, expected Revision) (*Resource, error) {
+	res, err := c.remote.UpdateResource(ctx, namespace, resource, info, expected)
+	if err == nil {
+		c.forget(cacheKey("resource", namespace, resource))
+	}
+	return res, err
+}
+
+// Deletes a resource from the remote registry and from the cache.
+func (c *CachingRegistry) DeleteResource(ctx context.Context, namespace string, resource string, expected Revision) error {
+	if err := c.remote.DeleteResource(ctx, namespace, resource, expected); err != nil {
+		return err
+	}
+
+	key := cacheKey("resource", namespace, resource)
+	c.forget(key)
+	c.evict(key, c.local.DeleteResource(cacheContext(ctx), namespace, resource, AnyRevision))
+	return nil
+}
+
+// Lists the resources of a namespace in the remote registry.
+func (c *CachingRegistry) ListResources(ctx context.Context, namespace string) (*ResourceList, error) {
+	return c.remote.ListResources(ctx, namespace)
+}
+
+// Searches resources in the remote registry.
+func (c *CachingRegistry) Search(ctx context.Context, query SearchQuery) (*SearchResults, error) {
+	return c.remote.Search(ctx, query)
+}
+
+// Creates a version in the remote registry.
+func (c *CachingRegistry) CreateVersion(ctx context.Context, namespace string, resource string, info VersionInfo) (*Version, error) {
+	return c.remote.CreateVersion(ctx, namespace, resource, info)
+}
+
+// Retrieves a version, caching it with its archive on a miss.
+//
+// Versions with an archive are served from the cache without contacting the
+// remote. Versions without an archive are still being prepared and are read
+// from the remote every time.
+func (c *CachingRegistry) ReadVersion(ctx context.Context, namespace string, resource string, version string) (*Version, error) {
+	if v, err := c.local.ReadVersion(ctx, namespace, resource, version); err == nil && v.Digest != nil {
+		v.Revision = c.revision(cacheKey("version", namespace, resource, version))
+		return v, nil
+	}
+	return c.cacheVersion(ctx, namespace, resource, version)
+}
+
+// Updates a version in the remote registry.
+func (c *CachingRegistry) UpdateVersion(ctx context.Context, namespace string, resource string, version string, info VersionInfo, expected Revision) (*Version, error) {
+	return c.remote.UpdateVersion(ctx, namespace, resource, version, info, expected)
+}
+
+// Deletes a version from the remote registry and from the cache.
+func (c *CachingRegistry) DeleteVersion(ctx context.Context, namespace string, resource string, version string, expected Revision) error {
+	if err := c.remote.DeleteVersion(ctx, namespace, resource, version, expected); err != nil {
+		return err
+	}
+
+	c.evict(cacheKey("version", namespace, resource, version), c.local.DeleteVersion(cacheContext(ctx), namespace, resource, version, AnyRevision))
+	return nil
+}
+
+// Lists the versions of a resource in the remote registry.
+func (c *CachingRegistry) ListVersions(ctx context.Context, namespace string, resource string) (*VersionList, error) {
+	return c.remote.ListVersions(ctx, namespace, resource)
+}
+
+// Uploads an archive to the remote registry and evicts the cached version.
+func (c *CachingRegistry) UploadArchive(ctx context.Context, namespace string, resource string, version string, archive io.Reader) (*Version, error) {
+	v, err := c.remote.UploadArchive(ctx, namespace, resource, version, archive)
+	if err == nil {
+		c.evict(cacheKey("version", namespace, resource, version), c.local.DeleteVersion(cacheContext(ctx), namespace, resource, version, AnyRevision))
+	}
+	return v, err
+}
+
+// Downloads a version archive, caching the version on a miss.
+func (c *CachingRegistry) DownloadArchive(ctx context.Context, namespace string, resource string, version string) (io.ReadCloser, error) {
+	return c.DownloadArchiveRange(ctx, namespace, resource, version, 0, -1)
+}
+
+// Downloads part of a version archive, caching the version on a miss.
+//
+// Archives of versions that cannot be cached are read from the remote.
+func (c *CachingRegistry) DownloadArchiveRange(ctx context.Context, namespace string, resource string, version string, offset int64, length int64) (io.ReadCloser, error) {
+	v, err := c.ReadVersion(ctx, namespace, resource, version)
+	if err != nil {
+		return nil, err
+	}
+	if v.Digest == nil {
+		return c.remote.DownloadArchiveRange(ctx, namespace, resource, version, offset, length)
+	}
+	return c.local.DownloadArchiveRange(ctx, namespace, resource, version, offset, length)
+}
+
+// Starts a resumable upload in the remote registry.
+func (c *CachingRegistry) StartUpload(ctx context.Context, namespace string, resource string, version string) (*Upload, error) {
+	return c.remote.StartUpload(ctx, namespace, resource, version)
+}
+
+// Retrieves a resumable upload session from the remote registry.
+func (c *CachingRegistry) ReadUpload(ctx context.Context, namespace string, resource string, version string, id string) (*Upload, error) {
+	return c.remote.ReadUpload(ctx, namespace, resource, version, id)
+}
+
+// Appends a chunk to a resumable upload in the remote registry.
+func (c *CachingRegistry) UploadChunk(ctx context.Context, namespace string, resource string, version string, id string, offset int64, chunk io.Reader) (*Upload, error) {
+	return c.remote.UploadChunk(ctx, namespace, resource, version, id, offset, chunk)
+}
+
+// Completes a resumable upload in the remote registry and evicts the cached
+// version.
+func (c *CachingRegistry) CommitUpload(ctx context.Context, namespace string, resource string, version string, id string, digest string) (*Version, error) {
+	v, err := c.remote.CommitUpload(ctx, namespace, resource, version, id, digest)
+	if err == nil {
+		c.evict(cacheKey("version", namespace, resource, version), c.local.DeleteVersion(cacheContext(ctx), namespace, resource, version, AnyRevision))
+	}
+	return v, err
+}
+
+// Discards a resumable upload in the remote registry.
+func (c *CachingRegistry) CancelUpload(ctx context.Context, namespace string, resource string, version string, id string) error {
+	return c.remote.CancelUpload(ctx, namespace, resource, version, id)
+}
+
+// Creates a channel in the remote registry.
+func (c *CachingRegistry) CreateChannel(ctx context.Context, namespace string, resource string, info ChannelInfo) (*Channel, error) {
+	ch, err := c.remote.CreateChannel(ctx, namespace, resource, info)
+	if err == nil {
+		c.forget(cacheKey("channel", namespace, resource, info.Name))
+	}
+	return ch, err
+}
+
+// Updates a channel in the remote registry and evicts the cached copy.
+func (c *CachingRegistry) UpdateChannel(ctx context.Context, namespace string, resource string, channel string, info ChannelInfo, expected Revision) (*Channel, error) {
+	ch, err := c.remote.UpdateChannel(ctx, namespace, resource, channel, info, expected)
+	if err == nil {
+		c.forget(cacheKey("channel", namespace, resource, channel))
+	}
+	return ch, err
+}
+
+// Retrieves a channel, revalidating the cached copy once its TTL expires.
+//
+// The version the channel points to is cached with its archive. Channels
+// pointing to a version without an archive are read from the remote.
+func (c *CachingRegistry) ReadChannel(ctx context.Context, namespace string, resource string, channel string) (*Channel, error) {
+	key := cacheKey("channel", namespace, resource, channel)
+	if c.fresh(key) {
+		if ch, err := c.local.ReadChannel(ctx, namespace, resource, channel); err == nil {
+			return c.stampChannel(ch), nil
+		}
+	}
+
+	ch, err := c.cacheChannel(ctx, namespace, resource, channel)
+	if err != nil && c.serveStale(err, key) {
+		if cached, lerr := c.local.ReadChannel(ctx, namespace, resource, channel); lerr == nil {
+			return c.stampChannel(cached), nil
+		}
+	}
+	return ch, err
+}
+
+// Deletes a channel from the remote registry and from the cache.
+func (c *CachingRegistry) DeleteChannel(ctx context.Context, namespace string, resource string, channel string, expected Revision) error {
+	if err := c.remote.DeleteChannel(ctx, namespace, resource, channel, expected); err != nil {
+		return err
+	}
+
+	key := cacheKey("channel", namespace, resource, channel)
+	c.forget(key)
+	c.evict(key, c.local.DeleteChannel(cacheContext(ctx), namespace, resource, channel, AnyRevision))
+	return nil
+}
+
+// Lists the channels of a resource in the remote registry.
+func (c *CachingRegistry) ListChannels(ctx context.Context, namespace string, resource string) (*ChannelList, error) {
+	return c.remote.ListChannels(ctx, namespace, resource)
+}
+
+// Lists the history of a channel in the remote registry.
+func (c *CachingRegistry) ListChannelHistory(ctx context.Context, namespace string, resource string, channel string) (*ChannelHistory, error) {
+	return c.remote.ListChannelHistory(ctx, namespace, resource, channel)
+}
+
+// Rolls back a channel in the remote registry and evicts the cached copy.
+func (c *CachingRegistry) RollbackChannel(ctx context.Context, namespace string, resource string, channel string, version string) (*Channel, error) {
+	ch, err := c.remote.RollbackChannel(ctx, namespace, resource, channel, version)
+	if err == nil {
+		c.forget(cacheKey("channel", namespace, resource, channel))
+	}
+	return ch, err
+}
+
+// Lists the audit events of the remote registry.
+func (c *CachingRegistry) ListAuditEvents(ctx context.Context, filter AuditFilter) (*AuditEventList, error) {
+	return c.remote.ListAuditEvents(ctx, filter)
+}
+
+// Copies a namespace from the remote registry into the cache.
+//
+// The cached copy is only written if it is missing or differs from the
+// remote. A namespace missing from the remote is forgotten.
+func (c *CachingRegistry) cacheNamespace(ctx context.Context, namespace string) error {
+	key := cacheKey("namespace", namespace)
+
+	remote, err := c.remote.ReadNamespace(ctx, namespace)
+	if err != nil {
+		if hasErrorCode(err, ErrorCodeNotFound) {
+			c.forget(key)
+		}
+		return err
+	}
+
+	info := NamespaceInfo{Name: namespace, Description: remote.Description}
+	lctx := cacheContext(ctx)
+
+	cached, err := c.local.ReadNamespace(ctx, namespace)
+	switch {
+	case hasErrorCode(err, ErrorCodeNotFound):
+		_, err = c.local.CreateNamespace(lctx, info)
+		if hasErrorCode(err, ErrorCodeNamespaceExists) {
+			err = nil
+		}
+	case err == nil && cached.Description != info.Description:
+		_, err = c.local.UpdateNamespace(lctx, namespace, info, AnyRevision)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.touch(key, remote.Revision)
+	return nil
+}
+
+// Copies a resource from the remote registry into the cache.
+//
+// The namespace is cached first if it is missing. The cached copy is only
+// written if it is missing or differs from the remote. A resource missing
+// from the remote is forgotten.
+func (c *CachingRegistry) cacheResource(ctx context.Context, namespace, resource string) error {
+	key := cacheKey("resource", namespace, resource)
+
+	remote, err := c.remote.ReadResource(ctx, namespace, resource)
+	if err != nil {
+		if hasErrorCode(err, ErrorCodeNotFound) {
+			c.forget(key)
+		}
+		return err
+	}
+
+	if _, err := c.local.ReadNamespace(ctx, namespace); err != nil {
+		if err := c.cacheNamespace(ctx, namespace); err != nil {
+			return err
+		}
+	}
+
+	info := ResourceInfo{Name: resource, Type: remote.Type, Description: remote.Description}
+	lctx := cacheContext(ctx)
+
+	cached, err := c.local.ReadResource(ctx, namespace, resource)
+	switch {
+	case hasErrorCode(err, ErrorCodeNotFound):
+		_, err = c.local.CreateResource(lctx, namespace, info)
+		if hasErrorCode(err, ErrorCodeResourceExists) {
+			err = nil
+		}
+	case err == nil && (cached.Type != info.Type || cached.Description != info.Description):
+		_, err = c.local.UpdateResource(lctx, namespace, resource, info, AnyRevision)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.touch(key, remote.Revision)
+	return nil
+}
+
+// Copies a version and its archive from the remote registry into the cache.
+//
+// Returns the cached version, or the remote version if it has no archive yet.
+// The resource is cached first if it is missing. The archive is stored in
+// the local archive store and its digest must match the one reported by the
+// remote. The archive is verified as it is downloaded, so an archive that
+// does not match is never stored and [ErrorCodeDigestMismatch] is returned.
+// Fills of the same version are serialized; fills of different versions run
+// concurrently.
+func (c *CachingRegistry) cacheVersion(ctx context.Context, namespace, resource, version string) (*Version, error) {
+	key := cacheKey("version", namespace, resource, version)
+	unlock := c.lockFill(key)
+	defer unlock()
+
+	// Another reader may have filled the version while we waited
+	if v, err := c.local.ReadVersion(ctx, namespace, resource, version); err == nil && v.Digest != nil {
+		v.Revision = c.revision(key)
+		return v, nil
+	}
+
+	remote, err := c.remote.ReadVersion(ctx, namespace, resource, version)
+	if err != nil {
+		return nil, err
+	}
+	if remote.Digest == nil {
+		return remote, nil
+	}
+
+	if _, err := c.local.ReadResource(ctx, namespace, resource); err != nil {
+		if err := c.cacheResource(ctx, namespace, resource); err != nil {
+			return nil, err
+		}
+	}
+
+	lctx := cacheContext(ctx)
+	if _, err := c.local.CreateVersion(lctx, namespace, resource, VersionInfo{String: version}); err != nil && !hasErrorCode(err, ErrorCodeVersionExists) {
+		return nil, err
+	}
+
+	expected, err := reference.ParseDigest(*remote.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.remote.DownloadArchive(ctx, namespace, resource, version)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	vr, err := reference.NewVerifyingReader(body, expected)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := c.local.UploadArchive(lctx, namespace, resource, version, vr)
+	if err != nil {
+		// The verifying reader keeps returning the error it ended with
+		if _, rerr := vr.Read(nil); errors.Is(rerr, reference.ErrDigestMismatch) {
+			c.logger.Error(errMsgCacheDigestMismatch, "namespace", namespace, "resource", resource, "version", version, "expected", *remote.Digest, "error", rerr)
+			return nil, &Error{Code: ErrorCodeDigestMismatch, Message: errMsgCacheDigestMismatch}
+		}
+		return nil, err
+	}
+
+	c.remember(key, remote.Revision)
+	v.Revision = remote.Revision
+	return v, nil
+}
+
+// Takes the fill lock of a version, returning a function that releases it.
+func (c *CachingRegistry) lockFill(key string) (unlock func()) {
+	c.mu.Lock()
+	l := c.fills[key]
+	if l == nil {
+		l = &fillLock{}
+		c.fills[key] = l
+	}
+	l.waiters++
+	c.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if l.waiters--; l.waiters == 0 {
+			delete(c.fills, key)
+		}
+	}
+}
+
+// Copies a channel from the remote registry into the cache.
+//
+// The version the channel points to is cached first. Returns the cached
+// channel, or the remote channel if its version cannot be cached. A channel
+// missing from the remote is removed from the cache.
+func (c *CachingRegistry) cacheChannel(ctx context.Context, namespace, resource, channel string) (*Channel, error) {
+	key := cacheKey("channel", namespace, resource, channel)
+	lctx := cacheContext(ctx)
+
+	remote, err := c.remote.ReadChannel(ctx, namespace, resource, channel)
+	if err != nil {
+		if hasErrorCode(err, ErrorCodeNotFound) {
+			c.forget(key)
+			c.evict(key, c.local.DeleteChannel(lctx, namespace, resource, channel, AnyRevision))
+		}
+		return nil, err
+	}
+
+	v, err := c.cacheVersion(ctx, namespace, resource, remote.Version.String)
+	if err != nil {
+		return nil, err
+	}
+	if v.Digest == nil {
+		return remote, nil
+	}
+
+	info := ChannelInfo{Name: channel, Version: v.String, Description: remote.Description}
+
+	cached, err := c.local.ReadChannel(ctx, namespace, resource, channel)
+	switch {
+	case hasErrorCode(err, ErrorCodeNotFound):
+		cached, err = c.local.CreateChannel(lctx, namespace, resource, info)
+	case err == nil && (cached.Version.String != info.Version || cached.Description != info.Description):
+		cached, err = c.local.UpdateChannel(lctx, namespace, resource, channel, info, AnyRevision)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.touch(key, remote.Revision)
+	return c.stampChannel(cached), nil
+}
+
+// Reports whether a cached entry was revalidated within the TTL.
+func (c *CachingRegistry) fresh(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fetched, ok := c.fetched[key]
+	return ok && time.Since(fetched) < c.ttl
+}
+
+// Marks a cached entry as revalidated now at the given remote revision.
+func (c *CachingRegistry) touch(key string, revision Revision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetched[key] = time.Now()
+	c.revisions[key] = revision
+}
+
+// Records the remote revision of a cached entry.
+func (c *CachingRegistry) remember(key string, revision Revision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revisions[key] = revision
+}
+
+// Returns the remote revision of a cached entry, or [AnyRevision] if it is
+// not known.
+func (c *CachingRegistry) revision(key string) Revision {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.revisions[key]
+}
+
+// Replaces the local revisions of a cached channel and its version with the
+// remote ones.
+func (c *CachingRegistry) stampChannel(ch *Channel) *Channel {
+	ch.Revision = c.revision(cacheKey("channel", ch.Namespace, ch.Resource, ch.Name))
+	ch.Version.Revision = c.revision(cacheKey("version", ch.Namespace, ch.Resource, ch.Version.String))
+	return ch
+}
+
+// Marks a cached entry as needing revalidation.
+func (c *CachingRegistry) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.fetched, key)
+}
+
+// Logs a failure to remove an entry from the cache.
+//
+// Eviction is best effort: the remote has already been changed, and entries
+// left behind are revalidated or replaced on a later read.
+func (c *CachingRegistry) evict(key string, err error) {
+	if err != nil {
+		c.logger.Warn(errMsgEvictCache, "error", err, "key", key)
+	}
+}
+
+// Reports whether a cached entry may be served after revalidation failed.
+//
+// Only failures to reach the remote qualify; answers from the remote, such
+// as [ErrorCodeNotFound], are returned to the caller.
+func (c *CachingRegistry) serveStale(err error, key string) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var regErr *Error
+	if errors.As(err, &regErr) && regErr.Code != ErrorCodeInternalError {
+		return false
+	}
+
+	c.logger.Warn(errMsgServeStale, "error", err, "key", key)
+	return true
+}
+
+// Returns the context used to write to the local registry.
+//
+// Cache writes are made anonymously on behalf of the remote registry, so the
+// principal of the request does not become a member of cached namespaces.
+func cacheContext(ctx context.Context) context.Context {
+	return WithPrincipal(ctx, nil)
+}
+
+// Returns the key of a cached entry.
+func cacheKey(kind string, parts ...string) string {
+	return kind + ":" + strings.Join(parts, "/")
+}
+
+// Reports whether err is an [*Error] with the given code.
+func hasErrorCode(err error, code ErrorCode) bool {
+	var regErr *Error
+	return errors.As(err, &regErr) && regErr.Code == code
+}
@@ -0,0 +1,350 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// Remote registry that counts reads and can be taken offline.
+type flakyRegistry struct {
+	Registry
+	down         bool
+	tampered     bool
+	versionReads int
+	channelReads int
+}
+
+var errRemoteDown = errors.New("connection refused")
+
+func (f *flakyRegistry) ReadNamespace(ctx context.Context, namespace string) (*Namespace, error) {
+	if f.down {
+		return nil, errRemoteDown
+	}
+	return f.Registry.ReadNamespace(ctx, namespace)
+}
+
+func (f *flakyRegistry) ReadVersion(ctx context.Context, namespace, resource, version string) (*Version, error) {
+	f.versionReads++
+	if f.down {
+		return nil, errRemoteDown
+	}
+	return f.Registry.ReadVersion(ctx, namespace, resource, version)
+}
+
+func (f *flakyRegistry) ReadChannel(ctx context.Context, namespace, resource, channel string) (*Channel, error) {
+	f.channelReads++
+	if f.down {
+		return nil, errRemoteDown
+	}
+	return f.Registry.ReadChannel(ctx, namespace, resource, channel)
+}
+
+func (f *flakyRegistry) DownloadArchive(ctx context.Context, namespace, resource, version string) (io.ReadCloser, error) {
+	if f.tampered {
+		return io.NopCloser(bytes.NewReader([]byte("tampered"))), nil
+	}
+	return f.Registry.DownloadArchive(ctx, namespace, resource, version)
+}
+
+func setupTestCache(t *testing.T, ttl time.Duration) (*CachingRegistry, *SQLRegistry, *flakyRegistry, func()) {
+	t.Helper()
+
+	remote, cleanupRemote := setupTestDB(t)
+	local, cleanupLocal := setupTestDB(t)
+
+	ctx := context.Background()
+	_, _ = remote.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = remote.CreateResource(ctx, "test-ns", ResourceInfo{Name: "app", Type: "widget", Description: "App"})
+	for _, v := range []string{"1.0.0", "1.1.0"} {
+		_, _ = remote.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: v})
+		_, _ = remote.UploadArchive(ctx, "test-ns", "app", v, bytes.NewReader([]byte("archive "+v)))
+	}
+	_, _ = remote.CreateChannel(ctx, "test-ns", "app", ChannelInfo{Name: "stable", Version: "1.0.0"})
+
+	flaky := &flakyRegistry{Registry: remote}
+	cache := NewCachingRegistryWithOptions(flaky, local, nil, &CachingRegistryOptions{TTL: ttl})
+
+	return cache, remote, flaky, func() {
+		cleanupLocal()
+		cleanupRemote()
+	}
+}
+
+func TestCachingRegistry_ReadVersion(t *testing.T) {
+	cache, _, flaky, cleanup := setupTestCache(t, time.Hour)
+	defer cleanup()
+
+	ctx := context.Background()
+	v, err := cache.ReadVersion(ctx, "test-ns", "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("ReadVersion() error = %v", err)
+	}
+	if v.Digest == nil {
+		t.Fatal("expected cached version to have a digest")
+	}
+
+	// Versions are served from the cache even when the remote is down
+	flaky.down = true
+	if _, err := cache.ReadVersion(ctx, "test-ns", "app", "1.0.0"); err != nil {
+		t.Fatalf("ReadVersion() error = %v", err)
+	}
+	if flaky.versionReads != 1 {
+		t.Errorf("expected 1 remote read, got %d", flaky.versionReads)
+	}
+
+	rc, err := cache.DownloadArchive(ctx, "test-ns", "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("DownloadArchive() error = %v", err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if string(data) != "archive 1.0.0" {
+		t.Errorf("archive = %q, want 'archive 1.0.0'", data)
+	}
+
+	// Parents were cached along the way
+	res, err := cache.local.ReadResource(ctx, "test-ns", "app")
+	if err != nil {
+		t.Fatalf("ReadResource() error = %v", err)
+	}
+	if res.Type != "widget" || res.Description != "App" {
+		t.Errorf("unexpected cached resource: %+v", res)
+	}
+}
+
+func TestCachingRegistry_ReadVersion_DigestMismatch(t *testing.T) {
+	cache, _, flaky, cleanup := setupTestCache(t, time.Hour)
+	defer cleanup()
+
+	ctx := context.Background()
+	flaky.tampered = true
+	_, err := cache.ReadVersion(ctx, "test-ns", "app", "1.0.0")
+	if regErr, ok := err.(*Error); !ok || regErr.Code != ErrorCodeDigestMismatch {
+		t.Fatalf("expected digest mismatch, got %v", err)
+	}
+
+	// The tampered archive was never stored
+	if v, err := cache.local.ReadVersion(ctx, "test-ns", "app", "1.0.0"); err == nil && v.Digest != nil {
+		t.Errorf("expected no cached archive, got %s", *v.Digest)
+	}
+
+	flaky.tampered = false
+	v, err := cache.ReadVersion(ctx, "test-ns", "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("ReadVersion() error = %v", err)
+	}
+	if v.Digest == nil {
+		t.Error("expected cached version to have a digest")
+	}
+}
+
+func TestCachingRegistry_ReadVersion_ConcurrentFills(t *testing.T) {
+	cache, _, _, cleanup := setupTestCache(t, time.Hour)
+	defer cleanup()
+
+	// A held fill lock only blocks fills of the same version
+	unlock := cache.lockFill(cacheKey("version", "test-ns", "app", "1.0.0"))
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.ReadVersion(context.Background(), "test-ns", "app", "1.1.0")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ReadVersion() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("fill of another version blocked")
+	}
+	unlock()
+
+	if len(cache.fills) != 0 {
+		t.Errorf("expected fill locks to be released, got %d", len(cache.fills))
+	}
+}
+
+func TestCachingRegistry_ReadVersion_NoArchive(t *testing.T) {
+	cache, remote, flaky, cleanup := setupTestCache(t, time.Hour)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = remote.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "2.0.0"})
+
+	v, err := cache.ReadVersion(ctx, "test-ns", "app", "2.0.0")
+	if err != nil {
+		t.Fatalf("ReadVersion() error = %v", err)
+	}
+	if v.Digest != nil {
+		t.Errorf("expected no digest, got %s", *v.Digest)
+	}
+
+	// Versions without an archive are not cached
+	_, _ = cache.ReadVersion(ctx, "test-ns", "app", "2.0.0")
+	if flaky.versionReads != 2 {
+		t.Errorf("expected 2 remote reads, got %d", flaky.versionReads)
+	}
+	if _, err := cache.local.ReadVersion(ctx, "test-ns", "app", "2.0.0"); err == nil {
+		t.Error("expected version not to be cached")
+	}
+}
+
+func TestCachingRegistry_ReadChannel_TTL(t *testing.T) {
+	cache, remote, flaky, cleanup := setupTestCache(t, time.Hour)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := cache.ReadChannel(ctx, "test-ns", "app", "stable"); err != nil {
+		t.Fatalf("ReadChannel() error = %v", err)
+	}
+
+	// Within the TTL the cached channel is served
+	_, _ = remote.UpdateChannel(ctx, "test-ns", "app", "stable", ChannelInfo{Name: "stable", Version: "1.1.0"}, AnyRevision)
+	ch, err := cache.ReadChannel(ctx, "test-ns", "app", "stable")
+	if err != nil {
+		t.Fatalf("ReadChannel() error = %v", err)
+	}
+	if ch.Version.String != "1.0.0" || flaky.channelReads != 1 {
+		t.Errorf("expected cached 1.0.0 after 1 remote read, got %s after %d", ch.Version.String, flaky.channelReads)
+	}
+
+	// Moving the channel through the cache evicts it
+	_, err = cache.UpdateChannel(ctx, "test-ns", "app", "stable", ChannelInfo{Name: "stable", Version: "1.1.0", Description: "Moved"}, AnyRevision)
+	if err != nil {
+		t.Fatalf("UpdateChannel() error = %v", err)
+	}
+	ch, err = cache.ReadChannel(ctx, "test-ns", "app", "stable")
+	if err != nil {
+		t.Fatalf("ReadChannel() error = %v", err)
+	}
+	if ch.Version.String != "1.1.0" || ch.Description != "Moved" {
+		t.Errorf("unexpected channel: %+v", ch)
+	}
+	if ch.Version.Digest == nil {
+		t.Error("expected channel version to be cached with its archive")
+	}
+}
+
+func TestCachingRegistry_ReadChannel_Revalidate(t *testing.T) {
+	cache, remote, flaky, cleanup := setupTestCache(t, -1)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = cache.ReadChannel(ctx, "test-ns", "app", "stable")
+	_, _ = remote.UpdateChannel(ctx, "test-ns", "app", "stable", ChannelInfo{Name: "stable", Version: "1.1.0"}, AnyRevision)
+
+	ch, err := cache.ReadChannel(ctx, "test-ns", "app", "stable")
+	if err != nil {
+		t.Fatalf("ReadChannel() error = %v", err)
+	}
+	if ch.Version.String != "1.1.0" {
+		t.Errorf("Version.String = %q, want '1.1.0'", ch.Version.String)
+	}
+
+	// The stale channel is served while the remote is down
+	flaky.down = true
+	ch, err = cache.ReadChannel(ctx, "test-ns", "app", "stable")
+	if err != nil {
+		t.Fatalf("ReadChannel() error = %v", err)
+	}
+	if ch.Version.String != "1.1.0" {
+		t.Errorf("Version.String = %q, want '1.1.0'", ch.Version.String)
+	}
+
+	// Nothing is served for entries that were never cached
+	_, err = cache.ReadChannel(ctx, "test-ns", "app", "beta")
+	if !errors.Is(err, errRemoteDown) {
+		t.Errorf("expected errRemoteDown, got: %v", err)
+	}
+}
+
+func TestCachingRegistry_ReadChannel_Revision(t *testing.T) {
+	cache, remote, _, cleanup := setupTestCache(t, time.Hour)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = remote.UpdateChannel(ctx, "test-ns", "app", "stable", ChannelInfo{Name: "stable", Version: "1.1.0"}, AnyRevision)
+
+	// Cached channels carry the remote revision, not the local one
+	ch, err := cache.ReadChannel(ctx, "test-ns", "app", "stable")
+	if err != nil {
+		t.Fatalf("ReadChannel() error = %v", err)
+	}
+	if ch.Revision != 2 {
+		t.Errorf("Revision = %d, want 2", ch.Revision)
+	}
+
+	if _, err := cache.UpdateChannel(ctx, "test-ns", "app", "stable", ChannelInfo{Name: "stable", Version: "1.0.0"}, ch.Revision); err != nil {
+		t.Fatalf("UpdateChannel() error = %v", err)
+	}
+	_, err = cache.UpdateChannel(ctx, "test-ns", "app", "stable", ChannelInfo{Name: "stable", Version: "1.1.0"}, ch.Revision)
+	assertErrorCode(t, err, ErrorCodePreconditionFailed)
+}
+
+func TestCachingRegistry_ReadChannel_Deleted(t *testing.T) {
+	cache, remote, _, cleanup := setupTestCache(t, -1)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = cache.ReadChannel(ctx, "test-ns", "app", "stable")
+	_ = remote.DeleteChannel(ctx, "test-ns", "app", "stable", AnyRevision)
+
+	_, err := cache.ReadChannel(ctx, "test-ns", "app", "stable")
+	assertErrorCode(t, err, ErrorCodeNotFound)
+
+	if _, err := cache.local.ReadChannel(ctx, "test-ns", "app", "stable"); err == nil {
+		t.Error("expected channel to be evicted")
+	}
+}
+
+func TestCachingRegistry_ReadNamespace(t *testing.T) {
+	cache, remote, flaky, cleanup := setupTestCache(t, -1)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = cache.ReadNamespace(ctx, "test-ns")
+	_, _ = remote.UpdateNamespace(ctx, "test-ns", NamespaceInfo{Name: "test-ns", Description: "Updated"}, AnyRevision)
+
+	ns, err := cache.ReadNamespace(ctx, "test-ns")
+	if err != nil {
+		t.Fatalf("ReadNamespace() error = %v", err)
+	}
+	if ns.Description != "Updated" {
+		t.Errorf("Description = %q, want 'Updated'", ns.Description)
+	}
+
+	flaky.down = true
+	ns, err = cache.ReadNamespace(ctx, "test-ns")
+	if err != nil {
+		t.Fatalf("ReadNamespace() error = %v", err)
+	}
+	if ns.Description != "Updated" {
+		t.Errorf("Description = %q, want 'Updated'", ns.Description)
+	}
+
+	_, err = cache.ReadNamespace(ctx, "missing")
+	if !errors.Is(err, errRemoteDown) {
+		t.Errorf("expected errRemoteDown, got: %v", err)
+	}
+}
+
+func TestCachingRegistry_DeleteVersion(t *testing.T) {
+	cache, remote, _, cleanup := setupTestCache(t, time.Hour)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = remote.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "2.0.0"})
+	_, _ = remote.UploadArchive(ctx, "test-ns", "app", "2.0.0", bytes.NewReader([]byte("archive 2.0.0")))
+	_, _ = cache.ReadVersion(ctx, "test-ns", "app", "2.0.0")
+
+	if err := cache.DeleteVersion(ctx, "test-ns", "app", "2.0.0", AnyRevision); err != nil {
+		t.Fatalf("DeleteVersion() error = %v", err)
+	}
+
+	_, err := cache.ReadVersion(ctx, "test-ns", "app", "2.0.0")
+	assertErrorCode(t, err, ErrorCodeNotFound)
+}
@@ -2,35 +2,150 @@ package registry
 
 import (
 	"bytes"
+	"container/list"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+
+	// Default number of read responses kept when revalidating reads.
+	DefaultResponseCacheSize = 256
 )
 
 // HTTP client for interacting with the Crucible Hub registry.
 //
 // Implements the Registry interface over HTTP, providing a remote client for
 // registry operations. Handles request serialization, response parsing, and
-// error handling according to the Hub API conventions.
+// error handling according to the Hub API conventions. Failed requests are
+// retried according to a [RetryPolicy], and a circuit breaker (see
+// [BreakerPolicy]) stops sending requests to a registry that keeps failing.
+//
+// Updates and deletes send the expected revision in an If-Match header, and
+// reads can be revalidated with If-None-Match (see [ClientOptions.Revalidate]).
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL     string
+	httpClient  *http.Client
+	retry       *RetryPolicy
+	breaker     *circuitBreaker
+	credentials Credentials
+	responses   *responseCache // Cached read responses, or nil if reads are not revalidated
+}
+
+// Options for creating a [Client].
+//
+// The zero value (and a nil *ClientOptions) uses the default retry and
+// circuit breaker policies.
+type ClientOptions struct {
+
+	// HTTP client used to send requests.
+	//
+	// Nil uses http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Policy for retrying failed requests.
+	//
+	// Nil uses the defaults described in [RetryPolicy].
+	Retry *RetryPolicy
+
+	// Policy for the circuit breaker.
+	//
+	// Nil uses the defaults described in [BreakerPolicy].
+	Breaker *BreakerPolicy
+
+	// Credentials attached to every request.
+	//
+	// Nil sends requests anonymously. See [BearerToken] and
+	// [BasicCredentials].
+	Credentials Credentials
+
+	// Whether read responses are kept and revalidated.
+	//
+	// When set, the client remembers the body and entity tag of every read
+	// and sends the tag in an If-None-Match header when reading again. The
+	// registry answers 304 Not Modified if the entity has not changed, and
+	// the remembered body is returned without being transferred again.
+	Revalidate bool
+
+	// Maximum number of read responses kept when revalidating reads.
+	//
+	// The least recently used response is dropped to make room. Zero or
+	// negative uses [DefaultResponseCacheSize].
+	ResponseCacheSize int
+}
+
+// Returns the HTTP client, applying the default.
+func (o *ClientOptions) httpClient() *http.Client {
+	if o == nil || o.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return o.HTTPClient
+}
+
+// Returns the retry policy, or nil for the defaults.
+func (o *ClientOptions) retry() *RetryPolicy {
+	if o == nil {
+		return nil
+	}
+	return o.Retry
+}
+
+// Returns the circuit breaker policy, or nil for the defaults.
+func (o *ClientOptions) breaker() *BreakerPolicy {
+	if o == nil {
+		return nil
+	}
+	return o.Breaker
+}
+
+// Returns the credentials, or nil for anonymous requests.
+func (o *ClientOptions) credentials() Credentials {
+	if o == nil {
+		return nil
+	}
+	return o.Credentials
+}
+
+// Returns the read response cache, or nil if reads are not revalidated.
+func (o *ClientOptions) responses() *responseCache {
+	if o == nil || !o.Revalidate {
+		return nil
+	}
+
+	size := o.ResponseCacheSize
+	if size <= 0 {
+		size = DefaultResponseCacheSize
+	}
+	return &responseCache{size: size, entries: make(map[string]*list.Element), order: list.New()}
 }
 
 // Creates a new Hub client.
 //
 // The base URL should point to the Hub registry. If httpClient is nil,
-// http.DefaultClient is used.
+// http.DefaultClient is used. Retries and circuit breaking use the default
+// policies.
 func NewClient(baseURL string, httpClient *http.Client) *Client {
-	if httpClient == nil {
-		httpClient = http.DefaultClient
-	}
+	return NewClientWithOptions(baseURL, &ClientOptions{HTTPClient: httpClient})
+}
+
+// Creates a new Hub client using options.
+//
+// Same as [NewClient], configured by the given options. Options can be nil.
+func NewClientWithOptions(baseURL string, options *ClientOptions) *Client {
 	return &Client{
-		baseURL:    baseURL,
-		httpClient: httpClient,
+		baseURL:     baseURL,
+		httpClient:  options.httpClient(),
+		retry:       options.retry(),
+		breaker:     newCircuitBreaker(options.breaker()),
+		credentials: options.credentials(),
+		responses:   options.responses(),
 	}
 }
 
@@ -72,7 +187,7 @@ func (c *Client) ReadNamespace(ctx context.Context, namespace string) (*Namespac
 }
 
 // Updates mutable namespace metadata.
-func (c *Client) UpdateNamespace(ctx context.Context, namespace string, info NamespaceInfo) (*Namespace, error) {
+func (c *Client) UpdateNamespace(ctx context.Context, namespace string, info NamespaceInfo, expected Revision) (*Namespace, error) {
 	body, err := json.Marshal(info)
 	if err != nil {
 		return nil, fmt.Errorf("marshal namespace info: %w", err)
@@ -85,6 +200,7 @@ func (c *Client) UpdateNamespace(ctx context.Context, namespace string, info Nam
 	}
 	req.Header.Set("Content-Type", string(MediaTypeNamespaceInfo)+"+json")
 	req.Header.Set("Accept", string(MediaTypeNamespace)+"+json")
+	setIfMatch(req, expected)
 
 	var ns Namespace
 	if err := c.do(req, &ns); err != nil {
@@ -94,12 +210,13 @@ func (c *Client) UpdateNamespace(ctx context.Context, namespace string, info Nam
 }
 
 // Permanently deletes a namespace.
-func (c *Client) DeleteNamespace(ctx context.Context, namespace string) error {
+func (c *Client) DeleteNamespace(ctx context.Context, namespace string, expected Revision) error {
 	path, _ := url.JoinPath("/namespaces", namespace)
 	req, err := c.newRequest(ctx, "DELETE", path, nil)
 	if err != nil {
 		return err
 	}
+	setIfMatch(req, expected)
 	return c.do(req, nil)
 }
 
@@ -118,6 +235,94 @@ func (c *Client) ListNamespaces(ctx context.Context) (*NamespaceList, error) {
 	return &list, nil
 }
 
+// Adds a member to a namespace.
+func (c *Client) CreateMember(ctx context.Context, namespace string, info MemberInfo) (*Member, error) {
+	body, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("marshal member info: %w", err)
+	}
+
+	path, _ := url.JoinPath("/namespaces", namespace, "members")
+	req, err := c.newRequest(ctx, "POST", path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", string(MediaTypeMemberInfo)+"+json")
+	req.Header.Set("Accept", string(MediaTypeMember)+"+json")
+
+	var m Member
+	if err := c.do(req, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Retrieves a namespace member.
+func (c *Client) ReadMember(ctx context.Context, namespace, subject string) (*Member, error) {
+	path, _ := url.JoinPath("/namespaces", namespace, "members", subject)
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(MediaTypeMember)+"+json")
+
+	var m Member
+	if err := c.do(req, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Changes the role of a namespace member.
+func (c *Client) UpdateMember(ctx context.Context, namespace, subject string, info MemberInfo, expected Revision) (*Member, error) {
+	body, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("marshal member info: %w", err)
+	}
+
+	path, _ := url.JoinPath("/namespaces", namespace, "members", subject)
+	req, err := c.newRequest(ctx, "PUT", path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", string(MediaTypeMemberInfo)+"+json")
+	req.Header.Set("Accept", string(MediaTypeMember)+"+json")
+	setIfMatch(req, expected)
+
+	var m Member
+	if err := c.do(req, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Removes a member from a namespace.
+func (c *Client) DeleteMember(ctx context.Context, namespace, subject string, expected Revision) error {
+	path, _ := url.JoinPath("/namespaces", namespace, "members", subject)
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	setIfMatch(req, expected)
+	return c.do(req, nil)
+}
+
+// Lists all members of a namespace.
+func (c *Client) ListMembers(ctx context.Context, namespace string) (*MemberList, error) {
+	path, _ := url.JoinPath("/namespaces", namespace, "members")
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(MediaTypeMemberList)+"+json")
+
+	var list MemberList
+	if err := c.do(req, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
 // Creates a new resource in the specified namespace.
 func (c *Client) CreateResource(ctx context.Context, namespace string, info ResourceInfo) (*Resource, error) {
 	body, err := json.Marshal(info)
@@ -157,7 +362,7 @@ func (c *Client) ReadResource(ctx context.Context, namespace, resource string) (
 }
 
 // Updates mutable resource metadata.
-func (c *Client) UpdateResource(ctx context.Context, namespace, resource string, info ResourceInfo) (*Resource, error) {
+func (c *Client) UpdateResource(ctx context.Context, namespace, resource string, info ResourceInfo, expected Revision) (*Resource, error) {
 	body, err := json.Marshal(info)
 	if err != nil {
 		return nil, fmt.Errorf("marshal resource info: %w", err)
@@ -170,6 +375,7 @@ func (c *Client) UpdateResource(ctx context.Context, namespace, resource string,
 	}
 	req.Header.Set("Content-Type", string(MediaTypeResourceInfo)+"+json")
 	req.Header.Set("Accept", string(MediaTypeResource)+"+json")
+	setIfMatch(req, expected)
 
 	var res Resource
 	if err := c.do(req, &res); err != nil {
@@ -179,12 +385,13 @@ func (c *Client) UpdateResource(ctx context.Context, namespace, resource string,
 }
 
 // Permanently deletes a resource.
-func (c *Client) DeleteResource(ctx context.Context, namespace, resource string) error {
+func (c *Client) DeleteResource(ctx context.Context, namespace, resource string, expected Revision) error {
 	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource)
 	req, err := c.newRequest(ctx, "DELETE", path, nil)
 	if err != nil {
 		return err
 	}
+	setIfMatch(req, expected)
 	return c.do(req, nil)
 }
 
@@ -204,6 +411,24 @@ func (c *Client) ListResources(ctx context.Context, namespace string) (*Resource
 	return &list, nil
 }
 
+// Searches resources across all namespaces.
+//
+// The query is sent as query parameters; zero-valued fields are omitted.
+func (c *Client) Search(ctx context.Context, query SearchQuery) (*SearchResults, error) {
+	req, err := c.newRequest(ctx, "GET", "/search", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = searchQuery(query).Encode()
+	req.Header.Set("Accept", string(MediaTypeSearchResults)+"+json")
+
+	var results SearchResults
+	if err := c.do(req, &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
 // Creates a new version for a resource.
 func (c *Client) CreateVersion(ctx context.Context, namespace, resource string, info VersionInfo) (*Version, error) {
 	body, err := json.Marshal(info)
@@ -243,7 +468,7 @@ func (c *Client) ReadVersion(ctx context.Context, namespace, resource, version s
 }
 
 // Updates mutable version metadata.
-func (c *Client) UpdateVersion(ctx context.Context, namespace, resource, version string, info VersionInfo) (*Version, error) {
+func (c *Client) UpdateVersion(ctx context.Context, namespace, resource, version string, info VersionInfo, expected Revision) (*Version, error) {
 	body, err := json.Marshal(info)
 	if err != nil {
 		return nil, fmt.Errorf("marshal version info: %w", err)
@@ -256,6 +481,7 @@ func (c *Client) UpdateVersion(ctx context.Context, namespace, resource, version
 	}
 	req.Header.Set("Content-Type", string(MediaTypeVersionInfo)+"+json")
 	req.Header.Set("Accept", string(MediaTypeVersion)+"+json")
+	setIfMatch(req, expected)
 
 	var ver Version
 	if err := c.do(req, &ver); err != nil {
@@ -265,12 +491,13 @@ func (c *Client) UpdateVersion(ctx context.Context, namespace, resource, version
 }
 
 // Permanently deletes a version.
-func (c *Client) DeleteVersion(ctx context.Context, namespace, resource, version string) error {
+func (c *Client) DeleteVersion(ctx context.Context, namespace, resource, version string, expected Revision) error {
 	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "versions", version)
 	req, err := c.newRequest(ctx, "DELETE", path, nil)
 	if err != nil {
 		return err
 	}
+	setIfMatch(req, expected)
 	return c.do(req, nil)
 }
 
@@ -309,30 +536,139 @@ func (c *Client) UploadArchive(ctx context.Context, namespace, resource, version
 
 // Downloads a version archive.
 func (c *Client) DownloadArchive(ctx context.Context, namespace, resource, version string) (io.ReadCloser, error) {
-	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "versions", version, "archive")
-	req, err := c.newRequest(ctx, "GET", path, nil)
+	resp, err := c.getArchive(ctx, namespace, resource, version, "")
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Accept", string(MediaTypeArchive))
+	return resp.Body, nil
+}
+
+// Downloads part of a version archive.
+//
+// Sends an HTTP Range request. If the server ignores the range and returns
+// the whole archive, the bytes before offset are skipped.
+func (c *Client) DownloadArchiveRange(ctx context.Context, namespace, resource, version string, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: "range offset must not be negative"}
+	}
 
-	resp, err := c.httpClient.Do(req)
+	rangeSpec := fmt.Sprintf("bytes=%d-", offset)
+	if length >= 0 {
+		if length == 0 {
+			return io.NopCloser(bytes.NewReader(nil)), nil
+		}
+		rangeSpec += strconv.FormatInt(offset+length-1, 10)
+	}
+
+	resp, err := c.getArchive(ctx, namespace, resource, version, rangeSpec)
 	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		defer resp.Body.Close()
-		var regErr Error
-		if err := json.NewDecoder(resp.Body).Decode(&regErr); err != nil {
-			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	if resp.StatusCode != http.StatusPartialContent && offset > 0 {
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("skip to range offset: %w", err)
 		}
-		return nil, &regErr
+	}
+	if resp.StatusCode != http.StatusPartialContent && length >= 0 {
+		return &archiveRange{Reader: io.LimitReader(resp.Body, length), Closer: resp.Body}, nil
 	}
 
 	return resp.Body, nil
 }
 
+// Starts a resumable archive upload.
+func (c *Client) StartUpload(ctx context.Context, namespace, resource, version string) (*Upload, error) {
+	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "versions", version, "uploads")
+	req, err := c.newRequest(ctx, "POST", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(MediaTypeUpload)+"+json")
+
+	var upload Upload
+	if err := c.do(req, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Retrieves a resumable upload session.
+func (c *Client) ReadUpload(ctx context.Context, namespace, resource, version, id string) (*Upload, error) {
+	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "versions", version, "uploads", id)
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(MediaTypeUpload)+"+json")
+
+	var upload Upload
+	if err := c.do(req, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Appends a chunk to a resumable upload.
+//
+// The chunk is read into memory so that its range can be announced in the
+// Content-Range header, as the OCI distribution spec does. An empty chunk
+// sends nothing and returns the current session.
+func (c *Client) UploadChunk(ctx context.Context, namespace, resource, version, id string, offset int64, chunk io.Reader) (*Upload, error) {
+	data, err := io.ReadAll(chunk)
+	if err != nil {
+		return nil, fmt.Errorf("read chunk: %w", err)
+	}
+	if len(data) == 0 {
+		return c.ReadUpload(ctx, namespace, resource, version, id)
+	}
+
+	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "versions", version, "uploads", id)
+	req, err := c.newRequest(ctx, "PATCH", path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", string(MediaTypeArchive))
+	req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+int64(len(data))-1))
+	req.Header.Set("Accept", string(MediaTypeUpload)+"+json")
+
+	var upload Upload
+	if err := c.do(req, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Completes a resumable upload.
+//
+// The digest is sent in the digest query parameter.
+func (c *Client) CommitUpload(ctx context.Context, namespace, resource, version, id, digest string) (*Version, error) {
+	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "versions", version, "uploads", id)
+	req, err := c.newRequest(ctx, "PUT", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = url.Values{"digest": {digest}}.Encode()
+	req.Header.Set("Accept", string(MediaTypeVersion)+"+json")
+
+	var ver Version
+	if err := c.do(req, &ver); err != nil {
+		return nil, err
+	}
+	return &ver, nil
+}
+
+// Discards a resumable upload.
+func (c *Client) CancelUpload(ctx context.Context, namespace, resource, version, id string) error {
+	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "versions", version, "uploads", id)
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, nil)
+}
+
 // Creates a new channel.
 func (c *Client) CreateChannel(ctx context.Context, namespace, resource string, info ChannelInfo) (*Channel, error) {
 	body, err := json.Marshal(info)
@@ -372,7 +708,7 @@ func (c *Client) ReadChannel(ctx context.Context, namespace, resource, channel s
 }
 
 // Updates a channel's mutable metadata.
-func (c *Client) UpdateChannel(ctx context.Context, namespace, resource, channel string, info ChannelInfo) (*Channel, error) {
+func (c *Client) UpdateChannel(ctx context.Context, namespace, resource, channel string, info ChannelInfo, expected Revision) (*Channel, error) {
 	body, err := json.Marshal(info)
 	if err != nil {
 		return nil, fmt.Errorf("marshal channel info: %w", err)
@@ -385,6 +721,7 @@ func (c *Client) UpdateChannel(ctx context.Context, namespace, resource, channel
 	}
 	req.Header.Set("Content-Type", string(MediaTypeChannelInfo)+"+json")
 	req.Header.Set("Accept", string(MediaTypeChannel)+"+json")
+	setIfMatch(req, expected)
 
 	var ch Channel
 	if err := c.do(req, &ch); err != nil {
@@ -394,12 +731,13 @@ func (c *Client) UpdateChannel(ctx context.Context, namespace, resource, channel
 }
 
 // Permanently deletes a channel.
-func (c *Client) DeleteChannel(ctx context.Context, namespace, resource, channel string) error {
+func (c *Client) DeleteChannel(ctx context.Context, namespace, resource, channel string, expected Revision) error {
 	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "channels", channel)
 	req, err := c.newRequest(ctx, "DELETE", path, nil)
 	if err != nil {
 		return err
 	}
+	setIfMatch(req, expected)
 	return c.do(req, nil)
 }
 
@@ -419,7 +757,114 @@ func (c *Client) ListChannels(ctx context.Context, namespace, resource string) (
 	return &list, nil
 }
 
+// Lists the pointer changes of a channel, newest first.
+func (c *Client) ListChannelHistory(ctx context.Context, namespace, resource, channel string) (*ChannelHistory, error) {
+	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "channels", channel, "history")
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(MediaTypeChannelHistory)+"+json")
+
+	var history ChannelHistory
+	if err := c.do(req, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// Points a channel back at a version it pointed to before.
+//
+// An empty version undoes the most recent change.
+func (c *Client) RollbackChannel(ctx context.Context, namespace, resource, channel, version string) (*Channel, error) {
+	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "channels", channel, "rollback")
+	req, err := c.newRequest(ctx, "POST", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if version != "" {
+		req.URL.RawQuery = url.Values{"version": {version}}.Encode()
+	}
+	req.Header.Set("Accept", string(MediaTypeChannel)+"+json")
+
+	var ch Channel
+	if err := c.do(req, &ch); err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// Lists recorded mutations, newest first.
+//
+// Filters are sent as query parameters; zero-valued fields are omitted.
+func (c *Client) ListAuditEvents(ctx context.Context, filter AuditFilter) (*AuditEventList, error) {
+	req, err := c.newRequest(ctx, "GET", "/audit", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = auditQuery(filter).Encode()
+	req.Header.Set("Accept", string(MediaTypeAuditEventList)+"+json")
+
+	var list AuditEventList
+	if err := c.do(req, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Returns the query parameters for an audit filter.
+func auditQuery(filter AuditFilter) url.Values {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("namespace", filter.Namespace)
+	set("resource", filter.Resource)
+	set("target", filter.Target)
+	set("actor", filter.Actor)
+	set("action", string(filter.Action))
+	if filter.Since != 0 {
+		query.Set("since", strconv.FormatInt(filter.Since, 10))
+	}
+	if filter.Until != 0 {
+		query.Set("until", strconv.FormatInt(filter.Until, 10))
+	}
+	if filter.Limit != 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	return query
+}
+
+// Returns the query parameters for a search query.
+func searchQuery(search SearchQuery) url.Values {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("q", search.Text)
+	set("type", search.Type)
+	set("namespace", search.Namespace)
+	if search.Stable {
+		query.Set("stable", "true")
+	}
+	if search.Limit != 0 {
+		query.Set("limit", strconv.Itoa(search.Limit))
+	}
+	if search.Offset != 0 {
+		query.Set("offset", strconv.Itoa(search.Offset))
+	}
+	return query
+}
+
 // Creates an HTTP request with the given method, path, and body.
+//
+// Bodies that implement [io.Seeker] are made rewindable so the request can be
+// retried, and are never closed by the HTTP client. In-memory bodies created
+// with bytes.NewReader and similar are rewindable as well.
 func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
 	u, err := url.Parse(c.baseURL)
 	if err != nil {
@@ -427,21 +872,145 @@ func (c *Client) newRequest(ctx context.Context, method, path string, body io.Re
 	}
 	u.Path = path
 
+	seeker, rewindable := body.(io.ReadSeeker)
+	switch body.(type) {
+	case *bytes.Reader, *bytes.Buffer, *strings.Reader:
+		rewindable = false // Handled by http.NewRequest
+	}
+
+	var start int64
+	if rewindable {
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		body = io.NopCloser(seeker)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
+
+	if rewindable {
+		req.GetBody = func() (io.ReadCloser, error) {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+			return io.NopCloser(seeker), nil
+		}
+	}
+
 	return req, nil
 }
 
+// Sends an HTTP request, retrying and circuit breaking as configured.
+//
+// Returns the final response, which may have any status code; the caller
+// must close its body. Transport errors are returned after the last attempt.
+// Returns [ErrCircuitOpen] without sending the request if the circuit is open.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		if c.credentials != nil {
+			if err := c.credentials.Authorize(req); err != nil {
+				c.breaker.release()
+				return nil, fmt.Errorf("authorize request: %w", err)
+			}
+		}
+
+		resp, err := c.httpClient.Do(req)
+
+		if attempt >= c.retry.maxAttempts() || !retryable(req, resp, err) {
+			if ctx.Err() != nil {
+				c.breaker.release()
+			} else {
+				c.breaker.record(resp, err)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("execute request: %w", err)
+			}
+			return resp, nil
+		}
+
+		wait := c.retry.wait(attempt, retryAfter(resp))
+		if resp != nil {
+			discard(resp)
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			c.breaker.release()
+			return nil, fmt.Errorf("execute request: %w", err)
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				c.breaker.release()
+				return nil, fmt.Errorf("rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+	}
+}
+
+// Requests a version archive and checks the response status.
+//
+// The rangeSpec parameter is the value of the Range header, or empty to
+// request the whole archive. The caller must close the response body.
+func (c *Client) getArchive(ctx context.Context, namespace, resource, version, rangeSpec string) (*http.Response, error) {
+	path, _ := url.JoinPath("/namespaces", namespace, "resources", resource, "versions", version, "archive")
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(MediaTypeArchive))
+	if rangeSpec != "" {
+		req.Header.Set("Range", rangeSpec)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var regErr Error
+		if err := json.NewDecoder(resp.Body).Decode(&regErr); err != nil {
+			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		}
+		return nil, &regErr
+	}
+
+	return resp, nil
+}
+
 // Executes an HTTP request and decodes the JSON response.
+//
+// Reads are revalidated against the cached response, if any, which is decoded
+// instead when the registry answers 304 Not Modified. Other requests drop the
+// cached responses of their path and the paths below it.
 func (c *Client) do(req *http.Request, result interface{}) error {
-	resp, err := c.httpClient.Do(req)
+	c.responses.invalidate(req)
+	cached, revalidating := c.responses.lookup(req)
+	if revalidating {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := c.send(req)
 	if err != nil {
-		return fmt.Errorf("execute request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && revalidating {
+		return decodeResponse(cached.body, result)
+	}
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		var regErr Error
 		if err := json.NewDecoder(resp.Body).Decode(&regErr); err != nil {
@@ -450,11 +1019,129 @@ func (c *Client) do(req *http.Request, result interface{}) error {
 		return &regErr
 	}
 
-	if result != nil {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("decode response: %w", err)
-		}
+	if result == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if err := decodeResponse(body, result); err != nil {
+		return err
 	}
 
+	c.responses.store(req, resp, body)
+	return nil
+}
+
+// Decodes a JSON response body into result.
+func decodeResponse(body []byte, result interface{}) error {
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
 	return nil
 }
+
+// Sets the If-Match header of a request to the expected revision.
+//
+// Nothing is set for [AnyRevision], so the request applies to any revision.
+func setIfMatch(req *http.Request, expected Revision) {
+	if expected != AnyRevision {
+		req.Header.Set("If-Match", expected.ETag())
+	}
+}
+
+// Read responses kept for revalidation, by URL and Accept header.
+//
+// Holds up to size responses, dropping the least recently used one to make
+// room. A nil *responseCache keeps nothing. Thread-safe for concurrent access.
+type responseCache struct {
+	mu      sync.Mutex
+	size    int                      // Maximum number of responses
+	entries map[string]*list.Element // Responses by key, as *cachedResponse
+	order   *list.List               // Responses, most recently used first
+}
+
+// Read response kept for revalidation.
+type cachedResponse struct {
+	key  string // Key of the response, see responseKey
+	path string // URL path of the request
+	etag string // Entity tag the response was sent with
+	body []byte // Response body
+}
+
+// Returns the cached response to a request, if it is a read with one.
+func (rc *responseCache) lookup(req *http.Request) (cachedResponse, bool) {
+	if rc == nil || req.Method != http.MethodGet {
+		return cachedResponse{}, false
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	elem, ok := rc.entries[responseKey(req)]
+	if !ok {
+		return cachedResponse{}, false
+	}
+	rc.order.MoveToFront(elem)
+	return *elem.Value.(*cachedResponse), true
+}
+
+// Keeps the response to a read if it carries an entity tag, and drops the
+// previous response otherwise.
+func (rc *responseCache) store(req *http.Request, resp *http.Response, body []byte) {
+	if rc == nil || req.Method != http.MethodGet {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	key := responseKey(req)
+	if elem, ok := rc.entries[key]; ok {
+		rc.order.Remove(elem)
+		delete(rc.entries, key)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return
+	}
+
+	rc.entries[key] = rc.order.PushFront(&cachedResponse{key: key, path: req.URL.Path, etag: etag, body: body})
+	for rc.order.Len() > rc.size {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// Drops the responses to reads of the path of a mutation and the paths below
+// it, since the mutation likely changed them.
+func (rc *responseCache) invalidate(req *http.Request) {
+	if rc == nil || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	for elem := rc.order.Front(); elem != nil; {
+		next := elem.Next()
+		cached := elem.Value.(*cachedResponse)
+		if cached.path == path || strings.HasPrefix(cached.path, path+"/") {
+			rc.order.Remove(elem)
+			delete(rc.entries, cached.key)
+		}
+		elem = next
+	}
+}
+
+// Returns the key of the cached response to a request.
+func responseKey(req *http.Request) string {
+	return req.Header.Get("Accept") + " " + req.URL.String()
+}
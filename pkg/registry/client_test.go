@@ -80,7 +80,7 @@ func TestClient_UpdateNamespace(t *testing.T) {
 	ns, err := client.UpdateNamespace(context.Background(), "test", NamespaceInfo{
 		Name:        "test",
 		Description: "updated",
-	})
+	}, AnyRevision)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -102,7 +102,7 @@ func TestClient_DeleteNamespace(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL, nil)
-	err := client.DeleteNamespace(context.Background(), "test")
+	err := client.DeleteNamespace(context.Background(), "test", AnyRevision)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -131,6 +131,94 @@ func TestClient_ListNamespaces(t *testing.T) {
 	}
 }
 
+func TestClient_CreateMember(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/namespaces/test/members" {
+			t.Errorf("expected /namespaces/test/members, got %s", r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); !strings.Contains(ct, "member-info") {
+			t.Errorf("expected member-info content type, got %s", ct)
+		}
+		w.Header().Set("Content-Type", "application/vnd.crucible.member.v0+json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"namespace":"test","subject":"alice","role":"publisher","createdAt":0,"updatedAt":0}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	m, err := client.CreateMember(context.Background(), "test", MemberInfo{Subject: "alice", Role: RolePublisher})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Subject != "alice" || m.Role != RolePublisher {
+		t.Errorf("unexpected member: %+v", m)
+	}
+}
+
+func TestClient_UpdateMember(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		if r.URL.Path != "/namespaces/test/members/alice" {
+			t.Errorf("expected /namespaces/test/members/alice, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/vnd.crucible.member.v0+json")
+		w.Write([]byte(`{"namespace":"test","subject":"alice","role":"admin","createdAt":0,"updatedAt":0}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	m, err := client.UpdateMember(context.Background(), "test", "alice", MemberInfo{Subject: "alice", Role: RoleAdmin}, AnyRevision)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Role != RoleAdmin {
+		t.Errorf("expected role admin, got %s", m.Role)
+	}
+}
+
+func TestClient_DeleteMember(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Errorf("expected DELETE, got %s", r.Method)
+		}
+		if r.URL.Path != "/namespaces/test/members/alice" {
+			t.Errorf("expected /namespaces/test/members/alice, got %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	if err := client.DeleteMember(context.Background(), "test", "alice", AnyRevision); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_ListMembers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/namespaces/test/members" {
+			t.Errorf("expected /namespaces/test/members, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/vnd.crucible.member-list.v0+json")
+		w.Write([]byte(`{"members":[{"namespace":"test","subject":"alice","role":"reader","createdAt":0,"updatedAt":0}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	list, err := client.ListMembers(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Members) != 1 || list.Members[0].Role != RoleReader {
+		t.Errorf("unexpected members: %+v", list.Members)
+	}
+}
+
 func TestClient_CreateResource(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "POST" {
@@ -200,7 +288,7 @@ func TestClient_UpdateResource(t *testing.T) {
 		Name:        "myres",
 		Type:        "widget",
 		Description: "updated",
-	})
+	}, AnyRevision)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -222,7 +310,7 @@ func TestClient_DeleteResource(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL, nil)
-	err := client.DeleteResource(context.Background(), "test", "myres")
+	err := client.DeleteResource(context.Background(), "test", "myres", AnyRevision)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -316,7 +404,7 @@ func TestClient_UpdateVersion(t *testing.T) {
 	client := NewClient(server.URL, nil)
 	ver, err := client.UpdateVersion(context.Background(), "test", "myres", "1.0.0", VersionInfo{
 		String: "1.0.0",
-	})
+	}, AnyRevision)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -338,7 +426,7 @@ func TestClient_DeleteVersion(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL, nil)
-	err := client.DeleteVersion(context.Background(), "test", "myres", "1.0.0")
+	err := client.DeleteVersion(context.Background(), "test", "myres", "1.0.0", AnyRevision)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -495,7 +583,7 @@ func TestClient_UpdateChannel(t *testing.T) {
 		Name:        "stable",
 		Version:     "1.0.1",
 		Description: "updated",
-	})
+	}, AnyRevision)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -520,7 +608,7 @@ func TestClient_DeleteChannel(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL, nil)
-	err := client.DeleteChannel(context.Background(), "test", "myres", "stable")
+	err := client.DeleteChannel(context.Background(), "test", "myres", "stable", AnyRevision)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -594,3 +682,212 @@ func TestNewClient_CustomHTTPClient(t *testing.T) {
 		t.Error("expected custom HTTP client to be used")
 	}
 }
+
+func TestClient_DownloadArchiveRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rng := r.Header.Get("Range"); rng != "bytes=2-4" {
+			t.Errorf("expected Range bytes=2-4, got %s", rng)
+		}
+		w.Header().Set("Content-Type", string(MediaTypeArchive))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte("234"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	reader, err := client.DownloadArchiveRange(context.Background(), "ns", "res", "1.0.0", 2, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reader.Close()
+
+	data, _ := io.ReadAll(reader)
+	if string(data) != "234" {
+		t.Errorf("expected '234', got %q", data)
+	}
+}
+
+func TestClient_DownloadArchiveRange_Ignored(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", string(MediaTypeArchive))
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	reader, err := client.DownloadArchiveRange(context.Background(), "ns", "res", "1.0.0", 2, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reader.Close()
+
+	data, _ := io.ReadAll(reader)
+	if string(data) != "234" {
+		t.Errorf("expected '234', got %q", data)
+	}
+}
+
+func TestClient_ListChannelHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/namespaces/test/resources/myres/channels/stable/history" {
+			t.Errorf("expected /namespaces/test/resources/myres/channels/stable/history, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/vnd.crucible.channel-history.v0+json")
+		w.Write([]byte(`{"entries":[{"id":2,"namespace":"test","resource":"myres","channel":"stable","version":"1.1.0","previous":"1.0.0","actor":"alice","createdAt":0}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	history, err := client.ListChannelHistory(context.Background(), "test", "myres", "stable")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history.Entries) != 1 || history.Entries[0].Previous == nil || *history.Entries[0].Previous != "1.0.0" {
+		t.Errorf("unexpected entries: %+v", history.Entries)
+	}
+}
+
+func TestClient_RollbackChannel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/namespaces/test/resources/myres/channels/stable/rollback" {
+			t.Errorf("expected /namespaces/test/resources/myres/channels/stable/rollback, got %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("version"); got != "1.0.0" {
+			t.Errorf("expected version 1.0.0, got %q", got)
+		}
+		w.Header().Set("Content-Type", "application/vnd.crucible.channel.v0+json")
+		w.Write([]byte(`{"namespace":"test","resource":"myres","name":"stable","version":{"namespace":"test","resource":"myres","string":"1.0.0","createdAt":0,"updatedAt":0},"description":"","createdAt":0,"updatedAt":0}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	ch, err := client.RollbackChannel(context.Background(), "test", "myres", "stable", "1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ch.Version.String != "1.0.0" {
+		t.Errorf("expected version 1.0.0, got %s", ch.Version.String)
+	}
+}
+
+func TestClient_UpdateChannel_IfMatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("If-Match"); got != `"3"` {
+			t.Errorf("expected If-Match \"3\", got %s", got)
+		}
+		w.Header().Set("Content-Type", "application/vnd.crucible.error.v0+json")
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write([]byte(`{"code":"precondition_failed","message":"revision does not match the expected revision"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	_, err := client.UpdateChannel(context.Background(), "test", "myres", "stable", ChannelInfo{Name: "stable", Version: "1.0.1"}, 3)
+
+	regErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if regErr.Code != ErrorCodePreconditionFailed {
+		t.Errorf("expected precondition_failed, got %s", regErr.Code)
+	}
+}
+
+func TestClient_DeleteChannel_AnyRevision(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("If-Match"); got != "" {
+			t.Errorf("expected no If-Match, got %s", got)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	if err := client.DeleteChannel(context.Background(), "test", "myres", "stable", AnyRevision); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_Revalidate(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			if got := r.Header.Get("If-None-Match"); got != `"2"` {
+				t.Errorf("expected If-None-Match \"2\", got %s", got)
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.crucible.namespace.v0+json")
+		w.Header().Set("ETag", `"2"`)
+		w.Write([]byte(`{"Name":"test","Description":"Test","Revision":2}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, &ClientOptions{Revalidate: true})
+	for range 2 {
+		ns, err := client.ReadNamespace(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ns.Name != "test" || ns.Revision != 2 {
+			t.Errorf("expected namespace test at revision 2, got %s at %d", ns.Name, ns.Revision)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestClient_RevalidateCacheSize(t *testing.T) {
+	rc := (&ClientOptions{Revalidate: true, ResponseCacheSize: 2}).responses()
+	resp := &http.Response{Header: http.Header{"Etag": {`"1"`}}}
+
+	for _, path := range []string{"/namespaces/a", "/namespaces/b", "/namespaces/a", "/namespaces/c"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if _, ok := rc.lookup(req); !ok {
+			rc.store(req, resp, []byte("{}"))
+		}
+	}
+
+	for path, want := range map[string]bool{"/namespaces/a": true, "/namespaces/b": false, "/namespaces/c": true} {
+		if _, ok := rc.lookup(httptest.NewRequest(http.MethodGet, path, nil)); ok != want {
+			t.Errorf("cached %s = %v, want %v", path, ok, want)
+		}
+	}
+}
+
+func TestClient_RevalidateInvalidate(t *testing.T) {
+	var reads []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		reads = append(reads, r.Header.Get("If-None-Match"))
+		w.Header().Set("Content-Type", "application/vnd.crucible.namespace.v0+json")
+		w.Header().Set("ETag", `"1"`)
+		w.Write([]byte(`{"Name":"test","Description":"Test","Revision":1}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := NewClientWithOptions(server.URL, &ClientOptions{Revalidate: true})
+	if _, err := client.ReadNamespace(ctx, "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.DeleteNamespace(ctx, "test", AnyRevision); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.ReadNamespace(ctx, "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(reads) != 2 || reads[1] != "" {
+		t.Errorf("expected the delete to drop the cached read, got If-None-Match %q", reads)
+	}
+}
@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"net/http"
+)
+
+// Attaches credentials to outgoing registry requests.
+//
+// Set on a [Client] through [ClientOptions.Credentials]. Authorize is called
+// before every attempt, including retries, so implementations may refresh
+// short-lived credentials.
+type Credentials interface {
+
+	// Adds credentials to the request, typically as an Authorization header.
+	Authorize(req *http.Request) error
+}
+
+// Bearer token sent in the Authorization header.
+type BearerToken string
+
+// Sets the Authorization header to "Bearer <token>".
+func (t BearerToken) Authorize(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// Username and password sent using HTTP basic authentication.
+type BasicCredentials struct {
+	Username string // Username, usually the subject.
+	Password string // Password or token.
+}
+
+// Sets the Authorization header using HTTP basic authentication.
+func (c BasicCredentials) Authorize(req *http.Request) error {
+	req.SetBasicAuth(c.Username, c.Password)
+	return nil
+}
+
+// Adapts a function to the [Credentials] interface.
+type CredentialsFunc func(req *http.Request) error
+
+// Calls f(req).
+func (f CredentialsFunc) Authorize(req *http.Request) error {
+	return f(req)
+}
@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_BearerToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("expected bearer token, got %q", got)
+		}
+		w.Write([]byte(`{"namespaces":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, &ClientOptions{Credentials: BearerToken("secret")})
+	if _, err := client.ListNamespaces(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_BasicCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "alice" || pass != "secret" {
+			t.Errorf("expected basic credentials, got %q %q %v", user, pass, ok)
+		}
+		w.Write([]byte(`{"namespaces":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, &ClientOptions{
+		Credentials: BasicCredentials{Username: "alice", Password: "secret"},
+	})
+	if _, err := client.ListNamespaces(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_CredentialsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not be sent")
+	}))
+	defer server.Close()
+
+	failure := errors.New("token expired")
+	client := NewClientWithOptions(server.URL, &ClientOptions{
+		Credentials: CredentialsFunc(func(*http.Request) error { return failure }),
+	})
+	if _, err := client.ListNamespaces(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("expected credentials error, got: %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package registry
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// SQL dialect spoken by the database behind a [SQLRegistry] or [SQLAuthStore].
+//
+// The embedded queries are written once, with ? placeholders and
+// double-quoted identifiers. A dialect rewrites them for its database,
+// supplies schema migrations in the database's own types, builds conflict clauses
+// for upserts, and recognizes the driver's constraint errors, so conflicts
+// are reported as [ErrorCodeNamespaceExists] and friends without re-reading
+// the row that caused them. It also decides how transactions isolate reads
+// and lock what they are about to change.
+//
+// Dialects hold no connection state. Use one of the predefined dialects; a
+// nil *Dialect is [DialectSQLite].
+type Dialect struct {
+	name        string                              // Name used in logs.
+	migrations  string                              // Directory of the dialect's schema migrations.
+	placeholder func(n int) string                  // Placeholder for the nth parameter (from 1), or nil to keep ?.
+	quote       byte                                // Identifier quote character.
+	returning   bool                                // Whether INSERT ... RETURNING reports generated IDs.
+	isolation   sql.IsolationLevel                  // Isolation giving read-only transactions a snapshot, or the default.
+	lockRows    bool                                // Whether SELECT ... FOR UPDATE locks the rows read.
+	reserve     string                              // Statement taking the write lock at the start of write transactions, or empty.
+	conflict    func(keys, updates []string) string // Clause appended to an INSERT to upsert.
+	violation   func(err error) violation           // Classifies a driver error.
+	fullText    bool                                // Whether search may use an SQLite FTS5 index, if the driver has FTS5.
+}
+
+// SQLite, through any driver (e.g., github.com/mattn/go-sqlite3).
+//
+// Foreign keys must be enabled on the connection (e.g., the _foreign_keys=on
+// DSN parameter of go-sqlite3), or parent rows are not checked. Write
+// transactions take the database write lock as their first statement, so
+// concurrent writers wait for each other for up to the busy timeout of the
+// connection. The statement writes to the empty write_lock table rather than
+// relying on BEGIN IMMEDIATE: database/sql always begins transactions with
+// the driver's own BEGIN, and making it immediate for the whole connection
+// (the _txlock=immediate DSN parameter of go-sqlite3) would also make
+// read-only snapshots wait for writers.
+//
+// Search uses an FTS5 index if the driver was built with FTS5 (the
+// sqlite_fts5 build tag of go-sqlite3), and substring matching otherwise.
+// Registries sharing a database should be built alike: one without FTS5
+// drops the triggers keeping the index in sync, and the others then match
+// substrings rather than search a stale index, until a registry with FTS5
+// starts and rebuilds it.
+var DialectSQLite = &Dialect{
+	name:       "sqlite",
+	migrations: sqlMigrationsDir + "/sqlite",
+	quote:      '"',
+	reserve:    sqlWriteLockReserve,
+	conflict:   onConflict,
+	violation:  sqliteViolation,
+	fullText:   true,
+}
+
+// PostgreSQL 14 or later, through any driver whose errors implement
+// SQLState() string (e.g., github.com/lib/pq or github.com/jackc/pgx/v5/stdlib).
+var DialectPostgreSQL = &Dialect{
+	name:        "postgres",
+	migrations:  sqlMigrationsDir + "/postgres",
+	placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+	quote:       '"',
+	returning:   true,
+	isolation:   sql.LevelRepeatableRead,
+	lockRows:    true,
+	conflict:    onConflict,
+	violation:   sqlStateViolation,
+}
+
+// MySQL 8.0.29 or later, through github.com/go-sql-driver/mysql.
+//
+// Each schema migration runs as a single multi-statement Exec, so the
+// connection must be opened with multiStatements=true. MySQL commits schema
+// changes implicitly, so a migration that fails halfway must be repaired by
+// hand.
+var DialectMySQL = &Dialect{
+	name:       "mysql",
+	migrations: sqlMigrationsDir + "/mysql",
+	quote:      '`',
+	isolation:  sql.LevelRepeatableRead,
+	lockRows:   true,
+	conflict:   onDuplicateKey,
+	violation:  mysqlViolation,
+}
+
+// Kind of constraint a statement violated.
+type violation int
+
+const (
+	violationNone       violation = iota // Not a constraint violation.
+	violationUnique                      // Primary key or unique constraint.
+	violationForeignKey                  // Foreign key constraint.
+)
+
+// Returns the dialect name.
+func (d *Dialect) String() string {
+	return d.dialect().name
+}
+
+// Returns the dialect, defaulting to [DialectSQLite].
+func (d *Dialect) dialect() *Dialect {
+	if d == nil {
+		return DialectSQLite
+	}
+	return d
+}
+
+// Returns the directory of the dialect's schema migrations.
+func (d *Dialect) migrationsDir() string {
+	return d.dialect().migrations
+}
+
+// Returns whether generated IDs are read with INSERT ... RETURNING rather
+// than [sql.Result.LastInsertId].
+func (d *Dialect) useReturning() bool {
+	return d.dialect().returning
+}
+
+// Returns whether search may use an SQLite FTS5 index.
+func (d *Dialect) supportsFullText() bool {
+	return d.dialect().fullText
+}
+
+// Rewrites a query for the dialect.
+//
+// Placeholders are numbered and double-quoted identifiers are requoted.
+// String literals and -- comments are copied unchanged.
+func (d *Dialect) rebind(query string) string {
+	d = d.dialect()
+	if d.placeholder == nil && d.quote == '"' {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			end := skipQuoted(query, i)
+			b.WriteString(query[i:end])
+			i = end - 1
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end - 1
+		case c == '"':
+			end := skipQuoted(query, i)
+			b.WriteByte(d.quote)
+			b.WriteString(query[i+1 : end-1])
+			b.WriteByte(d.quote)
+			i = end - 1
+		case c == '?' && d.placeholder != nil:
+			n++
+			b.WriteString(d.placeholder(n))
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// Returns the options of a read-only transaction that reads a consistent
+// snapshot, or nil if every transaction already does (SQLite).
+func (d *Dialect) snapshotOptions() *sql.TxOptions {
+	d = d.dialect()
+	if d.isolation == sql.LevelDefault {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: d.isolation, ReadOnly: true}
+}
+
+// Returns the statement that takes the write lock at the start of a write
+// transaction, or an empty string if the dialect locks rows as they are read.
+func (d *Dialect) reserveStatement() string {
+	return d.dialect().reserve
+}
+
+// Rewrites a SELECT to lock the rows it reads until the transaction ends.
+//
+// Dialects whose write transactions lock the whole database (SQLite) only
+// rebind the query.
+func (d *Dialect) forUpdate(query string) string {
+	if !d.dialect().lockRows {
+		return d.rebind(query)
+	}
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	return d.rebind(query + " FOR UPDATE;")
+}
+
+// Rewrites an INSERT into an upsert.
+//
+// When a row with the same keys exists, the listed columns are overwritten
+// with the inserted values; with no columns, the existing row is kept and
+// the statement succeeds without changes.
+func (d *Dialect) upsert(insert string, keys, updates []string) string {
+	insert = strings.TrimRight(strings.TrimSpace(insert), ";")
+	return d.rebind(insert + " " + d.dialect().conflict(keys, updates) + ";")
+}
+
+// Rewrites an INSERT to return the generated id column.
+func (d *Dialect) insertReturningID(insert string) string {
+	insert = strings.TrimRight(strings.TrimSpace(insert), ";")
+	return d.rebind(insert + " RETURNING id;")
+}
+
+// Classifies a driver error as a constraint violation.
+func (d *Dialect) classify(err error) violation {
+	if err == nil {
+		return violationNone
+	}
+	return d.dialect().violation(err)
+}
+
+// Returns the index just past the quoted string or identifier starting at i.
+//
+// A doubled quote character is an escaped quote. Unterminated quotes run to
+// the end of the query.
+func skipQuoted(query string, i int) int {
+	q := query[i]
+	for j := i + 1; j < len(query); j++ {
+		if query[j] != q {
+			continue
+		}
+		if j+1 < len(query) && query[j+1] == q {
+			j++
+			continue
+		}
+		return j + 1
+	}
+	return len(query)
+}
+
+// Builds an ON CONFLICT clause (SQLite and PostgreSQL).
+func onConflict(keys, updates []string) string {
+	clause := "ON CONFLICT (" + strings.Join(keys, ", ") + ") DO "
+	if len(updates) == 0 {
+		return clause + "NOTHING"
+	}
+	set := make([]string, len(updates))
+	for i, col := range updates {
+		set[i] = col + " = excluded." + col
+	}
+	return clause + "UPDATE SET " + strings.Join(set, ", ")
+}
+
+// Builds an ON DUPLICATE KEY UPDATE clause (MySQL).
+//
+// Keeping a row is written as assigning a key to itself, since INSERT IGNORE
+// would also swallow foreign key and data errors.
+func onDuplicateKey(keys, updates []string) string {
+	if len(updates) == 0 {
+		return "ON DUPLICATE KEY UPDATE " + keys[0] + " = " + keys[0]
+	}
+	set := make([]string, len(updates))
+	for i, col := range updates {
+		set[i] = col + " = VALUES(" + col + ")"
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+}
+
+// Classifies SQLite errors by their message, which is the same across drivers.
+func sqliteViolation(err error) violation {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "UNIQUE constraint failed"), strings.Contains(msg, "PRIMARY KEY constraint failed"):
+		return violationUnique
+	case strings.Contains(msg, "FOREIGN KEY constraint failed"):
+		return violationForeignKey
+	}
+	return violationNone
+}
+
+// Classifies errors carrying a standard SQLSTATE code (PostgreSQL drivers).
+func sqlStateViolation(err error) violation {
+	var state interface{ SQLState() string }
+	if !errors.As(err, &state) {
+		return violationNone
+	}
+	switch state.SQLState() {
+	case "23505": // unique_violation
+		return violationUnique
+	case "23503": // foreign_key_violation
+		return violationForeignKey
+	}
+	return violationNone
+}
+
+// Classifies MySQL errors by the server error number in their message.
+//
+// The driver formats errors as "Error 1062 (23000): ...", or "Error 1062: ..."
+// in older versions.
+func mysqlViolation(err error) violation {
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "Error ") {
+		return violationNone
+	}
+	number, _, _ := strings.Cut(strings.TrimPrefix(msg, "Error "), " ")
+	switch strings.TrimSuffix(number, ":") {
+	case "1062", "1586": // ER_DUP_ENTRY, ER_DUP_ENTRY_WITH_KEY_NAME
+		return violationUnique
+	case "1216", "1452": // ER_NO_REFERENCED_ROW, ER_NO_REFERENCED_ROW_2
+		return violationForeignKey
+	case "1217", "1451": // ER_ROW_IS_REFERENCED, ER_ROW_IS_REFERENCED_2
+		return violationForeignKey
+	}
+	return violationNone
+}
@@ -0,0 +1,272 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Stand-in for a PostgreSQL database.
+//
+// Speaks SQLite with numbered placeholders and reports constraint violations
+// with SQLSTATE codes, the way PostgreSQL drivers do.
+var dialectStandIn = &Dialect{
+	name:        "postgres-standin",
+	migrations:  DialectSQLite.migrations,
+	placeholder: DialectPostgreSQL.placeholder,
+	quote:       '"',
+	returning:   true,
+	conflict:    onConflict,
+	violation:   sqlStateViolation,
+}
+
+// Driver error carrying a SQLSTATE code.
+type sqlStateError struct {
+	state string
+	err   error
+}
+
+func (e *sqlStateError) Error() string    { return e.err.Error() }
+func (e *sqlStateError) SQLState() string { return e.state }
+
+// Translates SQLite constraint errors into SQLSTATE errors.
+func withSQLState(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch sqliteViolation(err) {
+	case violationUnique:
+		return &sqlStateError{state: "23505", err: err}
+	case violationForeignKey:
+		return &sqlStateError{state: "23503", err: err}
+	}
+	return err
+}
+
+// SQLite driver whose errors carry SQLSTATE codes.
+type sqlStateDriver struct{}
+
+func (sqlStateDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := (&sqlite3.SQLiteDriver{}).Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStateConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+type sqlStateConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *sqlStateConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.SQLiteConn.ExecContext(ctx, query, args)
+	return result, withSQLState(err)
+}
+
+func (c *sqlStateConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.SQLiteConn.QueryContext(ctx, query, args)
+	return rows, withSQLState(err)
+}
+
+func init() {
+	sql.Register("sqlite3-sqlstate", sqlStateDriver{})
+}
+
+func setupTestDialect(t *testing.T, driverName string, dialect *Dialect) (*SQLRegistry, *SQLAuthStore) {
+	t.Helper()
+
+	db, err := sql.Open(driverName, filepath.Join(t.TempDir(), "registry.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	registry, err := NewSQLRegistryWithOptions(ctx, db, t.TempDir(), logger, &SQLRegistryOptions{Dialect: dialect})
+	if err != nil {
+		t.Fatalf("NewSQLRegistryWithOptions() error = %v", err)
+	}
+	store, err := NewSQLAuthStoreWithOptions(ctx, db, logger, &SQLAuthStoreOptions{Dialect: dialect})
+	if err != nil {
+		t.Fatalf("NewSQLAuthStoreWithOptions() error = %v", err)
+	}
+	return registry, store
+}
+
+func TestDialect_Conflicts(t *testing.T) {
+	dialects := []struct {
+		name    string
+		driver  string
+		dialect *Dialect
+	}{
+		{"sqlite", "sqlite3", nil},
+		{"standin", "sqlite3-sqlstate", dialectStandIn},
+	}
+
+	for _, d := range dialects {
+		t.Run(d.name, func(t *testing.T) {
+			registry, store := setupTestDialect(t, d.driver, d.dialect)
+			ctx := context.Background()
+
+			_, err := registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "app", Type: "widget"})
+			assertErrorCode(t, err, ErrorCodeNotFound)
+			_, err = registry.CreateMember(ctx, "test-ns", MemberInfo{Subject: "alice", Role: RoleReader})
+			assertErrorCode(t, err, ErrorCodeNotFound)
+
+			if _, err := registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns"}); err != nil {
+				t.Fatalf("CreateNamespace() error = %v", err)
+			}
+			_, err = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns"})
+			assertErrorCode(t, err, ErrorCodeNamespaceExists)
+
+			_, _ = registry.CreateMember(ctx, "test-ns", MemberInfo{Subject: "alice", Role: RoleReader})
+			_, err = registry.CreateMember(ctx, "test-ns", MemberInfo{Subject: "alice", Role: RoleAdmin})
+			assertErrorCode(t, err, ErrorCodeMemberExists)
+
+			_, err = registry.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "1.0.0"})
+			assertErrorCode(t, err, ErrorCodeNotFound)
+
+			_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "app", Type: "widget"})
+			_, err = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "app", Type: "widget"})
+			assertErrorCode(t, err, ErrorCodeResourceExists)
+
+			_, err = registry.CreateChannel(ctx, "test-ns", "app", ChannelInfo{Name: "stable", Version: "1.0.0"})
+			assertErrorCode(t, err, ErrorCodeNotFound)
+
+			_, _ = registry.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "1.0.0"})
+			_, err = registry.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "1.0.0"})
+			assertErrorCode(t, err, ErrorCodeVersionExists)
+
+			if _, err := registry.UploadArchive(ctx, "test-ns", "app", "1.0.0", bytes.NewReader([]byte("archive"))); err != nil {
+				t.Fatalf("UploadArchive() error = %v", err)
+			}
+
+			_, _ = registry.CreateChannel(ctx, "test-ns", "app", ChannelInfo{Name: "stable", Version: "1.0.0"})
+			_, err = registry.CreateChannel(ctx, "test-ns", "app", ChannelInfo{Name: "stable", Version: "1.0.0"})
+			assertErrorCode(t, err, ErrorCodeChannelExists)
+
+			_, err = registry.UpdateChannel(ctx, "test-ns", "app", "stable", ChannelInfo{Name: "stable", Version: "2.0.0"}, AnyRevision)
+			assertErrorCode(t, err, ErrorCodeNotFound)
+
+			for range 2 {
+				if err := store.GrantRole(ctx, "alice", RoleAdmin); err != nil {
+					t.Fatalf("GrantRole() error = %v", err)
+				}
+			}
+
+			list, err := registry.ListAuditEvents(ctx, AuditFilter{Namespace: "test-ns"})
+			if err != nil {
+				t.Fatalf("ListAuditEvents() error = %v", err)
+			}
+			if len(list.Events) != 6 {
+				t.Fatalf("expected 6 events, got %d: %+v", len(list.Events), list.Events)
+			}
+			if list.Events[0].ID <= list.Events[1].ID {
+				t.Errorf("expected descending IDs, got %d then %d", list.Events[0].ID, list.Events[1].ID)
+			}
+		})
+	}
+}
+
+func TestDialect_Rebind(t *testing.T) {
+	query := "-- Is it? \"quoted\"\nSELECT \"offset\", '?' FROM t WHERE a = ? AND b = '''?' AND c = ?;"
+
+	tests := []struct {
+		dialect *Dialect
+		want    string
+	}{
+		{nil, query},
+		{DialectSQLite, query},
+		{DialectPostgreSQL, "-- Is it? \"quoted\"\nSELECT \"offset\", '?' FROM t WHERE a = $1 AND b = '''?' AND c = $2;"},
+		{DialectMySQL, "-- Is it? \"quoted\"\nSELECT `offset`, '?' FROM t WHERE a = ? AND b = '''?' AND c = ?;"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.rebind(query); got != tt.want {
+			t.Errorf("%s: rebind() = %q, want %q", tt.dialect, got, tt.want)
+		}
+	}
+}
+
+func TestDialect_Upsert(t *testing.T) {
+	insert := "INSERT INTO roles (subject, role, created_at)\nVALUES (?, ?, ?);\n"
+
+	tests := []struct {
+		dialect *Dialect
+		updates []string
+		want    string
+	}{
+		{DialectSQLite, nil, "INSERT INTO roles (subject, role, created_at)\nVALUES (?, ?, ?) ON CONFLICT (subject, role) DO NOTHING;"},
+		{DialectPostgreSQL, []string{"created_at"}, "INSERT INTO roles (subject, role, created_at)\nVALUES ($1, $2, $3) ON CONFLICT (subject, role) DO UPDATE SET created_at = excluded.created_at;"},
+		{DialectMySQL, nil, "INSERT INTO roles (subject, role, created_at)\nVALUES (?, ?, ?) ON DUPLICATE KEY UPDATE subject = subject;"},
+		{DialectMySQL, []string{"created_at"}, "INSERT INTO roles (subject, role, created_at)\nVALUES (?, ?, ?) ON DUPLICATE KEY UPDATE created_at = VALUES(created_at);"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.upsert(insert, []string{"subject", "role"}, tt.updates); got != tt.want {
+			t.Errorf("%s: upsert() = %q, want %q", tt.dialect, got, tt.want)
+		}
+	}
+}
+
+func TestDialect_Classify(t *testing.T) {
+	tests := []struct {
+		dialect *Dialect
+		err     error
+		want    violation
+	}{
+		{DialectSQLite, errors.New("UNIQUE constraint failed: namespaces.name"), violationUnique},
+		{DialectSQLite, errors.New("FOREIGN KEY constraint failed"), violationForeignKey},
+		{DialectSQLite, errors.New("database is locked"), violationNone},
+		{DialectPostgreSQL, &sqlStateError{state: "23505", err: errors.New("duplicate key")}, violationUnique},
+		{DialectPostgreSQL, &sqlStateError{state: "23503", err: errors.New("foreign key")}, violationForeignKey},
+		{DialectPostgreSQL, &sqlStateError{state: "40001", err: errors.New("serialization failure")}, violationNone},
+		{DialectPostgreSQL, errors.New("UNIQUE constraint failed"), violationNone},
+		{DialectMySQL, errors.New("Error 1062 (23000): Duplicate entry 'acme' for key 'PRIMARY'"), violationUnique},
+		{DialectMySQL, errors.New("Error 1452: Cannot add or update a child row"), violationForeignKey},
+		{DialectMySQL, errors.New("Error 1451 (23000): Cannot delete or update a parent row"), violationForeignKey},
+		{DialectMySQL, errors.New("Error 1213 (40001): Deadlock found"), violationNone},
+		{DialectMySQL, nil, violationNone},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.classify(tt.err); got != tt.want {
+			t.Errorf("%s: classify(%v) = %v, want %v", tt.dialect, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDialect_Migrations(t *testing.T) {
+	sqlite, _ := loadMigrations(DialectSQLite.migrationsDir())
+
+	for _, dialect := range []*Dialect{DialectSQLite, DialectPostgreSQL, DialectMySQL} {
+		migrations, err := loadMigrations(dialect.migrationsDir())
+		if err != nil {
+			t.Fatalf("%s: loadMigrations() error = %v", dialect, err)
+		}
+		if len(migrations) != len(sqlite) {
+			t.Fatalf("%s: expected %d migrations, got %d", dialect, len(sqlite), len(migrations))
+		}
+		for i, m := range migrations {
+			if m.name != sqlite[i].name {
+				t.Errorf("%s: migration %d is %s, want %s", dialect, i, m.name, sqlite[i].name)
+			}
+		}
+
+		for _, table := range []string{"namespaces", "members", "resources", "versions", "channels", "channel_history", "uploads", "tokens", "roles", "audit_log"} {
+			if !strings.Contains(migrations[0].sql, "CREATE TABLE IF NOT EXISTS "+table+" (") {
+				t.Errorf("%s: initial migration does not create %s", dialect, table)
+			}
+		}
+	}
+}
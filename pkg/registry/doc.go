@@ -23,6 +23,57 @@
 // pattern application/vnd.crucible.{name}.v0. Clients specify media types in
 // Content-Type headers for requests and Accept headers for responses.
 //
+// Archives can be uploaded in one request or through a resumable upload
+// session, in the style of the OCI distribution spec: a session is started,
+// chunks are appended at the current offset, and the session is committed with
+// the digest of the whole archive. Sessions are persistent, so an interrupted
+// upload resumes from the offset the registry reports. Downloads can be
+// limited to a byte range to resume interrupted transfers.
+//
+// [SQLRegistry] keeps everything in SQLite, PostgreSQL or MySQL; the
+// [Dialect] of the database adapts the embedded queries and schema to it.
+// The schema is versioned, and pending migrations are applied when a registry
+// starts. Operations spanning several statements run in one transaction.
+// Archive files are stored under keys relative to the archive root; clients
+// receive URLs built by an [ArchiveURLBuilder] instead.
+//
+// Entities carry a [Revision] that grows with every change. Updates and
+// deletes take the revision the caller expects and fail with
+// [ErrorCodePreconditionFailed] if the entity has changed since, so concurrent
+// writers cannot overwrite each other; [AnyRevision] skips the check. [Client]
+// sends expected revisions in If-Match headers and can revalidate reads with
+// If-None-Match.
+//
+// Mutations can be restricted by an [Authorizer], which decides whether the
+// [Principal] carried by the request context may perform an [Action] on a
+// namespace. Roles are granted globally or per namespace through a [Member]
+// record. [SQLAuthStore] issues tokens and grants roles, and [Client]
+// sends credentials through [ClientOptions.Credentials]. Reads are never
+// authorized.
+//
+// Successful mutations are recorded in an append-only audit log (see
+// [AuditEvent]), queried with [Registry.ListAuditEvents].
+// Channel moves are also kept in a per-channel history (see
+// [ChannelHistoryEntry]), and [Registry.RollbackChannel] points a channel back
+// at a version it referenced before.
+//
+// Committed changes are also announced as an [Event] to every [Subscriber] of
+// the registry. [WebhookDispatcher] posts events to webhooks with signed
+// payloads and keeps a delivery log, and [EventStreamHandler] serves them as
+// Server-Sent Events, consumed with [Client.Events].
+//
+// [Registry.Search] finds resources across namespaces by words in their name
+// or description, with facets for type, namespace and stable channel. SQLite
+// databases use an FTS5 index when the driver has one; other databases match
+// substrings.
+//
+// [CachingRegistry] is a pull-through cache that serves a remote registry
+// from a local [SQLRegistry], so build agents on slow links can share a
+// local mirror.
+// [Mirror] copies the contents of one registry into another, and
+// [ExportBundle] and [ImportBundle] carry resolved versions across an air gap
+// as a single archive.
+//
 // Operations return errors with platform-specific error codes providing granular
 // classification beyond HTTP status codes. Error responses use the Error type
 // with machine-readable codes and human-readable messages.
@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+	"github.com/cruciblehq/protocol/pkg/archive"
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+// Downloads a version archive, verifying its digest while streaming.
+//
+// The expected parameter is the digest the archive must match. When nil, the
+// version is read first and its Digest and Size are used instead, and a
+// version without a digest returns [ErrMissingDigest]. The returned reader
+// yields the archive as it arrives and only reports [io.EOF] once the whole
+// archive has been read and matched, so callers must read to EOF before
+// trusting anything they read. A mismatch returns an error wrapping
+// [reference.ErrDigestMismatch], and an archive ending early returns an error
+// wrapping [ErrShortRead]. All errors are wrapped with [ErrDownloadFailed].
+func (c *Client) DownloadVerifiedArchive(ctx context.Context, namespace, resource, version string, expected *reference.Digest) (io.ReadCloser, error) {
+	size := int64(-1)
+	if expected == nil {
+		ver, err := c.ReadVersion(ctx, namespace, resource, version)
+		if err != nil {
+			return nil, helpers.Wrap(ErrDownloadFailed, err)
+		}
+		if ver.Digest == nil {
+			return nil, helpers.Wrap(ErrDownloadFailed, fmt.Errorf("%w: %s/%s %s", ErrMissingDigest, namespace, resource, version))
+		}
+		expected, err = reference.ParseDigest(*ver.Digest)
+		if err != nil {
+			return nil, helpers.Wrap(ErrDownloadFailed, err)
+		}
+		if ver.Size != nil {
+			size = *ver.Size
+		}
+	}
+
+	resp, err := c.getArchive(ctx, namespace, resource, version, "")
+	if err != nil {
+		return nil, helpers.Wrap(ErrDownloadFailed, err)
+	}
+
+	if size < 0 {
+		size = resp.ContentLength
+	}
+
+	vr, err := reference.NewVerifyingReader(resp.Body, expected)
+	if err != nil {
+		resp.Body.Close()
+		return nil, helpers.Wrap(ErrDownloadFailed, err)
+	}
+
+	return &verifiedArchive{body: resp.Body, vr: vr, size: size}, nil
+}
+
+// Downloads a version archive and extracts it to a directory.
+//
+// The archive is verified as described in [Client.DownloadVerifiedArchive] and
+// extracted with [archive.ExtractFromReaderWithOptions] as it streams. Files
+// are extracted into a staging directory next to dest, which is renamed to
+// dest only after the whole archive has been read and matched, so a tampered
+// or truncated archive never appears at dest. Options can be nil. All errors
+// are wrapped with [ErrDownloadFailed].
+func (c *Client) DownloadAndExtractArchive(ctx context.Context, namespace, resource, version, dest string, expected *reference.Digest, options *archive.Options) error {
+	if _, err := os.Stat(dest); err == nil {
+		return helpers.Wrap(ErrDownloadFailed, helpers.Wrap(archive.ErrExtractFailed, os.ErrExist))
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(dest), ".crucible-download-*")
+	if err != nil {
+		return helpers.Wrap(ErrDownloadFailed, err)
+	}
+	defer os.RemoveAll(tmp)
+
+	body, err := c.DownloadVerifiedArchive(ctx, namespace, resource, version, expected)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	stage := filepath.Join(tmp, "archive")
+	if err := archive.ExtractFromReaderWithOptions(body, stage, options); err != nil {
+		if errors.Is(err, ErrDownloadFailed) {
+			return err
+		}
+		return helpers.Wrap(ErrDownloadFailed, err)
+	}
+
+	// The decompressor may stop before the end of the stream, so read the
+	// remainder to reach EOF and have the digest checked
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return err
+	}
+
+	if err := os.Rename(stage, dest); err != nil {
+		return helpers.Wrap(ErrDownloadFailed, err)
+	}
+
+	return nil
+}
+
+// An archive download verified against its expected digest and size.
+type verifiedArchive struct {
+	body io.ReadCloser              // Response body.
+	vr   *reference.VerifyingReader // Verifies the body digest at EOF.
+	size int64                      // Expected size in bytes, or -1 if unknown.
+	err  error                      // First error returned, repeated on later reads.
+}
+
+// Reads from the response body, classifying verification failures.
+func (a *verifiedArchive) Read(p []byte) (int, error) {
+	if a.err != nil {
+		return 0, a.err
+	}
+
+	n, err := a.vr.Read(p)
+	if err == nil || err == io.EOF {
+		return n, err
+	}
+
+	// A body ending early shows up as an unexpected EOF when the length was
+	// announced, and as a digest mismatch otherwise
+	if errors.Is(err, io.ErrUnexpectedEOF) || (errors.Is(err, reference.ErrDigestMismatch) && a.vr.Size() < a.size) {
+		err = helpers.Wrap(ErrShortRead, fmt.Errorf("got %d of %d bytes: %w", a.vr.Size(), a.size, err))
+	}
+
+	a.err = helpers.Wrap(ErrDownloadFailed, err)
+	return n, a.err
+}
+
+// Closes the response body.
+func (a *verifiedArchive) Close() error {
+	return a.body.Close()
+}
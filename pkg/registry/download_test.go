@@ -0,0 +1,212 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cruciblehq/protocol/pkg/archive"
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+func TestClient_DownloadVerifiedArchive(t *testing.T) {
+	data := testArchive(t)
+	server := newArchiveServer(t, data, data)
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	body, err := client.DownloadVerifiedArchive(context.Background(), "ns", "res", "1.0.0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer body.Close()
+
+	got, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != string(data) {
+		t.Fatalf("archive contents differ")
+	}
+}
+
+func TestClient_DownloadVerifiedArchive_Mismatch(t *testing.T) {
+	data := testArchive(t)
+	tampered := append([]byte(nil), data...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	server := newArchiveServer(t, data, tampered)
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	body, err := client.DownloadVerifiedArchive(context.Background(), "ns", "res", "1.0.0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer body.Close()
+
+	_, err = io.ReadAll(body)
+	if !errors.Is(err, reference.ErrDigestMismatch) {
+		t.Fatalf("expected ErrDigestMismatch, got: %v", err)
+	}
+	if !errors.Is(err, ErrDownloadFailed) {
+		t.Fatalf("expected ErrDownloadFailed, got: %v", err)
+	}
+}
+
+func TestClient_DownloadVerifiedArchive_ShortRead(t *testing.T) {
+	data := testArchive(t)
+	server := newArchiveServer(t, data, data[:len(data)/2])
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	body, err := client.DownloadVerifiedArchive(context.Background(), "ns", "res", "1.0.0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer body.Close()
+
+	if _, err := io.ReadAll(body); !errors.Is(err, ErrShortRead) {
+		t.Fatalf("expected ErrShortRead, got: %v", err)
+	}
+}
+
+func TestClient_DownloadVerifiedArchive_ExpectedDigest(t *testing.T) {
+	data := testArchive(t)
+	server := newArchiveServer(t, data, data)
+	defer server.Close()
+
+	expected, err := reference.FromBytes(reference.SHA256, []byte("something else"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(server.URL, nil)
+	body, err := client.DownloadVerifiedArchive(context.Background(), "ns", "res", "1.0.0", expected)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer body.Close()
+
+	if _, err := io.ReadAll(body); !errors.Is(err, reference.ErrDigestMismatch) {
+		t.Fatalf("expected ErrDigestMismatch, got: %v", err)
+	}
+}
+
+func TestClient_DownloadVerifiedArchive_MissingDigest(t *testing.T) {
+	server := newArchiveServer(t, nil, nil)
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	_, err := client.DownloadVerifiedArchive(context.Background(), "ns", "res", "1.0.0", nil)
+	if !errors.Is(err, ErrMissingDigest) {
+		t.Fatalf("expected ErrMissingDigest, got: %v", err)
+	}
+}
+
+func TestClient_DownloadAndExtractArchive(t *testing.T) {
+	data := testArchive(t)
+	server := newArchiveServer(t, data, data)
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "out")
+
+	client := NewClient(server.URL, nil)
+	if err := client.DownloadAndExtractArchive(context.Background(), "ns", "res", "1.0.0", dest, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dest, "file.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(content) != "hello" {
+		t.Fatalf("expected 'hello', got: %q", content)
+	}
+}
+
+func TestClient_DownloadAndExtractArchive_Tampered(t *testing.T) {
+	data := testArchive(t)
+	tampered := append([]byte(nil), data...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	server := newArchiveServer(t, data, tampered)
+	defer server.Close()
+
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "out")
+
+	client := NewClient(server.URL, nil)
+	err := client.DownloadAndExtractArchive(context.Background(), "ns", "res", "1.0.0", dest, nil, nil)
+	if !errors.Is(err, ErrDownloadFailed) {
+		t.Fatalf("expected ErrDownloadFailed, got: %v", err)
+	}
+
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no files left behind, got: %v", entries)
+	}
+}
+
+// Creates a small archive and returns its contents.
+func testArchive(t *testing.T) []byte {
+	t.Helper()
+
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "file.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "test"+archive.ArchiveFileExtension)
+	if err := archive.Create(src, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// Serves a version describing published and an archive download of served.
+//
+// The download announces the length of published, so serving fewer bytes
+// simulates a truncated transfer. A nil published version has no digest.
+func newArchiveServer(t *testing.T, published, served []byte) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/archive"):
+			w.Header().Set("Content-Type", string(MediaTypeArchive))
+			w.Header().Set("Content-Length", strconv.Itoa(len(published)))
+			w.Write(served)
+		case strings.HasSuffix(r.URL.Path, "/versions/1.0.0"):
+			w.Header().Set("Content-Type", string(MediaTypeVersion)+"+json")
+			if published == nil {
+				w.Write([]byte(`{"namespace":"ns","resource":"res","string":"1.0.0","archive":null,"size":null,"digest":null,"createdAt":0,"updatedAt":0}`))
+				return
+			}
+			digest, err := reference.FromBytes(reference.SHA256, published)
+			if err != nil {
+				t.Error(err)
+			}
+			fmt.Fprintf(w, `{"namespace":"ns","resource":"res","string":"1.0.0","archive":"/a","size":%d,"digest":%q,"createdAt":0,"updatedAt":0}`, len(published), digest)
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
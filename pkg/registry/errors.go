@@ -0,0 +1,29 @@
+package registry
+
+import "errors"
+
+var (
+
+	// Broad sentinel errors
+	ErrDownloadFailed = errors.New("download failed")
+	ErrCircuitOpen    = errors.New("registry circuit open")
+	ErrMirrorFailed   = errors.New("mirror failed")
+	ErrExportFailed   = errors.New("bundle export failed")
+	ErrImportFailed   = errors.New("bundle import failed")
+
+	// Specific download errors
+	ErrMissingDigest = errors.New("version has no archive digest")
+	ErrShortRead     = errors.New("archive shorter than expected")
+
+	// Specific bundle errors
+	ErrNoMatchingVersion = errors.New("no version matches constraint")
+	ErrUnsupportedBundle = errors.New("unsupported bundle format version")
+
+	// Specific signed URL errors
+	ErrURLSignature = errors.New("invalid archive URL signature")
+	ErrURLExpired   = errors.New("archive URL expired")
+
+	// Specific webhook errors
+	ErrWebhookSignature = errors.New("invalid webhook signature")
+	ErrWebhookExpired   = errors.New("webhook request expired")
+)
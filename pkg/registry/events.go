@@ -0,0 +1,182 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cruciblehq/protocol/pkg/codec"
+)
+
+const (
+
+	// Number of random bytes in an event identifier.
+	eventIDSize = 16
+)
+
+// Kind of change announced by an [Event].
+//
+// Event types follow the pattern {entity}.{past participle}. Unlike audit
+// actions, they only cover changes that other systems react to.
+type EventType string
+
+const (
+	EventNamespaceCreated EventType = "namespace.created" // Namespace created.
+	EventResourceCreated  EventType = "resource.created"  // Resource created.
+	EventVersionCreated   EventType = "version.created"   // Version created, still without an archive.
+	EventArchiveUploaded  EventType = "archive.uploaded"  // Archive uploaded for a version, replacing any previous one.
+	EventVersionPublished EventType = "version.published" // Version received its first archive and can be downloaded.
+	EventChannelMoved     EventType = "channel.moved"     // Channel created or pointed at another version.
+)
+
+// Receives registry events.
+//
+// Events are delivered once the change they announce has been committed, from
+// the goroutine that made the change, so implementations must not block. See
+// [WebhookDispatcher] and [EventStreamHandler].
+type Subscriber interface {
+
+	// Handles an event.
+	HandleEvent(event Event)
+}
+
+// Adapts a function to the [Subscriber] interface.
+type SubscriberFunc func(event Event)
+
+// Calls f with the event.
+func (f SubscriberFunc) HandleEvent(event Event) {
+	f(event)
+}
+
+// Publishes registry events to subscribers.
+//
+// Implemented by [SQLRegistry].
+type EventSource interface {
+
+	// Adds a subscriber, returning a function that removes it.
+	Subscribe(subscriber Subscriber) (unsubscribe func())
+}
+
+// Criteria for selecting events.
+//
+// Zero-valued fields do not filter.
+type EventFilter struct {
+	Namespace string      // Namespace the event concerns.
+	Resource  string      // Resource the event concerns.
+	Types     []EventType // Event types to select.
+}
+
+// Reports whether an event meets the criteria of the filter.
+func (f EventFilter) Matches(event Event) bool {
+	if f.Namespace != "" && f.Namespace != event.Namespace {
+		return false
+	}
+	if f.Resource != "" && f.Resource != event.Resource {
+		return false
+	}
+	return len(f.Types) == 0 || slices.Contains(f.Types, event.Type)
+}
+
+// Subscribers of a registry.
+//
+// A nil *eventHub has no subscribers. Thread-safe for concurrent access.
+type eventHub struct {
+	mu          sync.RWMutex
+	subscribers map[int]Subscriber
+	next        int
+}
+
+// Creates a hub without subscribers.
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[int]Subscriber)}
+}
+
+// Adds a subscriber, returning a function that removes it.
+func (h *eventHub) subscribe(subscriber Subscriber) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id := h.next
+	h.next++
+	h.subscribers[id] = subscriber
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subscribers, id)
+		})
+	}
+}
+
+// Delivers events to every subscriber, in order.
+func (h *eventHub) publish(events []Event) {
+	if h == nil || len(events) == 0 {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, event := range events {
+		for _, subscriber := range h.subscribers {
+			subscriber.HandleEvent(event)
+		}
+	}
+}
+
+// Subscribes to the events of the registry.
+//
+// The subscriber receives every event announced after it subscribed, once the
+// change has been committed. Events of a rolled back change are never
+// delivered. Call the returned function to unsubscribe.
+func (r *SQLRegistry) Subscribe(subscriber Subscriber) (unsubscribe func()) {
+	return r.events.subscribe(subscriber)
+}
+
+// Announces an event.
+//
+// In a transaction, the event is held back until the transaction commits and
+// discarded if it rolls back.
+func (r *SQLRegistry) emit(ctx context.Context, event Event) {
+	event.ID = newEventID()
+	event.Actor = subjectFromContext(ctx)
+	event.CreatedAt = time.Now().Unix()
+
+	if r.pending != nil {
+		*r.pending = append(*r.pending, event)
+		return
+	}
+	r.events.publish([]Event{event})
+}
+
+// Returns a new random event identifier.
+func newEventID() string {
+	buf := make([]byte, eventIDSize)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Encodes an event as JSON, using the field tags of its type.
+func encodeEvent(event Event) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := codec.Encode(&buf, codec.ContentTypeJSON, "field", false, event); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+// Decodes an event encoded by [encodeEvent].
+func decodeEvent(r io.Reader) (*Event, error) {
+	var event Event
+	if err := codec.Decode(r, codec.ContentTypeJSON, "field", &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
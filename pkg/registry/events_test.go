@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestSubscribe_Events(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	var events []Event
+	unsubscribe := registry.Subscribe(SubscriberFunc(func(event Event) {
+		events = append(events, event)
+	}))
+
+	ctx := context.Background()
+	alice := WithPrincipal(ctx, &Principal{Subject: "alice"})
+	_, _ = registry.CreateNamespace(alice, NamespaceInfo{Name: "test-ns"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "app", Type: "widget"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "1.0.0"})
+	_, _ = registry.UploadArchive(ctx, "test-ns", "app", "1.0.0", bytes.NewReader([]byte("archive")))
+	_, _ = registry.UploadArchive(ctx, "test-ns", "app", "1.0.0", bytes.NewReader([]byte("archive v2")))
+	_, _ = registry.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "1.1.0"})
+	_, _ = registry.CreateChannel(ctx, "test-ns", "app", ChannelInfo{Name: "stable", Version: "1.0.0"})
+	_, _ = registry.UpdateChannel(ctx, "test-ns", "app", "stable", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "same version"}, AnyRevision)
+	_, _ = registry.UpdateChannel(ctx, "test-ns", "app", "stable", ChannelInfo{Name: "stable", Version: "1.1.0"}, AnyRevision)
+
+	want := []EventType{
+		EventNamespaceCreated,
+		EventResourceCreated,
+		EventVersionCreated,
+		EventArchiveUploaded,
+		EventVersionPublished,
+		EventArchiveUploaded,
+		EventVersionCreated,
+		EventChannelMoved,
+		EventChannelMoved,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, event := range events {
+		if event.Type != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], event.Type)
+		}
+		if event.ID == "" || event.CreatedAt == 0 {
+			t.Errorf("event %d: missing identifier or time: %+v", i, event)
+		}
+	}
+
+	if events[0].Actor != "alice" || events[0].Namespace != "test-ns" {
+		t.Errorf("unexpected namespace event: %+v", events[0])
+	}
+	if events[4].Version != "1.0.0" || events[4].Digest == nil {
+		t.Errorf("unexpected published event: %+v", events[4])
+	}
+	moved := events[8]
+	if moved.Channel != "stable" || moved.Version != "1.1.0" || moved.Previous != "1.0.0" {
+		t.Errorf("unexpected channel event: %+v", moved)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "other-ns"})
+	if len(events) != len(want) {
+		t.Errorf("expected no events after unsubscribing, got %d", len(events)-len(want))
+	}
+}
+
+func TestSubscribe_FailedChange(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	var events []Event
+	registry.Subscribe(SubscriberFunc(func(event Event) {
+		events = append(events, event)
+	}))
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns"})
+	if _, err := registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns"}); err == nil {
+		t.Fatal("expected duplicate namespace to fail")
+	}
+	if _, err := registry.CreateChannel(ctx, "test-ns", "app", ChannelInfo{Name: "stable", Version: "1.0.0"}); err == nil {
+		t.Fatal("expected channel of a missing resource to fail")
+	}
+
+	if len(events) != 1 {
+		t.Errorf("expected 1 event, got %d: %+v", len(events), events)
+	}
+}
+
+func TestEventFilter_Matches(t *testing.T) {
+	event := Event{Type: EventChannelMoved, Namespace: "test-ns", Resource: "app"}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		want   bool
+	}{
+		{"zero", EventFilter{}, true},
+		{"namespace", EventFilter{Namespace: "test-ns"}, true},
+		{"other namespace", EventFilter{Namespace: "other"}, false},
+		{"resource", EventFilter{Namespace: "test-ns", Resource: "app"}, true},
+		{"other resource", EventFilter{Resource: "other"}, false},
+		{"type", EventFilter{Types: []EventType{EventVersionPublished, EventChannelMoved}}, true},
+		{"other type", EventFilter{Types: []EventType{EventVersionPublished}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cruciblehq/protocol/pkg/codec"
+)
+
+const (
+
+	// Interval between comments sent on an idle event stream to keep proxies
+	// from closing it.
+	DefaultEventKeepAlive = 30 * time.Second
+
+	// Number of events held for a slow event stream client before it is
+	// disconnected.
+	eventStreamBuffer = 64
+
+	// Largest event accepted by [Client.Events].
+	maxStreamEvent = 1 << 20
+
+	// Content type of Server-Sent Events streams.
+	contentTypeEventStream = "text/event-stream"
+
+	// Event stream error messages
+	errMsgStreamUnsupported = "response writer does not support streaming"
+	errMsgStreamLagged      = "event stream client fell behind, disconnecting"
+)
+
+// Serves registry events as a Server-Sent Events stream.
+//
+// Every event is sent with its identifier as the SSE id, its type as the SSE
+// event name, and the event encoded as JSON (see [MediaTypeEvent]) as data.
+// The namespace, resource and type query parameters select the events, as the
+// fields of an [EventFilter]; type can be repeated. Comments are sent while
+// the stream is idle so proxies keep it open. A client that falls too far
+// behind is disconnected instead of silently missing events. [Client.Events]
+// consumes the stream.
+type EventStreamHandler struct {
+	source EventSource  // Registry whose events are streamed
+	logger *slog.Logger // Logger for stream failures
+}
+
+// Creates a handler streaming the events of source.
+func NewEventStreamHandler(source EventSource, logger *slog.Logger) *EventStreamHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &EventStreamHandler{
+		source: source,
+		logger: logger,
+	}
+}
+
+// Streams events until the client disconnects.
+func (h *EventStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter := eventFilterFromQuery(r.URL.Query())
+	if filter.Namespace != "" {
+		if err := validateName(filter.Namespace); err != nil {
+			writeStreamError(w, http.StatusBadRequest, &Error{Code: ErrorCodeBadRequest, Message: err.Error()})
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeStreamError(w, http.StatusInternalServerError, &Error{Code: ErrorCodeInternalError, Message: errMsgStreamUnsupported})
+		return
+	}
+
+	events := make(chan Event, eventStreamBuffer)
+	lagged := make(chan struct{})
+	var once sync.Once
+
+	unsubscribe := h.source.Subscribe(SubscriberFunc(func(event Event) {
+		if !filter.Matches(event) {
+			return
+		}
+		select {
+		case events <- event:
+		default:
+			once.Do(func() { close(lagged) })
+		}
+	}))
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", contentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(DefaultEventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-lagged:
+			h.logger.Warn(errMsgStreamLagged, "remote", r.RemoteAddr)
+			return
+		case event := <-events:
+			err = writeStreamEvent(w, event)
+		case <-keepAlive.C:
+			_, err = io.WriteString(w, ": keep-alive\n\n")
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// Subscribes to the events of the registry.
+//
+// Opens the event stream served by [EventStreamHandler] at /events and
+// returns a channel receiving the events selected by filter. The channel is
+// closed when ctx is canceled or the stream ends, for example because the
+// registry disconnected a client that fell behind. Events announced while no
+// stream is open are missed; they can be found with [Client.ListAuditEvents].
+// Returns an error if the stream cannot be opened.
+func (c *Client) Events(ctx context.Context, filter EventFilter) (<-chan Event, error) {
+	req, err := c.newRequest(ctx, "GET", "/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = eventQuery(filter).Encode()
+	req.Header.Set("Accept", contentTypeEventStream)
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var regErr Error
+		if err := codec.Decode(resp.Body, codec.ContentTypeJSON, "field", &regErr); err != nil {
+			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		}
+		return nil, &regErr
+	}
+
+	events := make(chan Event)
+	go readEventStream(ctx, resp.Body, events)
+	return events, nil
+}
+
+// Returns the query parameters for an event filter.
+func eventQuery(filter EventFilter) url.Values {
+	query := url.Values{}
+	if filter.Namespace != "" {
+		query.Set("namespace", filter.Namespace)
+	}
+	if filter.Resource != "" {
+		query.Set("resource", filter.Resource)
+	}
+	for _, t := range filter.Types {
+		query.Add("type", string(t))
+	}
+	return query
+}
+
+// Returns the event filter described by query parameters.
+func eventFilterFromQuery(query url.Values) EventFilter {
+	filter := EventFilter{
+		Namespace: query.Get("namespace"),
+		Resource:  query.Get("resource"),
+	}
+	for _, t := range query["type"] {
+		filter.Types = append(filter.Types, EventType(t))
+	}
+	return filter
+}
+
+// Writes an event to a Server-Sent Events stream.
+func writeStreamEvent(w io.Writer, event Event) error {
+	data, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// Writes an error response in place of an event stream.
+func writeStreamError(w http.ResponseWriter, status int, regErr *Error) {
+	w.Header().Set("Content-Type", string(MediaTypeError)+"+json")
+	w.WriteHeader(status)
+	codec.Encode(w, codec.ContentTypeJSON, "field", false, regErr)
+}
+
+// Reads events from a Server-Sent Events stream into a channel.
+//
+// Only the data of each event is used, since it carries the identifier and
+// type as well; other fields and comments are skipped, as are events whose
+// data cannot be decoded. Closes the channel and the stream once the stream
+// ends or ctx is canceled.
+func readEventStream(ctx context.Context, body io.ReadCloser, events chan<- Event) {
+	defer close(events)
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, maxStreamEvent)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		event, err := decodeEvent(strings.NewReader(data.String()))
+		data.Reset()
+		if err != nil {
+			continue
+		}
+
+		select {
+		case events <- *event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
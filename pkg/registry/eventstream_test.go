@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_Events(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mux := http.NewServeMux()
+	mux.Handle("/events", NewEventStreamHandler(registry, nil))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := NewClient(server.URL, nil)
+	events, err := client.Events(ctx, EventFilter{Namespace: "test-ns", Types: []EventType{EventResourceCreated, EventChannelMoved}})
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns"})
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "other-ns"})
+	_, _ = registry.CreateResource(ctx, "other-ns", ResourceInfo{Name: "app", Type: "widget"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "app", Type: "widget"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "1.0.0"})
+	_, _ = registry.CreateChannel(ctx, "test-ns", "app", ChannelInfo{Name: "stable", Version: "1.0.0"})
+
+	want := []EventType{EventResourceCreated, EventChannelMoved}
+	for i, typ := range want {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("stream closed after %d events", i)
+			}
+			if event.Type != typ || event.Namespace != "test-ns" || event.ID == "" {
+				t.Errorf("event %d: unexpected event: %+v", i, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected no more events")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the stream to close")
+	}
+}
+
+func TestClient_Events_Error(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	server := httptest.NewServer(NewEventStreamHandler(registry, nil))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	_, err := client.Events(context.Background(), EventFilter{Namespace: "Invalid Name"})
+	if regErr, ok := err.(*Error); !ok || regErr.Code != ErrorCodeBadRequest {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
+
+func TestReadEventStream(t *testing.T) {
+	stream := strings.Join([]string{
+		": keep-alive",
+		"",
+		"id: 1",
+		"event: channel.moved",
+		`data: {"id":"1","type":"channel.moved",`,
+		`data: "channel":"stable"}`,
+		"",
+		"data: not json",
+		"",
+		`data: {"id":"2","type":"version.published"}`,
+	}, "\n") + "\n\n"
+
+	events := make(chan Event)
+	go readEventStream(context.Background(), io.NopCloser(strings.NewReader(stream)), events)
+
+	var got []Event
+	for event := range events {
+		got = append(got, event)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(got), got)
+	}
+	if got[0].ID != "1" || got[0].Channel != "stable" || got[1].Type != EventVersionPublished {
+		t.Errorf("unexpected events: %+v", got)
+	}
+}
@@ -1,17 +1,75 @@
 package registry
 
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+)
+
 // Logs an error with structured context and returns a registry Error.
 //
 // The error is logged with the provided message and key-value pairs, while the
 // returned Error contains only the error code and message for the client. This
 // prevents leaking internal implementation details.
 func (r *SQLRegistry) logAndReturnError(code ErrorCode, message string, err error, keyvals ...any) *Error {
+	return logError(r.logger, code, message, err, keyvals...)
+}
+
+// Logs an error with structured context to logger and returns a registry Error.
+//
+// Shared by the SQL-backed types; see [SQLRegistry.logAndReturnError].
+func logError(logger *slog.Logger, code ErrorCode, message string, err error, keyvals ...any) *Error {
 	args := make([]any, 0, 2+len(keyvals))
 	args = append(args, "error", err)
 	args = append(args, keyvals...)
-	r.logger.Error(message, args...)
+	logger.Error(message, args...)
 	return &Error{
 		Code:    code,
 		Message: message,
 	}
 }
+
+// Checks that the request principal may perform an action on a namespace.
+//
+// Returns nil if no authorizer is configured. If the principal is a member of
+// the namespace, the authorizer sees the member role added to the principal's
+// roles. Errors from the authorizer that are not an [*Error] are logged and
+// reported as [ErrorCodeInternalError].
+func (r *SQLRegistry) authorize(ctx context.Context, action Action, namespace string) error {
+	if r.authorizer == nil {
+		return nil
+	}
+
+	if principal := PrincipalFromContext(ctx); principal != nil {
+		m, err := r.getMember(ctx, namespace, principal.Subject)
+		if err != nil && err != sql.ErrNoRows {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgAuthorize, err, "action", action, "namespace", namespace)
+		}
+		if m != nil {
+			ctx = WithPrincipal(ctx, principal.withRole(m.Role))
+		}
+	}
+
+	err := r.authorizer.Authorize(ctx, action, namespace)
+	if err == nil {
+		return nil
+	}
+
+	if regErr, ok := err.(*Error); ok {
+		return regErr
+	}
+	return r.logAndReturnError(ErrorCodeInternalError, errMsgAuthorize, err, "action", action, "namespace", namespace)
+}
+
+// Returns an error from a transaction as a registry Error.
+//
+// Errors that already are an [*Error], as returned by the steps of an
+// operation, are returned unchanged. Others, such as failures to begin or
+// commit the transaction, are logged and reported as
+// [ErrorCodeInternalError] with message.
+func (r *SQLRegistry) registryError(err error, message string, keyvals ...any) error {
+	if regErr, ok := err.(*Error); ok {
+		return regErr
+	}
+	return r.logAndReturnError(ErrorCodeInternalError, message, err, keyvals...)
+}
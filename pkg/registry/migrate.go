@@ -0,0 +1,237 @@
+package registry
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+
+	// Age after which a migration lock is considered abandoned by a registry
+	// that crashed while migrating, and is broken.
+	migrationLockTimeout = 10 * time.Minute
+
+	// Interval between attempts to take a held migration lock.
+	migrationLockPoll = 100 * time.Millisecond
+
+	// Migration error messages
+	errMsgMigrateSchema = "failed to migrate schema"
+	errMsgSchemaTooNew  = "database schema is newer than this registry supports"
+)
+
+// Returned by [migrate] when the database has migrations this build does not
+// know about.
+var errSchemaTooNew = errors.New("database schema is newer than this registry")
+
+// Schema migration embedded in the package.
+type migration struct {
+	version int64  // Migration number, from the file name.
+	name    string // File name (e.g., "0001_initial.sql").
+	sql     string // Statements applied by the migration.
+}
+
+// Brings the database schema up to date for the constructors.
+//
+// Same as [migrate], but logs failures and returns them as [*Error].
+func migrateSchema(ctx context.Context, db *sql.DB, dialect *Dialect, logger *slog.Logger) error {
+	err := migrate(ctx, db, dialect, logger)
+	if err == nil {
+		return nil
+	}
+
+	message := errMsgMigrateSchema
+	if errors.Is(err, errSchemaTooNew) {
+		message = errMsgSchemaTooNew
+	}
+	return logError(logger, ErrorCodeInternalError, message, err, "dialect", dialect)
+}
+
+// Applies the pending schema migrations of a dialect.
+//
+// Migrations are applied in order, each in its own transaction together with
+// its record in schema_migrations. A lock row serializes registries starting
+// concurrently against the same database; the others wait for it, then find
+// nothing left to do. Returns an error wrapping errSchemaTooNew, without
+// changing anything, if the database has a migration newer than the newest
+// embedded one. Returns the raw database error on failure otherwise.
+func migrate(ctx context.Context, db *sql.DB, dialect *Dialect, logger *slog.Logger) error {
+	migrations, err := loadMigrations(dialect.migrationsDir())
+	if err != nil {
+		return err
+	}
+
+	for _, query := range []string{sqlSchemaMigrationsCreate, sqlMigrationLockCreate} {
+		if err := createMigrationTable(ctx, db, dialect, query); err != nil {
+			return err
+		}
+	}
+
+	owner, err := lockMigrations(ctx, db, dialect)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := db.ExecContext(context.WithoutCancel(ctx), dialect.rebind(sqlMigrationLockDelete), owner); err != nil {
+			logger.Error("failed to release migration lock", "error", err)
+		}
+	}()
+
+	applied, err := appliedMigrations(ctx, db, dialect)
+	if err != nil {
+		return err
+	}
+
+	latest := migrations[len(migrations)-1].version
+	if len(applied) > 0 && applied[len(applied)-1] > latest {
+		return fmt.Errorf("%w: database is at version %d, newest known is %d", errSchemaTooNew, applied[len(applied)-1], latest)
+	}
+
+	for _, m := range migrations {
+		if _, ok := slices.BinarySearch(applied, m.version); ok {
+			continue
+		}
+		if err := applyMigration(ctx, db, dialect, m); err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+		logger.Info("applied schema migration", "dialect", dialect, "version", m.version, "name", m.name)
+	}
+
+	return nil
+}
+
+// Reads the migrations in a directory of the embedded filesystem, in order.
+//
+// File names must start with a positive migration number followed by an
+// underscore. Numbers must be unique.
+func loadMigrations(dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(sqlFS, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []migration
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration name %q", entry.Name())
+		}
+		migrations = append(migrations, migration{
+			version: version,
+			name:    entry.Name(),
+			sql:     mustReadSQL(path.Join(dir, entry.Name())),
+		})
+	}
+
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations in %s", dir)
+	}
+
+	slices.SortFunc(migrations, func(a, b migration) int { return cmp.Compare(a.version, b.version) })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Creates a migration bookkeeping table if it doesn't exist.
+//
+// Concurrent CREATE TABLE IF NOT EXISTS statements can still collide on some
+// databases (PostgreSQL reports a unique violation on its catalog); the loser
+// retries once, by which time the table exists.
+func createMigrationTable(ctx context.Context, db *sql.DB, dialect *Dialect, query string) error {
+	_, err := db.ExecContext(ctx, dialect.rebind(query))
+	if dialect.classify(err) == violationUnique {
+		_, err = db.ExecContext(ctx, dialect.rebind(query))
+	}
+	return err
+}
+
+// Takes the migration lock, waiting while another registry holds it.
+//
+// Locks older than migrationLockTimeout are broken. Returns the owner
+// identifier needed to release the lock, or the context error if ctx ends
+// while waiting.
+func lockMigrations(ctx context.Context, db *sql.DB, dialect *Dialect) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	owner := hex.EncodeToString(buf)
+
+	for {
+		now := time.Now()
+		_, err := db.ExecContext(ctx, dialect.rebind(sqlMigrationLockInsert), owner, now.Unix())
+		if err == nil {
+			return owner, nil
+		}
+		if dialect.classify(err) != violationUnique {
+			return "", err
+		}
+
+		if _, err := db.ExecContext(ctx, dialect.rebind(sqlMigrationLockExpire), now.Add(-migrationLockTimeout).Unix()); err != nil {
+			return "", err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(migrationLockPoll):
+		}
+	}
+}
+
+// Queries the versions of applied migrations, in ascending order.
+func appliedMigrations(ctx context.Context, db *sql.DB, dialect *Dialect) ([]int64, error) {
+	rows, err := db.QueryContext(ctx, dialect.rebind(sqlSchemaMigrationsList))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []int64
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// Applies a migration and records it in one transaction.
+//
+// Migration statements are written for the dialect and are not rebound.
+func applyMigration(ctx context.Context, db *sql.DB, dialect *Dialect, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, dialect.rebind(sqlSchemaMigrationsInsert), m.version, m.name, time.Now().Unix()); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,164 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func openTestMigrationDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func countRows(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatalf("count %s: %v", table, err)
+	}
+	return n
+}
+
+func TestMigrate(t *testing.T) {
+	db := openTestMigrationDB(t, filepath.Join(t.TempDir(), "registry.db"))
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	ctx := context.Background()
+
+	migrations, err := loadMigrations(DialectSQLite.migrationsDir())
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+
+	for range 2 {
+		if err := migrate(ctx, db, nil, logger); err != nil {
+			t.Fatalf("migrate() error = %v", err)
+		}
+		if n := countRows(t, db, "schema_migrations"); n != len(migrations) {
+			t.Errorf("expected %d applied migrations, got %d", len(migrations), n)
+		}
+		if n := countRows(t, db, "schema_migrations_lock"); n != 0 {
+			t.Errorf("expected the lock to be released, got %d rows", n)
+		}
+	}
+}
+
+func TestMigrate_AdoptsExistingSchema(t *testing.T) {
+	db := openTestMigrationDB(t, filepath.Join(t.TempDir(), "registry.db"))
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	ctx := context.Background()
+
+	// A database created before migrations were introduced
+	migrations, _ := loadMigrations(DialectSQLite.migrationsDir())
+	if _, err := db.Exec(migrations[0].sql); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO namespaces (name, description, created_at, updated_at) VALUES ('test-ns', 'Test', 0, 0)"); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := NewSQLRegistry(ctx, db, t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("NewSQLRegistry() error = %v", err)
+	}
+	if _, err := registry.ReadNamespace(ctx, "test-ns"); err != nil {
+		t.Errorf("ReadNamespace() error = %v", err)
+	}
+}
+
+func TestMigrate_SchemaTooNew(t *testing.T) {
+	db := openTestMigrationDB(t, filepath.Join(t.TempDir(), "registry.db"))
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	ctx := context.Background()
+
+	if err := migrate(ctx, db, nil, logger); err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, '9999_future.sql', 0)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrate(ctx, db, nil, logger); !errors.Is(err, errSchemaTooNew) {
+		t.Errorf("expected errSchemaTooNew, got: %v", err)
+	}
+
+	_, err := NewSQLRegistry(ctx, db, t.TempDir(), logger)
+	assertErrorCode(t, err, ErrorCodeInternalError)
+	if err.(*Error).Message != errMsgSchemaTooNew {
+		t.Errorf("Message = %q, want %q", err.(*Error).Message, errMsgSchemaTooNew)
+	}
+}
+
+func TestMigrate_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.db")
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	ctx := context.Background()
+
+	const starts = 8
+	var wg sync.WaitGroup
+	errs := make([]error, starts)
+	for i := range starts {
+		db := openTestMigrationDB(t, path)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = NewSQLRegistry(ctx, db, t.TempDir(), logger)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("start %d: NewSQLRegistry() error = %v", i, err)
+		}
+	}
+
+	migrations, _ := loadMigrations(DialectSQLite.migrationsDir())
+	if n := countRows(t, openTestMigrationDB(t, path), "schema_migrations"); n != len(migrations) {
+		t.Errorf("expected %d applied migrations, got %d", len(migrations), n)
+	}
+}
+
+func TestMigrate_Lock(t *testing.T) {
+	db := openTestMigrationDB(t, filepath.Join(t.TempDir(), "registry.db"))
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if err := migrate(context.Background(), db, nil, logger); err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+
+	// Another registry is migrating
+	if _, err := db.Exec("INSERT INTO schema_migrations_lock (id, owner, acquired_at) VALUES (1, 'other', ?)", time.Now().Unix()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*migrationLockPoll)
+	defer cancel()
+	if err := migrate(ctx, db, nil, logger); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected to wait for the lock, got: %v", err)
+	}
+
+	// The other registry crashed long ago
+	if _, err := db.Exec("UPDATE schema_migrations_lock SET acquired_at = ?", time.Now().Add(-2*migrationLockTimeout).Unix()); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrate(context.Background(), db, nil, logger); err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+	if n := countRows(t, db, "schema_migrations_lock"); n != 0 {
+		t.Errorf("expected the lock to be released, got %d rows", n)
+	}
+}
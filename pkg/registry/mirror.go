@@ -0,0 +1,347 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/cruciblehq/protocol/internal/helpers"
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+// Change made, or planned in a dry run, by [Mirror].
+type MirrorAction string
+
+const (
+	MirrorCreateNamespace MirrorAction = "create-namespace" // Namespace created on the target.
+	MirrorUpdateNamespace MirrorAction = "update-namespace" // Namespace description copied to the target.
+	MirrorCreateResource  MirrorAction = "create-resource"  // Resource created on the target.
+	MirrorUpdateResource  MirrorAction = "update-resource"  // Resource type or description copied to the target.
+	MirrorCreateVersion   MirrorAction = "create-version"   // Version created on the target.
+	MirrorCopyArchive     MirrorAction = "copy-archive"     // Archive streamed from the source to the target.
+	MirrorCreateChannel   MirrorAction = "create-channel"   // Channel created on the target.
+	MirrorUpdateChannel   MirrorAction = "update-channel"   // Channel pointer or description copied to the target.
+)
+
+// Options for [Mirror].
+//
+// The zero value (and a nil *MirrorOptions) mirrors every namespace and
+// resource. Filters are [path.Match] patterns; an entry is mirrored if it
+// matches any include pattern (or there are none) and no exclude pattern.
+type MirrorOptions struct {
+
+	// Patterns of namespace names to mirror.
+	//
+	// Empty mirrors every namespace not excluded.
+	IncludeNamespaces []string
+
+	// Patterns of namespace names to skip.
+	ExcludeNamespaces []string
+
+	// Patterns of resource types to mirror.
+	//
+	// Empty mirrors every resource type not excluded.
+	IncludeTypes []string
+
+	// Patterns of resource types to skip.
+	ExcludeTypes []string
+
+	// Report the changes without making them.
+	//
+	// The target is only read. The report lists what a real run would do.
+	DryRun bool
+}
+
+// Returns whether a namespace passes the namespace filters.
+func (o *MirrorOptions) includesNamespace(name string) bool {
+	if o == nil {
+		return true
+	}
+	return matchFilter(name, o.IncludeNamespaces, o.ExcludeNamespaces)
+}
+
+// Returns whether a resource type passes the type filters.
+func (o *MirrorOptions) includesType(typ string) bool {
+	if o == nil {
+		return true
+	}
+	return matchFilter(typ, o.IncludeTypes, o.ExcludeTypes)
+}
+
+// Returns whether changes should only be reported.
+func (o *MirrorOptions) dryRun() bool {
+	return o != nil && o.DryRun
+}
+
+// Single change made, or planned in a dry run, by [Mirror].
+type MirrorStep struct {
+	Action    MirrorAction `field:"action"`    // Change made.
+	Namespace string       `field:"namespace"` // Namespace of the target.
+	Resource  string       `field:"resource"`  // Resource of the target (empty for namespace changes).
+	Target    string       `field:"target"`    // Version or channel (empty for namespace and resource changes).
+	Digest    *string      `field:"digest"`    // Archive digest copied (archive copies only).
+}
+
+// Outcome of a [Mirror] run.
+//
+// Lists the changes in the order they were made. An empty report means the
+// target already matched the source.
+type MirrorReport struct {
+	DryRun bool         `field:"dryRun"` // Whether the changes were only planned.
+	Steps  []MirrorStep `field:"steps"`  // Changes made or planned.
+}
+
+// Copies namespaces, resources, versions and channels between registries.
+//
+// The source is enumerated through its List and Read methods, and anything
+// missing or different on the target is created or updated. Archives are
+// streamed from the source into the target and verified against the source
+// digest on the way; a target version whose digest already matches is left
+// alone. Channels are pointed at the same versions as on the source.
+// Entries on the target that are not on the source are kept, so mirroring is
+// safe to re-run and resumes where an interrupted run stopped.
+//
+// Options can be nil. The report lists the changes made before any error.
+// All errors are wrapped with [ErrMirrorFailed]; an archive that does not
+// match its source digest returns an error wrapping
+// [reference.ErrDigestMismatch].
+func Mirror(ctx context.Context, source, target Registry, options *MirrorOptions) (*MirrorReport, error) {
+	m := &mirror{source: source, target: target, options: options, report: &MirrorReport{DryRun: options.dryRun()}}
+
+	namespaces, err := source.ListNamespaces(ctx)
+	if err != nil {
+		return m.report, helpers.Wrap(ErrMirrorFailed, err)
+	}
+
+	for _, ns := range namespaces.Namespaces {
+		if !options.includesNamespace(ns.Name) {
+			continue
+		}
+		if err := m.namespace(ctx, ns); err != nil {
+			return m.report, helpers.Wrap(ErrMirrorFailed, err)
+		}
+	}
+
+	return m.report, nil
+}
+
+// State of a single [Mirror] run.
+type mirror struct {
+	source  Registry
+	target  Registry
+	options *MirrorOptions
+	report  *MirrorReport
+}
+
+// Records a change, and makes it unless this is a dry run.
+func (m *mirror) apply(step MirrorStep, fn func() error) error {
+	if !m.report.DryRun {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	m.report.Steps = append(m.report.Steps, step)
+	return nil
+}
+
+// Mirrors a namespace and its resources.
+func (m *mirror) namespace(ctx context.Context, ns NamespaceSummary) error {
+	info := NamespaceInfo{Name: ns.Name, Description: ns.Description}
+	step := MirrorStep{Namespace: ns.Name}
+
+	existing, err := m.target.ReadNamespace(ctx, ns.Name)
+	switch {
+	case hasErrorCode(err, ErrorCodeNotFound):
+		step.Action = MirrorCreateNamespace
+		err = m.apply(step, func() error {
+			_, err := m.target.CreateNamespace(ctx, info)
+			return err
+		})
+	case err == nil && existing.Description != info.Description:
+		step.Action = MirrorUpdateNamespace
+		err = m.apply(step, func() error {
+			_, err := m.target.UpdateNamespace(ctx, ns.Name, info, AnyRevision)
+			return err
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("namespace %s: %w", ns.Name, err)
+	}
+
+	resources, err := m.source.ListResources(ctx, ns.Name)
+	if err != nil {
+		return fmt.Errorf("namespace %s: %w", ns.Name, err)
+	}
+
+	for _, res := range resources.Resources {
+		if !m.options.includesType(res.Type) {
+			continue
+		}
+		if err := m.resource(ctx, ns.Name, res); err != nil {
+			return fmt.Errorf("resource %s/%s: %w", ns.Name, res.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Mirrors a resource with its versions and channels.
+func (m *mirror) resource(ctx context.Context, namespace string, res ResourceSummary) error {
+	info := ResourceInfo{Name: res.Name, Type: res.Type, Description: res.Description}
+	step := MirrorStep{Namespace: namespace, Resource: res.Name}
+
+	existing, err := m.target.ReadResource(ctx, namespace, res.Name)
+	switch {
+	case hasErrorCode(err, ErrorCodeNotFound):
+		step.Action = MirrorCreateResource
+		err = m.apply(step, func() error {
+			_, err := m.target.CreateResource(ctx, namespace, info)
+			return err
+		})
+	case err == nil && (existing.Type != info.Type || existing.Description != info.Description):
+		step.Action = MirrorUpdateResource
+		err = m.apply(step, func() error {
+			_, err := m.target.UpdateResource(ctx, namespace, res.Name, info, AnyRevision)
+			return err
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	versions, err := m.source.ListVersions(ctx, namespace, res.Name)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions.Versions {
+		if err := m.version(ctx, namespace, res.Name, v.String); err != nil {
+			return fmt.Errorf("version %s: %w", v.String, err)
+		}
+	}
+
+	channels, err := m.source.ListChannels(ctx, namespace, res.Name)
+	if err != nil {
+		return err
+	}
+	for _, ch := range channels.Channels {
+		if err := m.channel(ctx, namespace, res.Name, ch); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Mirrors a version and its archive.
+func (m *mirror) version(ctx context.Context, namespace, resource, version string) error {
+	src, err := m.source.ReadVersion(ctx, namespace, resource, version)
+	if err != nil {
+		return err
+	}
+
+	var targetDigest *string
+	existing, err := m.target.ReadVersion(ctx, namespace, resource, version)
+	switch {
+	case hasErrorCode(err, ErrorCodeNotFound):
+		step := MirrorStep{Action: MirrorCreateVersion, Namespace: namespace, Resource: resource, Target: version}
+		err = m.apply(step, func() error {
+			_, err := m.target.CreateVersion(ctx, namespace, resource, VersionInfo{String: version})
+			return err
+		})
+	case err == nil:
+		targetDigest = existing.Digest
+	}
+	if err != nil {
+		return err
+	}
+
+	if src.Digest == nil || (targetDigest != nil && *targetDigest == *src.Digest) {
+		return nil
+	}
+
+	step := MirrorStep{Action: MirrorCopyArchive, Namespace: namespace, Resource: resource, Target: version, Digest: src.Digest}
+	return m.apply(step, func() error {
+		return m.copyArchive(ctx, namespace, resource, version, *src.Digest)
+	})
+}
+
+// Streams an archive from the source to the target, verifying its digest.
+//
+// The source stream is verified while it is uploaded, so a corrupted stream
+// fails the upload, and the digest reported by the target is checked too.
+func (m *mirror) copyArchive(ctx context.Context, namespace, resource, version, digest string) error {
+	expected, err := reference.ParseDigest(digest)
+	if err != nil {
+		return err
+	}
+
+	body, err := m.source.DownloadArchive(ctx, namespace, resource, version)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	vr, err := reference.NewVerifyingReader(body, expected)
+	if err != nil {
+		return err
+	}
+
+	uploaded, err := m.target.UploadArchive(ctx, namespace, resource, version, vr)
+	if err != nil {
+		return err
+	}
+
+	if uploaded.Digest == nil || *uploaded.Digest != digest {
+		actual := "none"
+		if uploaded.Digest != nil {
+			actual = *uploaded.Digest
+		}
+		return helpers.Wrap(reference.ErrDigestMismatch, fmt.Errorf("expected %s, target stored %s", digest, actual))
+	}
+
+	return nil
+}
+
+// Mirrors a channel pointer.
+func (m *mirror) channel(ctx context.Context, namespace, resource string, ch ChannelSummary) error {
+	info := ChannelInfo{Name: ch.Name, Version: ch.Version, Description: ch.Description}
+	step := MirrorStep{Namespace: namespace, Resource: resource, Target: ch.Name}
+
+	existing, err := m.target.ReadChannel(ctx, namespace, resource, ch.Name)
+	switch {
+	case hasErrorCode(err, ErrorCodeNotFound):
+		step.Action = MirrorCreateChannel
+		err = m.apply(step, func() error {
+			_, err := m.target.CreateChannel(ctx, namespace, resource, info)
+			return err
+		})
+	case err == nil && (existing.Version.String != info.Version || existing.Description != info.Description):
+		step.Action = MirrorUpdateChannel
+		err = m.apply(step, func() error {
+			_, err := m.target.UpdateChannel(ctx, namespace, resource, ch.Name, info, AnyRevision)
+			return err
+		})
+	}
+	return err
+}
+
+// Reports whether name matches any include pattern (or there are none) and
+// no exclude pattern.
+//
+// Malformed patterns never match.
+func matchFilter(name string, include, exclude []string) bool {
+	for _, pattern := range exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, pattern := range include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// Source registry that serves corrupted archives.
+type corruptingRegistry struct {
+	Registry
+}
+
+func (c *corruptingRegistry) DownloadArchive(ctx context.Context, namespace, resource, version string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader([]byte("tampered"))), nil
+}
+
+func setupTestMirror(t *testing.T) (*SQLRegistry, *SQLRegistry, func()) {
+	t.Helper()
+
+	source, cleanupSource := setupTestDB(t)
+	target, cleanupTarget := setupTestDB(t)
+
+	ctx := context.Background()
+	_, _ = source.CreateNamespace(ctx, NamespaceInfo{Name: "acme", Description: "Acme"})
+	_, _ = source.CreateNamespace(ctx, NamespaceInfo{Name: "other", Description: "Other"})
+	_, _ = source.CreateResource(ctx, "acme", ResourceInfo{Name: "app", Type: "widget", Description: "App"})
+	_, _ = source.CreateResource(ctx, "acme", ResourceInfo{Name: "api", Type: "service", Description: "API"})
+	_, _ = source.CreateVersion(ctx, "acme", "app", VersionInfo{String: "1.0.0"})
+	_, _ = source.UploadArchive(ctx, "acme", "app", "1.0.0", bytes.NewReader([]byte("app 1.0.0")))
+	_, _ = source.CreateVersion(ctx, "acme", "app", VersionInfo{String: "1.1.0"})
+	_, _ = source.CreateChannel(ctx, "acme", "app", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Stable"})
+	_, _ = source.CreateVersion(ctx, "acme", "api", VersionInfo{String: "2.0.0"})
+
+	return source, target, func() {
+		cleanupTarget()
+		cleanupSource()
+	}
+}
+
+func countActions(report *MirrorReport, action MirrorAction) int {
+	n := 0
+	for _, step := range report.Steps {
+		if step.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+func TestMirror(t *testing.T) {
+	source, target, cleanup := setupTestMirror(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	report, err := Mirror(ctx, source, target, nil)
+	if err != nil {
+		t.Fatalf("Mirror() error = %v", err)
+	}
+
+	if n := countActions(report, MirrorCreateNamespace); n != 2 {
+		t.Errorf("expected 2 namespaces created, got %d", n)
+	}
+	if n := countActions(report, MirrorCreateVersion); n != 3 {
+		t.Errorf("expected 3 versions created, got %d", n)
+	}
+	if n := countActions(report, MirrorCopyArchive); n != 1 {
+		t.Errorf("expected 1 archive copied, got %d", n)
+	}
+
+	src, _ := source.ReadVersion(ctx, "acme", "app", "1.0.0")
+	dst, err := target.ReadVersion(ctx, "acme", "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("ReadVersion() error = %v", err)
+	}
+	if dst.Digest == nil || *dst.Digest != *src.Digest {
+		t.Errorf("digest = %v, want %s", dst.Digest, *src.Digest)
+	}
+
+	ch, err := target.ReadChannel(ctx, "acme", "app", "stable")
+	if err != nil {
+		t.Fatalf("ReadChannel() error = %v", err)
+	}
+	if ch.Version.String != "1.0.0" || ch.Description != "Stable" {
+		t.Errorf("unexpected channel: %+v", ch)
+	}
+
+	// Re-running changes nothing
+	report, err = Mirror(ctx, source, target, nil)
+	if err != nil {
+		t.Fatalf("Mirror() error = %v", err)
+	}
+	if len(report.Steps) != 0 {
+		t.Errorf("expected no steps, got %+v", report.Steps)
+	}
+}
+
+func TestMirror_Sync(t *testing.T) {
+	source, target, cleanup := setupTestMirror(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = Mirror(ctx, source, target, nil)
+
+	_, _ = source.UploadArchive(ctx, "acme", "app", "1.1.0", bytes.NewReader([]byte("app 1.1.0")))
+	_, _ = source.UpdateChannel(ctx, "acme", "app", "stable", ChannelInfo{Name: "stable", Version: "1.1.0", Description: "Stable"}, AnyRevision)
+	_, _ = source.UpdateResource(ctx, "acme", "app", ResourceInfo{Name: "app", Type: "widget", Description: "Updated"}, AnyRevision)
+
+	report, err := Mirror(ctx, source, target, nil)
+	if err != nil {
+		t.Fatalf("Mirror() error = %v", err)
+	}
+
+	want := []MirrorAction{MirrorUpdateResource, MirrorCopyArchive, MirrorUpdateChannel}
+	if len(report.Steps) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, report.Steps)
+	}
+	for i, action := range want {
+		if report.Steps[i].Action != action {
+			t.Errorf("step %d = %s, want %s", i, report.Steps[i].Action, action)
+		}
+	}
+
+	ch, _ := target.ReadChannel(ctx, "acme", "app", "stable")
+	if ch.Version.String != "1.1.0" {
+		t.Errorf("Version.String = %q, want '1.1.0'", ch.Version.String)
+	}
+}
+
+func TestMirror_ResourceType(t *testing.T) {
+	source, target, cleanup := setupTestMirror(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = Mirror(ctx, source, target, nil)
+	_, _ = target.UpdateResource(ctx, "acme", "app", ResourceInfo{Name: "app", Type: "service", Description: "App"}, AnyRevision)
+
+	report, err := Mirror(ctx, source, target, nil)
+	if err != nil {
+		t.Fatalf("Mirror() error = %v", err)
+	}
+	if len(report.Steps) != 1 || report.Steps[0].Action != MirrorUpdateResource {
+		t.Fatalf("expected %s, got %+v", MirrorUpdateResource, report.Steps)
+	}
+
+	res, _ := target.ReadResource(ctx, "acme", "app")
+	if res.Type != "widget" {
+		t.Errorf("Type = %q, want 'widget'", res.Type)
+	}
+}
+
+func TestMirror_DryRun(t *testing.T) {
+	source, target, cleanup := setupTestMirror(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	report, err := Mirror(ctx, source, target, &MirrorOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Mirror() error = %v", err)
+	}
+	if !report.DryRun {
+		t.Error("expected report to be marked as a dry run")
+	}
+	if len(report.Steps) != 9 {
+		t.Errorf("expected 9 steps, got %d: %+v", len(report.Steps), report.Steps)
+	}
+
+	list, _ := target.ListNamespaces(ctx)
+	if len(list.Namespaces) != 0 {
+		t.Errorf("expected target to be untouched, got %+v", list.Namespaces)
+	}
+}
+
+func TestMirror_Filters(t *testing.T) {
+	source, target, cleanup := setupTestMirror(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := Mirror(ctx, source, target, &MirrorOptions{
+		IncludeNamespaces: []string{"ac*"},
+		ExcludeTypes:      []string{"service"},
+	})
+	if err != nil {
+		t.Fatalf("Mirror() error = %v", err)
+	}
+
+	if _, err := target.ReadNamespace(ctx, "other"); err == nil {
+		t.Error("expected namespace 'other' to be filtered out")
+	}
+	if _, err := target.ReadResource(ctx, "acme", "api"); err == nil {
+		t.Error("expected resource 'api' to be filtered out")
+	}
+	if _, err := target.ReadResource(ctx, "acme", "app"); err != nil {
+		t.Errorf("ReadResource() error = %v", err)
+	}
+}
+
+func TestMirror_DigestMismatch(t *testing.T) {
+	source, target, cleanup := setupTestMirror(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := Mirror(ctx, &corruptingRegistry{Registry: source}, target, nil)
+	if !errors.Is(err, ErrMirrorFailed) {
+		t.Fatalf("expected ErrMirrorFailed, got: %v", err)
+	}
+
+	v, err := target.ReadVersion(ctx, "acme", "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("ReadVersion() error = %v", err)
+	}
+	if v.Digest != nil {
+		t.Errorf("expected no archive on the target, got %s", *v.Digest)
+	}
+}
+
+func TestMatchFilter(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             bool
+	}{
+		{"acme", nil, nil, true},
+		{"acme", []string{"ac*"}, nil, true},
+		{"acme", []string{"other"}, nil, false},
+		{"acme", nil, []string{"acme"}, false},
+		{"acme", []string{"*"}, []string{"a*"}, false},
+		{"acme", []string{"["}, nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := matchFilter(tt.name, tt.include, tt.exclude); got != tt.want {
+			t.Errorf("matchFilter(%q, %v, %v) = %v, want %v", tt.name, tt.include, tt.exclude, got, tt.want)
+		}
+	}
+}
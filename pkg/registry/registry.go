@@ -36,13 +36,21 @@ type Registry interface {
 	// Immutable identifiers cannot be changed. Updating metadata does not affect
 	// contained resources or their timestamps. If the namespace does not exist,
 	// the operation fails.
-	UpdateNamespace(ctx context.Context, namespace string, info NamespaceInfo) (*Namespace, error)
+	//
+	// The expected revision, if not [AnyRevision], must be the current revision
+	// of the namespace, otherwise [ErrorCodePreconditionFailed] is returned and
+	// nothing is changed.
+	UpdateNamespace(ctx context.Context, namespace string, info NamespaceInfo, expected Revision) (*Namespace, error)
 
 	// Permanently deletes a namespace.
 	//
 	// Namespaces cannot be deleted if they contain any resources. The operation
 	// is idempotent, returning success if the namespace does not exist.
-	DeleteNamespace(ctx context.Context, namespace string) error
+	//
+	// The expected revision, if not [AnyRevision], must be the current revision
+	// of the namespace, otherwise [ErrorCodePreconditionFailed] is returned and
+	// nothing is deleted.
+	DeleteNamespace(ctx context.Context, namespace string, expected Revision) error
 
 	// Lists all namespaces.
 	//
@@ -50,6 +58,46 @@ type Registry interface {
 	// exist. The list order is implementation-dependent.
 	ListNamespaces(ctx context.Context) (*NamespaceList, error)
 
+	// Adds a member to a namespace.
+	//
+	// The subject is granted the given role within the namespace. Returns an
+	// error if the subject is already a member, if the role is not one of the
+	// predefined roles, or if the namespace does not exist.
+	CreateMember(ctx context.Context, namespace string, info MemberInfo) (*Member, error)
+
+	// Retrieves a namespace member.
+	//
+	// If the namespace does not exist or the subject is not a member, an
+	// error is returned.
+	ReadMember(ctx context.Context, namespace string, subject string) (*Member, error)
+
+	// Changes the role of a namespace member.
+	//
+	// The subject cannot be changed. Returns an error if the subject is not a
+	// member of the namespace.
+	//
+	// The expected revision, if not [AnyRevision], must be the current revision
+	// of the membership, otherwise [ErrorCodePreconditionFailed] is returned and
+	// nothing is changed.
+	UpdateMember(ctx context.Context, namespace string, subject string, info MemberInfo, expected Revision) (*Member, error)
+
+	// Removes a member from a namespace.
+	//
+	// The operation is idempotent, returning success if the subject is not a
+	// member.
+	//
+	// The expected revision, if not [AnyRevision], must be the current revision
+	// of the membership, otherwise [ErrorCodePreconditionFailed] is returned and
+	// nothing is deleted.
+	DeleteMember(ctx context.Context, namespace string, subject string, expected Revision) error
+
+	// Lists all members of a namespace.
+	//
+	// Returns the members sorted by subject. The list is empty if the
+	// namespace has no members. If the namespace does not exist, an error is
+	// returned.
+	ListMembers(ctx context.Context, namespace string) (*MemberList, error)
+
 	// Creates a new resource.
 	//
 	// Resource names follow the same constraints as namespace names. If a resource
@@ -69,13 +117,21 @@ type Registry interface {
 	//
 	// Immutable identifiers cannot be changed. If the namespace or resource does
 	// not exist, the operation fails.
-	UpdateResource(ctx context.Context, namespace string, resource string, info ResourceInfo) (*Resource, error)
+	//
+	// The expected revision, if not [AnyRevision], must be the current revision
+	// of the resource, otherwise [ErrorCodePreconditionFailed] is returned and
+	// nothing is changed.
+	UpdateResource(ctx context.Context, namespace string, resource string, info ResourceInfo, expected Revision) (*Resource, error)
 
 	// Permanently deletes a resource.
 	//
 	// Resources cannot be deleted if they contain any published versions. The
 	// operation is idempotent, returning success if the resource does not exist.
-	DeleteResource(ctx context.Context, namespace string, resource string) error
+	//
+	// The expected revision, if not [AnyRevision], must be the current revision
+	// of the resource, otherwise [ErrorCodePreconditionFailed] is returned and
+	// nothing is deleted.
+	DeleteResource(ctx context.Context, namespace string, resource string, expected Revision) error
 
 	// Lists all resources in a namespace.
 	//
@@ -85,6 +141,14 @@ type Registry interface {
 	// an error is returned.
 	ListResources(ctx context.Context, namespace string) (*ResourceList, error)
 
+	// Searches resources across all namespaces.
+	//
+	// Matches free text against resource names and descriptions and returns
+	// one page of the matching resources, most relevant first, with the
+	// number of matches for each value of the type, namespace and stable
+	// channel facets. Returns an error if the query is invalid.
+	Search(ctx context.Context, query SearchQuery) (*SearchResults, error)
+
 	// Creates a new version.
 	//
 	// If a version with the given string already exists, an error is returned.
@@ -105,13 +169,21 @@ type Registry interface {
 	// Only unpublished versions can be updated. Immutable identifiers cannot
 	// be changed. If the version does not exist or is published, the operation
 	// fails.
-	UpdateVersion(ctx context.Context, namespace string, resource string, version string, info VersionInfo) (*Version, error)
+	//
+	// The expected revision, if not [AnyRevision], must be the current revision
+	// of the version, otherwise [ErrorCodePreconditionFailed] is returned and
+	// nothing is changed.
+	UpdateVersion(ctx context.Context, namespace string, resource string, version string, info VersionInfo, expected Revision) (*Version, error)
 
 	// Permanently deletes a version.
 	//
 	// Only unpublished versions can be deleted. The operation is idempotent,
 	// returning success if the version does not exist.
-	DeleteVersion(ctx context.Context, namespace string, resource string, version string) error
+	//
+	// The expected revision, if not [AnyRevision], must be the current revision
+	// of the version, otherwise [ErrorCodePreconditionFailed] is returned and
+	// nothing is deleted.
+	DeleteVersion(ctx context.Context, namespace string, resource string, version string, expected Revision) error
 
 	// Lists all versions for a resource.
 	//
@@ -135,6 +207,52 @@ type Registry interface {
 	// exist, or if the version has no uploaded archive, an error is returned.
 	DownloadArchive(ctx context.Context, namespace string, resource string, version string) (io.ReadCloser, error)
 
+	// Downloads part of a version archive.
+	//
+	// Returns a reader for length bytes of the archive starting at offset, or
+	// for the rest of the archive if length is negative. A range extending past
+	// the end of the archive is truncated. An offset past the end of the
+	// archive returns [ErrorCodeRangeNotSatisfiable]. Used to resume
+	// interrupted downloads.
+	DownloadArchiveRange(ctx context.Context, namespace string, resource string, version string, offset int64, length int64) (io.ReadCloser, error)
+
+	// Starts a resumable archive upload.
+	//
+	// The version must exist. Returns a new session with a zero offset. Chunks
+	// are appended with UploadChunk and the session is completed with
+	// CommitUpload. Sessions are persistent and survive registry restarts.
+	StartUpload(ctx context.Context, namespace string, resource string, version string) (*Upload, error)
+
+	// Retrieves a resumable upload session.
+	//
+	// Returns the session with the number of bytes received so far, which is
+	// the offset the next chunk must start at. If the session does not exist,
+	// an error is returned.
+	ReadUpload(ctx context.Context, namespace string, resource string, version string, id string) (*Upload, error)
+
+	// Appends a chunk to a resumable upload.
+	//
+	// The offset must equal the current offset of the session, otherwise
+	// [ErrorCodeInvalidOffset] is returned and nothing is written. If the
+	// chunk is interrupted, the session keeps its previous offset. Returns the
+	// session with its new offset.
+	UploadChunk(ctx context.Context, namespace string, resource string, version string, id string, offset int64, chunk io.Reader) (*Upload, error)
+
+	// Completes a resumable upload.
+	//
+	// The digest, in "algorithm:hash" format, must match the uploaded data,
+	// otherwise [ErrorCodeDigestMismatch] is returned and the session is
+	// discarded. On success the archive is attached to the version as with
+	// UploadArchive, the session is removed, and the updated version is
+	// returned.
+	CommitUpload(ctx context.Context, namespace string, resource string, version string, id string, digest string) (*Version, error)
+
+	// Discards a resumable upload.
+	//
+	// Removes the session and the data received so far. The operation is
+	// idempotent, returning success if the session does not exist.
+	CancelUpload(ctx context.Context, namespace string, resource string, version string, id string) error
+
 	// Creates a new channel.
 	//
 	// Channel names follow the same constraints as namespace names. The
@@ -149,7 +267,11 @@ type Registry interface {
 	// cannot be changed after creation. Returns an error if the channel does
 	// not exist. The response includes the updated channel's metadata with the
 	// full version object it points to.
-	UpdateChannel(ctx context.Context, namespace string, resource string, channel string, info ChannelInfo) (*Channel, error)
+	//
+	// The expected revision, if not [AnyRevision], must be the current revision
+	// of the channel, otherwise [ErrorCodePreconditionFailed] is returned and
+	// nothing is changed.
+	UpdateChannel(ctx context.Context, namespace string, resource string, channel string, info ChannelInfo, expected Revision) (*Channel, error)
 
 	// Retrieves channel metadata with full version details.
 	//
@@ -161,7 +283,11 @@ type Registry interface {
 	// Permanently deletes a channel.
 	//
 	// The operation is idempotent, returning success if the channel does not exist.
-	DeleteChannel(ctx context.Context, namespace string, resource string, channel string) error
+	//
+	// The expected revision, if not [AnyRevision], must be the current revision
+	// of the channel, otherwise [ErrorCodePreconditionFailed] is returned and
+	// nothing is deleted.
+	DeleteChannel(ctx context.Context, namespace string, resource string, channel string, expected Revision) error
 
 	// Lists all channels for a resource.
 	//
@@ -169,4 +295,28 @@ type Registry interface {
 	// and timestamps. The list is empty if the resource has no channels. If
 	// the namespace or resource does not exist, an error is returned.
 	ListChannels(ctx context.Context, namespace string, resource string) (*ChannelList, error)
+
+	// Lists the pointer changes of a channel, newest first.
+	//
+	// Every change of the version a channel points to is recorded with its
+	// time and the principal that made it. The history of a deleted channel
+	// remains available. The list is empty if the channel never existed.
+	ListChannelHistory(ctx context.Context, namespace string, resource string, channel string) (*ChannelHistory, error)
+
+	// Points a channel back at a version it pointed to before.
+	//
+	// The version must appear in the channel history. An empty version undoes
+	// the most recent change. The channel is moved atomically: if it is moved
+	// concurrently, the rollback fails rather than overwriting that change.
+	// The response includes the channel's metadata with the full version
+	// object it now points to.
+	RollbackChannel(ctx context.Context, namespace string, resource string, channel string, version string) (*Channel, error)
+
+	// Lists recorded mutations, newest first.
+	//
+	// Every successful mutation is recorded with the principal that made it,
+	// the target and a summary of the change. The filter selects events by
+	// target, actor, action and time range. Returns an error if the filter is
+	// invalid.
+	ListAuditEvents(ctx context.Context, filter AuditFilter) (*AuditEventList, error)
 }
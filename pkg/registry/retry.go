@@ -0,0 +1,165 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+
+	// Default number of attempts per request, including the first.
+	DefaultRetryAttempts = 4
+
+	// Default delay before the first retry.
+	DefaultRetryInitialBackoff = 200 * time.Millisecond
+
+	// Default upper bound on the delay between attempts.
+	DefaultRetryMaxBackoff = 10 * time.Second
+)
+
+// Controls how [Client] retries failed requests.
+//
+// Requests with idempotent methods (GET, HEAD, PUT, DELETE, OPTIONS) are
+// retried after transport errors and 502, 503 and 504 responses. Requests
+// with any method are retried after 429 and 503 responses, which signal that
+// the registry did not process them. Requests whose body cannot be rewound
+// are never retried. The zero value (and a nil *RetryPolicy) uses the
+// defaults.
+type RetryPolicy struct {
+
+	// Maximum number of attempts, including the first.
+	//
+	// Zero uses [DefaultRetryAttempts]. Set to 1 to disable retries.
+	MaxAttempts int
+
+	// Delay before the first retry.
+	//
+	// The delay doubles with every attempt, up to MaxBackoff, and is
+	// randomized to between half and all of its value so that clients do not
+	// retry in lockstep. Zero uses [DefaultRetryInitialBackoff].
+	InitialBackoff time.Duration
+
+	// Upper bound on the delay between attempts.
+	//
+	// A Retry-After header sent with a 429 or 503 response takes precedence
+	// when it asks for a longer delay, but is capped at MaxBackoff so that a
+	// misbehaving registry cannot stall the client. Zero uses
+	// [DefaultRetryMaxBackoff].
+	MaxBackoff time.Duration
+}
+
+// Returns the maximum number of attempts, applying the default.
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts <= 0 {
+		return DefaultRetryAttempts
+	}
+	return p.MaxAttempts
+}
+
+// Returns the delay before the first retry, applying the default.
+func (p *RetryPolicy) initialBackoff() time.Duration {
+	if p == nil || p.InitialBackoff <= 0 {
+		return DefaultRetryInitialBackoff
+	}
+	return p.InitialBackoff
+}
+
+// Returns the upper bound on the delay, applying the default.
+func (p *RetryPolicy) maxBackoff() time.Duration {
+	if p == nil || p.MaxBackoff <= 0 {
+		return DefaultRetryMaxBackoff
+	}
+	return p.MaxBackoff
+}
+
+// Returns the jittered delay before the given retry, counting from 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	d := p.initialBackoff()
+	for i := 1; i < retry && d < p.maxBackoff(); i++ {
+		d *= 2
+	}
+	d = min(d, p.maxBackoff())
+	return d/2 + rand.N(d/2+1)
+}
+
+// Returns the delay before the given retry, honoring a Retry-After delay up
+// to the upper bound.
+func (p *RetryPolicy) wait(retry int, after time.Duration) time.Duration {
+	return max(p.backoff(retry), min(after, p.maxBackoff()))
+}
+
+// Whether a request may be retried after the given outcome.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		return idempotent(req.Method) && req.Context().Err() == nil
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent(req.Method)
+	default:
+		return false
+	}
+}
+
+// Whether an HTTP method is idempotent.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns the delay requested by a Retry-After header, or zero.
+//
+// Both the delay-seconds and the HTTP-date forms are accepted.
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+
+	return 0
+}
+
+// Waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Discards and closes a response body so the connection can be reused.
+func discard(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
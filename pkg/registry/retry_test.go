@@ -0,0 +1,186 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Retry policy with short delays for tests.
+var testRetryPolicy = &RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", string(MediaTypeNamespace)+"+json")
+		w.Write([]byte(`{"name":"test","description":"","resources":[],"createdAt":0,"updatedAt":0}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, &ClientOptions{Retry: testRetryPolicy})
+	if _, err := client.ReadNamespace(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got: %d", attempts)
+	}
+}
+
+func TestClient_RetryStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		attempts int
+	}{
+		{"GET 503", "GET", http.StatusServiceUnavailable, 4},
+		{"POST 503", "POST", http.StatusServiceUnavailable, 4},
+		{"POST 429", "POST", http.StatusTooManyRequests, 4},
+		{"POST 502", "POST", http.StatusBadGateway, 1},
+		{"GET 500", "GET", http.StatusInternalServerError, 1},
+		{"GET 404", "GET", http.StatusNotFound, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client := NewClientWithOptions(server.URL, &ClientOptions{Retry: testRetryPolicy})
+			req, err := client.newRequest(context.Background(), tt.method, "/namespaces", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := client.do(req, nil); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if attempts != tt.attempts {
+				t.Fatalf("expected %d attempts, got: %d", tt.attempts, attempts)
+			}
+		})
+	}
+}
+
+func TestClient_RetryRewindsBody(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "archive.tar.zst")
+	if err := os.WriteFile(p, []byte("archive contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "archive contents" {
+			t.Errorf("attempt %d: expected full body, got %q", attempts, body)
+		}
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", string(MediaTypeVersion)+"+json")
+		w.Write([]byte(`{"namespace":"ns","resource":"res","string":"1.0.0","createdAt":0,"updatedAt":0}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, &ClientOptions{Retry: testRetryPolicy})
+	if _, err := client.UploadArchive(context.Background(), "ns", "res", "1.0.0", f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got: %d", attempts)
+	}
+}
+
+func TestClient_NoRetryForStreamedBody(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	body, w := io.Pipe()
+	go func() {
+		w.Write([]byte("archive contents"))
+		w.Close()
+	}()
+
+	client := NewClientWithOptions(server.URL, &ClientOptions{Retry: testRetryPolicy})
+	if _, err := client.UploadArchive(context.Background(), "ns", "res", "1.0.0", body); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected 1 attempt, got: %d", attempts)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"invalid", 0, 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.value != "" {
+			resp.Header.Set("Retry-After", tt.value)
+		}
+		if d := retryAfter(resp); d < tt.min || d > tt.max {
+			t.Errorf("retryAfter(%q) = %s, want between %s and %s", tt.value, d, tt.min, tt.max)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for retry, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		for range 20 {
+			d := policy.backoff(retry)
+			if d < want/2 || d > want {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", retry, d, want/2, want)
+			}
+		}
+	}
+}
+
+func TestRetryPolicy_Wait(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	if d := policy.wait(1, 500*time.Millisecond); d != 500*time.Millisecond {
+		t.Errorf("wait(1, 500ms) = %s, want 500ms", d)
+	}
+	if d := policy.wait(1, time.Hour); d != time.Second {
+		t.Errorf("wait(1, 1h) = %s, want Retry-After capped at 1s", d)
+	}
+	if d := policy.wait(1, 0); d < 50*time.Millisecond || d > 100*time.Millisecond {
+		t.Errorf("wait(1, 0) = %s, want between 50ms and 100ms", d)
+	}
+}
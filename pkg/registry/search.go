@@ -0,0 +1,191 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+const (
+
+	// Number of results returned when [SearchQuery.Limit] is zero.
+	DefaultSearchLimit = 20
+
+	// Maximum number of results returned by one query.
+	MaxSearchLimit = 100
+
+	// Maximum length of [SearchQuery.Text] in bytes.
+	MaxSearchTextLength = 256
+
+	// Channel selected by the [SearchQuery.Stable] facet.
+	StableChannel = "stable"
+
+	// Number of triggers keeping the full-text index in sync.
+	searchIndexTriggers = 3
+
+	// Search error messages
+	errMsgSearch              = "unable to search resources"
+	errMsgSearchIndex         = "unable to prepare full-text search index"
+	errMsgFullTextUnavailable = "full-text search unavailable, using substring matching"
+	errMsgSearchIndexStale    = "full-text index triggers missing, using substring matching"
+)
+
+// Searches resources across all namespaces.
+//
+// With an FTS5 index (see [DialectSQLite]), every word of the text matches
+// words in names and descriptions that start with it, and matches are ranked
+// by BM25 with names weighing more than descriptions. Otherwise every word
+// must appear as a substring of the name or the description, and matches are
+// ranked by how closely the name matches the text. Filtering, paging and facet
+// counts are done by the database, in one snapshot. Returns
+// [ErrorCodeBadRequest] if the query is invalid. A zero limit returns up to
+// [DefaultSearchLimit] results.
+func (r *SQLRegistry) Search(ctx context.Context, query SearchQuery) (*SearchResults, error) {
+	if err := validateSearchQuery(query); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultSearchLimit
+	}
+
+	var results *SearchResults
+	err := r.withSnapshot(ctx, func(tx *SQLRegistry) error {
+		var err error
+		if results, err = tx.searchResources(ctx, query); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgSearch, err, "text", query.Text)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, r.registryError(err, errMsgSearch, "text", query.Text)
+	}
+
+	return results, nil
+}
+
+// Returns the parameters of the type, namespace and stable filters of the
+// search queries.
+func searchFilters(query SearchQuery) (typ, namespace, stable []any) {
+	stableFlag := 0
+	if query.Stable {
+		stableFlag = 1
+	}
+	return []any{query.Type, query.Type}, []any{query.Namespace, query.Namespace}, []any{stableFlag, StableChannel}
+}
+
+// Inserts the query selecting the matches into a search query.
+func withMatches(query, matches string) string {
+	return strings.Replace(query, "{matches}", matches, 1)
+}
+
+// Splits search text into lowercase words.
+//
+// Words are runs of letters and digits, as split by the FTS5 unicode61
+// tokenizer, so both search strategies see the same words.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	})
+}
+
+// Returns the FTS5 query matching every word as a prefix.
+//
+// Words only contain letters and digits, so quoting them is enough to keep
+// FTS5 operators out of the query.
+func fullTextQuery(words []string) string {
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = `"` + w + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// Returns the query matching every word by substring, and its parameters.
+//
+// Completes sql/resources/search.sql with one condition per word. The text is
+// the lowercased search text, compared with names for scoring. Without words,
+// every resource matches.
+func substringMatches(text string, words []string) (string, []any) {
+	nameWords := []string{"1 = 1"}
+	conditions := []string{"1 = 1"}
+	args := []any{text, escapeLike(text) + "%"}
+
+	if len(words) > 0 {
+		nameWords = make([]string, len(words))
+		conditions = make([]string, len(words))
+		for i, w := range words {
+			nameWords[i] = "LOWER(name) LIKE ? ESCAPE '!'"
+			conditions[i] = "(LOWER(name) LIKE ? ESCAPE '!' OR LOWER(description) LIKE ? ESCAPE '!')"
+			args = append(args, containsPattern(w))
+		}
+		for _, w := range words {
+			args = append(args, containsPattern(w), containsPattern(w))
+		}
+	}
+
+	query := strings.NewReplacer(
+		"{name_words}", strings.Join(nameWords, " AND "),
+		"{words}", strings.Join(conditions, " AND "),
+	).Replace(sqlResourcesSearch)
+	return query, args
+}
+
+// Returns a LIKE pattern matching text anywhere, escaped with !.
+func containsPattern(text string) string {
+	return "%" + escapeLike(text) + "%"
+}
+
+// Escapes the LIKE wildcards in text with !.
+func escapeLike(text string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(text)
+}
+
+// Prepares the full-text index of resources, if the dialect may have one.
+//
+// Creates the SQLite FTS5 index and, if the triggers keeping it in sync are
+// missing, installs them and rebuilds the index in one transaction. If the
+// index cannot be created because SQLite lacks FTS5, the triggers are dropped
+// instead, since they would make every change to resources fail; they are
+// installed again, and the index rebuilt, by the next registry started with
+// FTS5. Until then, running registries with FTS5 find the triggers missing
+// and match substrings (see searchMatches). The index is set up here rather
+// than by a schema migration because it depends on how the driver was built,
+// not on the schema. Reports whether search can use the index. Returns an
+// error if the triggers cannot be installed or dropped.
+func prepareSearchIndex(ctx context.Context, db *sql.DB, dialect *Dialect, logger *slog.Logger) (bool, error) {
+	if !dialect.supportsFullText() {
+		return false, nil
+	}
+
+	if _, err := db.ExecContext(ctx, sqlResourceSearchCreate); err != nil {
+		logger.Info(errMsgFullTextUnavailable, "dialect", dialect, "reason", err)
+		if _, err := db.ExecContext(ctx, sqlResourceSearchUninstall); err != nil {
+			return false, logError(logger, ErrorCodeInternalError, errMsgSearchIndex, err)
+		}
+		return false, nil
+	}
+
+	var installed int
+	if err := db.QueryRowContext(ctx, sqlResourceSearchInstalled).Scan(&installed); err != nil {
+		return false, logError(logger, ErrorCodeInternalError, errMsgSearchIndex, err)
+	}
+	if installed == searchIndexTriggers {
+		return true, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, logError(logger, ErrorCodeInternalError, errMsgSearchIndex, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, sqlResourceSearchInstall); err != nil {
+		return false, logError(logger, ErrorCodeInternalError, errMsgSearchIndex, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, logError(logger, ErrorCodeInternalError, errMsgSearchIndex, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,355 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Creates a registry through its constructor, preparing the search index.
+func setupSearchRegistry(t *testing.T) *SQLRegistry {
+	t.Helper()
+
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "registry.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	registry, err := NewSQLRegistry(context.Background(), db, dir, logger)
+	if err != nil {
+		t.Fatalf("NewSQLRegistry() error = %v", err)
+	}
+	return registry
+}
+
+// Creates resources in two namespaces, two of them with a stable channel.
+func seedSearch(t *testing.T, registry *SQLRegistry) {
+	t.Helper()
+
+	ctx := context.Background()
+	resources := []struct {
+		namespace string
+		info      ResourceInfo
+		stable    bool
+	}{
+		{"acme", ResourceInfo{Name: "widget-core", Type: "widget", Description: "Core widget runtime"}, true},
+		{"acme", ResourceInfo{Name: "gateway", Type: "service", Description: "API gateway for widgets"}, false},
+		{"other", ResourceInfo{Name: "widget", Type: "widget", Description: "Plain widget"}, true},
+		{"other", ResourceInfo{Name: "db", Type: "service", Description: "Database"}, false},
+	}
+
+	for _, ns := range []string{"acme", "other"} {
+		if _, err := registry.CreateNamespace(ctx, NamespaceInfo{Name: ns}); err != nil {
+			t.Fatalf("CreateNamespace() error = %v", err)
+		}
+	}
+	for _, r := range resources {
+		if _, err := registry.CreateResource(ctx, r.namespace, r.info); err != nil {
+			t.Fatalf("CreateResource() error = %v", err)
+		}
+		if !r.stable {
+			continue
+		}
+		_, _ = registry.CreateVersion(ctx, r.namespace, r.info.Name, VersionInfo{String: "1.0.0"})
+		if _, err := registry.CreateChannel(ctx, r.namespace, r.info.Name, ChannelInfo{Name: StableChannel, Version: "1.0.0"}); err != nil {
+			t.Fatalf("CreateChannel() error = %v", err)
+		}
+	}
+}
+
+// Returns the namespace/name of each result.
+func resultNames(results *SearchResults) []string {
+	names := make([]string, len(results.Results))
+	for i, res := range results.Results {
+		names[i] = res.Namespace + "/" + res.Resource.Name
+	}
+	return names
+}
+
+func TestSearch(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+	seedSearch(t, registry)
+
+	ctx := context.Background()
+	results, err := registry.Search(ctx, SearchQuery{Text: "Widget"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	if got := strings.Join(resultNames(results), ","); got != "other/widget,acme/widget-core,acme/gateway" {
+		t.Errorf("unexpected results: %s", got)
+	}
+	if results.Total != 3 {
+		t.Errorf("Total = %d, want 3", results.Total)
+	}
+	first := results.Results[0]
+	if !first.Stable || first.Resource.VersionCount != 1 || first.Resource.LatestVersion == nil {
+		t.Errorf("unexpected result: %+v", first)
+	}
+	if got := results.Facets.Types; len(got) != 2 || got[0] != (FacetCount{"widget", 2}) || got[1] != (FacetCount{"service", 1}) {
+		t.Errorf("unexpected type facets: %+v", got)
+	}
+	if got := results.Facets.Namespaces; len(got) != 2 || got[0] != (FacetCount{"acme", 2}) || got[1] != (FacetCount{"other", 1}) {
+		t.Errorf("unexpected namespace facets: %+v", got)
+	}
+	if results.Facets.Stable != 2 {
+		t.Errorf("Facets.Stable = %d, want 2", results.Facets.Stable)
+	}
+}
+
+func TestSearch_Facets(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+	seedSearch(t, registry)
+
+	ctx := context.Background()
+	results, err := registry.Search(ctx, SearchQuery{Text: "widget", Type: "widget", Namespace: "acme"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if got := strings.Join(resultNames(results), ","); got != "acme/widget-core" {
+		t.Errorf("unexpected results: %s", got)
+	}
+
+	// Each facet is counted without its own selection
+	if got := results.Facets.Types; len(got) != 2 || got[0] != (FacetCount{"service", 1}) || got[1] != (FacetCount{"widget", 1}) {
+		t.Errorf("unexpected type facets: %+v", got)
+	}
+	if got := results.Facets.Namespaces; len(got) != 2 || got[0] != (FacetCount{"acme", 1}) || got[1] != (FacetCount{"other", 1}) {
+		t.Errorf("unexpected namespace facets: %+v", got)
+	}
+
+	results, err = registry.Search(ctx, SearchQuery{Stable: true})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if got := strings.Join(resultNames(results), ","); got != "acme/widget-core,other/widget" {
+		t.Errorf("unexpected stable results: %s", got)
+	}
+}
+
+func TestSearch_Words(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+	seedSearch(t, registry)
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"runtime core", "acme/widget-core"},
+		{"gateway api", "acme/gateway"},
+		{"widget database", ""},
+		{"100%", ""},
+		{"---", "acme/gateway,acme/widget-core,other/db,other/widget"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			results, err := registry.Search(context.Background(), SearchQuery{Text: tt.text})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if got := strings.Join(resultNames(results), ","); got != tt.want {
+				t.Errorf("results = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearch_Pagination(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+	seedSearch(t, registry)
+
+	ctx := context.Background()
+	results, err := registry.Search(ctx, SearchQuery{Text: "widget", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if got := strings.Join(resultNames(results), ","); got != "acme/widget-core" || results.Total != 3 {
+		t.Errorf("unexpected page: %s of %d", got, results.Total)
+	}
+
+	results, err = registry.Search(ctx, SearchQuery{Text: "widget", Offset: 10})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if results.Results == nil || len(results.Results) != 0 || results.Total != 3 {
+		t.Errorf("expected empty page of 3 results, got %+v", results)
+	}
+}
+
+func TestSearch_InvalidQuery(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name  string
+		query SearchQuery
+	}{
+		{"long text", SearchQuery{Text: strings.Repeat("a", MaxSearchTextLength+1)}},
+		{"invalid namespace", SearchQuery{Namespace: "Invalid Name"}},
+		{"negative limit", SearchQuery{Limit: -1}},
+		{"large limit", SearchQuery{Limit: MaxSearchLimit + 1}},
+		{"negative offset", SearchQuery{Offset: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := registry.Search(context.Background(), tt.query)
+			if regErr, ok := err.(*Error); !ok || regErr.Code != ErrorCodeBadRequest {
+				t.Errorf("expected bad request, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSearch_FullText(t *testing.T) {
+	registry := setupSearchRegistry(t)
+	if !registry.fullText {
+		t.Skip("SQLite built without FTS5; run with -tags sqlite_fts5")
+	}
+	seedSearch(t, registry)
+
+	ctx := context.Background()
+	results, err := registry.Search(ctx, SearchQuery{Text: "wid"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	names := resultNames(results)
+	if len(names) != 3 || names[2] != "acme/gateway" {
+		t.Errorf("expected description match to rank last, got %v", names)
+	}
+
+	// The index follows updates and deletes
+	_, _ = registry.UpdateResource(ctx, "other", "db", ResourceInfo{Name: "db", Type: "service", Description: "Widget catalog"}, AnyRevision)
+	_ = registry.DeleteResource(ctx, "acme", "gateway", AnyRevision)
+
+	results, _ = registry.Search(ctx, SearchQuery{Text: "catalog"})
+	if got := strings.Join(resultNames(results), ","); got != "other/db" {
+		t.Errorf("unexpected results after update: %s", got)
+	}
+	results, _ = registry.Search(ctx, SearchQuery{Text: "gateway"})
+	if len(results.Results) != 0 {
+		t.Errorf("expected deleted resource to be removed, got %v", resultNames(results))
+	}
+}
+
+func TestSearch_FullTextRebuild(t *testing.T) {
+	registry := setupSearchRegistry(t)
+	if !registry.fullText {
+		t.Skip("SQLite built without FTS5; run with -tags sqlite_fts5")
+	}
+
+	// Changes made while the triggers are missing are picked up by a rebuild
+	ctx := context.Background()
+	if _, err := registry.db.ExecContext(ctx, sqlResourceSearchUninstall); err != nil {
+		t.Fatal(err)
+	}
+	seedSearch(t, registry)
+
+	if _, err := prepareSearchIndex(ctx, registry.db, nil, registry.logger); err != nil {
+		t.Fatalf("prepareSearchIndex() error = %v", err)
+	}
+	results, err := registry.Search(ctx, SearchQuery{Text: "database"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if got := strings.Join(resultNames(results), ","); got != "other/db" {
+		t.Errorf("unexpected results: %s", got)
+	}
+}
+
+func TestSearch_FullTextStale(t *testing.T) {
+	registry := setupSearchRegistry(t)
+	if !registry.fullText {
+		t.Skip("SQLite built without FTS5; run with -tags sqlite_fts5")
+	}
+	seedSearch(t, registry)
+
+	// Another registry without FTS5 dropped the triggers, so the index is stale
+	ctx := context.Background()
+	if _, err := registry.db.ExecContext(ctx, sqlResourceSearchUninstall); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = registry.UpdateResource(ctx, "other", "db", ResourceInfo{Name: "db", Type: "service", Description: "Widget catalog"}, AnyRevision)
+
+	results, err := registry.Search(ctx, SearchQuery{Text: "catalog"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if got := strings.Join(resultNames(results), ","); got != "other/db" {
+		t.Errorf("expected substring matching while the triggers are missing, got %q", got)
+	}
+}
+
+func TestSearch_FullTextUnavailable(t *testing.T) {
+	registry := setupSearchRegistry(t)
+	if registry.fullText {
+		t.Skip("SQLite built with FTS5")
+	}
+
+	// A trigger left behind by a registry with FTS5 breaks resource changes
+	ctx := context.Background()
+	_, err := registry.db.ExecContext(ctx, `CREATE TRIGGER resource_search_insert AFTER INSERT ON resources
+BEGIN
+    INSERT INTO resource_search (namespace, resource, name, description) VALUES (new.namespace, new.name, new.name, new.description);
+END`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "acme"})
+	if _, err := registry.CreateResource(ctx, "acme", ResourceInfo{Name: "app", Type: "widget"}); err == nil {
+		t.Fatal("expected resource creation to fail while the trigger exists")
+	}
+
+	fullText, err := prepareSearchIndex(ctx, registry.db, nil, registry.logger)
+	if err != nil || fullText {
+		t.Fatalf("prepareSearchIndex() = %v, %v; want false, nil", fullText, err)
+	}
+	if _, err := registry.CreateResource(ctx, "acme", ResourceInfo{Name: "app", Type: "widget"}); err != nil {
+		t.Errorf("CreateResource() error = %v", err)
+	}
+}
+
+func TestClient_Search(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" {
+			t.Errorf("expected /search, got %s", r.URL.Path)
+		}
+		if got := r.URL.RawQuery; got != "limit=10&namespace=acme&offset=20&q=widget+runtime&stable=true&type=widget" {
+			t.Errorf("unexpected query: %s", got)
+		}
+		w.Header().Set("Content-Type", "application/vnd.crucible.search-results.v0+json")
+		w.Write([]byte(`{"results":[{"namespace":"acme","resource":{"name":"widget-core","type":"widget","description":"","latestVersion":"1.0.0","versionCount":1,"channelCount":1,"createdAt":1,"updatedAt":1},"stable":true,"score":3}],"total":21,"facets":{"types":[{"value":"widget","count":21}],"namespaces":[{"value":"acme","count":21}],"stable":21}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	results, err := client.Search(context.Background(), SearchQuery{
+		Text:      "widget runtime",
+		Type:      "widget",
+		Namespace: "acme",
+		Stable:    true,
+		Limit:     10,
+		Offset:    20,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results.Total != 21 || len(results.Results) != 1 || results.Results[0].Resource.Name != "widget-core" || !results.Results[0].Stable {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if len(results.Facets.Types) != 1 || results.Facets.Types[0].Count != 21 {
+		t.Errorf("unexpected facets: %+v", results.Facets)
+	}
+}
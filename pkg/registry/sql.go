@@ -2,7 +2,7 @@ package registry
 
 import "embed"
 
-//go:embed sql/*.sql sql/**/*.sql
+//go:embed sql/**/*.sql sql/migrations/*/*.sql
 var sqlFS embed.FS
 
 // Reads a SQL file from the embedded filesystem, panicking if it doesn't exist.
@@ -14,15 +14,44 @@ func mustReadSQL(path string) string {
 	return string(data)
 }
 
-// Schema definition for creating all registry tables.
+// Directory of the schema migrations, with one subdirectory per dialect.
+//
+// Each migration is a file named NNNN_description.sql, applied in order by
+// [migrate] and recorded in the schema_migrations table. Migrations are only
+// ever added; an applied migration is never edited. Every dialect has the same
+// migrations, with the same tables and constraints in its own types.
 //
 // All foreign key constraints use ON DELETE RESTRICT to prevent accidental
 // data loss. Deletion must be done bottom-up (channels first, then versions,
-// then resources, then namespaces).
+// then resources, then namespaces). Upload sessions, namespace memberships
+// and channel history are the exception: sessions are transient and are
+// deleted along with their version, memberships along with their namespace,
+// and channel history along with its resource. Channel history outlives the
+// channel itself.
+//
+// The audit log has no foreign keys, so its entries outlive the entities they
+// describe, and triggers reject any change to existing entries.
 //
 // Archive data is stored within the versions table as nullable columns (digest,
 // size, path), populated when an archive is uploaded via UploadArchive.
-var sqlSchema = mustReadSQL("sql/schema.sql")
+const sqlMigrationsDir = "sql/migrations"
+
+var (
+	sqlSchemaMigrationsCreate = mustReadSQL("sql/schema_migrations/create.sql") // Create migration tracking table
+	sqlSchemaMigrationsList   = mustReadSQL("sql/schema_migrations/list.sql")   // List applied migration versions
+	sqlSchemaMigrationsInsert = mustReadSQL("sql/schema_migrations/insert.sql") // Record applied migration
+)
+
+var (
+	sqlMigrationLockCreate = mustReadSQL("sql/schema_migrations_lock/create.sql") // Create migration lock table
+	sqlMigrationLockInsert = mustReadSQL("sql/schema_migrations_lock/insert.sql") // Take migration lock
+	sqlMigrationLockDelete = mustReadSQL("sql/schema_migrations_lock/delete.sql") // Release migration lock
+	sqlMigrationLockExpire = mustReadSQL("sql/schema_migrations_lock/expire.sql") // Break abandoned migration lock
+)
+
+var (
+	sqlWriteLockReserve = mustReadSQL("sql/write_lock/reserve.sql") // Take database write lock
+)
 
 var (
 	sqlNamespacesInsert = mustReadSQL("sql/namespaces/insert.sql") // Insert new namespace
@@ -32,27 +61,92 @@ var (
 	sqlNamespacesDelete = mustReadSQL("sql/namespaces/delete.sql") // Delete namespace (requires no resources)
 )
 
+var (
+	sqlMembersInsert = mustReadSQL("sql/members/insert.sql") // Add member to namespace
+	sqlMembersGet    = mustReadSQL("sql/members/get.sql")    // Get member details
+	sqlMembersList   = mustReadSQL("sql/members/list.sql")   // List members of namespace
+	sqlMembersUpdate = mustReadSQL("sql/members/update.sql") // Change member role
+	sqlMembersDelete = mustReadSQL("sql/members/delete.sql") // Remove member from namespace
+)
+
 var (
 	sqlResourcesInsert = mustReadSQL("sql/resources/insert.sql") // Insert new resource
 	sqlResourcesGet    = mustReadSQL("sql/resources/get.sql")    // Get resource details
 	sqlResourcesList   = mustReadSQL("sql/resources/list.sql")   // List resources in namespace
 	sqlResourcesUpdate = mustReadSQL("sql/resources/update.sql") // Update resource metadata
 	sqlResourcesDelete = mustReadSQL("sql/resources/delete.sql") // Delete resource (requires no versions)
+	sqlResourcesSearch = mustReadSQL("sql/resources/search.sql") // Match resources by substring
+)
+
+var (
+	sqlResourceSearchCreate    = mustReadSQL("sql/resource_search/create.sql")    // Create full-text index (SQLite FTS5)
+	sqlResourceSearchInstalled = mustReadSQL("sql/resource_search/installed.sql") // Count full-text index triggers
+	sqlResourceSearchInstall   = mustReadSQL("sql/resource_search/install.sql")   // Install triggers and rebuild index
+	sqlResourceSearchUninstall = mustReadSQL("sql/resource_search/uninstall.sql") // Drop full-text index triggers
+	sqlResourceSearchMatch     = mustReadSQL("sql/resource_search/match.sql")     // Match resources with full-text index
+)
+
+var (
+	sqlResourceSearchResults    = mustReadSQL("sql/resource_search/results.sql")    // List a page of search results
+	sqlResourceSearchCount      = mustReadSQL("sql/resource_search/count.sql")      // Count search results
+	sqlResourceSearchTypes      = mustReadSQL("sql/resource_search/types.sql")      // Count search results by type
+	sqlResourceSearchNamespaces = mustReadSQL("sql/resource_search/namespaces.sql") // Count search results by namespace
+)
+
+var (
+	sqlVersionsInsert = mustReadSQL("sql/versions/insert.sql")      // Insert new version (archive fields NULL)
+	sqlVersionsGet    = mustReadSQL("sql/versions/get.sql")         // Get version with archive details
+	sqlVersionsList   = mustReadSQL("sql/versions/list.sql")        // List versions for resource
+	sqlVersionsUpdate = mustReadSQL("sql/versions/update.sql")      // Update version metadata
+	sqlVersionsUpload = mustReadSQL("sql/versions/upload.sql")      // Update version with archive metadata
+	sqlVersionsDelete = mustReadSQL("sql/versions/delete.sql")      // Delete version (requires no channels)
+	sqlVersionsPaths  = mustReadSQL("sql/versions/paths.sql")       // List archive files of all versions
+	sqlVersionsRefs   = mustReadSQL("sql/versions/referencing.sql") // Count versions stored in archive file
+)
+
+var (
+	sqlChannelsInsert  = mustReadSQL("sql/channels/insert.sql")  // Insert new channel
+	sqlChannelsGet     = mustReadSQL("sql/channels/get.sql")     // Get channel with version details
+	sqlChannelsList    = mustReadSQL("sql/channels/list.sql")    // List channels for resource
+	sqlChannelsUpdate  = mustReadSQL("sql/channels/update.sql")  // Update channel metadata
+	sqlChannelsDelete  = mustReadSQL("sql/channels/delete.sql")  // Delete channel
+	sqlChannelsVersion = mustReadSQL("sql/channels/version.sql") // Get version channel points to
+	sqlChannelsMove    = mustReadSQL("sql/channels/move.sql")    // Move channel if unchanged
+)
+
+var (
+	sqlChannelHistoryInsert = mustReadSQL("sql/channel_history/insert.sql") // Record channel pointer change
+	sqlChannelHistoryList   = mustReadSQL("sql/channel_history/list.sql")   // List pointer changes of channel
+	sqlChannelHistoryLatest = mustReadSQL("sql/channel_history/latest.sql") // Get latest pointer change of channel
+)
+
+var (
+	sqlUploadsInsert = mustReadSQL("sql/uploads/insert.sql") // Insert new upload session
+	sqlUploadsGet    = mustReadSQL("sql/uploads/get.sql")    // Get upload session
+	sqlUploadsUpdate = mustReadSQL("sql/uploads/update.sql") // Advance upload session offset
+	sqlUploadsDelete = mustReadSQL("sql/uploads/delete.sql") // Delete upload session
+	sqlUploadsClaim  = mustReadSQL("sql/uploads/claim.sql")  // Lock upload session for transaction
+	sqlUploadsPaths  = mustReadSQL("sql/uploads/paths.sql")  // List partial files of all upload sessions
+)
+
+var (
+	sqlAuditInsert = mustReadSQL("sql/audit/insert.sql") // Append audit event
+	sqlAuditList   = mustReadSQL("sql/audit/list.sql")   // List audit events with filters
+)
+
+var (
+	sqlWebhookDeliveriesInsert = mustReadSQL("sql/webhook_deliveries/insert.sql") // Record webhook delivery
+	sqlWebhookDeliveriesList   = mustReadSQL("sql/webhook_deliveries/list.sql")   // List webhook deliveries with filters
 )
 
 var (
-	sqlVersionsInsert = mustReadSQL("sql/versions/insert.sql") // Insert new version (archive fields NULL)
-	sqlVersionsGet    = mustReadSQL("sql/versions/get.sql")    // Get version with archive details
-	sqlVersionsList   = mustReadSQL("sql/versions/list.sql")   // List versions for resource
-	sqlVersionsUpdate = mustReadSQL("sql/versions/update.sql") // Update version metadata
-	sqlVersionsUpload = mustReadSQL("sql/versions/upload.sql") // Update version with archive metadata
-	sqlVersionsDelete = mustReadSQL("sql/versions/delete.sql") // Delete version (requires no channels)
+	sqlTokensInsert = mustReadSQL("sql/tokens/insert.sql") // Store new token digest
+	sqlTokensGet    = mustReadSQL("sql/tokens/get.sql")    // Get token subject and expiry
+	sqlTokensDelete = mustReadSQL("sql/tokens/delete.sql") // Revoke token
 )
 
 var (
-	sqlChannelsInsert = mustReadSQL("sql/channels/insert.sql") // Insert new channel
-	sqlChannelsGet    = mustReadSQL("sql/channels/get.sql")    // Get channel with version details
-	sqlChannelsList   = mustReadSQL("sql/channels/list.sql")   // List channels for resource
-	sqlChannelsUpdate = mustReadSQL("sql/channels/update.sql") // Update channel metadata
-	sqlChannelsDelete = mustReadSQL("sql/channels/delete.sql") // Delete channel
+	sqlRolesInsert = mustReadSQL("sql/roles/insert.sql") // Grant role to subject
+	sqlRolesList   = mustReadSQL("sql/roles/list.sql")   // List roles of subject
+	sqlRolesDelete = mustReadSQL("sql/roles/delete.sql") // Revoke role from subject
 )
@@ -0,0 +1,3 @@
+-- Appends an event to the audit log.
+INSERT INTO audit_log (actor, action, namespace, resource, target, "before", after, digest, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
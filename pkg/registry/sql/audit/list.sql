@@ -0,0 +1,25 @@
+-- Lists audit events, newest first.
+--
+-- Each filter is passed twice; an empty string (or zero for timestamps)
+-- disables it. The time range includes the start and excludes the end.
+SELECT
+    id,
+    actor,
+    action,
+    namespace,
+    resource,
+    target,
+    "before",
+    after,
+    digest,
+    created_at
+FROM audit_log
+WHERE (? = '' OR namespace = ?)
+  AND (? = '' OR resource = ?)
+  AND (? = '' OR target = ?)
+  AND (? = '' OR actor = ?)
+  AND (? = '' OR action = ?)
+  AND (? = 0 OR created_at >= ?)
+  AND (? = 0 OR created_at < ?)
+ORDER BY id DESC
+LIMIT ?;
@@ -0,0 +1,3 @@
+-- Records a change of the version a channel points to.
+INSERT INTO channel_history (namespace, resource, channel, version, previous, actor, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
@@ -0,0 +1,11 @@
+-- Retrieves the most recent pointer change of a channel.
+SELECT
+    id,
+    version,
+    previous,
+    actor,
+    created_at
+FROM channel_history
+WHERE namespace = ? AND resource = ? AND channel = ?
+ORDER BY id DESC
+LIMIT 1;
@@ -0,0 +1,10 @@
+-- Lists the pointer changes of a channel, newest first.
+SELECT
+    id,
+    version,
+    previous,
+    actor,
+    created_at
+FROM channel_history
+WHERE namespace = ? AND resource = ? AND channel = ?
+ORDER BY id DESC;
@@ -1,5 +1,8 @@
 -- Deletes a channel.
 --
 -- Does not delete the channel's version or archives, only the channel metadata.
+--
+-- Unless the expected revision is NULL, only deletes the channel at that
+-- revision.
 DELETE FROM channels
-WHERE namespace = ? AND resource = ? AND name = ?;
+WHERE namespace = ? AND resource = ? AND name = ? AND revision = COALESCE(?, revision);
@@ -5,11 +5,13 @@ SELECT
     channels.version,
     channels.created_at,
     channels.updated_at,
+    channels.revision,
     versions.created_at as version_created_at,
     versions.updated_at as version_updated_at,
     versions.digest,
     versions.size,
-    versions.path
+    versions.path,
+    versions.revision as version_revision
 FROM channels
 INNER JOIN versions ON versions.namespace = channels.namespace AND versions.resource = channels.resource AND versions.string = channels.version
 WHERE channels.namespace = ? AND channels.resource = ? AND channels.name = ?;
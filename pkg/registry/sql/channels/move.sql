@@ -0,0 +1,6 @@
+-- Points a channel at another version, if it still points at the expected one.
+--
+-- Affects no rows if the channel was moved concurrently.
+UPDATE channels
+SET version = ?, updated_at = ?, revision = revision + 1
+WHERE namespace = ? AND resource = ? AND name = ? AND version = ?;
@@ -1,4 +1,7 @@
 -- Updates an existing channel to point to a different version.
+--
+-- Affects no rows if the channel does not exist or, unless the expected
+-- revision is NULL, has another revision.
 UPDATE channels
-SET description = ?, version = ?, updated_at = ?
-WHERE namespace = ? AND resource = ? AND name = ?;
+SET description = ?, version = ?, updated_at = ?, revision = revision + 1
+WHERE namespace = ? AND resource = ? AND name = ? AND revision = COALESCE(?, revision);
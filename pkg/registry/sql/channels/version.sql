@@ -0,0 +1,4 @@
+-- Retrieves the version a channel points to.
+SELECT version
+FROM channels
+WHERE namespace = ? AND resource = ? AND name = ?;
@@ -0,0 +1,6 @@
+-- Removes a member from a namespace.
+--
+-- Unless the expected revision is NULL, only deletes the member at that
+-- revision.
+DELETE FROM members
+WHERE namespace = ? AND subject = ? AND revision = COALESCE(?, revision);
@@ -0,0 +1,9 @@
+-- Retrieves a member of a namespace.
+SELECT
+    subject,
+    role,
+    created_at,
+    updated_at,
+    revision
+FROM members
+WHERE namespace = ? AND subject = ?;
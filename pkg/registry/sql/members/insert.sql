@@ -0,0 +1,3 @@
+-- Adds a member to a namespace.
+INSERT INTO members (namespace, subject, role, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?);
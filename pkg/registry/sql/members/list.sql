@@ -0,0 +1,9 @@
+-- Lists all members of a namespace.
+SELECT
+    subject,
+    role,
+    created_at,
+    updated_at
+FROM members
+WHERE namespace = ?
+ORDER BY subject;
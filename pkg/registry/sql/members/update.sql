@@ -0,0 +1,7 @@
+-- Changes the role of a member.
+--
+-- Affects no rows if the member does not exist or, unless the expected
+-- revision is NULL, has another revision.
+UPDATE members
+SET role = ?, updated_at = ?, revision = revision + 1
+WHERE namespace = ? AND subject = ? AND revision = COALESCE(?, revision);
@@ -0,0 +1,124 @@
+-- Initial schema.
+--
+-- Tables are only created if missing, so databases created before migrations
+-- were introduced are adopted as version 1.
+
+CREATE TABLE IF NOT EXISTS namespaces (
+    name        VARCHAR(255) NOT NULL, -- Namespace identifier.
+    description TEXT NOT NULL,        -- Human-readable description.
+    created_at  BIGINT NOT NULL,      -- Unix timestamp when first cached.
+    updated_at  BIGINT NOT NULL,      -- Unix timestamp when last updated.
+    PRIMARY KEY (name)
+);
+
+CREATE TABLE IF NOT EXISTS members (
+    namespace   VARCHAR(255) NOT NULL, -- Parent namespace.
+    subject     VARCHAR(255) NOT NULL, -- Principal holding the membership.
+    role        VARCHAR(255) NOT NULL, -- Role within the namespace (admin, publisher, reader).
+    created_at  BIGINT NOT NULL,      -- Unix timestamp when the member was added.
+    updated_at  BIGINT NOT NULL,      -- Unix timestamp when the role last changed.
+    PRIMARY KEY (namespace, subject),
+    FOREIGN KEY (namespace) REFERENCES namespaces(name) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS resources (
+    namespace   VARCHAR(255) NOT NULL, -- Parent namespace.
+    name        VARCHAR(255) NOT NULL, -- Resource name.
+    type        VARCHAR(255) NOT NULL, -- Resource type.
+    description TEXT NOT NULL,        -- Human-readable description.
+    created_at  BIGINT NOT NULL,      -- Unix timestamp when first cached.
+    updated_at  BIGINT NOT NULL,      -- Unix timestamp when last updated.
+    PRIMARY KEY (namespace, name),
+    FOREIGN KEY (namespace) REFERENCES namespaces(name) ON DELETE RESTRICT
+);
+
+CREATE TABLE IF NOT EXISTS versions (
+    namespace    VARCHAR(255) NOT NULL, -- Parent namespace.
+    resource     VARCHAR(255) NOT NULL, -- Parent resource name.
+    string       VARCHAR(255) NOT NULL, -- Semantic version string.
+    digest       TEXT,                 -- Archive content digest (NULL until uploaded).
+    size         BIGINT,               -- Archive size in bytes (NULL until uploaded).
+    path         TEXT,                 -- Filesystem path to archive file (NULL until uploaded).
+    created_at   BIGINT NOT NULL,      -- Unix timestamp when first cached.
+    updated_at   BIGINT NOT NULL,      -- Unix timestamp when last updated.
+    PRIMARY KEY (namespace, resource, string),
+    FOREIGN KEY (namespace, resource) REFERENCES resources (namespace, name) ON DELETE RESTRICT
+);
+
+CREATE TABLE IF NOT EXISTS channels (
+    namespace   VARCHAR(255) NOT NULL, -- Parent namespace.
+    resource    VARCHAR(255) NOT NULL, -- Parent resource name.
+    name        VARCHAR(255) NOT NULL, -- Channel name.
+    description TEXT NOT NULL,        -- Human-readable description.
+    version     VARCHAR(255) NOT NULL, -- Version this channel points to.
+    created_at  BIGINT NOT NULL,      -- Unix timestamp when first cached.
+    updated_at  BIGINT NOT NULL,      -- Unix timestamp when last updated.
+    PRIMARY KEY (namespace, resource, name),
+    FOREIGN KEY (namespace, resource) REFERENCES resources (namespace, name) ON DELETE RESTRICT,
+    FOREIGN KEY (namespace, resource, version) REFERENCES versions (namespace, resource, string) ON DELETE RESTRICT
+);
+
+CREATE TABLE IF NOT EXISTS channel_history (
+    id          BIGINT NOT NULL AUTO_INCREMENT, -- Monotonic entry identifier.
+    namespace   VARCHAR(255) NOT NULL, -- Parent namespace.
+    resource    VARCHAR(255) NOT NULL, -- Parent resource name.
+    channel     VARCHAR(255) NOT NULL, -- Channel name.
+    version     VARCHAR(255) NOT NULL, -- Version the channel was pointed at.
+    previous    TEXT,                 -- Version the channel pointed at before (NULL when created).
+    actor       TEXT NOT NULL,        -- Subject of the principal (empty if anonymous).
+    created_at  BIGINT NOT NULL,      -- Unix timestamp of the change.
+    PRIMARY KEY (id),
+    INDEX channel_history_channel (namespace, resource, channel, id),
+    FOREIGN KEY (namespace, resource) REFERENCES resources (namespace, name) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS uploads (
+    id          VARCHAR(255) NOT NULL, -- Upload session identifier.
+    namespace   VARCHAR(255) NOT NULL, -- Parent namespace.
+    resource    VARCHAR(255) NOT NULL, -- Parent resource name.
+    version     VARCHAR(255) NOT NULL, -- Version the archive is uploaded for.
+    `offset`    BIGINT NOT NULL,      -- Bytes received and persisted so far.
+    path        TEXT NOT NULL,        -- Filesystem path to the partial upload file.
+    created_at  BIGINT NOT NULL,      -- Unix timestamp when the session was started.
+    updated_at  BIGINT NOT NULL,      -- Unix timestamp when the last chunk was received.
+    PRIMARY KEY (id),
+    FOREIGN KEY (namespace, resource, version) REFERENCES versions (namespace, resource, string) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS tokens (
+    hash        VARCHAR(255) NOT NULL, -- Digest of the token ("sha256:..."); tokens are never stored.
+    subject     VARCHAR(255) NOT NULL, -- Principal the token authenticates.
+    description TEXT NOT NULL,        -- Human-readable description.
+    created_at  BIGINT NOT NULL,      -- Unix timestamp when the token was issued.
+    expires_at  BIGINT,               -- Unix timestamp when the token expires (NULL if it never expires).
+    PRIMARY KEY (hash)
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    subject     VARCHAR(255) NOT NULL, -- Principal the role is granted to.
+    role        VARCHAR(255) NOT NULL, -- Role name.
+    created_at  BIGINT NOT NULL,      -- Unix timestamp when the role was granted.
+    PRIMARY KEY (subject, role)
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id          BIGINT NOT NULL AUTO_INCREMENT, -- Monotonic event identifier.
+    actor       TEXT NOT NULL,        -- Subject of the principal (empty if anonymous).
+    action      TEXT NOT NULL,        -- Mutation performed (e.g., "channel:update").
+    namespace   VARCHAR(255) NOT NULL, -- Namespace of the target.
+    resource    VARCHAR(255) NOT NULL, -- Resource of the target (empty for namespace-level events).
+    target      VARCHAR(255) NOT NULL, -- Version, channel or member subject (empty if the target is the namespace or resource).
+    `before`    TEXT NOT NULL,        -- JSON summary of the target before the change (empty for creations).
+    after       TEXT NOT NULL,        -- JSON summary of the target after the change (empty for deletions).
+    digest      TEXT,                 -- Archive digest involved in the change, if any.
+    created_at  BIGINT NOT NULL,      -- Unix timestamp when the change was made.
+    PRIMARY KEY (id),
+    INDEX audit_log_target (namespace, resource, target, created_at)
+);
+
+-- Audit entries are never changed or removed, not even by the registry.
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit log is append-only';
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit log is append-only';
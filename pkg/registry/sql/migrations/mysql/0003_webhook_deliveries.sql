@@ -0,0 +1,19 @@
+-- Webhook delivery log.
+--
+-- One row per event and webhook, written once the event is delivered or the
+-- dispatcher gives up on it. An empty error means the webhook accepted the
+-- event. Like the audit log, deliveries have no foreign keys.
+
+CREATE TABLE webhook_deliveries (
+    id           BIGINT NOT NULL AUTO_INCREMENT, -- Monotonic delivery identifier.
+    event_id     VARCHAR(255) NOT NULL, -- Identifier of the event delivered.
+    event_type   TEXT NOT NULL,        -- Type of the event delivered (e.g., "channel.moved").
+    url          TEXT NOT NULL,        -- URL of the webhook.
+    attempts     INT NOT NULL,         -- Number of requests sent.
+    status_code  INT NOT NULL,         -- Status of the last response, or 0 if none was received.
+    last_error   TEXT NOT NULL,        -- Why the last attempt failed (empty if delivered).
+    created_at   BIGINT NOT NULL,      -- Unix timestamp of the first attempt.
+    completed_at BIGINT NOT NULL,      -- Unix timestamp when the last attempt ended.
+    PRIMARY KEY (id),
+    INDEX webhook_deliveries_event (event_id)
+);
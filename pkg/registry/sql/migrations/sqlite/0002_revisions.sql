@@ -0,0 +1,12 @@
+-- Entity revisions.
+--
+-- Every namespace, member, resource, version and channel carries a revision,
+-- starting at 1 and incremented by every update. Updates and deletes compare
+-- it with the revision the caller expects to detect concurrent changes.
+-- Existing rows start at revision 1.
+
+ALTER TABLE namespaces ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE members ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE resources ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE versions ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE channels ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
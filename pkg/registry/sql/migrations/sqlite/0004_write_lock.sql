@@ -0,0 +1,10 @@
+-- Table written first by the write transactions of databases locked as a
+-- whole (SQLite), so that they take the write lock before reading.
+--
+-- It never holds rows. Other dialects lock rows as they read them and leave
+-- the table unused; it exists in every dialect to keep the schemas alike.
+
+CREATE TABLE write_lock (
+    id INTEGER NOT NULL, -- Unused; the table stays empty.
+    PRIMARY KEY (id)
+);
@@ -2,5 +2,8 @@
 --
 -- Fails with foreign key constraint violation if the namespace contains any
 -- resources. Resources must be deleted first.
+--
+-- Unless the expected revision is NULL, only deletes the namespace at that
+-- revision.
 DELETE FROM namespaces
-WHERE name = ?;
+WHERE name = ? AND revision = COALESCE(?, revision);
@@ -3,6 +3,7 @@ SELECT
     name,
     description,
     created_at,
-    updated_at
+    updated_at,
+    revision
 FROM namespaces
 WHERE name = ?;
@@ -1,4 +1,7 @@
 -- Updates an existing namespace's metadata.
+--
+-- Affects no rows if the namespace does not exist or, unless the expected
+-- revision is NULL, has another revision.
 UPDATE namespaces
-SET description = ?, updated_at = ?
-WHERE name = ?;
+SET description = ?, updated_at = ?, revision = revision + 1
+WHERE name = ? AND revision = COALESCE(?, revision);
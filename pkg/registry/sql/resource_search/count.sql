@@ -0,0 +1,14 @@
+-- Counts search matches.
+--
+-- The matches and filters are those of sql/resource_search/results.sql.
+-- Parameters are those of the matches, then the type, namespace and stable
+-- filters.
+SELECT COUNT(*)
+FROM ({matches}) AS matches
+JOIN resources ON resources.namespace = matches.namespace AND resources.name = matches.resource
+WHERE (? = '' OR resources.type = ?)
+  AND (? = '' OR resources.namespace = ?)
+  AND (? = 0 OR EXISTS (
+      SELECT 1 FROM channels AS stable
+      WHERE stable.namespace = resources.namespace AND stable.resource = resources.name AND stable.name = ?
+  ));
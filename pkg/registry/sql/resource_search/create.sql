@@ -0,0 +1,12 @@
+-- Creates the SQLite FTS5 index of resource names and descriptions.
+--
+-- The index keeps its own copy of the indexed text, keyed by the unindexed
+-- namespace and resource columns, so it does not depend on the rowids of the
+-- resources table. Fails if SQLite was built without FTS5, in which case
+-- search falls back to substring matching.
+CREATE VIRTUAL TABLE IF NOT EXISTS resource_search USING fts5(
+    namespace UNINDEXED,
+    resource UNINDEXED,
+    name,
+    description
+);
@@ -0,0 +1,27 @@
+-- Installs the triggers that keep the full-text index in sync with resources
+-- and rebuilds the index from the resources table.
+--
+-- Run whenever the triggers are missing, since resources may have changed
+-- while they were dropped.
+CREATE TRIGGER IF NOT EXISTS resource_search_insert AFTER INSERT ON resources
+BEGIN
+    INSERT INTO resource_search (namespace, resource, name, description)
+    VALUES (new.namespace, new.name, new.name, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS resource_search_update AFTER UPDATE ON resources
+BEGIN
+    DELETE FROM resource_search WHERE namespace = old.namespace AND resource = old.name;
+    INSERT INTO resource_search (namespace, resource, name, description)
+    VALUES (new.namespace, new.name, new.name, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS resource_search_delete AFTER DELETE ON resources
+BEGIN
+    DELETE FROM resource_search WHERE namespace = old.namespace AND resource = old.name;
+END;
+
+DELETE FROM resource_search;
+
+INSERT INTO resource_search (namespace, resource, name, description)
+SELECT namespace, name, name, description FROM resources;
@@ -0,0 +1,5 @@
+-- Counts the triggers that keep the full-text index in sync with resources.
+SELECT COUNT(*)
+FROM sqlite_master
+WHERE type = 'trigger'
+  AND name IN ('resource_search_insert', 'resource_search_update', 'resource_search_delete');
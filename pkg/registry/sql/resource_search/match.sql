@@ -0,0 +1,12 @@
+-- Matches resources in every namespace with the full-text index.
+--
+-- Matches an FTS5 query against resource names and descriptions and scores
+-- the matches by BM25, weighting the name four times as much as the
+-- description. Higher scores rank first. Selects the matches of the search
+-- queries (see sql/resource_search/results.sql). The parameter is the FTS5 query.
+SELECT
+    namespace,
+    resource,
+    -bm25(resource_search, 0.0, 0.0, 4.0, 1.0) AS score
+FROM resource_search
+WHERE resource_search MATCH ?
@@ -0,0 +1,15 @@
+-- Counts search matches by namespace, most frequent first.
+--
+-- The matches and filters are those of sql/resource_search/results.sql,
+-- without the namespace filter. Parameters are those of the matches, then the
+-- type and stable filters.
+SELECT resources.namespace, COUNT(*)
+FROM ({matches}) AS matches
+JOIN resources ON resources.namespace = matches.namespace AND resources.name = matches.resource
+WHERE (? = '' OR resources.type = ?)
+  AND (? = 0 OR EXISTS (
+      SELECT 1 FROM channels AS stable
+      WHERE stable.namespace = resources.namespace AND stable.resource = resources.name AND stable.name = ?
+  ))
+GROUP BY resources.namespace
+ORDER BY COUNT(*) DESC, resources.namespace;
@@ -0,0 +1,35 @@
+-- Lists a page of search matches with summary statistics, best first.
+--
+-- The matches are selected by sql/resource_search/match.sql or
+-- sql/resources/search.sql, inserted by withMatches. Statistics come from
+-- subqueries rather than a GROUP BY, which SQLite cannot combine with the
+-- bm25 function of the full-text matches. Each filter is passed twice; an
+-- empty string (or zero for the stable filter, followed by the name of the
+-- stable channel) disables it. Parameters are the name of the stable
+-- channel, those of the matches, the type, namespace and stable filters, the
+-- limit and the offset.
+SELECT
+    resources.namespace,
+    resources.name,
+    resources.type,
+    resources.description,
+    resources.created_at,
+    resources.updated_at,
+    (SELECT COUNT(*) FROM versions WHERE versions.namespace = resources.namespace AND versions.resource = resources.name) as version_count,
+    (SELECT COUNT(*) FROM channels WHERE channels.namespace = resources.namespace AND channels.resource = resources.name) as channel_count,
+    (SELECT MAX(versions.string) FROM versions WHERE versions.namespace = resources.namespace AND versions.resource = resources.name) as latest_version,
+    CASE WHEN EXISTS (
+        SELECT 1 FROM channels
+        WHERE channels.namespace = resources.namespace AND channels.resource = resources.name AND channels.name = ?
+    ) THEN 1 ELSE 0 END as stable,
+    matches.score
+FROM ({matches}) AS matches
+JOIN resources ON resources.namespace = matches.namespace AND resources.name = matches.resource
+WHERE (? = '' OR resources.type = ?)
+  AND (? = '' OR resources.namespace = ?)
+  AND (? = 0 OR EXISTS (
+      SELECT 1 FROM channels AS stable
+      WHERE stable.namespace = resources.namespace AND stable.resource = resources.name AND stable.name = ?
+  ))
+ORDER BY matches.score DESC, resources.namespace, resources.name
+LIMIT ? OFFSET ?;
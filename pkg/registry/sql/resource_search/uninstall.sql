@@ -0,0 +1,8 @@
+-- Drops the triggers that keep the full-text index in sync with resources.
+--
+-- Run when FTS5 is unavailable, since the triggers would otherwise make
+-- every change to resources fail. The index is rebuilt when the triggers are
+-- installed again.
+DROP TRIGGER IF EXISTS resource_search_insert;
+DROP TRIGGER IF EXISTS resource_search_update;
+DROP TRIGGER IF EXISTS resource_search_delete;
@@ -2,5 +2,8 @@
 --
 -- Fails with foreign key constraint violation if the resource contains any
 -- versions. Versions must be deleted first.
+--
+-- Unless the expected revision is NULL, only deletes the resource at that
+-- revision.
 DELETE FROM resources
-WHERE namespace = ? AND name = ?;
+WHERE namespace = ? AND name = ? AND revision = COALESCE(?, revision);
@@ -5,6 +5,7 @@ SELECT
     type,
     description,
     created_at,
-    updated_at
+    updated_at,
+    revision
 FROM resources
 WHERE namespace = ? AND name = ?;
@@ -0,0 +1,22 @@
+-- Matches resources in every namespace by substring.
+--
+-- Portable fallback for databases without a full-text index. Matches
+-- resources whose lowercased name or description contains every word, and
+-- scores them by how closely the name matches the text: 4 for the exact name,
+-- 3 for a name starting with the text, 2 for a name containing every word
+-- and 1 otherwise. Selects the matches of the search queries (see
+-- sql/resource_search/results.sql). The conditions on the words are inserted
+-- by substringMatches, each word given as a LIKE pattern with ! as the escape
+-- character. Parameters are the text, the text as a prefix pattern, each word
+-- pattern once, then each word pattern twice.
+SELECT
+    namespace,
+    name AS resource,
+    CASE
+        WHEN LOWER(name) = ? THEN 4
+        WHEN LOWER(name) LIKE ? ESCAPE '!' THEN 3
+        WHEN {name_words} THEN 2
+        ELSE 1
+    END AS score
+FROM resources
+WHERE {words}
@@ -1,4 +1,7 @@
 -- Updates an existing resource's mutable fields.
+--
+-- Affects no rows if the resource does not exist or, unless the expected
+-- revision is NULL, has another revision.
 UPDATE resources
-SET type = ?, description = ?, updated_at = ?
-WHERE namespace = ? AND name = ?;
+SET type = ?, description = ?, updated_at = ?, revision = revision + 1
+WHERE namespace = ? AND name = ? AND revision = COALESCE(?, revision);
@@ -0,0 +1,3 @@
+-- Revokes a role from a subject.
+DELETE FROM roles
+WHERE subject = ? AND role = ?;
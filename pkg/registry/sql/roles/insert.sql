@@ -0,0 +1,3 @@
+-- Grants a role to a subject.
+INSERT INTO roles (subject, role, created_at)
+VALUES (?, ?, ?);
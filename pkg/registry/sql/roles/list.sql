@@ -0,0 +1,5 @@
+-- Lists the roles granted to a subject.
+SELECT role
+FROM roles
+WHERE subject = ?
+ORDER BY role;
@@ -0,0 +1,9 @@
+-- Creates the table recording applied schema migrations.
+--
+-- Shared by all dialects, so it only uses types they all understand.
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version     BIGINT NOT NULL,       -- Migration number.
+    name        VARCHAR(255) NOT NULL, -- Migration file name.
+    applied_at  BIGINT NOT NULL,       -- Unix timestamp when the migration was applied.
+    PRIMARY KEY (version)
+);
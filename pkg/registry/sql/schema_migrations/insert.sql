@@ -0,0 +1,3 @@
+-- Records an applied schema migration.
+INSERT INTO schema_migrations (version, name, applied_at)
+VALUES (?, ?, ?);
@@ -0,0 +1,4 @@
+-- Lists the versions of applied schema migrations.
+SELECT version
+FROM schema_migrations
+ORDER BY version;
@@ -0,0 +1,10 @@
+-- Creates the table holding the migration lock.
+--
+-- The table has at most one row, inserted by the registry applying
+-- migrations and deleted when it is done.
+CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+    id          BIGINT NOT NULL,       -- Always 1, so only one row can exist.
+    owner       VARCHAR(255) NOT NULL, -- Random identifier of the holder.
+    acquired_at BIGINT NOT NULL,       -- Unix timestamp when the lock was taken.
+    PRIMARY KEY (id)
+);
@@ -0,0 +1,3 @@
+-- Releases the migration lock held by an owner.
+DELETE FROM schema_migrations_lock
+WHERE owner = ?;
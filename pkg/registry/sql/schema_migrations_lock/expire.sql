@@ -0,0 +1,3 @@
+-- Breaks a migration lock taken before a cutoff, left by a crashed registry.
+DELETE FROM schema_migrations_lock
+WHERE acquired_at < ?;
@@ -0,0 +1,3 @@
+-- Takes the migration lock; fails with a unique violation if it is held.
+INSERT INTO schema_migrations_lock (id, owner, acquired_at)
+VALUES (1, ?, ?);
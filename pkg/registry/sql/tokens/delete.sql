@@ -0,0 +1,3 @@
+-- Revokes a token by its digest.
+DELETE FROM tokens
+WHERE hash = ?;
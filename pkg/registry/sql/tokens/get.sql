@@ -0,0 +1,6 @@
+-- Retrieves the subject and expiry of a token by its digest.
+SELECT
+    subject,
+    expires_at
+FROM tokens
+WHERE hash = ?;
@@ -0,0 +1,3 @@
+-- Stores a new token by its digest.
+INSERT INTO tokens (hash, subject, description, created_at, expires_at)
+VALUES (?, ?, ?, ?, ?);
@@ -0,0 +1,7 @@
+-- Touches an upload session to lock it for the rest of the transaction.
+--
+-- Run first, so that concurrent chunks, commits and cancellations of the same
+-- session take their turn instead of interleaving.
+UPDATE uploads
+SET updated_at = ?
+WHERE namespace = ? AND resource = ? AND version = ? AND id = ?;
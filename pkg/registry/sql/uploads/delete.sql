@@ -0,0 +1,3 @@
+-- Deletes an upload session.
+DELETE FROM uploads
+WHERE namespace = ? AND resource = ? AND version = ? AND id = ?;
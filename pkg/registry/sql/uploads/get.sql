@@ -0,0 +1,9 @@
+-- Retrieves an upload session scoped to its target version.
+SELECT
+    id,
+    "offset",
+    path,
+    created_at,
+    updated_at
+FROM uploads
+WHERE namespace = ? AND resource = ? AND version = ? AND id = ?;
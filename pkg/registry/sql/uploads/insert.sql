@@ -0,0 +1,3 @@
+-- Inserts a new upload session with a zero offset.
+INSERT INTO uploads (id, namespace, resource, version, "offset", path, created_at, updated_at)
+VALUES (?, ?, ?, ?, 0, ?, ?, ?);
@@ -0,0 +1,3 @@
+-- Lists the partial files of all upload sessions.
+SELECT path
+FROM uploads;
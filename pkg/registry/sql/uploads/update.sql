@@ -0,0 +1,7 @@
+-- Advances an upload session after a chunk has been persisted.
+--
+-- Only matches if the session is still at the offset the chunk was written at,
+-- so concurrent chunks for the same offset cannot both succeed.
+UPDATE uploads
+SET "offset" = ?, updated_at = ?
+WHERE id = ? AND "offset" = ?;
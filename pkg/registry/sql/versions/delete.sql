@@ -2,5 +2,8 @@
 --
 -- Fails with foreign key constraint violation if any channels or archives
 -- reference this version. Channels and archives must be deleted first.
+--
+-- Unless the expected revision is NULL, only deletes the version at that
+-- revision.
 DELETE FROM versions
-WHERE namespace = ? AND resource = ? AND string = ?;
+WHERE namespace = ? AND resource = ? AND string = ? AND revision = COALESCE(?, revision);
@@ -7,6 +7,7 @@ SELECT
     size,
     path,
     created_at,
-    updated_at
+    updated_at,
+    revision
 FROM versions
 WHERE namespace = ? AND resource = ? AND string = ?;
@@ -0,0 +1,4 @@
+-- Lists the files of all uploaded archives.
+SELECT path
+FROM versions
+WHERE path IS NOT NULL;
@@ -0,0 +1,7 @@
+-- Counts the versions whose archive is stored in a file.
+--
+-- The file is matched by its storage key, or by its absolute path in rows
+-- written before storage keys were relative to the archive root.
+SELECT COUNT(*)
+FROM versions
+WHERE path = ? OR path = ?;
@@ -1,4 +1,7 @@
 -- Updates an existing version's mutable fields.
+--
+-- Affects no rows if the version does not exist or, unless the expected
+-- revision is NULL, has another revision.
 UPDATE versions
-SET updated_at = ?
-WHERE namespace = ? AND resource = ? AND string = ?;
+SET updated_at = ?, revision = revision + 1
+WHERE namespace = ? AND resource = ? AND string = ? AND revision = COALESCE(?, revision);
@@ -2,5 +2,5 @@
 --
 -- Sets the digest, size, and path fields which are NULL until an archive is uploaded.
 UPDATE versions 
-SET digest = ?, size = ?, path = ?, updated_at = ? 
+SET digest = ?, size = ?, path = ?, updated_at = ?, revision = revision + 1
 WHERE namespace = ? AND resource = ? AND string = ?;
@@ -0,0 +1,3 @@
+-- Records the outcome of a webhook delivery.
+INSERT INTO webhook_deliveries (event_id, event_type, url, attempts, status_code, last_error, created_at, completed_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
@@ -0,0 +1,20 @@
+-- Lists webhook deliveries, newest first.
+--
+-- Each filter is passed twice; an empty string disables it. The failed flag
+-- is 1 to only list deliveries that were given up on, and 0 to list all.
+SELECT
+    id,
+    event_id,
+    event_type,
+    url,
+    attempts,
+    status_code,
+    last_error,
+    created_at,
+    completed_at
+FROM webhook_deliveries
+WHERE (? = '' OR event_id = ?)
+  AND (? = '' OR url = ?)
+  AND (? = 0 OR last_error <> '')
+ORDER BY id DESC
+LIMIT ?;
@@ -0,0 +1,7 @@
+-- Takes the write lock of a database that is locked as a whole (SQLite)
+-- without changing anything.
+--
+-- Run first in write transactions, so that a transaction that reads before
+-- it writes waits for other writers instead of failing when it writes. The
+-- write_lock table is always empty, so the statement only takes the lock.
+DELETE FROM write_lock;
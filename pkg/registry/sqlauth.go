@@ -0,0 +1,290 @@
+package registry
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+const (
+
+	// Number of random bytes in an issued token.
+	tokenSize = 32
+
+	// Token error messages
+	errMsgCreateToken   = "unable to create token"
+	errMsgRevokeToken   = "unable to revoke token"
+	errMsgInvalidToken  = "invalid or expired token"
+	errMsgTokenNotFound = "token not found"
+	errMsgMissingToken  = "missing bearer token or basic credentials"
+
+	// Role error messages
+	errMsgGrantRole      = "unable to grant role"
+	errMsgRevokeRole     = "unable to revoke role"
+	errMsgRetrieveRoles  = "unable to retrieve roles"
+	errMsgInvalidRole    = "invalid role"
+	errMsgInvalidSubject = "subject must not be empty"
+	errMsgRoleNotFound   = "role not granted"
+)
+
+// SQL-backed store of access tokens and role grants.
+//
+// Tokens are random strings handed to callers once; only their digests are
+// stored. Roles are granted to subjects globally. The store authenticates
+// requests into a [Principal] and implements [Authorizer] using the roles of
+// that principal, so it can be passed to [SQLRegistryOptions.Authorizer].
+//
+// Shares the database and schema of [SQLRegistry]. All methods return [*Error]
+// on failure.
+type SQLAuthStore struct {
+	db      *sql.DB
+	logger  *slog.Logger
+	dialect *Dialect
+}
+
+// Options for creating a [SQLAuthStore].
+//
+// The zero value (and a nil *SQLAuthStoreOptions) creates a store for SQLite.
+type SQLAuthStoreOptions struct {
+
+	// SQL dialect of the database.
+	//
+	// Must match the dialect of the [SQLRegistry] sharing the database. Nil
+	// is [DialectSQLite].
+	Dialect *Dialect
+}
+
+// Returns the dialect, or nil for SQLite.
+func (o *SQLAuthStoreOptions) dialect() *Dialect {
+	if o == nil {
+		return nil
+	}
+	return o.Dialect
+}
+
+// Creates a new SQL database-backed token and role store.
+//
+// The caller is responsible for opening and closing the database, as with
+// [NewSQLRegistry]. The store applies any pending schema migrations, as
+// [NewSQLRegistry] does.
+func NewSQLAuthStore(ctx context.Context, db *sql.DB, logger *slog.Logger) (*SQLAuthStore, error) {
+	return NewSQLAuthStoreWithOptions(ctx, db, logger, nil)
+}
+
+// Creates a new SQL database-backed token and role store using options.
+//
+// Same as [NewSQLAuthStore], configured by the given options. Options can be
+// nil.
+func NewSQLAuthStoreWithOptions(ctx context.Context, db *sql.DB, logger *slog.Logger, options *SQLAuthStoreOptions) (*SQLAuthStore, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	dialect := options.dialect()
+	if err := migrateSchema(ctx, db, dialect, logger); err != nil {
+		return nil, err
+	}
+
+	return &SQLAuthStore{
+		db:      db,
+		logger:  logger,
+		dialect: dialect,
+	}, nil
+}
+
+// Issues a new token for a subject.
+//
+// The returned token is not stored and cannot be recovered; callers must hand
+// it to the subject now. A ttl of zero issues a token that never expires.
+func (s *SQLAuthStore) CreateToken(ctx context.Context, subject, description string, ttl time.Duration) (string, error) {
+	if subject == "" {
+		return "", &Error{Code: ErrorCodeBadRequest, Message: errMsgInvalidSubject}
+	}
+
+	buf := make([]byte, tokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", logError(s.logger, ErrorCodeInternalError, errMsgCreateToken, err, "subject", subject)
+	}
+	token := hex.EncodeToString(buf)
+
+	hash, err := hashToken(token)
+	if err != nil {
+		return "", logError(s.logger, ErrorCodeInternalError, errMsgCreateToken, err, "subject", subject)
+	}
+
+	now := time.Now()
+	var expiresAt *int64
+	if ttl > 0 {
+		expiry := now.Add(ttl).Unix()
+		expiresAt = &expiry
+	}
+
+	if _, err := s.db.ExecContext(ctx, s.dialect.rebind(sqlTokensInsert),
+		hash,
+		subject,
+		description,
+		now.Unix(), // created_at
+		expiresAt,  // expires_at
+	); err != nil {
+		return "", logError(s.logger, ErrorCodeInternalError, errMsgCreateToken, err, "subject", subject)
+	}
+
+	return token, nil
+}
+
+// Revokes a token.
+//
+// Returns [ErrorCodeNotFound] if the token does not exist.
+func (s *SQLAuthStore) RevokeToken(ctx context.Context, token string) error {
+	hash, err := hashToken(token)
+	if err != nil {
+		return logError(s.logger, ErrorCodeInternalError, errMsgRevokeToken, err)
+	}
+
+	result, err := s.db.ExecContext(ctx, s.dialect.rebind(sqlTokensDelete), hash)
+	if err != nil {
+		return logError(s.logger, ErrorCodeInternalError, errMsgRevokeToken, err)
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return &Error{Code: ErrorCodeNotFound, Message: errMsgTokenNotFound}
+	}
+
+	return nil
+}
+
+// Resolves a token into the principal it was issued to.
+//
+// The principal carries the roles currently granted to the subject. Returns
+// [ErrorCodeUnauthorized] if the token does not exist or has expired.
+func (s *SQLAuthStore) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	hash, err := hashToken(token)
+	if err != nil {
+		return nil, logError(s.logger, ErrorCodeInternalError, errMsgAuthorize, err)
+	}
+
+	var subject string
+	var expiresAt sql.NullInt64
+	err = s.db.QueryRowContext(ctx, s.dialect.rebind(sqlTokensGet), hash).Scan(&subject, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &Error{Code: ErrorCodeUnauthorized, Message: errMsgInvalidToken}
+	}
+	if err != nil {
+		return nil, logError(s.logger, ErrorCodeInternalError, errMsgAuthorize, err)
+	}
+
+	if expiresAt.Valid && time.Now().Unix() >= expiresAt.Int64 {
+		return nil, &Error{Code: ErrorCodeUnauthorized, Message: errMsgInvalidToken}
+	}
+
+	roles, err := s.Roles(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Principal{Subject: subject, Roles: roles}, nil
+}
+
+// Resolves the credentials of an HTTP request into a principal.
+//
+// Accepts a bearer token, or HTTP basic credentials with the token as the
+// password; the username is ignored. Returns [ErrorCodeUnauthorized] if the
+// request carries neither or the token is not valid. Servers typically attach
+// the result to the request context with [WithPrincipal].
+func (s *SQLAuthStore) AuthenticateRequest(req *http.Request) (*Principal, error) {
+	if _, password, ok := req.BasicAuth(); ok {
+		return s.Authenticate(req.Context(), password)
+	}
+
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, &Error{Code: ErrorCodeUnauthorized, Message: errMsgMissingToken}
+	}
+
+	return s.Authenticate(req.Context(), strings.TrimSpace(token))
+}
+
+// Grants a role to a subject.
+//
+// Granting a role the subject already holds succeeds. Returns
+// [ErrorCodeBadRequest] if the role is not one of the predefined roles.
+func (s *SQLAuthStore) GrantRole(ctx context.Context, subject string, role Role) error {
+	if subject == "" {
+		return &Error{Code: ErrorCodeBadRequest, Message: errMsgInvalidSubject}
+	}
+	if !IsValidRole(role) {
+		return &Error{Code: ErrorCodeBadRequest, Message: errMsgInvalidRole + ": " + string(role)}
+	}
+
+	// Granting a role twice keeps the original grant
+	query := s.dialect.upsert(sqlRolesInsert, []string{"subject", "role"}, nil)
+	if _, err := s.db.ExecContext(ctx, query, subject, string(role), time.Now().Unix()); err != nil {
+		return logError(s.logger, ErrorCodeInternalError, errMsgGrantRole, err, "subject", subject, "role", role)
+	}
+
+	return nil
+}
+
+// Revokes a role from a subject.
+//
+// Returns [ErrorCodeNotFound] if the subject does not hold the role.
+func (s *SQLAuthStore) RevokeRole(ctx context.Context, subject string, role Role) error {
+	result, err := s.db.ExecContext(ctx, s.dialect.rebind(sqlRolesDelete), subject, string(role))
+	if err != nil {
+		return logError(s.logger, ErrorCodeInternalError, errMsgRevokeRole, err, "subject", subject, "role", role)
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return &Error{Code: ErrorCodeNotFound, Message: errMsgRoleNotFound}
+	}
+
+	return nil
+}
+
+// Lists the roles granted to a subject, sorted by name.
+func (s *SQLAuthStore) Roles(ctx context.Context, subject string) ([]Role, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(sqlRolesList), subject)
+	if err != nil {
+		return nil, logError(s.logger, ErrorCodeInternalError, errMsgRetrieveRoles, err, "subject", subject)
+	}
+	defer rows.Close()
+
+	roles := []Role{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, logError(s.logger, ErrorCodeInternalError, errMsgRetrieveRoles, err, "subject", subject)
+		}
+		roles = append(roles, Role(role))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logError(s.logger, ErrorCodeInternalError, errMsgRetrieveRoles, err, "subject", subject)
+	}
+
+	return roles, nil
+}
+
+// Authorizes an action using the roles of the principal in ctx.
+//
+// Same as [RoleAuthorizer]; the principal is expected to come from
+// [SQLAuthStore.Authenticate].
+func (s *SQLAuthStore) Authorize(ctx context.Context, action Action, namespace string) error {
+	return RoleAuthorizer{}.Authorize(ctx, action, namespace)
+}
+
+// Returns the stored form of a token.
+func hashToken(token string) (string, error) {
+	digest, err := reference.FromBytes(reference.DefaultDigestAlgorithm, []byte(token))
+	if err != nil {
+		return "", err
+	}
+	return digest.String(), nil
+}
@@ -0,0 +1,206 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func setupTestAuthStore(t *testing.T) (*SQLRegistry, *SQLAuthStore, func()) {
+	t.Helper()
+
+	registry, cleanup := setupTestDB(t)
+	store, err := NewSQLAuthStore(context.Background(), registry.db, registry.logger)
+	if err != nil {
+		cleanup()
+		t.Fatalf("NewSQLAuthStore() error = %v", err)
+	}
+	registry.authorizer = store
+
+	return registry, store, cleanup
+}
+
+func assertErrorCode(t *testing.T, err error, code ErrorCode) {
+	t.Helper()
+
+	var regErr *Error
+	if !errors.As(err, &regErr) || regErr.Code != code {
+		t.Fatalf("expected %s, got: %v", code, err)
+	}
+}
+
+func TestSQLAuthStore_Authenticate(t *testing.T) {
+	_, store, cleanup := setupTestAuthStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	token, err := store.CreateToken(ctx, "alice", "ci", 0)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if err := store.GrantRole(ctx, "alice", RolePublisher); err != nil {
+		t.Fatalf("GrantRole() error = %v", err)
+	}
+	if err := store.GrantRole(ctx, "alice", RolePublisher); err != nil {
+		t.Fatalf("GrantRole() twice error = %v", err)
+	}
+
+	principal, err := store.Authenticate(ctx, token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if principal.Subject != "alice" || len(principal.Roles) != 1 || principal.Roles[0] != RolePublisher {
+		t.Fatalf("unexpected principal: %+v", principal)
+	}
+
+	_, err = store.Authenticate(ctx, "bogus")
+	assertErrorCode(t, err, ErrorCodeUnauthorized)
+
+	if err := store.RevokeToken(ctx, token); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	_, err = store.Authenticate(ctx, token)
+	assertErrorCode(t, err, ErrorCodeUnauthorized)
+}
+
+func TestSQLAuthStore_ExpiredToken(t *testing.T) {
+	_, store, cleanup := setupTestAuthStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	token, err := store.CreateToken(ctx, "alice", "", time.Nanosecond)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	time.Sleep(time.Second)
+
+	_, err = store.Authenticate(ctx, token)
+	assertErrorCode(t, err, ErrorCodeUnauthorized)
+}
+
+func TestSQLAuthStore_GrantInvalidRole(t *testing.T) {
+	_, store, cleanup := setupTestAuthStore(t)
+	defer cleanup()
+
+	err := store.GrantRole(context.Background(), "alice", Role("owner"))
+	assertErrorCode(t, err, ErrorCodeBadRequest)
+}
+
+func TestSQLAuthStore_AuthenticateRequest(t *testing.T) {
+	_, store, cleanup := setupTestAuthStore(t)
+	defer cleanup()
+
+	token, err := store.CreateToken(context.Background(), "alice", "", 0)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	bearer := httptest.NewRequest("GET", "/", nil)
+	BearerToken(token).Authorize(bearer)
+	if p, err := store.AuthenticateRequest(bearer); err != nil || p.Subject != "alice" {
+		t.Fatalf("bearer: principal = %+v, error = %v", p, err)
+	}
+
+	basic := httptest.NewRequest("GET", "/", nil)
+	BasicCredentials{Username: "alice", Password: token}.Authorize(basic)
+	if p, err := store.AuthenticateRequest(basic); err != nil || p.Subject != "alice" {
+		t.Fatalf("basic: principal = %+v, error = %v", p, err)
+	}
+
+	_, err = store.AuthenticateRequest(httptest.NewRequest("GET", "/", nil))
+	assertErrorCode(t, err, ErrorCodeUnauthorized)
+}
+
+func TestSQLRegistry_Authorization(t *testing.T) {
+	registry, store, cleanup := setupTestAuthStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	info := NamespaceInfo{Name: "acme"}
+
+	// No principal
+	_, err := registry.CreateNamespace(ctx, info)
+	assertErrorCode(t, err, ErrorCodeUnauthorized)
+
+	// Reader may not mutate
+	reader := WithPrincipal(ctx, &Principal{Subject: "bob", Roles: []Role{RoleReader}})
+	_, err = registry.CreateNamespace(reader, info)
+	assertErrorCode(t, err, ErrorCodeForbidden)
+
+	// Publisher may not create namespaces, but may create resources
+	if err := store.GrantRole(ctx, "alice", RolePublisher); err != nil {
+		t.Fatalf("GrantRole() error = %v", err)
+	}
+	token, err := store.CreateToken(ctx, "alice", "", 0)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	principal, err := store.Authenticate(ctx, token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	publisher := WithPrincipal(ctx, principal)
+
+	_, err = registry.CreateNamespace(publisher, info)
+	assertErrorCode(t, err, ErrorCodeForbidden)
+
+	admin := WithPrincipal(ctx, &Principal{Subject: "root", Roles: []Role{RoleAdmin}})
+	if _, err := registry.CreateNamespace(admin, info); err != nil {
+		t.Fatalf("CreateNamespace() error = %v", err)
+	}
+	if _, err := registry.CreateResource(publisher, "acme", ResourceInfo{Name: "app", Type: "widget"}); err != nil {
+		t.Fatalf("CreateResource() error = %v", err)
+	}
+
+	// Reads are not authorized
+	if _, err := registry.ReadNamespace(ctx, "acme"); err != nil {
+		t.Fatalf("ReadNamespace() error = %v", err)
+	}
+}
+
+func TestSQLRegistry_MemberAuthorization(t *testing.T) {
+	registry, _, cleanup := setupTestAuthStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// The creator owns the namespace
+	root := WithPrincipal(ctx, &Principal{Subject: "root", Roles: []Role{RoleAdmin}})
+	if _, err := registry.CreateNamespace(root, NamespaceInfo{Name: "team-a"}); err != nil {
+		t.Fatalf("CreateNamespace() error = %v", err)
+	}
+	if _, err := registry.CreateNamespace(root, NamespaceInfo{Name: "team-b"}); err != nil {
+		t.Fatalf("CreateNamespace() error = %v", err)
+	}
+	owner, err := registry.ReadMember(ctx, "team-a", "root")
+	if err != nil || owner.Role != RoleAdmin {
+		t.Fatalf("owner = %+v, error = %v", owner, err)
+	}
+
+	// Alice has no global roles, only a membership in team-a
+	alice := WithPrincipal(ctx, &Principal{Subject: "alice"})
+	_, err = registry.CreateResource(alice, "team-a", ResourceInfo{Name: "app", Type: "widget"})
+	assertErrorCode(t, err, ErrorCodeForbidden)
+
+	if _, err := registry.CreateMember(root, "team-a", MemberInfo{Subject: "alice", Role: RolePublisher}); err != nil {
+		t.Fatalf("CreateMember() error = %v", err)
+	}
+
+	if _, err := registry.CreateResource(alice, "team-a", ResourceInfo{Name: "app", Type: "widget"}); err != nil {
+		t.Fatalf("CreateResource() error = %v", err)
+	}
+	_, err = registry.CreateResource(alice, "team-b", ResourceInfo{Name: "app", Type: "widget"})
+	assertErrorCode(t, err, ErrorCodeForbidden)
+
+	// Publishers may not manage members
+	_, err = registry.CreateMember(alice, "team-a", MemberInfo{Subject: "bob", Role: RoleAdmin})
+	assertErrorCode(t, err, ErrorCodeForbidden)
+
+	// Removing the membership revokes access
+	if err := registry.DeleteMember(root, "team-a", "alice", AnyRevision); err != nil {
+		t.Fatalf("DeleteMember() error = %v", err)
+	}
+	_, err = registry.UpdateResource(alice, "team-a", "app", ResourceInfo{Name: "app", Type: "widget"}, AnyRevision)
+	assertErrorCode(t, err, ErrorCodeForbidden)
+}
@@ -3,10 +3,16 @@ package registry
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
-	"sync"
+	"path/filepath"
+	"time"
+
+	"github.com/cruciblehq/protocol/pkg/reference"
 )
 
 const (
@@ -43,30 +49,134 @@ const (
 	errMsgUpdateArchive     = "unable to update archive metadata"
 	errMsgAccessArchiveFile = "unable to access archive file - file may be missing or inaccessible"
 	errMsgArchiveNotFound   = "archive not found"
+	errMsgInvalidRange      = "range offset must not be negative"
+	errMsgRangeNotSatisfied = "range starts past the end of the archive"
+	errMsgSweepArchives     = "unable to sweep archive files"
+	errMsgArchiveURL        = "unable to build archive URL"
+
+	// Upload operation error messages
+	errMsgStartUpload          = "unable to start upload"
+	errMsgRetrieveUpload       = "unable to retrieve upload information"
+	errMsgStoreUploadChunk     = "unable to store upload chunk"
+	errMsgCommitUpload         = "unable to commit upload"
+	errMsgDeleteUpload         = "unable to cancel upload"
+	errMsgUploadNotFound       = "upload not found"
+	errMsgUploadOffsetChanged  = "upload offset changed by a concurrent chunk"
+	errMsgInvalidUploadDigest  = "invalid upload digest"
+	errMsgUploadDigestMismatch = "uploaded data does not match digest"
 
 	// Channel operation error messages
-	errMsgCreateChannel       = "unable to create channel - ensure the target version exists"
-	errMsgUpdateChannel       = "unable to update channel - ensure the target version exists"
-	errMsgRetrieveChannel     = "unable to retrieve channel information"
-	errMsgDeleteChannel       = "unable to delete channel"
-	errMsgRetrieveChannelList = "unable to retrieve channel list for resource"
-	errMsgChannelNotFound     = "channel not found"
-	errMsgChannelExists       = "channel already exists"
+	errMsgCreateChannel         = "unable to create channel - ensure the target version exists"
+	errMsgUpdateChannel         = "unable to update channel - ensure the target version exists"
+	errMsgRetrieveChannel       = "unable to retrieve channel information"
+	errMsgDeleteChannel         = "unable to delete channel"
+	errMsgRetrieveChannelList   = "unable to retrieve channel list for resource"
+	errMsgChannelNotFound       = "channel not found"
+	errMsgChannelExists         = "channel already exists"
+	errMsgChannelTargetNotFound = "resource or target version not found"
+	errMsgRetrieveHistory       = "unable to retrieve channel history"
+	errMsgRollbackChannel       = "unable to roll back channel"
+	errMsgNoPreviousVersion     = "channel has no previous version"
+	errMsgVersionNotInHistory   = "channel never pointed at version"
+	errMsgChannelMoved          = "channel was moved concurrently"
+
+	// Member operation error messages
+	errMsgAddOwner           = "unable to add namespace owner"
+	errMsgCreateMember       = "unable to add member due to internal error"
+	errMsgRetrieveMember     = "unable to retrieve member information"
+	errMsgSaveMemberChanges  = "unable to save member changes"
+	errMsgDeleteMember       = "unable to remove member"
+	errMsgRetrieveMemberList = "unable to retrieve member list for namespace"
+	errMsgMemberNotFound     = "member not found"
+	errMsgMemberExists       = "subject is already a member of the namespace"
+
+	// Authorization error messages
+	errMsgUnauthorized = "authentication required"
+	errMsgForbidden    = "not allowed"
+	errMsgAuthorize    = "unable to authorize request"
+
+	// Audit log error messages
+	errMsgRecordAudit         = "unable to record audit event"
+	errMsgRetrieveAuditEvents = "unable to retrieve audit events"
+
+	// Precondition error messages
+	errMsgRevisionMismatch = "revision does not match the expected revision"
 )
 
 // Implements the [Registry] interface using SQL databases.
 //
 // Provides persistent storage of namespaces, resources, versions, channels,
-// and archives. Supports any SQL database with a compatible driver (SQLite,
-// PostgreSQL, MySQL, etc.). Uses SQL for ACID transactions and referential
-// integrity. Thread-safe for concurrent access. The registry does not own
+// and archives. Supports SQLite, PostgreSQL and MySQL through their
+// [Dialect]; the database is SQLite unless [SQLRegistryOptions.Dialect] says
+// otherwise. Uses SQL for ACID transactions and referential integrity.
+// Operations spanning several statements run in one transaction, so readers
+// see either all of a change or none of it. Thread-safe for concurrent access,
+// including from several registries sharing the database and archive root:
+// concurrent writers are serialized by the database. The registry does not own
 // the database connection. The caller is responsible for connection lifecycle
 // management, including calling Close() on the *sql.DB.
 type SQLRegistry struct {
-	db          *sql.DB      // Database connection
-	logger      *slog.Logger // Logger for registry operations
-	archiveRoot string       // Root directory for archive storage
-	mu          sync.RWMutex // Protects archive storage operations
+	db          *sql.DB           // Database connection
+	logger      *slog.Logger      // Logger for registry operations
+	archiveRoot string            // Root directory for archive storage
+	authorizer  Authorizer        // Authorizes mutations, or nil to allow all
+	dialect     *Dialect          // SQL dialect, or nil for SQLite
+	urls        ArchiveURLBuilder // Builds archive URLs, or nil for API paths
+	events      *eventHub         // Subscribers to registry events
+	fullText    bool              // Whether search uses the FTS5 index
+	tx          *sql.Tx           // Transaction queries run in, or nil outside one
+	write       bool              // Whether tx may write, locking the rows it reads
+	pending     *[]Event          // Events announced once tx commits, or nil outside one
+}
+
+// Options for creating a [SQLRegistry].
+//
+// The zero value (and a nil *SQLRegistryOptions) creates a registry for
+// SQLite that allows every request.
+type SQLRegistryOptions struct {
+
+	// SQL dialect of the database.
+	//
+	// Nil is [DialectSQLite]. Use [DialectPostgreSQL] or [DialectMySQL] for
+	// those databases; the dialect creates its own schema.
+	Dialect *Dialect
+
+	// Authorizer consulted before every mutation.
+	//
+	// Nil allows every request, which suits a local registry used by a single
+	// user. See [SQLAuthStore] for a database-backed implementation.
+	Authorizer Authorizer
+
+	// Builds the URLs returned in [Version.Archive].
+	//
+	// Nil is an [APIURLBuilder] building paths relative to the API root.
+	// Archives are stored under keys relative to the archive root, which
+	// are never returned to clients.
+	URLBuilder ArchiveURLBuilder
+}
+
+// Returns the authorizer, or nil to allow every request.
+func (o *SQLRegistryOptions) authorizer() Authorizer {
+	if o == nil {
+		return nil
+	}
+	return o.Authorizer
+}
+
+// Returns the archive URL builder, or nil for API paths.
+func (o *SQLRegistryOptions) urlBuilder() ArchiveURLBuilder {
+	if o == nil {
+		return nil
+	}
+	return o.URLBuilder
+}
+
+// Returns the dialect, or nil for SQLite.
+func (o *SQLRegistryOptions) dialect() *Dialect {
+	if o == nil {
+		return nil
+	}
+	return o.Dialect
 }
 
 // Creates a new SQL database-backed registry.
@@ -77,50 +187,84 @@ type SQLRegistry struct {
 //   - Managing connection lifecycle (calling Close() when done)
 //   - Providing the archiveRoot directory where archive files will be stored
 //
-// The registry will create the necessary schema if it doesn't exist.
+// The registry applies any pending schema migrations before it is returned,
+// and refuses to start against a database migrated by a newer registry.
 func NewSQLRegistry(ctx context.Context, db *sql.DB, archiveRoot string, logger *slog.Logger) (*SQLRegistry, error) {
+	return NewSQLRegistryWithOptions(ctx, db, archiveRoot, logger, nil)
+}
+
+// Creates a new SQL database-backed registry using options.
+//
+// Same as [NewSQLRegistry], configured by the given options. Options can be
+// nil.
+func NewSQLRegistryWithOptions(ctx context.Context, db *sql.DB, archiveRoot string, logger *slog.Logger, options *SQLRegistryOptions) (*SQLRegistry, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
-	if _, err := db.ExecContext(ctx, sqlSchema); err != nil {
-		logger.Error("failed to create schema", "error", err)
-		return nil, &Error{
-			Code:    ErrorCodeInternalError,
-			Message: "failed to create schema",
-		}
+	dialect := options.dialect()
+	if err := migrateSchema(ctx, db, dialect, logger); err != nil {
+		return nil, err
+	}
+
+	fullText, err := prepareSearchIndex(ctx, db, dialect, logger)
+	if err != nil {
+		return nil, err
 	}
 
 	return &SQLRegistry{
 		db:          db,
 		logger:      logger,
 		archiveRoot: archiveRoot,
+		authorizer:  options.authorizer(),
+		dialect:     dialect,
+		urls:        options.urlBuilder(),
+		events:      newEventHub(),
+		fullText:    fullText,
 	}, nil
 }
 
 // Creates a new namespace in the registry.
 //
 // Validates the namespace name before creation. If a namespace with the given
-// name already exists, [ErrorCodeNamespaceExists] is returned. The response
-// includes the created namespace's metadata with an empty resources list.
+// name already exists, [ErrorCodeNamespaceExists] is returned. If the context
+// carries a principal, it becomes a member of the namespace with [RoleAdmin].
+// The response includes the created namespace's metadata with an empty
+// resources list.
 func (r *SQLRegistry) CreateNamespace(ctx context.Context, info NamespaceInfo) (ns *Namespace, err error) {
 	if err := validateNamespace(info.Name); err != nil {
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	if ns, err = r.insertNamespace(ctx, info); err != nil {
+	if err := r.authorize(ctx, ActionNamespaceWrite, info.Name); err != nil {
+		return nil, err
+	}
+
+	err = r.withTx(ctx, func(tx *SQLRegistry) error {
+		var err error
+		if ns, err = tx.insertNamespace(ctx, info); err != nil {
+			if r.dialect.classify(err) == violationUnique {
+				return &Error{
+					Code:    ErrorCodeNamespaceExists,
+					Message: errMsgNamespaceExists,
+				}
+			}
+
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgCreateNamespace, err, "namespace", info.Name)
+		}
 
-		// Check if namespace already exists (only on error). There's a race
-		// condition here, but the semantics hold: if it exists now, then it
-		// already exists.
-		if _, err := r.getNamespace(ctx, info.Name); err == nil {
-			return nil, &Error{
-				Code:    ErrorCodeNamespaceExists,
-				Message: errMsgNamespaceExists,
+		// The creator owns the namespace
+		if principal := PrincipalFromContext(ctx); principal != nil {
+			if _, err := tx.insertMember(ctx, info.Name, MemberInfo{Subject: principal.Subject, Role: RoleAdmin}); err != nil {
+				return r.logAndReturnError(ErrorCodeInternalError, errMsgAddOwner, err, "namespace", info.Name, "subject", principal.Subject)
 			}
 		}
 
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgCreateNamespace, err, "namespace", info.Name)
+		tx.emit(ctx, Event{Type: EventNamespaceCreated, Namespace: info.Name})
+		return tx.audit(ctx, AuditEvent{Action: AuditNamespaceCreate, Namespace: info.Name, After: summarize(namespaceSummary(ns))})
+	})
+	if err != nil {
+		return nil, r.registryError(err, errMsgCreateNamespace, "namespace", info.Name)
 	}
 
 	return ns, nil
@@ -137,20 +281,26 @@ func (r *SQLRegistry) ReadNamespace(ctx context.Context, namespace string) (*Nam
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	ns, err := r.getNamespace(ctx, namespace)
-	if err == sql.ErrNoRows {
-		return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgNamespaceNotFound}
-	}
-	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveNamespace, err, "namespace", namespace)
-	}
+	var ns *Namespace
+	err := r.withSnapshot(ctx, func(tx *SQLRegistry) error {
+		var err error
+		ns, err = tx.getNamespace(ctx, namespace)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgNamespaceNotFound}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveNamespace, err, "namespace", namespace)
+		}
 
-	// Get resource summaries
-	resources, err := r.listResources(ctx, namespace)
+		// Get resource summaries
+		if ns.Resources, err = tx.listResources(ctx, namespace); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveResourceList, err, "namespace", namespace)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveResourceList, err, "namespace", namespace)
+		return nil, r.registryError(err, errMsgRetrieveNamespace, "namespace", namespace)
 	}
-	ns.Resources = resources
 
 	return ns, nil
 }
@@ -159,25 +309,49 @@ func (r *SQLRegistry) ReadNamespace(ctx context.Context, namespace string) (*Nam
 //
 // Only the description field can be modified. The namespace name cannot be changed
 // after creation. Returns [ErrorCodeNotFound] if the namespace does not exist.
-func (r *SQLRegistry) UpdateNamespace(ctx context.Context, namespace string, info NamespaceInfo) (*Namespace, error) {
+//
+// Returns [ErrorCodePreconditionFailed] if expected is neither [AnyRevision]
+// nor the current revision of the namespace.
+func (r *SQLRegistry) UpdateNamespace(ctx context.Context, namespace string, info NamespaceInfo, expected Revision) (*Namespace, error) {
 	if err := validateNamespace(namespace); err != nil {
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	ns, err := r.updateNamespace(ctx, namespace, info)
-	if err == sql.ErrNoRows {
-		return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgNamespaceNotFound}
-	}
-	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgSaveNamespaceChanges, err, "namespace", namespace)
+	if err := r.authorize(ctx, ActionNamespaceWrite, namespace); err != nil {
+		return nil, err
 	}
 
-	// Get resources for the namespace
-	resources, err := r.listResources(ctx, namespace)
+	var ns *Namespace
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		before, err := tx.getNamespace(ctx, namespace)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgNamespaceNotFound}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveNamespace, err, "namespace", namespace)
+		}
+
+		ns, err = tx.updateNamespace(ctx, namespace, info, expected)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgRevisionMismatch}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgSaveNamespaceChanges, err, "namespace", namespace)
+		}
+
+		if err := tx.audit(ctx, AuditEvent{Action: AuditNamespaceUpdate, Namespace: namespace, Before: summarize(namespaceSummary(before)), After: summarize(namespaceSummary(ns))}); err != nil {
+			return err
+		}
+
+		// Get resources for the namespace
+		if ns.Resources, err = tx.listResources(ctx, namespace); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveResourceList, err, "namespace", namespace)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveResourceList, err, "namespace", namespace)
+		return nil, r.registryError(err, errMsgSaveNamespaceChanges, "namespace", namespace)
 	}
-	ns.Resources = resources
 
 	return ns, nil
 }
@@ -186,13 +360,39 @@ func (r *SQLRegistry) UpdateNamespace(ctx context.Context, namespace string, inf
 //
 // Foreign key constraints prevent deletion if the namespace contains resources.
 // The operation will fail with [ErrorCodeNamespaceNotEmpty] if resources exist.
-func (r *SQLRegistry) DeleteNamespace(ctx context.Context, namespace string) error {
+//
+// Returns [ErrorCodePreconditionFailed] if expected is neither [AnyRevision]
+// nor the current revision of the namespace.
+func (r *SQLRegistry) DeleteNamespace(ctx context.Context, namespace string, expected Revision) error {
 	if err := validateNamespace(namespace); err != nil {
 		return &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	if err := r.deleteNamespace(ctx, namespace); err != nil {
-		return r.logAndReturnError(ErrorCodeInternalError, errMsgDeleteNamespace, err, "namespace", namespace)
+	if err := r.authorize(ctx, ActionNamespaceWrite, namespace); err != nil {
+		return err
+	}
+
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		before, err := tx.getNamespace(ctx, namespace)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveNamespace, err, "namespace", namespace)
+		}
+
+		err = tx.deleteNamespace(ctx, namespace, expected)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgRevisionMismatch}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgDeleteNamespace, err, "namespace", namespace)
+		}
+
+		return tx.audit(ctx, AuditEvent{Action: AuditNamespaceDelete, Namespace: namespace, Before: summarize(namespaceSummary(before))})
+	})
+	if err != nil {
+		return r.registryError(err, errMsgDeleteNamespace, "namespace", namespace)
 	}
 
 	return nil
@@ -210,6 +410,176 @@ func (r *SQLRegistry) ListNamespaces(ctx context.Context) (*NamespaceList, error
 	return &NamespaceList{Namespaces: namespaces}, nil
 }
 
+// Adds a member to a namespace.
+//
+// Validates the subject and role before insertion. Returns
+// [ErrorCodeMemberExists] if the subject is already a member, and
+// [ErrorCodeNotFound] if the namespace does not exist.
+func (r *SQLRegistry) CreateMember(ctx context.Context, namespace string, info MemberInfo) (*Member, error) {
+	if err := validateMemberInfo(namespace, info); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	if err := r.authorize(ctx, ActionMemberWrite, namespace); err != nil {
+		return nil, err
+	}
+
+	var m *Member
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		var err error
+		if m, err = tx.insertMember(ctx, namespace, info); err != nil {
+			switch r.dialect.classify(err) {
+			case violationUnique:
+				return &Error{Code: ErrorCodeMemberExists, Message: errMsgMemberExists}
+			case violationForeignKey:
+				return &Error{Code: ErrorCodeNotFound, Message: errMsgNamespaceNotFound}
+			}
+
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgCreateMember, err, "namespace", namespace, "subject", info.Subject)
+		}
+
+		return tx.audit(ctx, AuditEvent{Action: AuditMemberCreate, Namespace: namespace, Target: info.Subject, After: summarize(memberSummary(m))})
+	})
+	if err != nil {
+		return nil, r.registryError(err, errMsgCreateMember, "namespace", namespace, "subject", info.Subject)
+	}
+
+	return m, nil
+}
+
+// Retrieves a namespace member.
+//
+// Returns [ErrorCodeNotFound] if the subject is not a member of the namespace.
+func (r *SQLRegistry) ReadMember(ctx context.Context, namespace string, subject string) (*Member, error) {
+	if err := validateMemberReference(namespace, subject); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	m, err := r.getMember(ctx, namespace, subject)
+	if err == sql.ErrNoRows {
+		return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgMemberNotFound}
+	}
+	if err != nil {
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveMember, err, "namespace", namespace, "subject", subject)
+	}
+	return m, nil
+}
+
+// Changes the role of a namespace member.
+//
+// Only the role can be modified. Returns [ErrorCodeNotFound] if the subject is
+// not a member of the namespace.
+//
+// Returns [ErrorCodePreconditionFailed] if expected is neither [AnyRevision]
+// nor the current revision of the membership.
+func (r *SQLRegistry) UpdateMember(ctx context.Context, namespace string, subject string, info MemberInfo, expected Revision) (*Member, error) {
+	info.Subject = subject
+	if err := validateMemberInfo(namespace, info); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	if err := r.authorize(ctx, ActionMemberWrite, namespace); err != nil {
+		return nil, err
+	}
+
+	var m *Member
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		before, err := tx.getMember(ctx, namespace, subject)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgMemberNotFound}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveMember, err, "namespace", namespace, "subject", subject)
+		}
+
+		m, err = tx.updateMember(ctx, namespace, info, expected)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgRevisionMismatch}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgSaveMemberChanges, err, "namespace", namespace, "subject", subject)
+		}
+
+		return tx.audit(ctx, AuditEvent{Action: AuditMemberUpdate, Namespace: namespace, Target: subject, Before: summarize(memberSummary(before)), After: summarize(memberSummary(m))})
+	})
+	if err != nil {
+		return nil, r.registryError(err, errMsgSaveMemberChanges, "namespace", namespace, "subject", subject)
+	}
+
+	return m, nil
+}
+
+// Removes a member from a namespace.
+//
+// Roles the subject holds globally are not affected. The operation is
+// idempotent, returning success if the subject is not a member.
+//
+// Returns [ErrorCodePreconditionFailed] if expected is neither [AnyRevision]
+// nor the current revision of the membership.
+func (r *SQLRegistry) DeleteMember(ctx context.Context, namespace string, subject string, expected Revision) error {
+	if err := validateMemberReference(namespace, subject); err != nil {
+		return &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	if err := r.authorize(ctx, ActionMemberWrite, namespace); err != nil {
+		return err
+	}
+
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		before, err := tx.getMember(ctx, namespace, subject)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveMember, err, "namespace", namespace, "subject", subject)
+		}
+
+		err = tx.deleteMember(ctx, namespace, subject, expected)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgRevisionMismatch}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgDeleteMember, err, "namespace", namespace, "subject", subject)
+		}
+
+		return tx.audit(ctx, AuditEvent{Action: AuditMemberDelete, Namespace: namespace, Target: subject, Before: summarize(memberSummary(before))})
+	})
+	if err != nil {
+		return r.registryError(err, errMsgDeleteMember, "namespace", namespace, "subject", subject)
+	}
+
+	return nil
+}
+
+// Returns all members of a namespace.
+//
+// Returns a [MemberList] sorted by subject. Returns [ErrorCodeNotFound] if the
+// namespace does not exist.
+func (r *SQLRegistry) ListMembers(ctx context.Context, namespace string) (*MemberList, error) {
+	if err := validateNamespace(namespace); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	var members []Member
+	err := r.withSnapshot(ctx, func(tx *SQLRegistry) error {
+		if _, err := tx.getNamespace(ctx, namespace); err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgNamespaceNotFound}
+		} else if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveNamespace, err, "namespace", namespace)
+		}
+
+		var err error
+		if members, err = tx.listMembers(ctx, namespace); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveMemberList, err, "namespace", namespace)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, r.registryError(err, errMsgRetrieveMemberList, "namespace", namespace)
+	}
+	return &MemberList{Members: members}, nil
+}
+
 // Creates a new resource in a namespace.
 //
 // Returns [ErrorCodeResourceExists] if a resource with the same name already
@@ -220,20 +590,29 @@ func (r *SQLRegistry) CreateResource(ctx context.Context, namespace string, info
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	res, err := r.insertResource(ctx, namespace, info)
-	if err != nil {
+	if err := r.authorize(ctx, ActionResourceWrite, namespace); err != nil {
+		return nil, err
+	}
 
-		// Check whether resource already exists (only on error)
-		if _, err := r.getResource(ctx, namespace, info.Name); err == nil {
-			return nil, &Error{Code: ErrorCodeResourceExists, Message: errMsgResourceExists}
-		}
+	var res *Resource
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		var err error
+		if res, err = tx.insertResource(ctx, namespace, info); err != nil {
+			switch r.dialect.classify(err) {
+			case violationUnique:
+				return &Error{Code: ErrorCodeResourceExists, Message: errMsgResourceExists}
+			case violationForeignKey:
+				return &Error{Code: ErrorCodeNotFound, Message: errMsgNamespaceNotFound}
+			}
 
-		// Check whether namespace exists (foreign key constraint - only on error)
-		if _, err := r.getNamespace(ctx, namespace); err == sql.ErrNoRows {
-			return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgNamespaceNotFound}
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgCreateResource, err, "namespace", namespace, "resource", info.Name)
 		}
 
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgCreateResource, err, "namespace", namespace, "resource", info.Name)
+		tx.emit(ctx, Event{Type: EventResourceCreated, Namespace: namespace, Resource: info.Name})
+		return tx.audit(ctx, AuditEvent{Action: AuditResourceCreate, Namespace: namespace, Resource: info.Name, After: summarize(resourceSummary(res))})
+	})
+	if err != nil {
+		return nil, r.registryError(err, errMsgCreateResource, "namespace", namespace, "resource", info.Name)
 	}
 
 	return res, nil
@@ -248,60 +627,89 @@ func (r *SQLRegistry) ReadResource(ctx context.Context, namespace string, resour
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	res, err := r.getResource(ctx, namespace, resource)
-	if err == sql.ErrNoRows {
-		return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgResourceNotFound}
-	}
+	var res *Resource
+	err := r.withSnapshot(ctx, func(tx *SQLRegistry) error {
+		var err error
+		res, err = tx.getResource(ctx, namespace, resource)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgResourceNotFound}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveResource, err, "namespace", namespace)
+		}
+
+		return tx.readResourceSummaries(ctx, res)
+	})
 	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveResource, err, "namespace", namespace)
+		return nil, r.registryError(err, errMsgRetrieveResource, "namespace", namespace, "resource", resource)
 	}
 
+	return res, nil
+}
+
+// Fills in the version and channel summaries of a resource.
+//
+// Failures are logged and returned as [ErrorCodeInternalError].
+func (r *SQLRegistry) readResourceSummaries(ctx context.Context, res *Resource) error {
+	var err error
+
 	// Get version summaries
-	versions, err := r.listVersions(ctx, namespace, resource)
-	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveVersionList, err, "namespace", namespace, "resource", resource)
+	if res.Versions, err = r.listVersions(ctx, res.Namespace, res.Name); err != nil {
+		return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveVersionList, err, "namespace", res.Namespace, "resource", res.Name)
 	}
-	res.Versions = versions
 
 	// Get channel summaries
-	channels, err := r.listChannels(ctx, namespace, resource)
-	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveChannelList, err, "namespace", namespace, "resource", resource)
+	if res.Channels, err = r.listChannels(ctx, res.Namespace, res.Name); err != nil {
+		return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveChannelList, err, "namespace", res.Namespace, "resource", res.Name)
 	}
-	res.Channels = channels
 
-	return res, nil
+	return nil
 }
 
 // Updates a resource's mutable metadata.
 //
 // The type and description fields can be modified. The resource name cannot be
 // changed after creation. Returns [ErrorCodeNotFound] if the resource does not exist.
-func (r *SQLRegistry) UpdateResource(ctx context.Context, namespace string, resource string, info ResourceInfo) (*Resource, error) {
+//
+// Returns [ErrorCodePreconditionFailed] if expected is neither [AnyRevision]
+// nor the current revision of the resource.
+func (r *SQLRegistry) UpdateResource(ctx context.Context, namespace string, resource string, info ResourceInfo, expected Revision) (*Resource, error) {
 	if err := validateIdentifier(namespace, resource); err != nil {
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	res, err := r.updateResource(ctx, namespace, resource, info)
-	if err == sql.ErrNoRows {
-		return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgResourceNotFound}
-	}
-	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgSaveResourceChanges, err, "namespace", namespace, "resource", resource)
+	if err := r.authorize(ctx, ActionResourceWrite, namespace); err != nil {
+		return nil, err
 	}
 
-	// Get versions and channels for the resource
-	versions, err := r.listVersions(ctx, namespace, resource)
-	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveVersionList, err, "namespace", namespace, "resource", resource)
-	}
-	res.Versions = versions
+	var res *Resource
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		before, err := tx.getResource(ctx, namespace, resource)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgResourceNotFound}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveResource, err, "namespace", namespace, "resource", resource)
+		}
 
-	channels, err := r.listChannels(ctx, namespace, resource)
+		res, err = tx.updateResource(ctx, namespace, resource, info, expected)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgRevisionMismatch}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgSaveResourceChanges, err, "namespace", namespace, "resource", resource)
+		}
+
+		if err := tx.audit(ctx, AuditEvent{Action: AuditResourceUpdate, Namespace: namespace, Resource: resource, Before: summarize(resourceSummary(before)), After: summarize(resourceSummary(res))}); err != nil {
+			return err
+		}
+
+		// Get versions and channels for the resource
+		return tx.readResourceSummaries(ctx, res)
+	})
 	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveChannelList, err, "namespace", namespace, "resource", resource)
+		return nil, r.registryError(err, errMsgSaveResourceChanges, "namespace", namespace, "resource", resource)
 	}
-	res.Channels = channels
 
 	return res, nil
 }
@@ -310,13 +718,39 @@ func (r *SQLRegistry) UpdateResource(ctx context.Context, namespace string, reso
 //
 // Foreign key constraints prevent deletion if the resource contains versions.
 // All versions and channels must be deleted first. This operation cannot be undone.
-func (r *SQLRegistry) DeleteResource(ctx context.Context, namespace string, resource string) error {
+//
+// Returns [ErrorCodePreconditionFailed] if expected is neither [AnyRevision]
+// nor the current revision of the resource.
+func (r *SQLRegistry) DeleteResource(ctx context.Context, namespace string, resource string, expected Revision) error {
 	if err := validateIdentifier(namespace, resource); err != nil {
 		return &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	if err := r.deleteResource(ctx, namespace, resource); err != nil {
-		return r.logAndReturnError(ErrorCodeInternalError, errMsgDeleteResource, err, "namespace", namespace, "resource", resource)
+	if err := r.authorize(ctx, ActionResourceWrite, namespace); err != nil {
+		return err
+	}
+
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		before, err := tx.getResource(ctx, namespace, resource)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveResource, err, "namespace", namespace, "resource", resource)
+		}
+
+		err = tx.deleteResource(ctx, namespace, resource, expected)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgRevisionMismatch}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgDeleteResource, err, "namespace", namespace, "resource", resource)
+		}
+
+		return tx.audit(ctx, AuditEvent{Action: AuditResourceDelete, Namespace: namespace, Resource: resource, Before: summarize(resourceSummary(before))})
+	})
+	if err != nil {
+		return r.registryError(err, errMsgDeleteResource, "namespace", namespace, "resource", resource)
 	}
 
 	return nil
@@ -350,23 +784,32 @@ func (r *SQLRegistry) CreateVersion(ctx context.Context, namespace string, resou
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	// Try to insert the version
-	v, err := r.insertVersion(ctx, namespace, resource, info)
-	if err == nil {
-		return v, nil
+	if err := r.authorize(ctx, ActionResourcePublish, namespace); err != nil {
+		return nil, err
 	}
 
-	// Check if version already exists
-	if _, checkErr := r.getVersion(ctx, namespace, resource, info.String); checkErr == nil {
-		return nil, &Error{Code: ErrorCodeVersionExists, Message: errMsgVersionExists}
-	}
+	var v *Version
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		var err error
+		if v, err = tx.insertVersion(ctx, namespace, resource, info); err != nil {
+			switch r.dialect.classify(err) {
+			case violationUnique:
+				return &Error{Code: ErrorCodeVersionExists, Message: errMsgVersionExists}
+			case violationForeignKey:
+				return &Error{Code: ErrorCodeNotFound, Message: errMsgResourceNotFound}
+			}
+
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgCreateVersion, err, "namespace", namespace, "resource", resource, "version", info.String)
+		}
 
-	// Check if resource exists
-	if _, checkErr := r.getResource(ctx, namespace, resource); checkErr == sql.ErrNoRows {
-		return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgResourceNotFound}
+		tx.emit(ctx, Event{Type: EventVersionCreated, Namespace: namespace, Resource: resource, Version: info.String})
+		return tx.audit(ctx, AuditEvent{Action: AuditVersionCreate, Namespace: namespace, Resource: resource, Target: info.String, After: summarize(versionSummary(v))})
+	})
+	if err != nil {
+		return nil, r.registryError(err, errMsgCreateVersion, "namespace", namespace, "resource", resource, "version", info.String)
 	}
 
-	return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgCreateVersion, err, "namespace", namespace, "resource", resource, "version", info.String)
+	return v, nil
 }
 
 // Retrieves a version with its archive details.
@@ -385,6 +828,9 @@ func (r *SQLRegistry) ReadVersion(ctx context.Context, namespace string, resourc
 	if err != nil {
 		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveVersion, err, "namespace", namespace, "resource", resource, "version", version)
 	}
+	if err := r.exposeArchive(ctx, v); err != nil {
+		return nil, err
+	}
 	return v, nil
 }
 
@@ -393,17 +839,44 @@ func (r *SQLRegistry) ReadVersion(ctx context.Context, namespace string, resourc
 // Metadata updates are allowed even after publication to support documentation
 // changes. The version string cannot be changed after creation. Returns
 // [ErrorCodeNotFound] if the version does not exist.
-func (r *SQLRegistry) UpdateVersion(ctx context.Context, namespace string, resource string, version string, info VersionInfo) (*Version, error) {
+//
+// Returns [ErrorCodePreconditionFailed] if expected is neither [AnyRevision]
+// nor the current revision of the version.
+func (r *SQLRegistry) UpdateVersion(ctx context.Context, namespace string, resource string, version string, info VersionInfo, expected Revision) (*Version, error) {
 	if err := validateReference(namespace, resource, version); err != nil {
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	v, err := r.updateVersion(ctx, namespace, resource, version)
-	if err == sql.ErrNoRows {
-		return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgVersionNotFound}
+	if err := r.authorize(ctx, ActionResourcePublish, namespace); err != nil {
+		return nil, err
 	}
+
+	var v *Version
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		before, err := tx.getVersion(ctx, namespace, resource, version)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgVersionNotFound}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveVersion, err, "namespace", namespace, "resource", resource, "version", version)
+		}
+
+		v, err = tx.updateVersion(ctx, namespace, resource, version, expected)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgRevisionMismatch}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgSaveVersionChanges, err, "namespace", namespace, "resource", resource, "version", version)
+		}
+
+		return tx.audit(ctx, AuditEvent{Action: AuditVersionUpdate, Namespace: namespace, Resource: resource, Target: version, Before: summarize(versionSummary(before)), After: summarize(versionSummary(v)), Digest: v.Digest})
+	})
 	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgSaveVersionChanges, err, "namespace", namespace, "resource", resource, "version", version)
+		return nil, r.registryError(err, errMsgSaveVersionChanges, "namespace", namespace, "resource", resource, "version", version)
+	}
+
+	if err := r.exposeArchive(ctx, v); err != nil {
+		return nil, err
 	}
 	return v, nil
 }
@@ -411,15 +884,47 @@ func (r *SQLRegistry) UpdateVersion(ctx context.Context, namespace string, resou
 // Permanently deletes a version.
 //
 // Foreign key constraints prevent deletion if the version is referenced by
-// channels or has an associated archive. These must be deleted first. This
-// operation cannot be undone.
-func (r *SQLRegistry) DeleteVersion(ctx context.Context, namespace string, resource string, version string) error {
+// channels. These must be deleted first. The archive file of the version is
+// removed once the deletion is committed. This operation cannot be undone.
+//
+// Returns [ErrorCodePreconditionFailed] if expected is neither [AnyRevision]
+// nor the current revision of the version.
+func (r *SQLRegistry) DeleteVersion(ctx context.Context, namespace string, resource string, version string, expected Revision) error {
 	if err := validateReference(namespace, resource, version); err != nil {
 		return &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	if err := r.deleteVersion(ctx, namespace, resource, version); err != nil {
-		return r.logAndReturnError(ErrorCodeInternalError, errMsgDeleteVersion, err, "namespace", namespace, "resource", resource, "version", version)
+	if err := r.authorize(ctx, ActionResourcePublish, namespace); err != nil {
+		return err
+	}
+
+	var before *Version
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		var err error
+		before, err = tx.getVersion(ctx, namespace, resource, version)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveVersion, err, "namespace", namespace, "resource", resource, "version", version)
+		}
+
+		err = tx.deleteVersion(ctx, namespace, resource, version, expected)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgRevisionMismatch}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgDeleteVersion, err, "namespace", namespace, "resource", resource, "version", version)
+		}
+
+		return tx.audit(ctx, AuditEvent{Action: AuditVersionDelete, Namespace: namespace, Resource: resource, Target: version, Before: summarize(versionSummary(before)), Digest: before.Digest})
+	})
+	if err != nil {
+		return r.registryError(err, errMsgDeleteVersion, "namespace", namespace, "resource", resource, "version", version)
+	}
+
+	if before != nil && before.Archive != nil {
+		r.discardArchive(ctx, *before.Archive)
 	}
 	return nil
 }
@@ -444,14 +949,18 @@ func (r *SQLRegistry) ListVersions(ctx context.Context, namespace string, resour
 //
 // The archive data is hashed using SHA-256 to calculate the digest for content
 // verification. If an archive already exists for the version, it will be replaced.
-// Returns the updated version with populated archive metadata.
+// The file is written and synced before the version refers to it, and a
+// replaced file is removed after the version no longer does, so the version
+// never refers to a missing file. Returns the updated version with populated
+// archive metadata.
 func (r *SQLRegistry) UploadArchive(ctx context.Context, namespace string, resource string, version string, archiveReader io.Reader) (*Version, error) {
 	if err := validateReference(namespace, resource, version); err != nil {
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	if err := r.authorize(ctx, ActionResourcePublish, namespace); err != nil {
+		return nil, err
+	}
 
 	// Store archive file and calculate digest
 	digest, archivePath, size, err := r.storeArchiveFile(namespace, resource, version, archiveReader)
@@ -459,22 +968,58 @@ func (r *SQLRegistry) UploadArchive(ctx context.Context, namespace string, resou
 		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgStoreArchive, err, "namespace", namespace, "resource", resource, "version", version)
 	}
 
-	// Update version with archive metadata
-	if err := r.uploadArchive(ctx, namespace, resource, version, digest, archivePath, size); err != nil {
-		os.Remove(archivePath)
+	before, v, err := r.publishArchive(ctx, namespace, resource, version, digest, archivePath, size)
+	if err != nil {
+		r.discardArchive(ctx, archivePath)
+		return nil, err
+	}
+
+	if before.Archive != nil && *before.Archive != archivePath {
+		r.discardArchive(ctx, *before.Archive)
+	}
+
+	if err := r.exposeArchive(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Points a version at a stored archive file and records the upload.
+//
+// Runs in the caller's transaction, or in one of its own. Returns the version
+// before and after the change. Failures are logged and returned as [*Error].
+func (r *SQLRegistry) publishArchive(ctx context.Context, namespace, resource, version, digest, path string, size int64) (before, after *Version, err error) {
+	err = r.withTx(ctx, func(tx *SQLRegistry) error {
+		var err error
+		before, err = tx.getVersion(ctx, namespace, resource, version)
 		if err == sql.ErrNoRows {
-			return nil, r.logAndReturnError(ErrorCodeNotFound, errMsgVersionNotFound, err, "namespace", namespace, "resource", resource, "version", version)
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgVersionNotFound}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveVersion, err, "namespace", namespace, "resource", resource, "version", version)
 		}
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgUpdateArchive, err, "namespace", namespace, "resource", resource, "version", version)
-	}
 
-	// Return the updated version with archive details
-	v, err := r.getVersion(ctx, namespace, resource, version)
+		// Update version with archive metadata
+		if err := tx.uploadArchive(ctx, namespace, resource, version, digest, path, size); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgUpdateArchive, err, "namespace", namespace, "resource", resource, "version", version)
+		}
+
+		// Return the updated version with archive details
+		if after, err = tx.getVersion(ctx, namespace, resource, version); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveVersion, err, "namespace", namespace, "resource", resource, "version", version)
+		}
+
+		tx.emit(ctx, Event{Type: EventArchiveUploaded, Namespace: namespace, Resource: resource, Version: version, Digest: after.Digest})
+		if before.Digest == nil {
+			tx.emit(ctx, Event{Type: EventVersionPublished, Namespace: namespace, Resource: resource, Version: version, Digest: after.Digest})
+		}
+		return tx.audit(ctx, AuditEvent{Action: AuditArchiveUpload, Namespace: namespace, Resource: resource, Target: version, Before: summarize(summarizeArchive(before)), After: summarize(summarizeArchive(after)), Digest: after.Digest})
+	})
 	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveVersion, err, "namespace", namespace, "resource", resource, "version", version)
+		return nil, nil, r.registryError(err, errMsgUpdateArchive, "namespace", namespace, "resource", resource, "version", version)
 	}
 
-	return v, nil
+	return before, after, nil
 }
 
 // Returns a reader for a version's archive.
@@ -483,9 +1028,22 @@ func (r *SQLRegistry) UploadArchive(ctx context.Context, namespace string, resou
 // has been uploaded for the version. The caller is responsible for closing the
 // returned reader.
 func (r *SQLRegistry) DownloadArchive(ctx context.Context, namespace string, resource string, version string) (io.ReadCloser, error) {
+	return r.DownloadArchiveRange(ctx, namespace, resource, version, 0, -1)
+}
+
+// Returns a reader for part of a version's archive.
+//
+// Returns [ErrorCodeNotFound] if the version does not exist or if no archive
+// has been uploaded for the version, and [ErrorCodeRangeNotSatisfiable] if
+// offset is past the end of the archive. The caller is responsible for closing
+// the returned reader.
+func (r *SQLRegistry) DownloadArchiveRange(ctx context.Context, namespace string, resource string, version string, offset int64, length int64) (io.ReadCloser, error) {
 	if err := validateReference(namespace, resource, version); err != nil {
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
+	if offset < 0 {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: errMsgInvalidRange}
+	}
 
 	v, err := r.getVersion(ctx, namespace, resource, version)
 	if err == sql.ErrNoRows {
@@ -501,12 +1059,346 @@ func (r *SQLRegistry) DownloadArchive(ctx context.Context, namespace string, res
 	}
 
 	// Open and return archive file
-	file, err := os.Open(*v.Archive)
+	reader, err := openArchiveRange(*v.Archive, offset, length)
+	if err == errRangeNotSatisfiable {
+		return nil, &Error{Code: ErrorCodeRangeNotSatisfiable, Message: errMsgRangeNotSatisfied}
+	}
 	if err != nil {
 		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgAccessArchiveFile, err, "namespace", namespace, "resource", resource, "version", version)
 	}
 
-	return file, nil
+	return reader, nil
+}
+
+// Starts a resumable archive upload.
+//
+// Returns [ErrorCodeNotFound] if the version does not exist. The session is
+// recorded in the database and its data is kept in a partial file next to
+// the version's archives, so both survive a restart.
+func (r *SQLRegistry) StartUpload(ctx context.Context, namespace string, resource string, version string) (*Upload, error) {
+	if err := validateReference(namespace, resource, version); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	if err := r.authorize(ctx, ActionResourcePublish, namespace); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.getVersion(ctx, namespace, resource, version); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgVersionNotFound}
+		}
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveVersion, err, "namespace", namespace, "resource", resource, "version", version)
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgStartUpload, err, "namespace", namespace, "resource", resource, "version", version)
+	}
+
+	path := r.uploadTempPath(namespace, resource, version, id)
+	if err := createUploadFile(path); err != nil {
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgStartUpload, err, "namespace", namespace, "resource", resource, "version", version)
+	}
+
+	u, err := r.insertUpload(ctx, namespace, resource, version, id, path)
+	if err != nil {
+		os.Remove(path)
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgStartUpload, err, "namespace", namespace, "resource", resource, "version", version)
+	}
+
+	return u, nil
+}
+
+// Retrieves a resumable upload session.
+//
+// Returns [ErrorCodeNotFound] if the session does not exist for the version.
+func (r *SQLRegistry) ReadUpload(ctx context.Context, namespace string, resource string, version string, id string) (*Upload, error) {
+	if err := validateReference(namespace, resource, version); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	u, _, err := r.getUpload(ctx, namespace, resource, version, id)
+	if err == sql.ErrNoRows {
+		return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgUploadNotFound}
+	}
+	if err != nil {
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveUpload, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+	}
+
+	return u, nil
+}
+
+// Appends a chunk to a resumable upload.
+//
+// Returns [ErrorCodeNotFound] if the session does not exist and
+// [ErrorCodeInvalidOffset] if offset is not the current offset of the
+// session. The chunk is received into a temporary file first, then appended
+// with the session locked, so concurrent chunks are applied one at a time
+// and only one of them for each offset. The chunk is persisted before the
+// offset is advanced, so an interrupted chunk leaves the session at its
+// previous offset.
+func (r *SQLRegistry) UploadChunk(ctx context.Context, namespace string, resource string, version string, id string, offset int64, chunk io.Reader) (*Upload, error) {
+	if err := validateReference(namespace, resource, version); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	if err := r.authorize(ctx, ActionResourcePublish, namespace); err != nil {
+		return nil, err
+	}
+
+	// Receive the chunk before locking the session
+	staged, err := r.stageChunk(namespace, resource, version, chunk)
+	if err != nil {
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgStoreUploadChunk, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+	}
+	defer os.Remove(staged)
+
+	var u *Upload
+	err = r.withTx(ctx, func(tx *SQLRegistry) error {
+		var path string
+		var err error
+		if u, path, err = tx.lockUpload(ctx, namespace, resource, version, id); err != nil {
+			return err
+		}
+
+		if offset != u.Offset {
+			return &Error{Code: ErrorCodeInvalidOffset, Message: fmt.Sprintf("upload is at offset %d", u.Offset)}
+		}
+
+		f, err := os.Open(staged)
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgStoreUploadChunk, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+		}
+		defer f.Close()
+
+		n, err := writeUploadChunk(path, offset, f)
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgStoreUploadChunk, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+		}
+
+		if err := tx.advanceUpload(ctx, id, offset, offset+n); err != nil {
+			if err == sql.ErrNoRows {
+				return &Error{Code: ErrorCodeInvalidOffset, Message: errMsgUploadOffsetChanged}
+			}
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgStoreUploadChunk, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+		}
+
+		u.Offset = offset + n
+		u.UpdatedAt = time.Now().Unix()
+		return nil
+	})
+	if err != nil {
+		return nil, r.registryError(err, errMsgStoreUploadChunk, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+	}
+
+	return u, nil
+}
+
+// Locks an upload session for the rest of the transaction and reads it.
+//
+// Returns the session and the path of its partial file. Returns
+// [ErrorCodeNotFound] if the session does not exist. Other failures are
+// logged and returned as [ErrorCodeInternalError].
+func (r *SQLRegistry) lockUpload(ctx context.Context, namespace, resource, version, id string) (*Upload, string, error) {
+	err := r.claimUpload(ctx, namespace, resource, version, id)
+	if err == nil {
+		var u *Upload
+		var path string
+		if u, path, err = r.getUpload(ctx, namespace, resource, version, id); err == nil {
+			return u, path, nil
+		}
+	}
+
+	if err == sql.ErrNoRows {
+		return nil, "", &Error{Code: ErrorCodeNotFound, Message: errMsgUploadNotFound}
+	}
+	return nil, "", r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveUpload, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+}
+
+// Completes a resumable upload.
+//
+// Returns [ErrorCodeNotFound] if the session does not exist,
+// [ErrorCodeBadRequest] if digest cannot be parsed, and
+// [ErrorCodeDigestMismatch] if the uploaded data does not match it, in which
+// case the session is discarded. Returns [ErrorCodeInvalidOffset] if a chunk
+// was appended while the data was being verified. The version is updated and
+// the session deleted in one transaction. Returns the updated version with
+// populated archive metadata.
+func (r *SQLRegistry) CommitUpload(ctx context.Context, namespace string, resource string, version string, id string, digest string) (*Version, error) {
+	if err := validateReference(namespace, resource, version); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	if err := r.authorize(ctx, ActionResourcePublish, namespace); err != nil {
+		return nil, err
+	}
+
+	expected, err := reference.ParseDigest(digest)
+	if err == nil {
+		err = expected.Validate()
+	}
+	if err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: errMsgInvalidUploadDigest}
+	}
+
+	u, path, err := r.getUpload(ctx, namespace, resource, version, id)
+	if err == sql.ErrNoRows {
+		return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgUploadNotFound}
+	}
+	if err != nil {
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveUpload, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+	}
+
+	// Verify before locking the session. Recorded data never changes, so the
+	// result holds as long as the offset does.
+	mismatch := false
+	if err := verifyUploadFile(path, u.Offset, expected); errors.Is(err, reference.ErrDigestMismatch) {
+		mismatch = true
+	} else if err != nil {
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgCommitUpload, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+	}
+
+	var before, v *Version
+	var archivePath string
+	err = r.withTx(ctx, func(tx *SQLRegistry) error {
+		locked, _, err := tx.lockUpload(ctx, namespace, resource, version, id)
+		if err != nil {
+			return err
+		}
+		if locked.Offset != u.Offset {
+			return &Error{Code: ErrorCodeInvalidOffset, Message: errMsgUploadOffsetChanged}
+		}
+
+		if err := tx.deleteUpload(ctx, namespace, resource, version, id); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgCommitUpload, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+		}
+		if mismatch {
+			return nil
+		}
+
+		if archivePath, err = r.storeUploadFile(namespace, resource, version, path, u.Offset, expected.Hash); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgCommitUpload, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+		}
+
+		before, v, err = tx.publishArchive(ctx, namespace, resource, version, expected.String(), archivePath, u.Offset)
+		return err
+	})
+	if err != nil {
+		if archivePath != "" {
+			r.discardArchive(ctx, archivePath)
+		}
+		return nil, r.registryError(err, errMsgCommitUpload, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+	}
+
+	// The session is gone, and the archive, if any, has its own link
+	os.Remove(path)
+
+	if mismatch {
+		return nil, &Error{Code: ErrorCodeDigestMismatch, Message: errMsgUploadDigestMismatch}
+	}
+
+	if before.Archive != nil && *before.Archive != archivePath {
+		r.discardArchive(ctx, *before.Archive)
+	}
+
+	if err := r.exposeArchive(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Discards a resumable upload.
+//
+// Removes the session and its partial file. The operation is idempotent,
+// returning success if the session does not exist.
+func (r *SQLRegistry) CancelUpload(ctx context.Context, namespace string, resource string, version string, id string) error {
+	if err := validateReference(namespace, resource, version); err != nil {
+		return &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	if err := r.authorize(ctx, ActionResourcePublish, namespace); err != nil {
+		return err
+	}
+
+	var path string
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		var err error
+		if _, path, err = tx.lockUpload(ctx, namespace, resource, version, id); err != nil {
+			return err
+		}
+
+		if err := tx.deleteUpload(ctx, namespace, resource, version, id); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgDeleteUpload, err, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+		}
+		return nil
+	})
+	if hasErrorCode(err, ErrorCodeNotFound) {
+		return nil
+	}
+	if err != nil {
+		return r.registryError(err, errMsgDeleteUpload, "namespace", namespace, "resource", resource, "version", version, "upload", id)
+	}
+
+	os.Remove(path)
+	return nil
+}
+
+// Removes archive files left behind by interrupted operations.
+//
+// Archives are stored before the database refers to them, and replaced
+// archives are removed after it no longer does, so a crash in between leaves
+// a file nothing refers to, but never a reference to a missing file. Removes
+// archive and temporary files under the archive root that no version or
+// upload session refers to, except those modified within the last hour, which
+// may belong to an upload in progress. Returns the number of files removed.
+// Safe to call while the registry is in use, e.g. periodically or on startup.
+func (r *SQLRegistry) SweepArchives(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-archiveSweepGrace)
+
+	// Collect candidates before the references, so that files published
+	// meanwhile are either referenced or too recent
+	var candidates []string
+	err := filepath.WalkDir(r.archiveRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() && isArchiveFile(path) {
+			candidates = append(candidates, path)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return 0, r.logAndReturnError(ErrorCodeInternalError, errMsgSweepArchives, err, "root", r.archiveRoot)
+	}
+
+	referenced, err := r.listArchivePaths(ctx)
+	if err != nil {
+		return 0, r.logAndReturnError(ErrorCodeInternalError, errMsgSweepArchives, err, "root", r.archiveRoot)
+	}
+
+	removed := 0
+	for _, path := range candidates {
+		if referenced[path] {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			r.logger.Warn("failed to remove unreferenced archive", "error", err, "path", path)
+			continue
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		r.logger.Info("swept unreferenced archives", "removed", removed)
+	}
+	return removed, nil
 }
 
 // Creates a new channel.
@@ -519,46 +1411,86 @@ func (r *SQLRegistry) CreateChannel(ctx context.Context, namespace string, resou
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	if err := r.insertChannel(ctx, namespace, resource, info); err != nil {
+	if err := r.authorize(ctx, ActionChannelUpdate, namespace); err != nil {
+		return nil, err
+	}
 
-		// Check if channel already exists
-		if _, checkErr := r.getChannel(ctx, namespace, resource, info.Name); checkErr == nil {
-			return nil, &Error{Code: ErrorCodeChannelExists, Message: errMsgChannelExists}
-		}
+	var c *Channel
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		if err := tx.insertChannel(ctx, namespace, resource, info, subjectFromContext(ctx)); err != nil {
+			switch r.dialect.classify(err) {
+			case violationUnique:
+				return &Error{Code: ErrorCodeChannelExists, Message: errMsgChannelExists}
+			case violationForeignKey:
+				return &Error{Code: ErrorCodeNotFound, Message: errMsgChannelTargetNotFound}
+			}
 
-		// Check if resource exists (foreign key constraint)
-		if _, checkErr := r.getResource(ctx, namespace, resource); checkErr == sql.ErrNoRows {
-			return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgResourceNotFound}
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgCreateChannel, err, "namespace", namespace, "resource", resource, "channel", info.Name)
 		}
 
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgCreateChannel, err, "namespace", namespace, "resource", resource, "channel", info.Name)
-	}
+		// Get the newly created channel
+		var err error
+		if c, err = tx.getChannel(ctx, namespace, resource, info.Name); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveChannel, err, "namespace", namespace, "resource", resource, "channel", info.Name)
+		}
 
-	// Get the newly created channel
-	c, err := r.getChannel(ctx, namespace, resource, info.Name)
+		tx.emit(ctx, Event{Type: EventChannelMoved, Namespace: namespace, Resource: resource, Channel: info.Name, Version: c.Version.String, Digest: c.Version.Digest})
+		return tx.audit(ctx, AuditEvent{Action: AuditChannelCreate, Namespace: namespace, Resource: resource, Target: info.Name, After: summarize(channelSummary(c)), Digest: c.Version.Digest})
+	})
 	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveChannel, err, "namespace", namespace, "resource", resource, "channel", info.Name)
+		return nil, r.registryError(err, errMsgCreateChannel, "namespace", namespace, "resource", resource, "channel", info.Name)
 	}
+
 	return c, nil
 }
 
 // Updates a channel's mutable metadata.
 //
 // The target version and description can be modified. The channel name cannot
-// be changed after creation. Returns [ErrorCodeNotFound] if the channel does
-// not exist.
-func (r *SQLRegistry) UpdateChannel(ctx context.Context, namespace string, resource string, channel string, info ChannelInfo) (*Channel, error) {
+// be changed after creation. Returns [ErrorCodeNotFound] if the channel or
+// the target version does not exist.
+//
+// Returns [ErrorCodePreconditionFailed] if expected is neither [AnyRevision]
+// nor the current revision of the channel.
+func (r *SQLRegistry) UpdateChannel(ctx context.Context, namespace string, resource string, channel string, info ChannelInfo, expected Revision) (*Channel, error) {
 	if err := validateChannelInfo(namespace, resource, info); err != nil {
 		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	c, err := r.updateChannel(ctx, namespace, resource, info)
-	if err == sql.ErrNoRows {
-		return nil, &Error{Code: ErrorCodeNotFound, Message: errMsgChannelNotFound}
+	if err := r.authorize(ctx, ActionChannelUpdate, namespace); err != nil {
+		return nil, err
 	}
+
+	var c *Channel
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		before, err := tx.getChannel(ctx, namespace, resource, info.Name)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgChannelNotFound}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveChannel, err, "namespace", namespace, "resource", resource, "channel", info.Name)
+		}
+
+		c, err = tx.updateChannel(ctx, namespace, resource, info, expected, subjectFromContext(ctx))
+		if r.dialect.classify(err) == violationForeignKey {
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgVersionNotFound}
+		}
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgRevisionMismatch}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgUpdateChannel, err, "namespace", namespace, "resource", resource, "channel", info.Name)
+		}
+
+		if c.Version.String != before.Version.String {
+			tx.emit(ctx, Event{Type: EventChannelMoved, Namespace: namespace, Resource: resource, Channel: info.Name, Version: c.Version.String, Previous: before.Version.String, Digest: c.Version.Digest})
+		}
+		return tx.audit(ctx, AuditEvent{Action: AuditChannelUpdate, Namespace: namespace, Resource: resource, Target: info.Name, Before: summarize(channelSummary(before)), After: summarize(channelSummary(c)), Digest: c.Version.Digest})
+	})
 	if err != nil {
-		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgUpdateChannel, err, "namespace", namespace, "resource", resource, "channel", info.Name)
+		return nil, r.registryError(err, errMsgUpdateChannel, "namespace", namespace, "resource", resource, "channel", info.Name)
 	}
+
 	return c, nil
 }
 
@@ -586,14 +1518,41 @@ func (r *SQLRegistry) ReadChannel(ctx context.Context, namespace string, resourc
 //
 // The referenced version and its archive are not affected. This operation only
 // removes the mutable pointer to the version.
-func (r *SQLRegistry) DeleteChannel(ctx context.Context, namespace string, resource string, channel string) error {
+//
+// Returns [ErrorCodePreconditionFailed] if expected is neither [AnyRevision]
+// nor the current revision of the channel.
+func (r *SQLRegistry) DeleteChannel(ctx context.Context, namespace string, resource string, channel string, expected Revision) error {
 	if err := validateChannelReference(namespace, resource, channel); err != nil {
 		return &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
 	}
 
-	if err := r.deleteChannel(ctx, namespace, resource, channel); err != nil {
-		return r.logAndReturnError(ErrorCodeInternalError, errMsgDeleteChannel, err, "namespace", namespace, "resource", resource, "channel", channel)
+	if err := r.authorize(ctx, ActionChannelUpdate, namespace); err != nil {
+		return err
+	}
+
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		before, err := tx.getChannel(ctx, namespace, resource, channel)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveChannel, err, "namespace", namespace, "resource", resource, "channel", channel)
+		}
+
+		err = tx.deleteChannel(ctx, namespace, resource, channel, expected)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgRevisionMismatch}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgDeleteChannel, err, "namespace", namespace, "resource", resource, "channel", channel)
+		}
+
+		return tx.audit(ctx, AuditEvent{Action: AuditChannelDelete, Namespace: namespace, Resource: resource, Target: channel, Before: summarize(channelSummary(before)), Digest: before.Version.Digest})
+	})
+	if err != nil {
+		return r.registryError(err, errMsgDeleteChannel, "namespace", namespace, "resource", resource, "channel", channel)
 	}
+
 	return nil
 }
 
@@ -613,3 +1572,135 @@ func (r *SQLRegistry) ListChannels(ctx context.Context, namespace string, resour
 	}
 	return &ChannelList{Channels: channels}, nil
 }
+
+// Returns the pointer changes of a channel, newest first.
+//
+// Returns a [ChannelHistory] that may be empty if the channel never existed.
+// The history of a deleted channel remains available until its resource is
+// deleted.
+func (r *SQLRegistry) ListChannelHistory(ctx context.Context, namespace string, resource string, channel string) (*ChannelHistory, error) {
+	if err := validateChannelReference(namespace, resource, channel); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+
+	entries, err := r.listChannelHistory(ctx, namespace, resource, channel)
+	if err != nil {
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveHistory, err, "namespace", namespace, "resource", resource, "channel", channel)
+	}
+	return &ChannelHistory{Entries: entries}, nil
+}
+
+// Points a channel back at a version it pointed to before.
+//
+// An empty version undoes the most recent change, so rolling back twice
+// returns the channel to where it started. Returns [ErrorCodeNotFound] if the
+// channel does not exist, has no previous version, never pointed at version,
+// or if that version has been deleted. Returns [ErrorCodePreconditionFailed]
+// if the channel is moved concurrently. Rolling back to the current version
+// changes nothing.
+func (r *SQLRegistry) RollbackChannel(ctx context.Context, namespace string, resource string, channel string, version string) (*Channel, error) {
+	if err := validateChannelReference(namespace, resource, channel); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+	if version != "" {
+		if err := validateVersionString(version); err != nil {
+			return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+		}
+	}
+
+	if err := r.authorize(ctx, ActionChannelUpdate, namespace); err != nil {
+		return nil, err
+	}
+
+	var c *Channel
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		current, err := tx.getChannel(ctx, namespace, resource, channel)
+		if err == sql.ErrNoRows {
+			return &Error{Code: ErrorCodeNotFound, Message: errMsgChannelNotFound}
+		}
+		if err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveChannel, err, "namespace", namespace, "resource", resource, "channel", channel)
+		}
+
+		target, err := tx.rollbackTarget(ctx, namespace, resource, channel, version)
+		if err != nil {
+			return err
+		}
+		if target == current.Version.String {
+			c = current
+			return nil
+		}
+
+		if err := tx.moveChannel(ctx, namespace, resource, channel, current.Version.String, target, subjectFromContext(ctx)); err != nil {
+			if err == errChannelMoved {
+				return &Error{Code: ErrorCodePreconditionFailed, Message: errMsgChannelMoved}
+			}
+
+			// The target version was deleted since it was recorded
+			if r.dialect.classify(err) == violationForeignKey {
+				return &Error{Code: ErrorCodeNotFound, Message: errMsgVersionNotFound}
+			}
+
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRollbackChannel, err, "namespace", namespace, "resource", resource, "channel", channel, "version", target)
+		}
+
+		if c, err = tx.getChannel(ctx, namespace, resource, channel); err != nil {
+			return r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveChannel, err, "namespace", namespace, "resource", resource, "channel", channel)
+		}
+
+		tx.emit(ctx, Event{Type: EventChannelMoved, Namespace: namespace, Resource: resource, Channel: channel, Version: c.Version.String, Previous: current.Version.String, Digest: c.Version.Digest})
+		return tx.audit(ctx, AuditEvent{Action: AuditChannelRollback, Namespace: namespace, Resource: resource, Target: channel, Before: summarize(channelSummary(current)), After: summarize(channelSummary(c)), Digest: c.Version.Digest})
+	})
+	if err != nil {
+		return nil, r.registryError(err, errMsgRollbackChannel, "namespace", namespace, "resource", resource, "channel", channel)
+	}
+
+	return c, nil
+}
+
+// Resolves the version a rollback points a channel at.
+//
+// An empty version resolves to the version before the most recent change.
+// Otherwise the version must appear in the channel history.
+func (r *SQLRegistry) rollbackTarget(ctx context.Context, namespace, resource, channel, version string) (string, error) {
+	if version == "" {
+		latest, err := r.latestChannelHistory(ctx, namespace, resource, channel)
+		if err != nil && err != sql.ErrNoRows {
+			return "", r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveHistory, err, "namespace", namespace, "resource", resource, "channel", channel)
+		}
+		if latest == nil || latest.Previous == nil {
+			return "", &Error{Code: ErrorCodeNotFound, Message: errMsgNoPreviousVersion}
+		}
+		return *latest.Previous, nil
+	}
+
+	entries, err := r.listChannelHistory(ctx, namespace, resource, channel)
+	if err != nil {
+		return "", r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveHistory, err, "namespace", namespace, "resource", resource, "channel", channel)
+	}
+	for _, e := range entries {
+		if e.Version == version {
+			return version, nil
+		}
+	}
+	return "", &Error{Code: ErrorCodeNotFound, Message: errMsgVersionNotInHistory}
+}
+
+// Returns recorded mutations matching a filter, newest first.
+//
+// Returns [ErrorCodeBadRequest] if the filter is invalid. A zero limit
+// returns up to [DefaultAuditLimit] events.
+func (r *SQLRegistry) ListAuditEvents(ctx context.Context, filter AuditFilter) (*AuditEventList, error) {
+	if err := validateAuditFilter(filter); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultAuditLimit
+	}
+
+	events, err := r.listAuditEvents(ctx, filter)
+	if err != nil {
+		return nil, r.logAndReturnError(ErrorCodeInternalError, errMsgRetrieveAuditEvents, err, "namespace", filter.Namespace, "resource", filter.Resource, "target", filter.Target)
+	}
+	return &AuditEventList{Events: events}, nil
+}
@@ -4,10 +4,18 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"io"
 	"log/slog"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/cruciblehq/protocol/pkg/archive"
+	"github.com/cruciblehq/protocol/pkg/reference"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -219,7 +227,7 @@ func TestUpdateNamespace_Success(t *testing.T) {
 	ctx := context.Background()
 	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Original"})
 
-	updated, err := registry.UpdateNamespace(ctx, "test-ns", NamespaceInfo{Name: "test-ns", Description: "Updated"})
+	updated, err := registry.UpdateNamespace(ctx, "test-ns", NamespaceInfo{Name: "test-ns", Description: "Updated"}, AnyRevision)
 	if err != nil {
 		t.Fatalf("UpdateNamespace() error = %v", err)
 	}
@@ -235,7 +243,7 @@ func TestUpdateNamespace_InvalidName(t *testing.T) {
 
 	ctx := context.Background()
 
-	_, err := registry.UpdateNamespace(ctx, "Invalid-Name", NamespaceInfo{Name: "test", Description: "Test"})
+	_, err := registry.UpdateNamespace(ctx, "Invalid-Name", NamespaceInfo{Name: "test", Description: "Test"}, AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for invalid namespace name, got nil")
 	}
@@ -255,7 +263,7 @@ func TestUpdateNamespace_NotFound(t *testing.T) {
 
 	ctx := context.Background()
 
-	_, err := registry.UpdateNamespace(ctx, "nonexistent", NamespaceInfo{Name: "nonexistent", Description: "Test"})
+	_, err := registry.UpdateNamespace(ctx, "nonexistent", NamespaceInfo{Name: "nonexistent", Description: "Test"}, AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for nonexistent namespace, got nil")
 	}
@@ -276,7 +284,7 @@ func TestDeleteNamespace_Success(t *testing.T) {
 	ctx := context.Background()
 	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
 
-	err := registry.DeleteNamespace(ctx, "test-ns")
+	err := registry.DeleteNamespace(ctx, "test-ns", AnyRevision)
 	if err != nil {
 		t.Fatalf("DeleteNamespace() error = %v", err)
 	}
@@ -293,7 +301,7 @@ func TestDeleteNamespace_InvalidName(t *testing.T) {
 
 	ctx := context.Background()
 
-	err := registry.DeleteNamespace(ctx, "Invalid-Name")
+	err := registry.DeleteNamespace(ctx, "Invalid-Name", AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for invalid namespace name, got nil")
 	}
@@ -315,7 +323,7 @@ func TestDeleteNamespace_WithResources(t *testing.T) {
 	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
 	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
 
-	err := registry.DeleteNamespace(ctx, "test-ns")
+	err := registry.DeleteNamespace(ctx, "test-ns", AnyRevision)
 	if err == nil {
 		t.Fatal("expected error deleting namespace with resources, got nil")
 	}
@@ -349,6 +357,111 @@ func TestListNamespaces(t *testing.T) {
 	}
 }
 
+func TestCreateMember_Success(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+
+	m, err := registry.CreateMember(ctx, "test-ns", MemberInfo{Subject: "alice", Role: RolePublisher})
+	if err != nil {
+		t.Fatalf("CreateMember() error = %v", err)
+	}
+	if m.Namespace != "test-ns" || m.Subject != "alice" || m.Role != RolePublisher {
+		t.Errorf("unexpected member: %+v", m)
+	}
+
+	_, err = registry.CreateMember(ctx, "test-ns", MemberInfo{Subject: "alice", Role: RoleReader})
+	assertErrorCode(t, err, ErrorCodeMemberExists)
+}
+
+func TestCreateMember_InvalidRole(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+
+	_, err := registry.CreateMember(ctx, "test-ns", MemberInfo{Subject: "alice", Role: "owner"})
+	assertErrorCode(t, err, ErrorCodeBadRequest)
+
+	_, err = registry.CreateMember(ctx, "test-ns", MemberInfo{Role: RoleReader})
+	assertErrorCode(t, err, ErrorCodeBadRequest)
+}
+
+func TestCreateMember_NamespaceNotFound(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, err := registry.CreateMember(context.Background(), "missing", MemberInfo{Subject: "alice", Role: RoleReader})
+	assertErrorCode(t, err, ErrorCodeNotFound)
+}
+
+func TestUpdateMember(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateMember(ctx, "test-ns", MemberInfo{Subject: "alice", Role: RoleReader})
+
+	m, err := registry.UpdateMember(ctx, "test-ns", "alice", MemberInfo{Role: RoleAdmin}, AnyRevision)
+	if err != nil {
+		t.Fatalf("UpdateMember() error = %v", err)
+	}
+	if m.Role != RoleAdmin {
+		t.Errorf("Role = %q, want %q", m.Role, RoleAdmin)
+	}
+
+	_, err = registry.UpdateMember(ctx, "test-ns", "bob", MemberInfo{Role: RoleAdmin}, AnyRevision)
+	assertErrorCode(t, err, ErrorCodeNotFound)
+}
+
+func TestDeleteMember(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateMember(ctx, "test-ns", MemberInfo{Subject: "alice", Role: RoleReader})
+
+	if err := registry.DeleteMember(ctx, "test-ns", "alice", AnyRevision); err != nil {
+		t.Fatalf("DeleteMember() error = %v", err)
+	}
+	if err := registry.DeleteMember(ctx, "test-ns", "alice", AnyRevision); err != nil {
+		t.Fatalf("DeleteMember() second call error = %v", err)
+	}
+
+	_, err := registry.ReadMember(ctx, "test-ns", "alice")
+	assertErrorCode(t, err, ErrorCodeNotFound)
+}
+
+func TestListMembers(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateMember(ctx, "test-ns", MemberInfo{Subject: "carol", Role: RoleReader})
+	_, _ = registry.CreateMember(ctx, "test-ns", MemberInfo{Subject: "alice", Role: RoleAdmin})
+
+	list, err := registry.ListMembers(ctx, "test-ns")
+	if err != nil {
+		t.Fatalf("ListMembers() error = %v", err)
+	}
+	if len(list.Members) != 2 || list.Members[0].Subject != "alice" || list.Members[1].Subject != "carol" {
+		t.Errorf("unexpected members: %+v", list.Members)
+	}
+
+	// Memberships are deleted along with the namespace
+	if err := registry.DeleteNamespace(ctx, "test-ns", AnyRevision); err != nil {
+		t.Fatalf("DeleteNamespace() error = %v", err)
+	}
+	_, err = registry.ListMembers(ctx, "test-ns")
+	assertErrorCode(t, err, ErrorCodeNotFound)
+}
+
 func TestCreateResource_Success(t *testing.T) {
 	registry, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -522,7 +635,7 @@ func TestUpdateResource_Success(t *testing.T) {
 	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
 	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Original"})
 
-	updated, err := registry.UpdateResource(ctx, "test-ns", "test-resource", ResourceInfo{Name: "test-resource", Type: "service", Description: "Updated"})
+	updated, err := registry.UpdateResource(ctx, "test-ns", "test-resource", ResourceInfo{Name: "test-resource", Type: "service", Description: "Updated"}, AnyRevision)
 	if err != nil {
 		t.Fatalf("UpdateResource() error = %v", err)
 	}
@@ -538,7 +651,7 @@ func TestUpdateResource_InvalidNames(t *testing.T) {
 
 	ctx := context.Background()
 
-	_, err := registry.UpdateResource(ctx, "Invalid-Name", "test-resource", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, err := registry.UpdateResource(ctx, "Invalid-Name", "test-resource", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"}, AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for invalid namespace name, got nil")
 	}
@@ -559,7 +672,7 @@ func TestUpdateResource_NotFound(t *testing.T) {
 	ctx := context.Background()
 	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
 
-	_, err := registry.UpdateResource(ctx, "test-ns", "nonexistent", ResourceInfo{Name: "nonexistent", Type: "widget", Description: "Test"})
+	_, err := registry.UpdateResource(ctx, "test-ns", "nonexistent", ResourceInfo{Name: "nonexistent", Type: "widget", Description: "Test"}, AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for nonexistent resource, got nil")
 	}
@@ -581,7 +694,7 @@ func TestDeleteResource_Success(t *testing.T) {
 	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
 	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
 
-	err := registry.DeleteResource(ctx, "test-ns", "test-resource")
+	err := registry.DeleteResource(ctx, "test-ns", "test-resource", AnyRevision)
 	if err != nil {
 		t.Fatalf("DeleteResource() error = %v", err)
 	}
@@ -593,7 +706,7 @@ func TestDeleteResource_InvalidNames(t *testing.T) {
 
 	ctx := context.Background()
 
-	err := registry.DeleteResource(ctx, "Invalid-Name", "test-resource")
+	err := registry.DeleteResource(ctx, "Invalid-Name", "test-resource", AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for invalid namespace name, got nil")
 	}
@@ -829,7 +942,7 @@ func TestUpdateVersion_Success(t *testing.T) {
 	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
 	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
 
-	updated, err := registry.UpdateVersion(ctx, "test-ns", "test-resource", "1.0.0", VersionInfo{String: "1.0.0"})
+	updated, err := registry.UpdateVersion(ctx, "test-ns", "test-resource", "1.0.0", VersionInfo{String: "1.0.0"}, AnyRevision)
 	if err != nil {
 		t.Fatalf("UpdateVersion() error = %v", err)
 	}
@@ -845,7 +958,7 @@ func TestUpdateVersion_InvalidNames(t *testing.T) {
 
 	ctx := context.Background()
 
-	_, err := registry.UpdateVersion(ctx, "Invalid-Name", "test-resource", "1.0.0", VersionInfo{String: "1.0.0"})
+	_, err := registry.UpdateVersion(ctx, "Invalid-Name", "test-resource", "1.0.0", VersionInfo{String: "1.0.0"}, AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for invalid namespace name, got nil")
 	}
@@ -867,7 +980,7 @@ func TestUpdateVersion_NotFound(t *testing.T) {
 	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
 	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
 
-	_, err := registry.UpdateVersion(ctx, "test-ns", "test-resource", "9.9.9", VersionInfo{String: "9.9.9"})
+	_, err := registry.UpdateVersion(ctx, "test-ns", "test-resource", "9.9.9", VersionInfo{String: "9.9.9"}, AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for nonexistent version, got nil")
 	}
@@ -890,7 +1003,7 @@ func TestDeleteVersion_Success(t *testing.T) {
 	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
 	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
 
-	err := registry.DeleteVersion(ctx, "test-ns", "test-resource", "1.0.0")
+	err := registry.DeleteVersion(ctx, "test-ns", "test-resource", "1.0.0", AnyRevision)
 	if err != nil {
 		t.Fatalf("DeleteVersion() error = %v", err)
 	}
@@ -907,7 +1020,7 @@ func TestDeleteVersion_InvalidNames(t *testing.T) {
 
 	ctx := context.Background()
 
-	err := registry.DeleteVersion(ctx, "Invalid-Name", "test-resource", "1.0.0")
+	err := registry.DeleteVersion(ctx, "Invalid-Name", "test-resource", "1.0.0", AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for invalid namespace name, got nil")
 	}
@@ -1244,7 +1357,7 @@ func TestUpdateChannel_Success(t *testing.T) {
 	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "2.0.0"})
 	_, _ = registry.CreateChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Stable"})
 
-	updated, err := registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "2.0.0", Description: "Updated"})
+	updated, err := registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "2.0.0", Description: "Updated"}, AnyRevision)
 	if err != nil {
 		t.Fatalf("UpdateChannel() error = %v", err)
 	}
@@ -1260,7 +1373,7 @@ func TestUpdateChannel_InvalidNames(t *testing.T) {
 
 	ctx := context.Background()
 
-	_, err := registry.UpdateChannel(ctx, "Invalid-Name", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Test"})
+	_, err := registry.UpdateChannel(ctx, "Invalid-Name", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Test"}, AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for invalid namespace name, got nil")
 	}
@@ -1283,7 +1396,7 @@ func TestUpdateChannel_NotFound(t *testing.T) {
 	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
 	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
 
-	_, err := registry.UpdateChannel(ctx, "test-ns", "test-resource", "nonexistent", ChannelInfo{Name: "nonexistent", Version: "1.0.0", Description: "Test"})
+	_, err := registry.UpdateChannel(ctx, "test-ns", "test-resource", "nonexistent", ChannelInfo{Name: "nonexistent", Version: "1.0.0", Description: "Test"}, AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for nonexistent channel, got nil")
 	}
@@ -1297,6 +1410,102 @@ func TestUpdateChannel_NotFound(t *testing.T) {
 	}
 }
 
+func TestUpdateChannel_Revision(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "2.0.0"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "3.0.0"})
+	created, err := registry.CreateChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("CreateChannel() error = %v", err)
+	}
+	if created.Revision != 1 {
+		t.Errorf("Revision = %d, want 1", created.Revision)
+	}
+
+	// Two writers read the same revision; only the first update applies
+	first, err := registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "2.0.0"}, created.Revision)
+	if err != nil {
+		t.Fatalf("UpdateChannel() error = %v", err)
+	}
+	if first.Revision != 2 {
+		t.Errorf("Revision = %d, want 2", first.Revision)
+	}
+
+	_, err = registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "3.0.0"}, created.Revision)
+	if regErr, ok := err.(*Error); !ok || regErr.Code != ErrorCodePreconditionFailed {
+		t.Fatalf("UpdateChannel() with stale revision error = %v, want %v", err, ErrorCodePreconditionFailed)
+	}
+
+	current, _ := registry.ReadChannel(ctx, "test-ns", "test-resource", "stable")
+	if current.Version.String != "2.0.0" || current.Revision != 2 {
+		t.Errorf("channel = %s at revision %d, want 2.0.0 at revision 2", current.Version.String, current.Revision)
+	}
+
+	rolled, err := registry.RollbackChannel(ctx, "test-ns", "test-resource", "stable", "1.0.0")
+	if err != nil {
+		t.Fatalf("RollbackChannel() error = %v", err)
+	}
+	if rolled.Revision != 3 {
+		t.Errorf("Revision after rollback = %d, want 3", rolled.Revision)
+	}
+}
+
+func TestDeleteChannel_Revision(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+	_, _ = registry.CreateChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0"})
+	updated, _ := registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Updated"}, AnyRevision)
+
+	err := registry.DeleteChannel(ctx, "test-ns", "test-resource", "stable", 1)
+	if regErr, ok := err.(*Error); !ok || regErr.Code != ErrorCodePreconditionFailed {
+		t.Fatalf("DeleteChannel() with stale revision error = %v, want %v", err, ErrorCodePreconditionFailed)
+	}
+	if _, err := registry.ReadChannel(ctx, "test-ns", "test-resource", "stable"); err != nil {
+		t.Fatalf("channel deleted despite stale revision: %v", err)
+	}
+
+	if err := registry.DeleteChannel(ctx, "test-ns", "test-resource", "stable", updated.Revision); err != nil {
+		t.Fatalf("DeleteChannel() error = %v", err)
+	}
+}
+
+func TestUpdateNamespace_Revision(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	created, _ := registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+
+	updated, err := registry.UpdateNamespace(ctx, "test-ns", NamespaceInfo{Name: "test-ns", Description: "Updated"}, created.Revision)
+	if err != nil {
+		t.Fatalf("UpdateNamespace() error = %v", err)
+	}
+	if updated.Revision != created.Revision+1 {
+		t.Errorf("Revision = %d, want %d", updated.Revision, created.Revision+1)
+	}
+
+	_, err = registry.UpdateNamespace(ctx, "test-ns", NamespaceInfo{Name: "test-ns", Description: "Lost"}, created.Revision)
+	if regErr, ok := err.(*Error); !ok || regErr.Code != ErrorCodePreconditionFailed {
+		t.Fatalf("UpdateNamespace() with stale revision error = %v, want %v", err, ErrorCodePreconditionFailed)
+	}
+
+	read, _ := registry.ReadNamespace(ctx, "test-ns")
+	if read.Description != "Updated" || read.Revision != updated.Revision {
+		t.Errorf("namespace = %q at revision %d, want %q at revision %d", read.Description, read.Revision, "Updated", updated.Revision)
+	}
+}
+
 func TestReadChannel_Success(t *testing.T) {
 	registry, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -1369,7 +1578,7 @@ func TestDeleteChannel_Success(t *testing.T) {
 	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
 	_, _ = registry.CreateChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Stable"})
 
-	err := registry.DeleteChannel(ctx, "test-ns", "test-resource", "stable")
+	err := registry.DeleteChannel(ctx, "test-ns", "test-resource", "stable", AnyRevision)
 	if err != nil {
 		t.Fatalf("DeleteChannel() error = %v", err)
 	}
@@ -1381,7 +1590,7 @@ func TestDeleteChannel_InvalidNames(t *testing.T) {
 
 	ctx := context.Background()
 
-	err := registry.DeleteChannel(ctx, "Invalid-Name", "test-resource", "stable")
+	err := registry.DeleteChannel(ctx, "Invalid-Name", "test-resource", "stable", AnyRevision)
 	if err == nil {
 		t.Fatal("expected error for invalid namespace name, got nil")
 	}
@@ -1435,3 +1644,801 @@ func TestListChannels_InvalidNames(t *testing.T) {
 		t.Errorf("error code = %v, want %v", regErr.Code, ErrorCodeBadRequest)
 	}
 }
+
+func TestDownloadArchiveRange(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+	_, _ = registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader([]byte("0123456789")))
+
+	tests := []struct {
+		name   string
+		offset int64
+		length int64
+		want   string
+	}{
+		{"middle", 2, 3, "234"},
+		{"to end", 7, -1, "789"},
+		{"past end", 8, 10, "89"},
+		{"at end", 10, -1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := registry.DownloadArchiveRange(ctx, "test-ns", "test-resource", "1.0.0", tt.offset, tt.length)
+			if err != nil {
+				t.Fatalf("DownloadArchiveRange() error = %v", err)
+			}
+			defer reader.Close()
+
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to read archive: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadArchiveRange_NotSatisfiable(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+	_, _ = registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader([]byte("0123456789")))
+
+	_, err := registry.DownloadArchiveRange(ctx, "test-ns", "test-resource", "1.0.0", 11, -1)
+
+	regErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if regErr.Code != ErrorCodeRangeNotSatisfiable {
+		t.Errorf("error code = %v, want %v", regErr.Code, ErrorCodeRangeNotSatisfiable)
+	}
+}
+
+func TestUpload_Success(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+
+	archiveData := []byte("test archive content")
+	digest, _ := reference.FromBytes(reference.SHA256, archiveData)
+
+	u, err := registry.StartUpload(ctx, "test-ns", "test-resource", "1.0.0")
+	if err != nil {
+		t.Fatalf("StartUpload() error = %v", err)
+	}
+
+	for offset := 0; offset < len(archiveData); offset += 8 {
+		end := min(offset+8, len(archiveData))
+		u, err = registry.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, int64(offset), bytes.NewReader(archiveData[offset:end]))
+		if err != nil {
+			t.Fatalf("UploadChunk() error = %v", err)
+		}
+		if u.Offset != int64(end) {
+			t.Fatalf("Offset = %d, want %d", u.Offset, end)
+		}
+	}
+
+	v, err := registry.CommitUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID, digest.String())
+	if err != nil {
+		t.Fatalf("CommitUpload() error = %v", err)
+	}
+	if v.Digest == nil || *v.Digest != digest.String() {
+		t.Errorf("Digest = %v, want %s", v.Digest, digest)
+	}
+	if v.Size == nil || *v.Size != int64(len(archiveData)) {
+		t.Errorf("Size = %v, want %d", v.Size, len(archiveData))
+	}
+
+	reader, err := registry.DownloadArchive(ctx, "test-ns", "test-resource", "1.0.0")
+	if err != nil {
+		t.Fatalf("DownloadArchive() error = %v", err)
+	}
+	defer reader.Close()
+
+	got, _ := io.ReadAll(reader)
+	if string(got) != string(archiveData) {
+		t.Errorf("downloaded content doesn't match uploaded content")
+	}
+
+	if _, err := registry.ReadUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID); err == nil {
+		t.Error("expected upload to be removed after commit")
+	}
+}
+
+func TestUploadChunk_InvalidOffset(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+
+	u, _ := registry.StartUpload(ctx, "test-ns", "test-resource", "1.0.0")
+	_, _ = registry.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, 0, bytes.NewReader([]byte("abc")))
+
+	_, err := registry.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, 0, bytes.NewReader([]byte("abc")))
+
+	regErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if regErr.Code != ErrorCodeInvalidOffset {
+		t.Errorf("error code = %v, want %v", regErr.Code, ErrorCodeInvalidOffset)
+	}
+}
+
+func TestUploadChunk_InterruptedChunk(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+
+	u, _ := registry.StartUpload(ctx, "test-ns", "test-resource", "1.0.0")
+	_, _ = registry.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, 0, bytes.NewReader([]byte("abc")))
+
+	// A chunk failing midway leaves the offset unchanged, and its partial
+	// bytes are discarded by the next chunk
+	_, err := registry.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, 3, io.MultiReader(bytes.NewReader([]byte("xyz")), &errorReader{err: io.ErrUnexpectedEOF}))
+	if err == nil {
+		t.Fatal("expected error for failing chunk, got nil")
+	}
+
+	u, err = registry.ReadUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID)
+	if err != nil {
+		t.Fatalf("ReadUpload() error = %v", err)
+	}
+	if u.Offset != 3 {
+		t.Fatalf("Offset = %d, want 3", u.Offset)
+	}
+
+	_, err = registry.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, 3, bytes.NewReader([]byte("def")))
+	if err != nil {
+		t.Fatalf("UploadChunk() error = %v", err)
+	}
+
+	digest, _ := reference.FromBytes(reference.SHA256, []byte("abcdef"))
+	if _, err := registry.CommitUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID, digest.String()); err != nil {
+		t.Fatalf("CommitUpload() error = %v", err)
+	}
+}
+
+func TestUpload_SurvivesRestart(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+
+	u, _ := registry.StartUpload(ctx, "test-ns", "test-resource", "1.0.0")
+	_, _ = registry.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, 0, bytes.NewReader([]byte("abc")))
+
+	restarted, err := NewSQLRegistry(ctx, registry.db, registry.archiveRoot, registry.logger)
+	if err != nil {
+		t.Fatalf("NewSQLRegistry() error = %v", err)
+	}
+
+	resumed, err := restarted.ReadUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID)
+	if err != nil {
+		t.Fatalf("ReadUpload() error = %v", err)
+	}
+	if resumed.Offset != 3 {
+		t.Fatalf("Offset = %d, want 3", resumed.Offset)
+	}
+
+	_, _ = restarted.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, 3, bytes.NewReader([]byte("def")))
+
+	digest, _ := reference.FromBytes(reference.SHA256, []byte("abcdef"))
+	if _, err := restarted.CommitUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID, digest.String()); err != nil {
+		t.Fatalf("CommitUpload() error = %v", err)
+	}
+}
+
+func TestCommitUpload_DigestMismatch(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+
+	u, _ := registry.StartUpload(ctx, "test-ns", "test-resource", "1.0.0")
+	_, _ = registry.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, 0, bytes.NewReader([]byte("abc")))
+
+	digest, _ := reference.FromBytes(reference.SHA256, []byte("something else"))
+	_, err := registry.CommitUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID, digest.String())
+
+	regErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if regErr.Code != ErrorCodeDigestMismatch {
+		t.Errorf("error code = %v, want %v", regErr.Code, ErrorCodeDigestMismatch)
+	}
+
+	v, _ := registry.ReadVersion(ctx, "test-ns", "test-resource", "1.0.0")
+	if v.Digest != nil {
+		t.Error("version should have no archive after a failed commit")
+	}
+}
+
+func TestCancelUpload(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+
+	u, _ := registry.StartUpload(ctx, "test-ns", "test-resource", "1.0.0")
+	path := registry.uploadTempPath("test-ns", "test-resource", "1.0.0", u.ID)
+
+	if err := registry.CancelUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID); err != nil {
+		t.Fatalf("CancelUpload() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected partial file to be removed, got: %v", err)
+	}
+
+	// Idempotent
+	if err := registry.CancelUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID); err != nil {
+		t.Fatalf("CancelUpload() error = %v", err)
+	}
+}
+
+func setupArchiveVersion(t *testing.T) (*SQLRegistry, func()) {
+	t.Helper()
+
+	registry, cleanup := setupTestDB(t)
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
+
+	return registry, cleanup
+}
+
+// Lists the files in the archive directory of version 1.0.0.
+func archiveFiles(t *testing.T, registry *SQLRegistry) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(registry.archiveDirectoryPath("test-ns", "test-resource", "1.0.0"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// Returns the path of the archive file of a version.
+func archiveFilePath(t *testing.T, registry *SQLRegistry, v *Version) string {
+	t.Helper()
+
+	digest, err := reference.ParseDigest(*v.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry.archiveFinalPath(v.Namespace, v.Resource, v.String, digest.Hash)
+}
+
+func TestUploadArchive_Concurrent(t *testing.T) {
+	registry, cleanup := setupArchiveVersion(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	const uploads = 8
+	var wg sync.WaitGroup
+	errs := make([]error, uploads)
+	for i := range uploads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader(bytes.Repeat([]byte{byte(i)}, 64<<10)))
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("upload %d: UploadArchive() error = %v", i, err)
+		}
+	}
+
+	v, _ := registry.ReadVersion(ctx, "test-ns", "test-resource", "1.0.0")
+	data, err := os.ReadFile(archiveFilePath(t, registry, v))
+	if err != nil {
+		t.Fatalf("archive of the version is missing: %v", err)
+	}
+	digest, _ := reference.FromBytes(reference.SHA256, data)
+	if digest.String() != *v.Digest {
+		t.Errorf("archive digest = %s, want %s", digest, *v.Digest)
+	}
+
+	// Replaced archives are removed; concurrent replacements may leave some
+	// behind for the sweep, but no temporary files
+	for _, name := range archiveFiles(t, registry) {
+		if strings.HasSuffix(name, TemporaryUploadSuffix) {
+			t.Errorf("temporary file left behind: %s", name)
+		}
+	}
+}
+
+func TestUploadArchive_Replace(t *testing.T) {
+	registry, cleanup := setupArchiveVersion(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	first, _ := registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader([]byte("first")))
+	second, err := registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader([]byte("second")))
+	if err != nil {
+		t.Fatalf("UploadArchive() error = %v", err)
+	}
+
+	if _, err := os.Stat(archiveFilePath(t, registry, first)); !os.IsNotExist(err) {
+		t.Errorf("expected replaced archive to be removed, got: %v", err)
+	}
+	if files := archiveFiles(t, registry); len(files) != 1 {
+		t.Errorf("expected only the current archive, got %v", files)
+	}
+
+	// Uploading the same content again keeps the file
+	if _, err := registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader([]byte("second"))); err != nil {
+		t.Fatalf("UploadArchive() error = %v", err)
+	}
+	if _, err := os.Stat(archiveFilePath(t, registry, second)); err != nil {
+		t.Errorf("expected archive to be kept, got: %v", err)
+	}
+}
+
+func TestUploadArchive_NoOrphans(t *testing.T) {
+	registry, cleanup := setupArchiveVersion(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := registry.UploadArchive(ctx, "test-ns", "test-resource", "2.0.0", bytes.NewReader([]byte("archive")))
+	assertErrorCode(t, err, ErrorCodeNotFound)
+
+	entries, _ := os.ReadDir(registry.archiveDirectoryPath("test-ns", "test-resource", "2.0.0"))
+	if len(entries) != 0 {
+		t.Errorf("expected no files for a missing version, got %d", len(entries))
+	}
+
+	// The audit record is part of the transaction; without it, the version
+	// keeps its previous archive
+	if _, err := registry.db.Exec("DROP TABLE audit_log"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader([]byte("archive")))
+	assertErrorCode(t, err, ErrorCodeInternalError)
+
+	v, _ := registry.ReadVersion(ctx, "test-ns", "test-resource", "1.0.0")
+	if v.Archive != nil {
+		t.Errorf("expected no archive after a failed upload, got %q", *v.Archive)
+	}
+	if files := archiveFiles(t, registry); len(files) != 0 {
+		t.Errorf("expected no files after a failed upload, got %v", files)
+	}
+}
+
+func TestUploadChunk_Concurrent(t *testing.T) {
+	registry, cleanup := setupArchiveVersion(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	u, _ := registry.StartUpload(ctx, "test-ns", "test-resource", "1.0.0")
+
+	const chunks = 8
+	var wg sync.WaitGroup
+	errs := make([]error, chunks)
+	for i := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = registry.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, 0, bytes.NewReader(bytes.Repeat([]byte{byte('a' + i)}, 64<<10)))
+		}()
+	}
+	wg.Wait()
+
+	winner := -1
+	for i, err := range errs {
+		if err == nil {
+			if winner >= 0 {
+				t.Fatalf("chunks %d and %d both succeeded at offset 0", winner, i)
+			}
+			winner = i
+			continue
+		}
+		assertErrorCode(t, err, ErrorCodeInvalidOffset)
+	}
+	if winner < 0 {
+		t.Fatal("expected one chunk to succeed")
+	}
+
+	want := bytes.Repeat([]byte{byte('a' + winner)}, 64<<10)
+	digest, _ := reference.FromBytes(reference.SHA256, want)
+	if _, err := registry.CommitUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID, digest.String()); err != nil {
+		t.Fatalf("CommitUpload() error = %v", err)
+	}
+
+	// Only the archive remains: no partial or staged chunk files
+	if files := archiveFiles(t, registry); len(files) != 1 {
+		t.Errorf("expected only the archive, got %v", files)
+	}
+}
+
+func TestWithTx_WriteLock(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Write transactions hold the write lock before they write anything
+	ctx := context.Background()
+	err := registry.withTx(ctx, func(tx *SQLRegistry) error {
+		conn, err := registry.db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if _, err := conn.ExecContext(ctx, "PRAGMA busy_timeout = 0"); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err == nil {
+			conn.ExecContext(ctx, "ROLLBACK")
+			t.Error("expected another writer to find the database locked")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withTx() error = %v", err)
+	}
+
+	if n := countRows(t, registry.db, "write_lock"); n != 0 {
+		t.Errorf("expected write_lock to stay empty, got %d rows", n)
+	}
+}
+
+func TestReadResource_Snapshot(t *testing.T) {
+	registry, cleanup := setupArchiveVersion(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.CreateChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0"})
+
+	// Versions and channels are read in the same transaction as the resource
+	err := registry.withSnapshot(ctx, func(tx *SQLRegistry) error {
+		if tx.tx == nil || tx.write {
+			t.Error("expected a read-only transaction")
+		}
+		res, err := tx.ReadResource(ctx, "test-ns", "test-resource")
+		if err != nil {
+			return err
+		}
+		if len(res.Versions) != 1 || len(res.Channels) != 1 {
+			t.Errorf("expected 1 version and 1 channel, got %d and %d", len(res.Versions), len(res.Channels))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadResource() error = %v", err)
+	}
+}
+
+func TestSweepArchives(t *testing.T) {
+	registry, cleanup := setupArchiveVersion(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	v, _ := registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader([]byte("archive")))
+	current := archiveFilePath(t, registry, v)
+	u, _ := registry.StartUpload(ctx, "test-ns", "test-resource", "1.0.0")
+	partial := registry.uploadTempPath("test-ns", "test-resource", "1.0.0", u.ID)
+
+	dir := registry.archiveDirectoryPath("test-ns", "test-resource", "1.0.0")
+	orphan := filepath.Join(dir, "orphan"+archive.ArchiveFileExtension)
+	staged := filepath.Join(dir, "staged"+TemporaryUploadSuffix)
+	fresh := filepath.Join(dir, "fresh"+archive.ArchiveFileExtension)
+	unrelated := filepath.Join(dir, "notes.txt")
+	for _, path := range []string{orphan, staged, fresh, unrelated} {
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old := time.Now().Add(-2 * archiveSweepGrace)
+	for _, path := range []string{current, partial, orphan, staged, unrelated} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := registry.SweepArchives(ctx)
+	if err != nil {
+		t.Fatalf("SweepArchives() error = %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+
+	for _, path := range []string{orphan, staged} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got: %v", filepath.Base(path), err)
+		}
+	}
+	for _, path := range []string{current, partial, fresh, unrelated} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be kept, got: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestReadVersion_ArchiveURL(t *testing.T) {
+	registry, cleanup := setupArchiveVersion(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	uploaded, err := registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader([]byte("archive")))
+	if err != nil {
+		t.Fatalf("UploadArchive() error = %v", err)
+	}
+	v, err := registry.ReadVersion(ctx, "test-ns", "test-resource", "1.0.0")
+	if err != nil {
+		t.Fatalf("ReadVersion() error = %v", err)
+	}
+
+	const want = "/namespaces/test-ns/resources/test-resource/versions/1.0.0/archive"
+	for _, got := range []*Version{uploaded, v} {
+		if got.Archive == nil || *got.Archive != want {
+			t.Errorf("Archive = %v, want %q", got.Archive, want)
+		}
+	}
+
+	// The database records the file relative to the archive root
+	var key string
+	if err := registry.db.QueryRow("SELECT path FROM versions WHERE string = '1.0.0'").Scan(&key); err != nil {
+		t.Fatal(err)
+	}
+	if filepath.IsAbs(key) || strings.Contains(key, registry.archiveRoot) {
+		t.Errorf("stored key %q exposes the archive root", key)
+	}
+	if registry.archiveFile(key) != archiveFilePath(t, registry, v) {
+		t.Errorf("stored key %q does not refer to the archive file", key)
+	}
+}
+
+func TestReadVersion_URLBuilder(t *testing.T) {
+	registry, cleanup := setupArchiveVersion(t)
+	defer cleanup()
+
+	builder := &SignedURLBuilder{BaseURL: "https://cdn.example.com", Key: []byte("secret")}
+	registry.urls = builder
+
+	ctx := context.Background()
+	if _, err := registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader([]byte("archive"))); err != nil {
+		t.Fatalf("UploadArchive() error = %v", err)
+	}
+	v, err := registry.ReadVersion(ctx, "test-ns", "test-resource", "1.0.0")
+	if err != nil {
+		t.Fatalf("ReadVersion() error = %v", err)
+	}
+
+	u, err := url.Parse(*v.Archive)
+	if err != nil {
+		t.Fatalf("Archive %q is not a URL: %v", *v.Archive, err)
+	}
+	if err := builder.Verify(u, time.Now()); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if u.Query().Get("digest") != *v.Digest {
+		t.Errorf("digest = %q, want %q", u.Query().Get("digest"), *v.Digest)
+	}
+}
+
+func TestSQLRegistry_MoveArchiveRoot(t *testing.T) {
+	registry, cleanup := setupArchiveVersion(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := registry.UploadArchive(ctx, "test-ns", "test-resource", "1.0.0", bytes.NewReader([]byte("archive"))); err != nil {
+		t.Fatalf("UploadArchive() error = %v", err)
+	}
+	u, _ := registry.StartUpload(ctx, "test-ns", "test-resource", "1.0.0")
+	if _, err := registry.UploadChunk(ctx, "test-ns", "test-resource", "1.0.0", u.ID, 0, bytes.NewReader([]byte("moved"))); err != nil {
+		t.Fatalf("UploadChunk() error = %v", err)
+	}
+
+	moved := filepath.Join(t.TempDir(), "moved")
+	if err := os.Rename(registry.archiveRoot, moved); err != nil {
+		t.Fatal(err)
+	}
+	registry.archiveRoot = moved
+
+	reader, err := registry.DownloadArchive(ctx, "test-ns", "test-resource", "1.0.0")
+	if err != nil {
+		t.Fatalf("DownloadArchive() error = %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "archive" {
+		t.Errorf("archive = %q, want %q", data, "archive")
+	}
+
+	// Upload sessions resume from the moved partial file
+	digest, _ := reference.FromBytes(reference.SHA256, []byte("moved"))
+	v, err := registry.CommitUpload(ctx, "test-ns", "test-resource", "1.0.0", u.ID, digest.String())
+	if err != nil {
+		t.Fatalf("CommitUpload() error = %v", err)
+	}
+	if _, err := os.Stat(archiveFilePath(t, registry, v)); err != nil {
+		t.Errorf("committed archive is not in the moved root: %v", err)
+	}
+}
+
+func setupChannelHistory(t *testing.T) (*SQLRegistry, func()) {
+	t.Helper()
+
+	registry, cleanup := setupTestDB(t)
+	ctx := context.Background()
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
+	for _, v := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+		_, _ = registry.CreateVersion(ctx, "test-ns", "test-resource", VersionInfo{String: v})
+	}
+	if _, err := registry.CreateChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0"}); err != nil {
+		cleanup()
+		t.Fatalf("CreateChannel() error = %v", err)
+	}
+
+	return registry, cleanup
+}
+
+func TestListChannelHistory(t *testing.T) {
+	registry, cleanup := setupChannelHistory(t)
+	defer cleanup()
+
+	ctx := WithPrincipal(context.Background(), &Principal{Subject: "alice"})
+	_, _ = registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "1.1.0"}, AnyRevision)
+	_, _ = registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "1.1.0", Description: "No move"}, AnyRevision)
+
+	history, err := registry.ListChannelHistory(ctx, "test-ns", "test-resource", "stable")
+	if err != nil {
+		t.Fatalf("ListChannelHistory() error = %v", err)
+	}
+	if len(history.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", history.Entries)
+	}
+
+	moved := history.Entries[0]
+	if moved.Version != "1.1.0" || moved.Previous == nil || *moved.Previous != "1.0.0" || moved.Actor != "alice" {
+		t.Errorf("unexpected entry: %+v", moved)
+	}
+	created := history.Entries[1]
+	if created.Version != "1.0.0" || created.Previous != nil || created.Actor != "" {
+		t.Errorf("unexpected entry: %+v", created)
+	}
+
+	// History outlives the channel
+	_ = registry.DeleteChannel(ctx, "test-ns", "test-resource", "stable", AnyRevision)
+	history, err = registry.ListChannelHistory(ctx, "test-ns", "test-resource", "stable")
+	if err != nil {
+		t.Fatalf("ListChannelHistory() error = %v", err)
+	}
+	if len(history.Entries) != 2 {
+		t.Errorf("expected 2 entries after delete, got %+v", history.Entries)
+	}
+}
+
+func TestRollbackChannel_Undo(t *testing.T) {
+	registry, cleanup := setupChannelHistory(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "1.2.0"}, AnyRevision)
+
+	ch, err := registry.RollbackChannel(ctx, "test-ns", "test-resource", "stable", "")
+	if err != nil {
+		t.Fatalf("RollbackChannel() error = %v", err)
+	}
+	if ch.Version.String != "1.0.0" {
+		t.Errorf("Version.String = %q, want '1.0.0'", ch.Version.String)
+	}
+
+	// Undoing the rollback moves the channel forward again
+	ch, err = registry.RollbackChannel(ctx, "test-ns", "test-resource", "stable", "")
+	if err != nil {
+		t.Fatalf("RollbackChannel() error = %v", err)
+	}
+	if ch.Version.String != "1.2.0" {
+		t.Errorf("Version.String = %q, want '1.2.0'", ch.Version.String)
+	}
+
+	history, _ := registry.ListChannelHistory(ctx, "test-ns", "test-resource", "stable")
+	if len(history.Entries) != 4 {
+		t.Errorf("expected 4 entries, got %+v", history.Entries)
+	}
+}
+
+func TestRollbackChannel_ToVersion(t *testing.T) {
+	registry, cleanup := setupChannelHistory(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "1.1.0"}, AnyRevision)
+	_, _ = registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "1.2.0"}, AnyRevision)
+
+	ch, err := registry.RollbackChannel(ctx, "test-ns", "test-resource", "stable", "1.0.0")
+	if err != nil {
+		t.Fatalf("RollbackChannel() error = %v", err)
+	}
+	if ch.Version.String != "1.0.0" {
+		t.Errorf("Version.String = %q, want '1.0.0'", ch.Version.String)
+	}
+}
+
+func TestRollbackChannel_Errors(t *testing.T) {
+	registry, cleanup := setupChannelHistory(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// No previous version
+	_, err := registry.RollbackChannel(ctx, "test-ns", "test-resource", "stable", "")
+	assertErrorCode(t, err, ErrorCodeNotFound)
+
+	// Never pointed at the version
+	_, err = registry.RollbackChannel(ctx, "test-ns", "test-resource", "stable", "1.2.0")
+	assertErrorCode(t, err, ErrorCodeNotFound)
+
+	// Missing channel
+	_, err = registry.RollbackChannel(ctx, "test-ns", "test-resource", "beta", "")
+	assertErrorCode(t, err, ErrorCodeNotFound)
+
+	// Invalid version
+	_, err = registry.RollbackChannel(ctx, "test-ns", "test-resource", "stable", "latest")
+	assertErrorCode(t, err, ErrorCodeBadRequest)
+}
+
+func TestMoveChannel_Concurrent(t *testing.T) {
+	registry, cleanup := setupChannelHistory(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _ = registry.UpdateChannel(ctx, "test-ns", "test-resource", "stable", ChannelInfo{Name: "stable", Version: "1.1.0"}, AnyRevision)
+
+	// Expect 1.0.0 while the channel points at 1.1.0
+	err := registry.moveChannel(ctx, "test-ns", "test-resource", "stable", "1.0.0", "1.2.0", "")
+	if err != errChannelMoved {
+		t.Fatalf("expected errChannelMoved, got: %v", err)
+	}
+
+	history, _ := registry.ListChannelHistory(ctx, "test-ns", "test-resource", "stable")
+	if len(history.Entries) != 2 {
+		t.Errorf("expected no history entry for a failed move, got %+v", history.Entries)
+	}
+}
@@ -3,9 +3,34 @@ package registry
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"slices"
+	"strings"
 	"time"
 )
 
+// Runs queries on a database or in a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Returns the query argument for an expected revision.
+//
+// [AnyRevision] is passed as NULL, which the UPDATE and DELETE statements
+// treat as matching any revision.
+func revisionArg(expected Revision) any {
+	if expected == AnyRevision {
+		return nil
+	}
+	return int64(expected)
+}
+
+// Returned by [SQLRegistry.moveChannel] when the channel no longer points at
+// the expected version.
+var errChannelMoved = errors.New("channel moved concurrently")
+
 // Executes an INSERT statement for a new namespace.
 //
 // Returns the created namespace with initialized timestamps on success, or the
@@ -13,7 +38,7 @@ import (
 func (r *SQLRegistry) insertNamespace(ctx context.Context, info NamespaceInfo) (*Namespace, error) {
 	now := time.Now().Unix()
 
-	_, err := r.db.ExecContext(ctx, sqlNamespacesInsert,
+	_, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlNamespacesInsert),
 		info.Name,
 		info.Description,
 		now, // created_at
@@ -30,6 +55,7 @@ func (r *SQLRegistry) insertNamespace(ctx context.Context, info NamespaceInfo) (
 		Resources:   []ResourceSummary{},
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		Revision:    1,
 	}, nil
 }
 
@@ -41,11 +67,12 @@ func (r *SQLRegistry) getNamespace(ctx context.Context, name string) (*Namespace
 
 	var ns Namespace
 
-	if err := r.db.QueryRowContext(ctx, sqlNamespacesGet, name).Scan(
+	if err := r.conn().QueryRowContext(ctx, r.selectRows(sqlNamespacesGet), name).Scan(
 		&ns.Name,
 		&ns.Description,
 		&ns.CreatedAt,
 		&ns.UpdatedAt,
+		&ns.Revision,
 	); err != nil {
 		return nil, err
 	}
@@ -56,11 +83,12 @@ func (r *SQLRegistry) getNamespace(ctx context.Context, name string) (*Namespace
 // Executes an UPDATE statement for a namespace's mutable fields.
 //
 // Returns the updated namespace on success, sql.ErrNoRows if the namespace does
-// not exist, or the raw database error on failure without any translation or logging.
-func (r *SQLRegistry) updateNamespace(ctx context.Context, namespace string, info NamespaceInfo) (*Namespace, error) {
+// not exist or has another revision than expected, or the raw database error on
+// failure without any translation or logging.
+func (r *SQLRegistry) updateNamespace(ctx context.Context, namespace string, info NamespaceInfo, expected Revision) (*Namespace, error) {
 	now := time.Now().Unix()
 
-	result, err := r.db.ExecContext(ctx, sqlNamespacesUpdate, info.Description, now, namespace)
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlNamespacesUpdate), info.Description, now, namespace, revisionArg(expected))
 	if err != nil {
 		return nil, err
 	}
@@ -77,11 +105,128 @@ func (r *SQLRegistry) updateNamespace(ctx context.Context, namespace string, inf
 
 // Executes a DELETE statement for a namespace.
 //
-// Returns the raw database error on failure without any translation or logging.
+// Returns sql.ErrNoRows if the namespace does not exist or has another revision
+// than expected, or the raw database error on failure without any
+// translation or logging.
 // Foreign key constraints prevent deletion if the namespace contains resources.
-func (r *SQLRegistry) deleteNamespace(ctx context.Context, namespace string) error {
-	_, err := r.db.ExecContext(ctx, sqlNamespacesDelete, namespace)
-	return err
+func (r *SQLRegistry) deleteNamespace(ctx context.Context, namespace string, expected Revision) error {
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlNamespacesDelete), namespace, revisionArg(expected))
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Executes an INSERT statement for a new namespace member.
+//
+// Returns the created member on success, or the raw database error on failure
+// without any translation or logging.
+func (r *SQLRegistry) insertMember(ctx context.Context, namespace string, info MemberInfo) (*Member, error) {
+	now := time.Now().Unix()
+
+	_, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlMembersInsert),
+		namespace,
+		info.Subject,
+		string(info.Role),
+		now, // created_at
+		now, // updated_at
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Member{
+		Namespace: namespace,
+		Subject:   info.Subject,
+		Role:      info.Role,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Revision:  1,
+	}, nil
+}
+
+// Queries a namespace member by subject from the database.
+//
+// Returns sql.ErrNoRows if the subject is not a member of the namespace.
+func (r *SQLRegistry) getMember(ctx context.Context, namespace, subject string) (*Member, error) {
+	m := Member{Namespace: namespace}
+
+	if err := r.conn().QueryRowContext(ctx, r.selectRows(sqlMembersGet), namespace, subject).Scan(
+		&m.Subject,
+		&m.Role,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+		&m.Revision,
+	); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Executes an UPDATE statement for a member's role.
+//
+// Returns the updated member on success, sql.ErrNoRows if the subject is not a
+// member or the membership has another revision than expected, or the raw
+// database error on failure without any translation or logging.
+func (r *SQLRegistry) updateMember(ctx context.Context, namespace string, info MemberInfo, expected Revision) (*Member, error) {
+	now := time.Now().Unix()
+
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlMembersUpdate), string(info.Role), now, namespace, info.Subject, revisionArg(expected))
+	if err != nil {
+		return nil, err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return r.getMember(ctx, namespace, info.Subject)
+}
+
+// Executes a DELETE statement for a namespace member.
+//
+// Returns sql.ErrNoRows if the member does not exist or has another revision
+// than expected, or the raw database error on failure without any
+// translation or logging.
+func (r *SQLRegistry) deleteMember(ctx context.Context, namespace, subject string, expected Revision) error {
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlMembersDelete), namespace, subject, revisionArg(expected))
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Queries all members of a namespace from the database.
+//
+// Returns the raw database error on failure without any translation or logging.
+func (r *SQLRegistry) listMembers(ctx context.Context, namespace string) ([]Member, error) {
+	rows, err := r.conn().QueryContext(ctx, r.dialect.rebind(sqlMembersList), namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []Member{}
+	for rows.Next() {
+		m := Member{Namespace: namespace}
+		if err := rows.Scan(&m.Subject, &m.Role, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
 }
 
 // Executes an INSERT statement for a new resource.
@@ -91,7 +236,7 @@ func (r *SQLRegistry) deleteNamespace(ctx context.Context, namespace string) err
 func (r *SQLRegistry) insertResource(ctx context.Context, namespace string, info ResourceInfo) (*Resource, error) {
 	now := time.Now().Unix()
 
-	_, err := r.db.ExecContext(ctx, sqlResourcesInsert, namespace, info.Name, info.Type, info.Description, now, now)
+	_, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlResourcesInsert), namespace, info.Name, info.Type, info.Description, now, now)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +250,7 @@ func (r *SQLRegistry) insertResource(ctx context.Context, namespace string, info
 		Channels:    []ChannelSummary{},
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		Revision:    1,
 	}, nil
 }
 
@@ -116,8 +262,8 @@ func (r *SQLRegistry) getResource(ctx context.Context, namespace, resource strin
 	var res Resource
 	var ns string
 
-	err := r.db.QueryRowContext(ctx, sqlResourcesGet, namespace, resource).Scan(
-		&ns, &res.Name, &res.Type, &res.Description, &res.CreatedAt, &res.UpdatedAt,
+	err := r.conn().QueryRowContext(ctx, r.selectRows(sqlResourcesGet), namespace, resource).Scan(
+		&ns, &res.Name, &res.Type, &res.Description, &res.CreatedAt, &res.UpdatedAt, &res.Revision,
 	)
 
 	if err != nil {
@@ -131,11 +277,12 @@ func (r *SQLRegistry) getResource(ctx context.Context, namespace, resource strin
 // Executes an UPDATE statement for a resource's mutable fields.
 //
 // Returns the updated resource on success, sql.ErrNoRows if the resource does
-// not exist, or the raw database error on failure without any translation or logging.
-func (r *SQLRegistry) updateResource(ctx context.Context, namespace, resource string, info ResourceInfo) (*Resource, error) {
+// not exist or has another revision than expected, or the raw database error on
+// failure without any translation or logging.
+func (r *SQLRegistry) updateResource(ctx context.Context, namespace, resource string, info ResourceInfo, expected Revision) (*Resource, error) {
 	now := time.Now().Unix()
 
-	result, err := r.db.ExecContext(ctx, sqlResourcesUpdate, info.Type, info.Description, now, namespace, resource)
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlResourcesUpdate), info.Type, info.Description, now, namespace, resource, revisionArg(expected))
 	if err != nil {
 		return nil, err
 	}
@@ -152,11 +299,21 @@ func (r *SQLRegistry) updateResource(ctx context.Context, namespace, resource st
 
 // Executes a DELETE statement for a resource.
 //
-// Returns the raw database error on failure without any translation or logging.
+// Returns sql.ErrNoRows if the resource does not exist or has another revision
+// than expected, or the raw database error on failure without any
+// translation or logging.
 // Foreign key constraints prevent deletion if the resource contains versions.
-func (r *SQLRegistry) deleteResource(ctx context.Context, namespace, resource string) error {
-	_, err := r.db.ExecContext(ctx, sqlResourcesDelete, namespace, resource)
-	return err
+func (r *SQLRegistry) deleteResource(ctx context.Context, namespace, resource string, expected Revision) error {
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlResourcesDelete), namespace, resource, revisionArg(expected))
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
 }
 
 // Executes an INSERT statement for a new version.
@@ -168,7 +325,7 @@ func (r *SQLRegistry) deleteResource(ctx context.Context, namespace, resource st
 func (r *SQLRegistry) insertVersion(ctx context.Context, namespace, resource string, info VersionInfo) (*Version, error) {
 	now := time.Now().Unix()
 
-	_, err := r.db.ExecContext(ctx, sqlVersionsInsert, namespace, resource, info.String, now, now)
+	_, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlVersionsInsert), namespace, resource, info.String, now, now)
 	if err != nil {
 		return nil, err
 	}
@@ -179,6 +336,7 @@ func (r *SQLRegistry) insertVersion(ctx context.Context, namespace, resource str
 		String:    info.String,
 		CreatedAt: now,
 		UpdatedAt: now,
+		Revision:  1,
 	}, nil
 }
 
@@ -186,13 +344,14 @@ func (r *SQLRegistry) insertVersion(ctx context.Context, namespace, resource str
 //
 // Returns sql.ErrNoRows if the version does not exist. Archive fields (Digest, Size,
 // Archive) are populated if an archive has been uploaded, otherwise they remain nil.
+// Archive holds the path of the archive file, not the client-facing URL.
 func (r *SQLRegistry) getVersion(ctx context.Context, namespace, resource, version string) (*Version, error) {
 	var v Version
 	var digest, path sql.NullString
 	var size sql.NullInt64
 
-	err := r.db.QueryRowContext(ctx, sqlVersionsGet, namespace, resource, version).Scan(
-		&v.String, &digest, &size, &path, &v.CreatedAt, &v.UpdatedAt,
+	err := r.conn().QueryRowContext(ctx, r.selectRows(sqlVersionsGet), namespace, resource, version).Scan(
+		&v.String, &digest, &size, &path, &v.CreatedAt, &v.UpdatedAt, &v.Revision,
 	)
 	if err != nil {
 		return nil, err
@@ -208,7 +367,8 @@ func (r *SQLRegistry) getVersion(ctx context.Context, namespace, resource, versi
 		v.Size = &size.Int64
 	}
 	if path.Valid {
-		v.Archive = &path.String
+		file := r.archiveFile(path.String)
+		v.Archive = &file
 	}
 
 	return &v, nil
@@ -217,11 +377,12 @@ func (r *SQLRegistry) getVersion(ctx context.Context, namespace, resource, versi
 // Executes an UPDATE statement for a version's mutable fields.
 //
 // Returns the updated version on success, sql.ErrNoRows if the version does not
-// exist, or the raw database error on failure without any translation or logging.
-func (r *SQLRegistry) updateVersion(ctx context.Context, namespace, resource, version string) (*Version, error) {
+// exist or has another revision than expected, or the raw database error on
+// failure without any translation or logging.
+func (r *SQLRegistry) updateVersion(ctx context.Context, namespace, resource, version string, expected Revision) (*Version, error) {
 	now := time.Now().Unix()
 
-	result, err := r.db.ExecContext(ctx, sqlVersionsUpdate, now, namespace, resource, version)
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlVersionsUpdate), now, namespace, resource, version, revisionArg(expected))
 	if err != nil {
 		return nil, err
 	}
@@ -238,38 +399,73 @@ func (r *SQLRegistry) updateVersion(ctx context.Context, namespace, resource, ve
 
 // Executes a DELETE statement for a version.
 //
-// Returns the raw database error on failure without any translation or logging.
+// Returns sql.ErrNoRows if the version does not exist or has another revision
+// than expected, or the raw database error on failure without any
+// translation or logging.
 // Foreign key constraints prevent deletion if the version is referenced by channels.
-func (r *SQLRegistry) deleteVersion(ctx context.Context, namespace, resource, version string) error {
-	_, err := r.db.ExecContext(ctx, sqlVersionsDelete, namespace, resource, version)
-	return err
+func (r *SQLRegistry) deleteVersion(ctx context.Context, namespace, resource, version string, expected Revision) error {
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlVersionsDelete), namespace, resource, version, revisionArg(expected))
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
 }
 
-// Executes an INSERT statement for a channel.
+// Executes an INSERT statement for a channel and records it in the history.
 //
-// Returns the raw database error on failure without any translation or logging.
-func (r *SQLRegistry) insertChannel(ctx context.Context, namespace, resource string, info ChannelInfo) error {
+// Both statements run in one transaction. Returns the raw database error on
+// failure without any translation or logging.
+func (r *SQLRegistry) insertChannel(ctx context.Context, namespace, resource string, info ChannelInfo, actor string) error {
 	now := time.Now().Unix()
-	_, err := r.db.ExecContext(ctx, sqlChannelsInsert, namespace, resource, info.Name, info.Description, info.Version, now, now)
-	return err
+
+	return r.withTx(ctx, func(tx *SQLRegistry) error {
+		if _, err := tx.conn().ExecContext(ctx, r.dialect.rebind(sqlChannelsInsert), namespace, resource, info.Name, info.Description, info.Version, now, now); err != nil {
+			return err
+		}
+		_, err := tx.conn().ExecContext(ctx, r.dialect.rebind(sqlChannelHistoryInsert), namespace, resource, info.Name, info.Version, nil, actor, now)
+		return err
+	})
 }
 
 // Executes an UPDATE statement for a channel.
 //
-// Returns the updated channel on success, sql.ErrNoRows if the channel does not
-// exist, or the raw database error on failure without any translation or logging.
-func (r *SQLRegistry) updateChannel(ctx context.Context, namespace, resource string, info ChannelInfo) (*Channel, error) {
+// If the channel now points at another version, the change is recorded in
+// the history in the same transaction. Returns the updated channel on success,
+// sql.ErrNoRows if the channel does not exist or has another revision than
+// expected, or the raw database error on failure without any translation or
+// logging.
+func (r *SQLRegistry) updateChannel(ctx context.Context, namespace, resource string, info ChannelInfo, expected Revision, actor string) (*Channel, error) {
 	now := time.Now().Unix()
 
-	result, err := r.db.ExecContext(ctx, sqlChannelsUpdate, info.Description, info.Version, now, namespace, resource, info.Name)
+	err := r.withTx(ctx, func(tx *SQLRegistry) error {
+		var previous string
+		if err := tx.conn().QueryRowContext(ctx, tx.selectRows(sqlChannelsVersion), namespace, resource, info.Name).Scan(&previous); err != nil {
+			return err
+		}
+
+		result, err := tx.conn().ExecContext(ctx, r.dialect.rebind(sqlChannelsUpdate), info.Description, info.Version, now, namespace, resource, info.Name, revisionArg(expected))
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return sql.ErrNoRows
+		}
+
+		if previous == info.Version {
+			return nil
+		}
+		_, err = tx.conn().ExecContext(ctx, r.dialect.rebind(sqlChannelHistoryInsert), namespace, resource, info.Name, info.Version, previous, actor, now)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if rows, _ := result.RowsAffected(); rows == 0 {
-		return nil, sql.ErrNoRows
-	}
-
 	// Return updated channel - need to query for created_at and version
 	// details. We could use a RETURNING clause, but the cost would be to lose
 	// compatibility with some databases, like MySQL.
@@ -285,10 +481,11 @@ func (r *SQLRegistry) getChannel(ctx context.Context, namespace, resource, chann
 	var versionString string
 	var channelCreatedAt, channelUpdatedAt, versionCreatedAt, versionUpdatedAt int64
 	var digest, size, path sql.NullString
+	var versionRevision Revision
 
-	err := r.db.QueryRowContext(ctx, sqlChannelsGet, namespace, resource, channel).Scan(
-		&c.Name, &c.Description, &versionString, &channelCreatedAt, &channelUpdatedAt,
-		&versionCreatedAt, &versionUpdatedAt, &digest, &size, &path,
+	err := r.conn().QueryRowContext(ctx, r.selectRows(sqlChannelsGet), namespace, resource, channel).Scan(
+		&c.Name, &c.Description, &versionString, &channelCreatedAt, &channelUpdatedAt, &c.Revision,
+		&versionCreatedAt, &versionUpdatedAt, &digest, &size, &path, &versionRevision,
 	)
 	if err != nil {
 		return nil, err
@@ -300,7 +497,7 @@ func (r *SQLRegistry) getChannel(ctx context.Context, namespace, resource, chann
 	c.UpdatedAt = channelUpdatedAt
 	c.Version = Version{
 		Namespace: namespace, Resource: resource, String: versionString,
-		CreatedAt: versionCreatedAt, UpdatedAt: versionUpdatedAt,
+		CreatedAt: versionCreatedAt, UpdatedAt: versionUpdatedAt, Revision: versionRevision,
 	}
 	if digest.Valid {
 		c.Version.Digest = &digest.String
@@ -308,19 +505,92 @@ func (r *SQLRegistry) getChannel(ctx context.Context, namespace, resource, chann
 	return &c, nil
 }
 
-// Executes a DELETE statement for a channel.
+// Points a channel at another version and records the change in the history.
+//
+// Both statements run in one transaction. The channel is only moved if it
+// still points at from; otherwise errChannelMoved is returned. Returns the
+// raw database error on failure without any translation or logging.
+func (r *SQLRegistry) moveChannel(ctx context.Context, namespace, resource, channel, from, to, actor string) error {
+	now := time.Now().Unix()
+
+	return r.withTx(ctx, func(tx *SQLRegistry) error {
+		result, err := tx.conn().ExecContext(ctx, r.dialect.rebind(sqlChannelsMove), to, now, namespace, resource, channel, from)
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return errChannelMoved
+		}
+		_, err = tx.conn().ExecContext(ctx, r.dialect.rebind(sqlChannelHistoryInsert), namespace, resource, channel, to, from, actor, now)
+		return err
+	})
+}
+
+// Queries the pointer changes of a channel, newest first.
 //
 // Returns the raw database error on failure without any translation or logging.
-func (r *SQLRegistry) deleteChannel(ctx context.Context, namespace, resource, channel string) error {
-	_, err := r.db.ExecContext(ctx, sqlChannelsDelete, namespace, resource, channel)
-	return err
+func (r *SQLRegistry) listChannelHistory(ctx context.Context, namespace, resource, channel string) ([]ChannelHistoryEntry, error) {
+	rows, err := r.conn().QueryContext(ctx, r.dialect.rebind(sqlChannelHistoryList), namespace, resource, channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []ChannelHistoryEntry{}
+	for rows.Next() {
+		e, err := scanChannelHistoryEntry(rows, namespace, resource, channel)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+// Queries the most recent pointer change of a channel.
+//
+// Returns sql.ErrNoRows if the channel has no history.
+func (r *SQLRegistry) latestChannelHistory(ctx context.Context, namespace, resource, channel string) (*ChannelHistoryEntry, error) {
+	row := r.conn().QueryRowContext(ctx, r.dialect.rebind(sqlChannelHistoryLatest), namespace, resource, channel)
+	return scanChannelHistoryEntry(row, namespace, resource, channel)
+}
+
+// Scans a channel history row.
+func scanChannelHistoryEntry(row interface{ Scan(...any) error }, namespace, resource, channel string) (*ChannelHistoryEntry, error) {
+	e := ChannelHistoryEntry{Namespace: namespace, Resource: resource, Channel: channel}
+	var previous sql.NullString
+	if err := row.Scan(&e.ID, &e.Version, &previous, &e.Actor, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	if previous.Valid {
+		e.Previous = &previous.String
+	}
+	return &e, nil
+}
+
+// Executes a DELETE statement for a channel.
+//
+// Returns sql.ErrNoRows if the channel does not exist or has another revision
+// than expected, or the raw database error on failure without any
+// translation or logging.
+func (r *SQLRegistry) deleteChannel(ctx context.Context, namespace, resource, channel string, expected Revision) error {
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlChannelsDelete), namespace, resource, channel, revisionArg(expected))
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
 }
 
 // Queries all namespaces from the database.
 //
 // Returns the raw database error on failure without any translation or logging.
 func (r *SQLRegistry) listNamespaces(ctx context.Context) ([]NamespaceSummary, error) {
-	rows, err := r.db.QueryContext(ctx, sqlNamespacesList)
+	rows, err := r.conn().QueryContext(ctx, r.dialect.rebind(sqlNamespacesList))
 	if err != nil {
 		return nil, err
 	}
@@ -341,7 +611,7 @@ func (r *SQLRegistry) listNamespaces(ctx context.Context) ([]NamespaceSummary, e
 //
 // Returns the raw database error on failure without any translation or logging.
 func (r *SQLRegistry) listResources(ctx context.Context, namespace string) ([]ResourceSummary, error) {
-	rows, err := r.db.QueryContext(ctx, sqlResourcesList, namespace)
+	rows, err := r.conn().QueryContext(ctx, r.dialect.rebind(sqlResourcesList), namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -362,11 +632,130 @@ func (r *SQLRegistry) listResources(ctx context.Context, namespace string) ([]Re
 	return resources, rows.Err()
 }
 
+// Queries a page of search results with their total and facet counts.
+//
+// Runs several queries over the same matches, so it should be called in a
+// snapshot. Returns the raw database error on failure without any translation
+// or logging.
+func (r *SQLRegistry) searchResources(ctx context.Context, query SearchQuery) (*SearchResults, error) {
+	matches, args, err := r.searchMatches(ctx, query.Text)
+	if err != nil {
+		return nil, err
+	}
+	typ, namespace, stable := searchFilters(query)
+
+	results := &SearchResults{}
+	if results.Results, err = r.searchPage(ctx, matches, slices.Concat([]any{StableChannel}, args, typ, namespace, stable, []any{query.Limit, query.Offset})); err != nil {
+		return nil, err
+	}
+	if results.Total, err = r.countSearchResults(ctx, matches, slices.Concat(args, typ, namespace, stable)); err != nil {
+		return nil, err
+	}
+
+	// Each facet is counted without its own filter
+	_, _, onlyStable := searchFilters(SearchQuery{Stable: true})
+	if results.Facets.Stable, err = r.countSearchResults(ctx, matches, slices.Concat(args, typ, namespace, onlyStable)); err != nil {
+		return nil, err
+	}
+	if results.Facets.Types, err = r.searchFacets(ctx, sqlResourceSearchTypes, matches, slices.Concat(args, namespace, stable)); err != nil {
+		return nil, err
+	}
+	if results.Facets.Namespaces, err = r.searchFacets(ctx, sqlResourceSearchNamespaces, matches, slices.Concat(args, typ, stable)); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Returns the query selecting the matches of search text, and its parameters.
+//
+// Uses the full-text index if the registry has one, the text has words, and
+// the triggers keeping the index in sync are installed. A registry sharing
+// the database without FTS5 drops the triggers (see prepareSearchIndex), so
+// the index may be stale while they are missing. Otherwise matches words by
+// substring. Returns the raw database error on failure without any
+// translation or logging.
+func (r *SQLRegistry) searchMatches(ctx context.Context, text string) (string, []any, error) {
+	words := searchWords(text)
+	if r.fullText && len(words) > 0 {
+		var installed int
+		if err := r.conn().QueryRowContext(ctx, sqlResourceSearchInstalled).Scan(&installed); err != nil {
+			return "", nil, err
+		}
+		if installed == searchIndexTriggers {
+			return sqlResourceSearchMatch, []any{fullTextQuery(words)}, nil
+		}
+		r.logger.Warn(errMsgSearchIndexStale)
+	}
+
+	matches, args := substringMatches(strings.ToLower(strings.TrimSpace(text)), words)
+	return matches, args, nil
+}
+
+// Queries a page of search results, best first.
+//
+// Returns the raw database error on failure without any translation or
+// logging.
+func (r *SQLRegistry) searchPage(ctx context.Context, matches string, args []any) ([]SearchResult, error) {
+	rows, err := r.conn().QueryContext(ctx, r.dialect.rebind(withMatches(sqlResourceSearchResults, matches)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var res SearchResult
+		var latestVersion sql.NullString
+		var stable int
+		if err := rows.Scan(&res.Namespace, &res.Resource.Name, &res.Resource.Type, &res.Resource.Description, &res.Resource.CreatedAt, &res.Resource.UpdatedAt, &res.Resource.VersionCount, &res.Resource.ChannelCount, &latestVersion, &stable, &res.Score); err != nil {
+			return nil, err
+		}
+		if latestVersion.Valid {
+			res.Resource.LatestVersion = &latestVersion.String
+		}
+		res.Stable = stable != 0
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// Counts search results.
+//
+// Returns the raw database error on failure without any translation or
+// logging.
+func (r *SQLRegistry) countSearchResults(ctx context.Context, matches string, args []any) (int, error) {
+	var count int
+	err := r.conn().QueryRowContext(ctx, r.dialect.rebind(withMatches(sqlResourceSearchCount, matches)), args...).Scan(&count)
+	return count, err
+}
+
+// Queries the counts of a search facet, most frequent first.
+//
+// Returns the raw database error on failure without any translation or
+// logging.
+func (r *SQLRegistry) searchFacets(ctx context.Context, query, matches string, args []any) ([]FacetCount, error) {
+	rows, err := r.conn().QueryContext(ctx, r.dialect.rebind(withMatches(query, matches)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := []FacetCount{}
+	for rows.Next() {
+		var facet FacetCount
+		if err := rows.Scan(&facet.Value, &facet.Count); err != nil {
+			return nil, err
+		}
+		facets = append(facets, facet)
+	}
+	return facets, rows.Err()
+}
+
 // Queries all versions for a resource from the database.
 //
 // Returns the raw database error on failure without any translation or logging.
 func (r *SQLRegistry) listVersions(ctx context.Context, namespace, resource string) ([]VersionSummary, error) {
-	rows, err := r.db.QueryContext(ctx, sqlVersionsList, namespace, resource)
+	rows, err := r.conn().QueryContext(ctx, r.dialect.rebind(sqlVersionsList), namespace, resource)
 	if err != nil {
 		return nil, err
 	}
@@ -388,7 +777,7 @@ func (r *SQLRegistry) listVersions(ctx context.Context, namespace, resource stri
 //
 // Returns the raw database error on failure without any translation or logging.
 func (r *SQLRegistry) listChannels(ctx context.Context, namespace, resource string) ([]ChannelSummary, error) {
-	rows, err := r.db.QueryContext(ctx, sqlChannelsList, namespace, resource)
+	rows, err := r.conn().QueryContext(ctx, r.dialect.rebind(sqlChannelsList), namespace, resource)
 	if err != nil {
 		return nil, err
 	}
@@ -407,12 +796,13 @@ func (r *SQLRegistry) listChannels(ctx context.Context, namespace, resource stri
 
 // Executes an UPDATE statement to set archive metadata for a version.
 //
-// Returns sql.ErrNoRows if the version does not exist, or the raw database
-// error on failure without any translation or logging.
+// The archive file is recorded by its storage key. Returns sql.ErrNoRows if
+// the version does not exist, or the raw database error on failure without any
+// translation or logging.
 func (r *SQLRegistry) uploadArchive(ctx context.Context, namespace, resource, version, digest, path string, size int64) error {
 	now := time.Now().Unix()
 
-	result, err := r.db.ExecContext(ctx, sqlVersionsUpload, digest, size, path, now, namespace, resource, version)
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlVersionsUpload), digest, size, r.archiveKey(path), now, namespace, resource, version)
 	if err != nil {
 		return err
 	}
@@ -423,3 +813,278 @@ func (r *SQLRegistry) uploadArchive(ctx context.Context, namespace, resource, ve
 
 	return nil
 }
+
+// Executes an INSERT statement for a new upload session.
+//
+// The partial file is recorded by its storage key. Returns the created session
+// with a zero offset on success, or the raw database error on failure without
+// any translation or logging.
+func (r *SQLRegistry) insertUpload(ctx context.Context, namespace, resource, version, id, path string) (*Upload, error) {
+	now := time.Now().Unix()
+
+	_, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlUploadsInsert), id, namespace, resource, version, r.archiveKey(path), now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Upload{
+		ID:        id,
+		Namespace: namespace,
+		Resource:  resource,
+		Version:   version,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// Queries an upload session scoped to its target version.
+//
+// Returns sql.ErrNoRows if the session does not exist. Returns the session and
+// the path of its partial upload file on success.
+func (r *SQLRegistry) getUpload(ctx context.Context, namespace, resource, version, id string) (*Upload, string, error) {
+	var u Upload
+	var path string
+
+	err := r.conn().QueryRowContext(ctx, r.selectRows(sqlUploadsGet), namespace, resource, version, id).Scan(
+		&u.ID, &u.Offset, &path, &u.CreatedAt, &u.UpdatedAt,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	u.Namespace = namespace
+	u.Resource = resource
+	u.Version = version
+	return &u, r.archiveFile(path), nil
+}
+
+// Executes an UPDATE statement moving an upload session from one offset to another.
+//
+// Returns sql.ErrNoRows if the session does not exist or is no longer at the
+// from offset, or the raw database error on failure without any translation
+// or logging.
+func (r *SQLRegistry) advanceUpload(ctx context.Context, id string, from, to int64) error {
+	now := time.Now().Unix()
+
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlUploadsUpdate), to, now, id, from)
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Executes a DELETE statement for an upload session.
+//
+// Returns the raw database error on failure without any translation or logging.
+func (r *SQLRegistry) deleteUpload(ctx context.Context, namespace, resource, version, id string) error {
+	_, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlUploadsDelete), namespace, resource, version, id)
+	return err
+}
+
+// Executes an UPDATE statement locking an upload session.
+//
+// The session stays locked until the transaction ends. Returns
+// sql.ErrNoRows if the session does not exist, or the raw database error on
+// failure without any translation or logging.
+func (r *SQLRegistry) claimUpload(ctx context.Context, namespace, resource, version, id string) error {
+	now := time.Now().Unix()
+
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlUploadsClaim), now, namespace, resource, version, id)
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Queries whether any version stores its archive in a file.
+//
+// Matches the storage key of the file as well as its path, which rows written
+// before storage keys were introduced hold. Returns the raw database error on
+// failure without any translation or logging.
+func (r *SQLRegistry) archiveReferenced(ctx context.Context, path string) (bool, error) {
+	var n int64
+	if err := r.conn().QueryRowContext(ctx, r.dialect.rebind(sqlVersionsRefs), r.archiveKey(path), path).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Queries the files referenced by versions and upload sessions.
+//
+// Returns the set of file paths.
+// Returns the raw database error on failure without any translation or logging.
+func (r *SQLRegistry) listArchivePaths(ctx context.Context) (map[string]bool, error) {
+	paths := make(map[string]bool)
+	for _, query := range []string{sqlVersionsPaths, sqlUploadsPaths} {
+		rows, err := r.conn().QueryContext(ctx, r.dialect.rebind(query))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var path string
+			if err := rows.Scan(&path); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			paths[r.archiveFile(path)] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// Executes an INSERT statement for an audit event.
+//
+// Sets the event ID on success. Returns the raw database error on failure
+// without any translation or logging.
+func (r *SQLRegistry) insertAuditEvent(ctx context.Context, event *AuditEvent) error {
+	args := []any{
+		event.Actor,
+		string(event.Action),
+		event.Namespace,
+		event.Resource,
+		event.Target,
+		event.Before,
+		event.After,
+		event.Digest,
+		event.CreatedAt,
+	}
+
+	// Some drivers (e.g., PostgreSQL) don't support LastInsertId
+	if r.dialect.useReturning() {
+		return r.conn().QueryRowContext(ctx, r.dialect.insertReturningID(sqlAuditInsert), args...).Scan(&event.ID)
+	}
+
+	result, err := r.conn().ExecContext(ctx, r.dialect.rebind(sqlAuditInsert), args...)
+	if err != nil {
+		return err
+	}
+
+	event.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// Queries audit events matching a filter, newest first.
+//
+// The filter must be validated and have its limit set. Returns the raw
+// database error on failure without any translation or logging.
+func (r *SQLRegistry) listAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	action := string(filter.Action)
+	rows, err := r.conn().QueryContext(ctx, r.dialect.rebind(sqlAuditList),
+		filter.Namespace, filter.Namespace,
+		filter.Resource, filter.Resource,
+		filter.Target, filter.Target,
+		filter.Actor, filter.Actor,
+		action, action,
+		filter.Since, filter.Since,
+		filter.Until, filter.Until,
+		filter.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []AuditEvent{}
+	for rows.Next() {
+		var e AuditEvent
+		var digest sql.NullString
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Namespace, &e.Resource, &e.Target, &e.Before, &e.After, &digest, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if digest.Valid {
+			e.Digest = &digest.String
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// Runs fn in a transaction.
+//
+// fn receives a view of the registry whose queries run in the transaction.
+// Rows the view reads with [SQLRegistry.selectRows] stay locked until the
+// transaction ends, so what is read before a change is what gets changed.
+// The transaction is committed if fn returns nil and rolled back otherwise.
+// Events the view emits are published once the transaction commits. On a
+// view, fn joins the enclosing transaction. Returns the error from fn or from
+// committing, without any translation or logging.
+func (r *SQLRegistry) withTx(ctx context.Context, fn func(tx *SQLRegistry) error) error {
+	return r.inTx(ctx, nil, true, fn)
+}
+
+// Runs fn in a read-only transaction.
+//
+// Every query of the view fn receives sees the same snapshot of the database,
+// so related rows read one after another are consistent with each other.
+// Same as [SQLRegistry.withTx] otherwise.
+func (r *SQLRegistry) withSnapshot(ctx context.Context, fn func(tx *SQLRegistry) error) error {
+	return r.inTx(ctx, r.dialect.snapshotOptions(), false, fn)
+}
+
+// Runs fn in a transaction begun with opts, for writing or reading only.
+func (r *SQLRegistry) inTx(ctx context.Context, opts *sql.TxOptions, write bool, fn func(tx *SQLRegistry) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+
+	tx, err := r.db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if reserve := r.dialect.reserveStatement(); write && reserve != "" {
+		if _, err := tx.ExecContext(ctx, r.dialect.rebind(reserve)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	view := *r
+	view.tx = tx
+	view.write = write
+	view.pending = &[]Event{}
+
+	if err := fn(&view); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	r.events.publish(*view.pending)
+	return nil
+}
+
+// Returns the transaction of a view, or the database.
+func (r *SQLRegistry) conn() querier {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// Rewrites a query selecting rows for the connection.
+//
+// In a write transaction, the rows are locked until it ends.
+func (r *SQLRegistry) selectRows(query string) string {
+	if r.tx != nil && r.write {
+		return r.dialect.forUpdate(query)
+	}
+	return r.dialect.rebind(query)
+}
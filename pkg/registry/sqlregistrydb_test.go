@@ -30,8 +30,8 @@ func setupTestDB(t *testing.T) (*SQLRegistry, func()) {
 	}
 
 	// Create schema
-	_, err = db.Exec(sqlSchema)
-	if err != nil {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	if err := migrate(context.Background(), db, nil, logger); err != nil {
 		db.Close()
 		os.Remove(tmpfile.Name())
 		t.Fatalf("failed to create schema: %v", err)
@@ -39,11 +39,11 @@ func setupTestDB(t *testing.T) (*SQLRegistry, func()) {
 
 	// Create registry
 	tmpDir := t.TempDir()
-	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
 	registry := &SQLRegistry{
 		db:          db,
 		logger:      logger,
 		archiveRoot: tmpDir,
+		events:      newEventHub(),
 	}
 
 	// Cleanup function
@@ -162,7 +162,7 @@ func TestUpdateDBNamespace(t *testing.T) {
 
 	// Update namespace
 	updateInfo := NamespaceInfo{Name: "test-ns", Description: "Updated"}
-	updated, err := registry.updateNamespace(ctx, "test-ns", updateInfo)
+	updated, err := registry.updateNamespace(ctx, "test-ns", updateInfo, AnyRevision)
 	if err != nil {
 		t.Fatalf("updateNamespace() error = %v", err)
 	}
@@ -182,7 +182,7 @@ func TestUpdateDBNamespace_NotFound(t *testing.T) {
 	ctx := context.Background()
 	info := NamespaceInfo{Name: "test", Description: "Test"}
 
-	_, err := registry.updateNamespace(ctx, "nonexistent", info)
+	_, err := registry.updateNamespace(ctx, "nonexistent", info, AnyRevision)
 	if err != sql.ErrNoRows {
 		t.Errorf("expected sql.ErrNoRows, got %v", err)
 	}
@@ -202,7 +202,7 @@ func TestDeleteDBNamespace(t *testing.T) {
 	}
 
 	// Delete namespace
-	err = registry.deleteNamespace(ctx, "test-ns")
+	err = registry.deleteNamespace(ctx, "test-ns", AnyRevision)
 	if err != nil {
 		t.Fatalf("deleteNamespace() error = %v", err)
 	}
@@ -299,7 +299,7 @@ func TestUpdateDBResource(t *testing.T) {
 
 	// Update resource
 	updateInfo := ResourceInfo{Name: "test-resource", Type: "service", Description: "Updated"}
-	updated, err := registry.updateResource(ctx, "test-ns", "test-resource", updateInfo)
+	updated, err := registry.updateResource(ctx, "test-ns", "test-resource", updateInfo, AnyRevision)
 	if err != nil {
 		t.Fatalf("updateResource() error = %v", err)
 	}
@@ -327,7 +327,7 @@ func TestDeleteDBResource(t *testing.T) {
 	}
 
 	// Delete resource
-	err = registry.deleteResource(ctx, "test-ns", "test-resource")
+	err = registry.deleteResource(ctx, "test-ns", "test-resource", AnyRevision)
 	if err != nil {
 		t.Fatalf("deleteResource() error = %v", err)
 	}
@@ -422,7 +422,7 @@ func TestUpdateDBVersion(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 
 	// Update version
-	_, err = registry.updateVersion(ctx, "test-ns", "test-resource", "1.0.0")
+	_, err = registry.updateVersion(ctx, "test-ns", "test-resource", "1.0.0", AnyRevision)
 	if err != nil {
 		t.Fatalf("updateVersion() error = %v", err)
 	}
@@ -443,7 +443,7 @@ func TestDeleteDBVersion(t *testing.T) {
 	}
 
 	// Delete version
-	err = registry.deleteVersion(ctx, "test-ns", "test-resource", "1.0.0")
+	err = registry.deleteVersion(ctx, "test-ns", "test-resource", "1.0.0", AnyRevision)
 	if err != nil {
 		t.Fatalf("deleteVersion() error = %v", err)
 	}
@@ -580,7 +580,7 @@ func TestInsertDBChannel(t *testing.T) {
 		Description: "Stable channel",
 	}
 
-	err := registry.insertChannel(ctx, "test-ns", "test-resource", info)
+	err := registry.insertChannel(ctx, "test-ns", "test-resource", info, "")
 	if err != nil {
 		t.Fatalf("insertChannel() error = %v", err)
 	}
@@ -610,13 +610,13 @@ func TestUpdateDBChannel(t *testing.T) {
 	_, _ = registry.insertResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
 	_, _ = registry.insertVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
 	_, _ = registry.insertVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "2.0.0"})
-	_ = registry.insertChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Stable"})
+	_ = registry.insertChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Stable"}, "")
 
 	time.Sleep(10 * time.Millisecond)
 
 	// Update channel to point to new version
 	updateInfo := ChannelInfo{Name: "stable", Version: "2.0.0", Description: "Updated stable"}
-	updated, err := registry.updateChannel(ctx, "test-ns", "test-resource", updateInfo)
+	updated, err := registry.updateChannel(ctx, "test-ns", "test-resource", updateInfo, AnyRevision, "")
 	if err != nil {
 		t.Fatalf("updateChannel() error = %v", err)
 	}
@@ -639,10 +639,10 @@ func TestDeleteDBChannel(t *testing.T) {
 	_, _ = registry.insertNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
 	_, _ = registry.insertResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
 	_, _ = registry.insertVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
-	_ = registry.insertChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Stable"})
+	_ = registry.insertChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Stable"}, "")
 
 	// Delete channel
-	err := registry.deleteChannel(ctx, "test-ns", "test-resource", "stable")
+	err := registry.deleteChannel(ctx, "test-ns", "test-resource", "stable", AnyRevision)
 	if err != nil {
 		t.Fatalf("deleteChannel() error = %v", err)
 	}
@@ -664,8 +664,8 @@ func TestListDBChannels(t *testing.T) {
 	_, _ = registry.insertNamespace(ctx, NamespaceInfo{Name: "test-ns", Description: "Test"})
 	_, _ = registry.insertResource(ctx, "test-ns", ResourceInfo{Name: "test-resource", Type: "widget", Description: "Test"})
 	_, _ = registry.insertVersion(ctx, "test-ns", "test-resource", VersionInfo{String: "1.0.0"})
-	_ = registry.insertChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Stable"})
-	_ = registry.insertChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "beta", Version: "1.0.0", Description: "Beta"})
+	_ = registry.insertChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "stable", Version: "1.0.0", Description: "Stable"}, "")
+	_ = registry.insertChannel(ctx, "test-ns", "test-resource", ChannelInfo{Name: "beta", Version: "1.0.0", Description: "Beta"}, "")
 
 	// List channels
 	channels, err := registry.listChannels(ctx, "test-ns", "test-resource")
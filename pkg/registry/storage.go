@@ -1,22 +1,35 @@
 package registry
 
 import (
-	"crypto/sha256"
+	"context"
+	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/cruciblehq/protocol/pkg/archive"
+	"github.com/cruciblehq/protocol/pkg/reference"
 )
 
 const (
 
 	// Suffix for temporary upload files
 	TemporaryUploadSuffix = ".upload.tmp"
+
+	// Age under which unreferenced files are kept by [SQLRegistry.SweepArchives],
+	// since they may belong to an upload still in progress.
+	archiveSweepGrace = time.Hour
 )
 
+// Returned by [openArchiveRange] when the offset is past the end of the file.
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
 // Returns the directory path for storing archives of a specific version.
 //
 // The path follows the pattern: {archiveRoot}/{namespace}/{resource}/{version}
@@ -26,52 +39,116 @@ func (r *SQLRegistry) archiveDirectoryPath(namespace, resource, version string)
 
 // Returns the path for a temporary upload file in the archive directory.
 //
-// Temporary files use the .upload.tmp suffix during the upload process.
-func (r *SQLRegistry) archiveTempPath(namespace, resource, version string) string {
+// Temporary files live next to the archives of the version and use the
+// .upload.tmp suffix, prefixed with a random identifier, so that concurrent
+// uploads never share one. The partial file of a resumable upload session is
+// named by the session identifier.
+func (r *SQLRegistry) uploadTempPath(namespace, resource, version, id string) string {
 	archiveDir := r.archiveDirectoryPath(namespace, resource, version)
-	return filepath.Join(archiveDir, TemporaryUploadSuffix)
+	return filepath.Join(archiveDir, id+TemporaryUploadSuffix)
 }
 
 // Returns the final path for an archive file based on its digest.
 //
-// The path follows the pattern: {archiveDir}/{digest}.tar.zst
-func (r *SQLRegistry) archiveFinalPath(namespace, resource, version, digest string) string {
+// The path follows the pattern: {archiveDir}/{hash}.tar.zst, where hash is
+// the hex-encoded digest hash without the algorithm prefix, keeping file names
+// portable.
+func (r *SQLRegistry) archiveFinalPath(namespace, resource, version, hash string) string {
 	archiveDir := r.archiveDirectoryPath(namespace, resource, version)
-	return filepath.Join(archiveDir, digest+archive.ArchiveFileExtension)
+	return filepath.Join(archiveDir, hash+archive.ArchiveFileExtension)
+}
+
+// Returns the storage key of a file in the archive root.
+//
+// Keys are slash-separated paths relative to the archive root. The database
+// records files by key, so the archive root can move without rewriting rows.
+// Files outside the archive root are keyed by their path.
+func (r *SQLRegistry) archiveKey(path string) string {
+	rel, err := filepath.Rel(r.archiveRoot, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Returns the path of the file a storage key refers to.
+//
+// Absolute keys, recorded before keys were relative to the archive root, are
+// returned unchanged.
+func (r *SQLRegistry) archiveFile(key string) string {
+	if filepath.IsAbs(key) {
+		return key
+	}
+	return filepath.Join(r.archiveRoot, filepath.FromSlash(key))
+}
+
+// Replaces the archive file path of a version with its client-facing URL.
+//
+// The URL is built by the [ArchiveURLBuilder] of the registry, or by an
+// [APIURLBuilder] if it has none. Failures are logged and returned as [*Error].
+func (r *SQLRegistry) exposeArchive(ctx context.Context, v *Version) error {
+	if v.Archive == nil || v.Digest == nil {
+		return nil
+	}
+
+	var urls ArchiveURLBuilder = APIURLBuilder{}
+	if r.urls != nil {
+		urls = r.urls
+	}
+
+	u, err := urls.ArchiveURL(ctx, v.Namespace, v.Resource, v.String, *v.Digest)
+	if err != nil {
+		return r.logAndReturnError(ErrorCodeInternalError, errMsgArchiveURL, err, "namespace", v.Namespace, "resource", v.Resource, "version", v.String)
+	}
+	v.Archive = &u
+	return nil
+}
+
+// Reports whether a file in the archive root is an archive or a temporary
+// upload file, as opposed to a file the registry did not create.
+func isArchiveFile(path string) bool {
+	return strings.HasSuffix(path, archive.ArchiveFileExtension) || strings.HasSuffix(path, TemporaryUploadSuffix)
 }
 
 // Stores an archive file to disk and calculates its digest.
 //
-// Writes the archive data to a temporary file while calculating the SHA-256
-// digest, then moves it to the final location named by the digest. Returns
-// the digest, final file path, and size in bytes.
+// Writes the archive data to a temporary file while calculating its digest
+// with [reference.DefaultDigestAlgorithm], syncs it, then moves it to the
+// final location named by the digest hash. The file is durable before any
+// version refers to it. Returns the digest in "algorithm:hash" format, final
+// file path, and size in bytes.
 func (r *SQLRegistry) storeArchiveFile(namespace, resource, version string, archiveReader io.Reader) (digest, path string, size int64, err error) {
-
-	// Create archive directory structure
-	archiveDir := r.archiveDirectoryPath(namespace, resource, version)
-	if err := os.MkdirAll(archiveDir, archive.DirMode); err != nil {
+	tempPath, err := r.stageFile(namespace, resource, version)
+	if err != nil {
 		return "", "", 0, err
 	}
 
-	// Create temporary file to store archive while calculating digest
-	tempPath := r.archiveTempPath(namespace, resource, version)
-	tempFile, err := os.Create(tempPath)
+	tempFile, err := os.OpenFile(tempPath, os.O_WRONLY, 0)
 	if err != nil {
+		os.Remove(tempPath)
 		return "", "", 0, err
 	}
 
-	// Copy archive data while calculating SHA-256 digest and size
-	hasher := sha256.New()
-	writer := io.MultiWriter(tempFile, hasher)
-	size, err = io.Copy(writer, archiveReader)
+	// Copy archive data while calculating digest and size
+	digester, err := reference.NewDigester(reference.DefaultDigestAlgorithm, tempFile)
 	if err != nil {
 		tempFile.Close()
 		os.Remove(tempPath)
 		return "", "", 0, err
 	}
+	if _, err := io.Copy(digester, archiveReader); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return "", "", 0, err
+	}
+	computed := digester.Digest()
 
-	// Calculate digest from hash
-	digest = fmt.Sprintf("sha256:%s", hex.EncodeToString(hasher.Sum(nil)))
+	// Persist the data before any version can refer to it
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return "", "", 0, err
+	}
 
 	// Close temp file before rename (required on Windows)
 	if err := tempFile.Close(); err != nil {
@@ -79,12 +156,220 @@ func (r *SQLRegistry) storeArchiveFile(namespace, resource, version string, arch
 		return "", "", 0, err
 	}
 
-	// Move temporary file to final location with digest-based name
-	path = r.archiveFinalPath(namespace, resource, version, digest)
+	// Move temporary file to final location with digest-based name. An
+	// existing file of the same name has the same content.
+	path = r.archiveFinalPath(namespace, resource, version, computed.Hash)
 	if err := os.Rename(tempPath, path); err != nil {
 		os.Remove(tempPath)
 		return "", "", 0, err
 	}
+	syncDir(filepath.Dir(path))
+
+	return computed.String(), path, digester.Size(), nil
+}
+
+// Creates an empty temporary file in the archive directory of a version.
+//
+// Creates the directory if needed. Returns the path of the file.
+func (r *SQLRegistry) stageFile(namespace, resource, version string) (string, error) {
+	id, err := newUploadID()
+	if err != nil {
+		return "", err
+	}
+
+	path := r.uploadTempPath(namespace, resource, version, id)
+	if err := createUploadFile(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Writes a chunk to a temporary file in the archive directory of a version.
+//
+// The file is synced before returning, so it can be appended to a partial
+// upload file without waiting on the client. Returns the path of the file.
+func (r *SQLRegistry) stageChunk(namespace, resource, version string, chunk io.Reader) (string, error) {
+	path, err := r.stageFile(namespace, resource, version)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := writeUploadChunk(path, 0, chunk); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// Syncs a directory, so that renames and links within it are durable.
+//
+// Errors are ignored, since some platforms cannot sync directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// Generates a random identifier for an upload session.
+func newUploadID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// Creates the empty partial file for a new upload session.
+func createUploadFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), archive.DirMode); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, archive.FileMode)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Writes a chunk to the partial file of an upload session at offset.
+//
+// The file is first truncated to offset, discarding bytes left behind by an
+// interrupted chunk that was never recorded, and is synced before returning
+// so that the recorded offset never exceeds the persisted data. Returns the
+// number of bytes written.
+func writeUploadChunk(path string, offset int64, chunk io.Reader) (int64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() < offset {
+		return 0, fmt.Errorf("partial upload file holds %d bytes, expected at least %d", info.Size(), offset)
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(f, chunk)
+	if err != nil {
+		return 0, err
+	}
+	if err := f.Sync(); err != nil {
+		return 0, err
+	}
+
+	return n, f.Close()
+}
+
+// Verifies the data of a completed upload.
+//
+// Only the first size bytes of the partial file are considered, matching the
+// recorded offset of the session. Returns an error wrapping
+// [reference.ErrDigestMismatch] if they do not match expected.
+func verifyUploadFile(path string, size int64, expected *reference.Digest) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	vr, err := reference.NewVerifyingReader(io.LimitReader(f, size), expected)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(io.Discard, vr); err != nil {
+		return err
+	}
+	if vr.Size() != size {
+		return fmt.Errorf("partial upload file holds %d bytes, expected %d", vr.Size(), size)
+	}
+	return nil
+}
+
+// Links a verified upload to its final archive location.
+//
+// The partial file is truncated to size, dropping bytes of interrupted
+// chunks, and stays in place until the session is deleted, so the session
+// survives a failed commit. An existing final file has the same content and
+// is kept. Returns the final file path.
+func (r *SQLRegistry) storeUploadFile(namespace, resource, version, path string, size int64, hash string) (string, error) {
+	if err := os.Truncate(path, size); err != nil {
+		return "", err
+	}
+
+	final := r.archiveFinalPath(namespace, resource, version, hash)
+	if err := os.Link(path, final); err != nil && !errors.Is(err, fs.ErrExist) {
+		return "", err
+	}
+	syncDir(filepath.Dir(final))
+
+	return final, nil
+}
+
+// Removes an archive file that no version refers to.
+//
+// Called once a transaction that may have stopped referring to the file has
+// ended. Files still referred to, such as the archive of a version that was
+// uploaded again with the same content, are kept. Failures are logged, since
+// [SQLRegistry.SweepArchives] removes leftover files later.
+func (r *SQLRegistry) discardArchive(ctx context.Context, path string) {
+	referenced, err := r.archiveReferenced(ctx, path)
+	if err != nil {
+		r.logger.Warn("failed to check archive references", "error", err, "path", path)
+		return
+	}
+	if referenced {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		r.logger.Warn("failed to remove archive", "error", err, "path", path)
+	}
+}
+
+// Opens part of an archive file.
+//
+// Returns a reader for length bytes starting at offset, or for the rest of the
+// file if length is negative. Returns [errRangeNotSatisfiable] if offset is
+// past the end of the file. The caller must close the reader.
+func openArchiveRange(path string, offset, length int64) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if offset > info.Size() {
+		f.Close()
+		return nil, errRangeNotSatisfiable
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if length < 0 {
+		return f, nil
+	}
+	return &archiveRange{Reader: io.LimitReader(f, length), Closer: f}, nil
+}
 
-	return digest, path, size, nil
+// A section of an archive file.
+type archiveRange struct {
+	io.Reader // Limited reader over the section.
+	io.Closer // Underlying file.
 }
@@ -50,7 +50,7 @@ func TestArchiveDirectoryPath(t *testing.T) {
 	}
 }
 
-func TestArchiveTempPath(t *testing.T) {
+func TestUploadTempPath(t *testing.T) {
 	tempDir := t.TempDir()
 	registry := &SQLRegistry{archiveRoot: tempDir}
 
@@ -59,6 +59,7 @@ func TestArchiveTempPath(t *testing.T) {
 		namespace string
 		resource  string
 		version   string
+		id        string
 		want      string
 	}{
 		{
@@ -66,15 +67,16 @@ func TestArchiveTempPath(t *testing.T) {
 			namespace: "test-ns",
 			resource:  "test-resource",
 			version:   "1.0.0",
-			want:      filepath.Join(tempDir, "test-ns", "test-resource", "1.0.0", TemporaryUploadSuffix),
+			id:        "abc123",
+			want:      filepath.Join(tempDir, "test-ns", "test-resource", "1.0.0", "abc123"+TemporaryUploadSuffix),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := registry.archiveTempPath(tt.namespace, tt.resource, tt.version)
+			got := registry.uploadTempPath(tt.namespace, tt.resource, tt.version, tt.id)
 			if got != tt.want {
-				t.Errorf("archiveTempPath() = %q, want %q", got, tt.want)
+				t.Errorf("uploadTempPath() = %q, want %q", got, tt.want)
 			}
 		})
 	}
@@ -134,8 +136,8 @@ func TestStoreArchiveFile_Success(t *testing.T) {
 	}
 
 	// Verify digest
-	if digest != expectedDigest {
-		t.Errorf("digest = %q, want %q", digest, expectedDigest)
+	if digest != "sha256:"+expectedDigest {
+		t.Errorf("digest = %q, want %q", digest, "sha256:"+expectedDigest)
 	}
 
 	// Verify size
@@ -159,9 +161,8 @@ func TestStoreArchiveFile_Success(t *testing.T) {
 	}
 
 	// Verify temp file was cleaned up
-	tempPath := filepath.Join(tempDir, "test-ns", "test-resource", "1.0.0", TemporaryUploadSuffix)
-	if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
-		t.Errorf("temporary file still exists at %q", tempPath)
+	if temps, _ := filepath.Glob(filepath.Join(tempDir, "test-ns", "test-resource", "1.0.0", "*"+TemporaryUploadSuffix)); len(temps) != 0 {
+		t.Errorf("temporary files still exist: %v", temps)
 	}
 }
 
@@ -187,8 +188,8 @@ func TestStoreArchiveFile_LargeFile(t *testing.T) {
 	}
 
 	// Verify digest
-	if digest != expectedDigest {
-		t.Errorf("digest = %q, want %q", digest, expectedDigest)
+	if digest != "sha256:"+expectedDigest {
+		t.Errorf("digest = %q, want %q", digest, "sha256:"+expectedDigest)
 	}
 
 	// Verify size
@@ -222,8 +223,8 @@ func TestStoreArchiveFile_EmptyFile(t *testing.T) {
 	}
 
 	// Verify digest
-	if digest != expectedDigest {
-		t.Errorf("digest = %q, want %q", digest, expectedDigest)
+	if digest != "sha256:"+expectedDigest {
+		t.Errorf("digest = %q, want %q", digest, "sha256:"+expectedDigest)
 	}
 
 	// Verify size
@@ -285,9 +286,8 @@ func TestStoreArchiveFile_ReadError(t *testing.T) {
 	}
 
 	// Verify temp file was cleaned up
-	tempPath := filepath.Join(tempDir, "test-ns", "test-resource", "1.0.0", TemporaryUploadSuffix)
-	if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
-		t.Errorf("temporary file was not cleaned up")
+	if temps, _ := filepath.Glob(filepath.Join(tempDir, "test-ns", "test-resource", "1.0.0", "*"+TemporaryUploadSuffix)); len(temps) != 0 {
+		t.Errorf("temporary file was not cleaned up: %v", temps)
 	}
 }
 
@@ -1,6 +1,10 @@
 package registry
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // String identifier for HTTP Content-Type and Accept headers.
 //
@@ -10,20 +14,29 @@ import "fmt"
 type MediaType string
 
 const (
-	MediaTypeError         MediaType = "application/vnd.crucible.error.v0"          // Error responses with codes and messages.
-	MediaTypeNamespaceInfo MediaType = "application/vnd.crucible.namespace-info.v0" // Namespace create/update requests.
-	MediaTypeNamespace     MediaType = "application/vnd.crucible.namespace.v0"      // Complete namespace with resource summaries.
-	MediaTypeNamespaceList MediaType = "application/vnd.crucible.namespace-list.v0" // Collection of namespace summaries.
-	MediaTypeResourceInfo  MediaType = "application/vnd.crucible.resource-info.v0"  // Resource create/update requests.
-	MediaTypeResource      MediaType = "application/vnd.crucible.resource.v0"       // Complete resource with version/channel summaries.
-	MediaTypeResourceList  MediaType = "application/vnd.crucible.resource-list.v0"  // Collection of resource summaries.
-	MediaTypeVersionInfo   MediaType = "application/vnd.crucible.version-info.v0"   // Version create/update requests.
-	MediaTypeVersion       MediaType = "application/vnd.crucible.version.v0"        // Complete version with archive details.
-	MediaTypeVersionList   MediaType = "application/vnd.crucible.version-list.v0"   // Collection of version summaries.
-	MediaTypeChannelInfo   MediaType = "application/vnd.crucible.channel-info.v0"   // Channel create/update requests.
-	MediaTypeChannel       MediaType = "application/vnd.crucible.channel.v0"        // Complete channel with full version object.
-	MediaTypeChannelList   MediaType = "application/vnd.crucible.channel-list.v0"   // Collection of channel summaries.
-	MediaTypeArchive       MediaType = "application/vnd.crucible.archive.v0"        // Binary archive data (tar.zst format).
+	MediaTypeError          MediaType = "application/vnd.crucible.error.v0"            // Error responses with codes and messages.
+	MediaTypeNamespaceInfo  MediaType = "application/vnd.crucible.namespace-info.v0"   // Namespace create/update requests.
+	MediaTypeNamespace      MediaType = "application/vnd.crucible.namespace.v0"        // Complete namespace with resource summaries.
+	MediaTypeNamespaceList  MediaType = "application/vnd.crucible.namespace-list.v0"   // Collection of namespace summaries.
+	MediaTypeMemberInfo     MediaType = "application/vnd.crucible.member-info.v0"      // Namespace member create/update requests.
+	MediaTypeMember         MediaType = "application/vnd.crucible.member.v0"           // Namespace member with role.
+	MediaTypeMemberList     MediaType = "application/vnd.crucible.member-list.v0"      // Collection of namespace members.
+	MediaTypeResourceInfo   MediaType = "application/vnd.crucible.resource-info.v0"    // Resource create/update requests.
+	MediaTypeResource       MediaType = "application/vnd.crucible.resource.v0"         // Complete resource with version/channel summaries.
+	MediaTypeResourceList   MediaType = "application/vnd.crucible.resource-list.v0"    // Collection of resource summaries.
+	MediaTypeVersionInfo    MediaType = "application/vnd.crucible.version-info.v0"     // Version create/update requests.
+	MediaTypeVersion        MediaType = "application/vnd.crucible.version.v0"          // Complete version with archive details.
+	MediaTypeVersionList    MediaType = "application/vnd.crucible.version-list.v0"     // Collection of version summaries.
+	MediaTypeChannelInfo    MediaType = "application/vnd.crucible.channel-info.v0"     // Channel create/update requests.
+	MediaTypeChannel        MediaType = "application/vnd.crucible.channel.v0"          // Complete channel with full version object.
+	MediaTypeChannelHistory MediaType = "application/vnd.crucible.channel-history.v0"  // Pointer changes of a channel.
+	MediaTypeChannelList    MediaType = "application/vnd.crucible.channel-list.v0"     // Collection of channel summaries.
+	MediaTypeAuditEventList MediaType = "application/vnd.crucible.audit-event-list.v0" // Collection of audit events.
+	MediaTypeSearchResults  MediaType = "application/vnd.crucible.search-results.v0"   // Ranked page of resource search results.
+	MediaTypeArchive        MediaType = "application/vnd.crucible.archive.v0"          // Binary archive data (tar.zst format).
+	MediaTypeUpload         MediaType = "application/vnd.crucible.upload.v0"           // Resumable archive upload session.
+	MediaTypeEvent          MediaType = "application/vnd.crucible.event.v0"            // Registry event sent to webhooks and event streams.
+	MediaTypeDeliveryList   MediaType = "application/vnd.crucible.delivery-list.v0"    // Collection of webhook deliveries.
 )
 
 // Platform-specific error code for machine-readable error classification.
@@ -35,15 +48,21 @@ type ErrorCode string
 
 const (
 	ErrorCodeBadRequest           ErrorCode = "bad_request"                     // Request validation failed (malformed body, invalid fields).
+	ErrorCodeUnauthorized         ErrorCode = "unauthorized"                    // Request carries no valid credentials.
+	ErrorCodeForbidden            ErrorCode = "forbidden"                       // Authenticated principal may not perform the action.
 	ErrorCodeNotFound             ErrorCode = "not_found"                       // Requested namespace, resource, version, or channel does not exist.
 	ErrorCodeNamespaceExists      ErrorCode = "namespace_exists"                // Cannot create namespace - name already in use.
 	ErrorCodeNamespaceNotEmpty    ErrorCode = "namespace_not_empty"             // Cannot delete namespace - contains resources.
+	ErrorCodeMemberExists         ErrorCode = "member_exists"                   // Cannot add member - subject is already a member of the namespace.
 	ErrorCodeResourceExists       ErrorCode = "resource_exists"                 // Cannot create resource - name already in use within namespace.
 	ErrorCodeResourceHasPublished ErrorCode = "resource_has_published_versions" // Cannot delete resource - contains published versions.
 	ErrorCodeVersionExists        ErrorCode = "version_exists"                  // Cannot create version - version string already in use.
 	ErrorCodeVersionPublished     ErrorCode = "version_published"               // Cannot modify or delete version - already published and immutable.
 	ErrorCodeChannelExists        ErrorCode = "channel_exists"                  // Cannot create channel - name already in use.
 	ErrorCodePreconditionFailed   ErrorCode = "precondition_failed"             // Request precondition not met (e.g., If-Match header mismatch).
+	ErrorCodeInvalidOffset        ErrorCode = "invalid_offset"                  // Upload chunk does not start at the current upload offset.
+	ErrorCodeDigestMismatch       ErrorCode = "digest_mismatch"                 // Uploaded archive does not match the digest given on commit.
+	ErrorCodeRangeNotSatisfiable  ErrorCode = "range_not_satisfiable"           // Requested byte range lies outside the archive.
 	ErrorCodeUnsupportedMediaType ErrorCode = "unsupported_media_type"          // Content-Type header specifies unsupported media type.
 	ErrorCodeNotAcceptable        ErrorCode = "not_acceptable"                  // Accept header specifies unsupported media type.
 	ErrorCodeInternalError        ErrorCode = "internal_error"                  // Unexpected server error occurred.
@@ -64,6 +83,44 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("%s: %s", e.Code, e.Message)
 }
 
+// Revision of a namespace, member, resource, version or channel.
+//
+// Starts at 1 when the entity is created and grows with every change, so it
+// identifies one state of the entity. Reads return the current revision, and
+// updates and deletes take the revision the caller expects, failing with
+// [ErrorCodePreconditionFailed] if the entity has changed since. Over HTTP,
+// revisions travel as entity tags in the ETag, If-Match and If-None-Match
+// headers (see [Revision.ETag]).
+type Revision int64
+
+// Expected revision that matches any revision, skipping the check.
+const AnyRevision Revision = 0
+
+// Returns the revision as a strong HTTP entity tag, such as "3" in quotes.
+func (r Revision) ETag() string {
+	return `"` + strconv.FormatInt(int64(r), 10) + `"`
+}
+
+// Parses a strong HTTP entity tag made by [Revision.ETag].
+//
+// Returns an error for weak tags, which cannot satisfy If-Match, and for tags
+// that are not revisions.
+func ParseETag(etag string) (Revision, error) {
+	unquoted, ok := strings.CutPrefix(etag, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	if !ok {
+		return 0, fmt.Errorf("invalid entity tag %q", etag)
+	}
+
+	n, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid entity tag %q", etag)
+	}
+	return Revision(n), nil
+}
+
 // Mutable properties of a namespace for creation or update.
 //
 // Used as the request body for namespace creation and update operations. The
@@ -102,6 +159,7 @@ type Namespace struct {
 	Resources   []ResourceSummary `field:"resources"`   // List of resources (summary form).
 	CreatedAt   int64             `field:"createdAt"`   // When the namespace was created.
 	UpdatedAt   int64             `field:"updatedAt"`   // When the namespace was last updated.
+	Revision    Revision          `field:"revision"`    // Revision of the namespace, changed by every update.
 }
 
 // Collection of namespaces.
@@ -112,6 +170,36 @@ type NamespaceList struct {
 	Namespaces []NamespaceSummary `field:"namespaces"` // List of namespaces.
 }
 
+// Mutable properties of a namespace membership for creation or update.
+//
+// Grants a subject a role within a single namespace, in addition to any roles
+// the subject holds globally. For update requests, Subject must match the URL
+// path parameter or update context. The media type is [MediaTypeMemberInfo].
+type MemberInfo struct {
+	Subject string `field:"subject"` // Principal the membership is granted to.
+	Role    Role   `field:"role"`    // Role within the namespace.
+}
+
+// Subject holding a role within a namespace.
+//
+// The subject that creates a namespace becomes its first member, with
+// [RoleAdmin]. The media type is [MediaTypeMember].
+type Member struct {
+	Namespace string   `field:"namespace"` // Namespace the membership belongs to.
+	Subject   string   `field:"subject"`   // Principal the membership is granted to.
+	Role      Role     `field:"role"`      // Role within the namespace.
+	CreatedAt int64    `field:"createdAt"` // When the member was added.
+	UpdatedAt int64    `field:"updatedAt"` // When the role last changed.
+	Revision  Revision `field:"revision"`  // Revision of the membership, changed by every update.
+}
+
+// Collection of namespace members.
+//
+// The media type is [MediaTypeMemberList].
+type MemberList struct {
+	Members []Member `field:"members"` // List of members, sorted by subject.
+}
+
 // Mutable properties of a resource for creation or update.
 //
 // Used as the request body for resource creation and update operations. For
@@ -157,6 +245,7 @@ type Resource struct {
 	Channels    []ChannelSummary `field:"channels"`    // List of channels (summary form).
 	CreatedAt   int64            `field:"createdAt"`   // When the resource was created.
 	UpdatedAt   int64            `field:"updatedAt"`   // When the resource was last updated.
+	Revision    Revision         `field:"revision"`    // Revision of the resource, changed by every update.
 }
 
 // Collection of resources.
@@ -166,6 +255,56 @@ type ResourceList struct {
 	Resources []ResourceSummary `field:"resources"` // List of resources.
 }
 
+// Criteria for searching resources across namespaces.
+//
+// Text is split into words of letters and digits, each of which must appear
+// in the name or the description of a resource; text without words matches
+// every resource. Type, Namespace and Stable are facets narrowing the
+// matches. Zero-valued facets do not filter.
+type SearchQuery struct {
+	Text      string // Words matched against resource names and descriptions.
+	Type      string // Resource type (e.g., "widget", "service").
+	Namespace string // Namespace of the resources.
+	Stable    bool   // Only resources with a channel named [StableChannel].
+	Limit     int    // Maximum number of results; zero uses [DefaultSearchLimit].
+	Offset    int    // Number of ranked results to skip.
+}
+
+// Resource matching a search.
+type SearchResult struct {
+	Namespace string          `field:"namespace"` // Namespace of the resource.
+	Resource  ResourceSummary `field:"resource"`  // Matching resource.
+	Stable    bool            `field:"stable"`    // Whether the resource has a channel named [StableChannel].
+	Score     float64         `field:"score"`     // Relevance of the match; higher ranks first.
+}
+
+// Number of matches with a facet value.
+type FacetCount struct {
+	Value string `field:"value"` // Facet value.
+	Count int    `field:"count"` // Number of matching resources with the value.
+}
+
+// Counts of search matches by facet.
+//
+// The counts of each facet apply the other facets of the query but not its
+// own, so they tell how many results selecting another value would give.
+// Values are ordered by count, most frequent first.
+type SearchFacets struct {
+	Types      []FacetCount `field:"types"`      // Matches by resource type.
+	Namespaces []FacetCount `field:"namespaces"` // Matches by namespace.
+	Stable     int          `field:"stable"`     // Matches with a channel named [StableChannel].
+}
+
+// Page of resources matching a search, most relevant first.
+//
+// Ties are ordered by namespace and resource name. The media type is
+// [MediaTypeSearchResults].
+type SearchResults struct {
+	Results []SearchResult `field:"results"` // Matching resources in this page.
+	Total   int            `field:"total"`   // Number of matching resources across all pages.
+	Facets  SearchFacets   `field:"facets"`  // Counts of matches by facet.
+}
+
 // Mutable properties of a version for creation or update.
 //
 // Used as the request body for version creation and update operations. For
@@ -199,14 +338,15 @@ type VersionSummary struct {
 // scoping information to identify the version's location. The media type is
 // [MediaTypeVersion].
 type Version struct {
-	Namespace string  `field:"namespace"` // Namespace this version belongs to.
-	Resource  string  `field:"resource"`  // Resource this version belongs to.
-	String    string  `field:"string"`    // Version string (e.g., "1.0.0").
-	Archive   *string `field:"archive"`   // Download URL or null if not uploaded.
-	Size      *int64  `field:"size"`      // Archive size in bytes (null if not uploaded).
-	Digest    *string `field:"digest"`    // Archive digest (e.g., "sha256:abc...", null if not uploaded).
-	CreatedAt int64   `field:"createdAt"` // When the version was created.
-	UpdatedAt int64   `field:"updatedAt"` // When the version was last updated.
+	Namespace string   `field:"namespace"` // Namespace this version belongs to.
+	Resource  string   `field:"resource"`  // Resource this version belongs to.
+	String    string   `field:"string"`    // Version string (e.g., "1.0.0").
+	Archive   *string  `field:"archive"`   // Download URL or null if not uploaded.
+	Size      *int64   `field:"size"`      // Archive size in bytes (null if not uploaded).
+	Digest    *string  `field:"digest"`    // Archive digest (e.g., "sha256:abc...", null if not uploaded).
+	CreatedAt int64    `field:"createdAt"` // When the version was created.
+	UpdatedAt int64    `field:"updatedAt"` // When the version was last updated.
+	Revision  Revision `field:"revision"`  // Revision of the version, changed by every update and upload.
 }
 
 // Collection of versions for a resource.
@@ -253,13 +393,14 @@ type ChannelSummary struct {
 // information to identify the channel's location. The media type is
 // [MediaTypeChannel].
 type Channel struct {
-	Namespace   string  `field:"namespace"`   // Namespace this channel belongs to.
-	Resource    string  `field:"resource"`    // Resource this channel belongs to.
-	Name        string  `field:"name"`        // Channel name.
-	Version     Version `field:"version"`     // Full version object this channel points to.
-	Description string  `field:"description"` // Human-readable description.
-	CreatedAt   int64   `field:"createdAt"`   // When the channel was created.
-	UpdatedAt   int64   `field:"updatedAt"`   // When the channel was last updated.
+	Namespace   string   `field:"namespace"`   // Namespace this channel belongs to.
+	Resource    string   `field:"resource"`    // Resource this channel belongs to.
+	Name        string   `field:"name"`        // Channel name.
+	Version     Version  `field:"version"`     // Full version object this channel points to.
+	Description string   `field:"description"` // Human-readable description.
+	CreatedAt   int64    `field:"createdAt"`   // When the channel was created.
+	UpdatedAt   int64    `field:"updatedAt"`   // When the channel was last updated.
+	Revision    Revision `field:"revision"`    // Revision of the channel, changed by every update and rollback.
 }
 
 // Collection of channels with their current version targets.
@@ -268,3 +409,141 @@ type Channel struct {
 type ChannelList struct {
 	Channels []ChannelSummary `field:"channels"` // List of channels.
 }
+
+// Change of the version a channel points to.
+//
+// Recorded when a channel is created, when an update points it at another
+// version, and when it is rolled back. Entries outlive the channel, so the
+// history of a deleted channel remains available.
+type ChannelHistoryEntry struct {
+	ID        int64   `field:"id"`        // Monotonic entry identifier.
+	Namespace string  `field:"namespace"` // Namespace this channel belongs to.
+	Resource  string  `field:"resource"`  // Resource this channel belongs to.
+	Channel   string  `field:"channel"`   // Channel name.
+	Version   string  `field:"version"`   // Version the channel was pointed at.
+	Previous  *string `field:"previous"`  // Version the channel pointed at before (null when created).
+	Actor     string  `field:"actor"`     // Subject of the principal, or empty if anonymous.
+	CreatedAt int64   `field:"createdAt"` // When the change was made.
+}
+
+// Pointer changes of a channel, newest first.
+//
+// The media type is [MediaTypeChannelHistory].
+type ChannelHistory struct {
+	Entries []ChannelHistoryEntry `field:"entries"` // List of changes.
+}
+
+// Resumable archive upload session.
+//
+// Created by starting an upload and advanced by appending chunks at the
+// current offset. The session survives registry restarts, so a client that
+// lost its connection reads the session to learn the offset to resume from.
+// Committing the session with the digest of the whole archive verifies the
+// uploaded data and attaches it to the version, as [Registry.UploadArchive]
+// does. The media type is [MediaTypeUpload].
+type Upload struct {
+	ID        string `field:"id"`        // Opaque session identifier.
+	Namespace string `field:"namespace"` // Namespace of the target version.
+	Resource  string `field:"resource"`  // Resource of the target version.
+	Version   string `field:"version"`   // Target version string.
+	Offset    int64  `field:"offset"`    // Number of bytes received so far.
+	CreatedAt int64  `field:"createdAt"` // When the session was started.
+	UpdatedAt int64  `field:"updatedAt"` // When the last chunk was received.
+}
+
+// Record of a successful registry mutation.
+//
+// Events are appended when a mutation succeeds and are never changed or
+// removed. Before and After hold JSON summaries of the mutable fields of the
+// target, in the form of its info type (for example [ChannelInfo]); archive
+// uploads summarize the archive digest and size instead. Events are listed
+// in an [AuditEventList].
+type AuditEvent struct {
+	ID        int64       `field:"id"`        // Monotonic event identifier.
+	Actor     string      `field:"actor"`     // Subject of the principal, or empty if anonymous.
+	Action    AuditAction `field:"action"`    // Mutation performed.
+	Namespace string      `field:"namespace"` // Namespace of the target.
+	Resource  string      `field:"resource"`  // Resource of the target, or empty for namespace-level events.
+	Target    string      `field:"target"`    // Version, channel or member subject, or empty.
+	Before    string      `field:"before"`    // Summary before the change, or empty for creations.
+	After     string      `field:"after"`     // Summary after the change, or empty for deletions.
+	Digest    *string     `field:"digest"`    // Archive digest involved in the change (null if none).
+	CreatedAt int64       `field:"createdAt"` // When the change was made.
+}
+
+// Criteria for listing audit events.
+//
+// Zero-valued fields do not filter. Namespace, Resource and Target select a
+// target at increasing precision; for example, the "stable" channel of
+// acme/app is Namespace "acme", Resource "app" and Target "stable".
+type AuditFilter struct {
+	Namespace string      // Namespace of the target.
+	Resource  string      // Resource of the target.
+	Target    string      // Version, channel or member subject.
+	Actor     string      // Subject that made the change.
+	Action    AuditAction // Mutation performed.
+	Since     int64       // Earliest event time, inclusive (Unix timestamp).
+	Until     int64       // Latest event time, exclusive (Unix timestamp).
+	Limit     int         // Maximum number of events; zero uses [DefaultAuditLimit].
+}
+
+// Collection of audit events, newest first.
+//
+// The media type is [MediaTypeAuditEventList].
+type AuditEventList struct {
+	Events []AuditEvent `field:"events"` // List of events.
+}
+
+// Change announced to subscribers of a registry.
+//
+// Events are sent to webhooks and event streams once the change is committed.
+// Unlike audit events, they are not stored; a subscriber that was not
+// listening misses them and can catch up with [Registry.ListAuditEvents]. The
+// media type is [MediaTypeEvent].
+type Event struct {
+	ID        string    `field:"id"`        // Unique event identifier.
+	Type      EventType `field:"type"`      // Kind of change.
+	Namespace string    `field:"namespace"` // Namespace of the change.
+	Resource  string    `field:"resource"`  // Resource of the change, or empty for namespace events.
+	Version   string    `field:"version"`   // Version concerned; for channel moves, the version the channel points to.
+	Channel   string    `field:"channel"`   // Channel moved, or empty for other events.
+	Previous  string    `field:"previous"`  // Version a moved channel pointed to before, or empty for new channels.
+	Digest    *string   `field:"digest"`    // Archive digest of the version (null if it has no archive).
+	Actor     string    `field:"actor"`     // Subject of the principal, or empty if anonymous.
+	CreatedAt int64     `field:"createdAt"` // When the change was made.
+}
+
+// Attempt to deliver an event to a webhook.
+//
+// Recorded by [WebhookDispatcher] once the event is delivered or the
+// dispatcher gives up on it. Deliveries are listed in a
+// [WebhookDeliveryList].
+type WebhookDelivery struct {
+	ID          int64     `field:"id"`          // Monotonic delivery identifier.
+	EventID     string    `field:"eventId"`     // Identifier of the event delivered.
+	EventType   EventType `field:"eventType"`   // Type of the event delivered.
+	URL         string    `field:"url"`         // URL of the webhook.
+	Attempts    int       `field:"attempts"`    // Number of requests sent.
+	StatusCode  int       `field:"statusCode"`  // Status of the last response, or zero if none was received.
+	Error       string    `field:"error"`       // Why the last attempt failed, or empty if the event was delivered.
+	Delivered   bool      `field:"delivered"`   // Whether the webhook accepted the event.
+	CreatedAt   int64     `field:"createdAt"`   // When the first attempt was made.
+	CompletedAt int64     `field:"completedAt"` // When the last attempt ended.
+}
+
+// Criteria for listing webhook deliveries.
+//
+// Zero-valued fields do not filter.
+type WebhookDeliveryFilter struct {
+	EventID string // Identifier of the event delivered.
+	URL     string // URL of the webhook.
+	Failed  bool   // Only list deliveries that were given up on.
+	Limit   int    // Maximum number of deliveries; zero uses [DefaultDeliveryLimit].
+}
+
+// Collection of webhook deliveries, newest first.
+//
+// The media type is [MediaTypeDeliveryList].
+type WebhookDeliveryList struct {
+	Deliveries []WebhookDelivery `field:"deliveries"` // List of deliveries.
+}
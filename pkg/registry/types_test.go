@@ -124,6 +124,27 @@ func TestErrorCode_Constants(t *testing.T) {
 	}
 }
 
+func TestRevision_ETag(t *testing.T) {
+	tag := Revision(42).ETag()
+	if tag != `"42"` {
+		t.Errorf("ETag() = %s, want \"42\"", tag)
+	}
+
+	rev, err := ParseETag(tag)
+	if err != nil {
+		t.Fatalf("ParseETag() error = %v", err)
+	}
+	if rev != 42 {
+		t.Errorf("ParseETag() = %d, want 42", rev)
+	}
+
+	for _, invalid := range []string{"", "42", `W/"42"`, `"abc"`, `"0"`, `"-1"`} {
+		if _, err := ParseETag(invalid); err == nil {
+			t.Errorf("ParseETag(%q) expected error", invalid)
+		}
+	}
+}
+
 func TestNamespaceInfo_Fields(t *testing.T) {
 	info := NamespaceInfo{
 		Name:        "test-namespace",
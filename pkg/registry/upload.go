@@ -0,0 +1,124 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+const (
+
+	// Default size of the chunks sent by [Client.UploadArchiveResumable].
+	DefaultUploadChunkSize = 16 << 20
+
+	// Number of consecutive failed chunks after which an upload gives up.
+	maxChunkAttempts = 3
+)
+
+// Options for [Client.UploadArchiveResumable].
+//
+// The zero value (and a nil *UploadOptions) starts a new session and sends
+// chunks of [DefaultUploadChunkSize].
+type UploadOptions struct {
+
+	// Size of each chunk in bytes.
+	//
+	// Each chunk is held in memory while it is sent. Zero or negative uses
+	// [DefaultUploadChunkSize].
+	ChunkSize int64
+
+	// Identifier of an existing session to resume.
+	//
+	// The upload continues from the offset the registry reports for the
+	// session. Empty starts a new session.
+	UploadID string
+}
+
+// Returns the chunk size, applying the default.
+func (o *UploadOptions) chunkSize() int64 {
+	if o == nil || o.ChunkSize <= 0 {
+		return DefaultUploadChunkSize
+	}
+	return o.ChunkSize
+}
+
+// Returns the session to resume, if any.
+func (o *UploadOptions) uploadID() string {
+	if o == nil {
+		return ""
+	}
+	return o.UploadID
+}
+
+// Uploads a version archive in resumable chunks.
+//
+// The archive is read from its current position. It is hashed first, then
+// sent in chunks through an upload session
+// (see [Client.StartUpload]) that is committed with the digest. When a chunk
+// fails, the session is read back to learn how much the registry persisted
+// and the upload resumes from there, giving up after several consecutive
+// failures. To resume after the process itself restarted, pass the session
+// identifier in [UploadOptions.UploadID]. Options can be nil. On failure the
+// session is left in place so it can be resumed; use [Client.CancelUpload] to
+// discard it.
+func (c *Client) UploadArchiveResumable(ctx context.Context, namespace, resource, version string, archive io.ReadSeeker, options *UploadOptions) (*Version, error) {
+	start, err := archive.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("seek archive: %w", err)
+	}
+
+	digester, err := reference.NewDigester(reference.DefaultDigestAlgorithm, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(digester, archive); err != nil {
+		return nil, fmt.Errorf("hash archive: %w", err)
+	}
+
+	var upload *Upload
+	if id := options.uploadID(); id != "" {
+		upload, err = c.ReadUpload(ctx, namespace, resource, version, id)
+	} else {
+		upload, err = c.StartUpload(ctx, namespace, resource, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, options.chunkSize())
+	offset := upload.Offset
+	failures := 0
+
+	for offset < digester.Size() {
+		if _, err := archive.Seek(start+offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("seek archive: %w", err)
+		}
+		n, err := io.ReadFull(archive, buf)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+
+		next, err := c.UploadChunk(ctx, namespace, resource, version, upload.ID, offset, bytes.NewReader(buf[:n]))
+		if err != nil {
+			failures++
+			if ctx.Err() != nil || failures >= maxChunkAttempts {
+				return nil, err
+			}
+
+			// Resume from whatever the registry persisted
+			if next, err = c.ReadUpload(ctx, namespace, resource, version, upload.ID); err != nil {
+				return nil, err
+			}
+		} else {
+			failures = 0
+		}
+
+		offset = next.Offset
+	}
+
+	return c.CommitUpload(ctx, namespace, resource, version, upload.ID, digester.Digest().String())
+}
@@ -0,0 +1,168 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cruciblehq/protocol/pkg/reference"
+)
+
+func TestClient_UploadArchiveResumable(t *testing.T) {
+	archiveData := []byte("0123456789abcdefghij")
+	server := newUploadServer(t, 2)
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	v, err := client.UploadArchiveResumable(context.Background(), "ns", "res", "1.0.0", bytes.NewReader(archiveData), &UploadOptions{ChunkSize: 8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	digest, _ := reference.FromBytes(reference.SHA256, archiveData)
+	if v.Digest == nil || *v.Digest != digest.String() {
+		t.Fatalf("expected digest %s, got: %v", digest, v.Digest)
+	}
+	if server.data.String() != string(archiveData) {
+		t.Fatalf("expected %q, got: %q", archiveData, server.data.String())
+	}
+	if server.failures != 1 {
+		t.Fatalf("expected 1 failed chunk, got: %d", server.failures)
+	}
+}
+
+func TestClient_UploadArchiveResumable_Resume(t *testing.T) {
+	archiveData := []byte("0123456789abcdefghij")
+	server := newUploadServer(t, 0)
+	defer server.Close()
+
+	// An earlier process sent the first 10 bytes
+	server.data.Write(archiveData[:10])
+
+	client := NewClient(server.URL, nil)
+	_, err := client.UploadArchiveResumable(context.Background(), "ns", "res", "1.0.0", bytes.NewReader(archiveData), &UploadOptions{UploadID: "abc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if server.data.String() != string(archiveData) {
+		t.Fatalf("expected %q, got: %q", archiveData, server.data.String())
+	}
+}
+
+func TestClient_UploadArchiveResumable_CurrentPosition(t *testing.T) {
+	archiveData := []byte("0123456789abcdefghij")
+	server := newUploadServer(t, 2)
+	defer server.Close()
+
+	// The archive starts after a header the caller already consumed
+	r := bytes.NewReader(append([]byte("header"), archiveData...))
+	if _, err := r.Seek(int64(len("header")), io.SeekStart); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := NewClient(server.URL, nil)
+	v, err := client.UploadArchiveResumable(context.Background(), "ns", "res", "1.0.0", r, &UploadOptions{ChunkSize: 8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	digest, _ := reference.FromBytes(reference.SHA256, archiveData)
+	if v.Digest == nil || *v.Digest != digest.String() {
+		t.Fatalf("expected digest %s, got: %v", digest, v.Digest)
+	}
+	if server.data.String() != string(archiveData) {
+		t.Fatalf("expected %q, got: %q", archiveData, server.data.String())
+	}
+}
+
+func TestClient_UploadChunk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" {
+			t.Errorf("expected PATCH, got %s", r.Method)
+		}
+		if r.URL.Path != "/namespaces/ns/resources/res/versions/1.0.0/uploads/abc" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if cr := r.Header.Get("Content-Range"); cr != "5-7" {
+			t.Errorf("expected Content-Range 5-7, got %s", cr)
+		}
+		w.Header().Set("Content-Type", string(MediaTypeUpload)+"+json")
+		w.Write([]byte(`{"id":"abc","namespace":"ns","resource":"res","version":"1.0.0","offset":8,"createdAt":0,"updatedAt":0}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	u, err := client.UploadChunk(context.Background(), "ns", "res", "1.0.0", "abc", 5, strings.NewReader("xyz"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u.Offset != 8 {
+		t.Fatalf("expected offset 8, got: %d", u.Offset)
+	}
+}
+
+// A fake registry serving a single upload session with identifier "abc".
+type uploadServer struct {
+	*httptest.Server
+	data     bytes.Buffer // Bytes received so far.
+	failAt   int          // Chunk number to fail after half of it was stored, or zero.
+	chunks   int          // Number of chunks received.
+	failures int          // Number of chunks failed.
+}
+
+// Starts a fake registry that fails chunk number failAt, or none if zero.
+func newUploadServer(t *testing.T, failAt int) *uploadServer {
+	t.Helper()
+
+	s := &uploadServer{failAt: failAt}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST", "GET":
+			s.writeUpload(w)
+		case "PATCH":
+			var start, end int
+			fmt.Sscanf(r.Header.Get("Content-Range"), "%d-%d", &start, &end)
+			if start != s.data.Len() {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				json.NewEncoder(w).Encode(Error{Code: ErrorCodeInvalidOffset})
+				return
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			s.chunks++
+			if s.chunks == s.failAt {
+				s.failures++
+				s.data.Write(body[:len(body)/2])
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			s.data.Write(body)
+			s.writeUpload(w)
+		case "PUT":
+			digest := r.URL.Query().Get("digest")
+			expected, _ := reference.FromBytes(reference.SHA256, s.data.Bytes())
+			if digest != expected.String() {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(Error{Code: ErrorCodeDigestMismatch})
+				return
+			}
+			fmt.Fprintf(w, `{"namespace":"ns","resource":"res","string":"1.0.0","digest":%q,"size":%d,"createdAt":0,"updatedAt":0}`, digest, s.data.Len())
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	return s
+}
+
+// Writes the current session.
+func (s *uploadServer) writeUpload(w http.ResponseWriter) {
+	fmt.Fprintf(w, `{"id":"abc","namespace":"ns","resource":"res","version":"1.0.0","offset":%d,"createdAt":0,"updatedAt":0}`, s.data.Len())
+}
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"errors"
+	"net/url"
 	"regexp"
 
 	"github.com/cruciblehq/protocol/pkg/reference"
@@ -101,3 +102,103 @@ func validateChannelInfo(namespace, resource string, info ChannelInfo) error {
 	}
 	return validateVersionString(info.Version)
 }
+
+// Validates a member reference (namespace + subject).
+//
+// Ensures the namespace name follows naming conventions and the subject is
+// not empty.
+func validateMemberReference(namespace, subject string) error {
+	if err := validateName(namespace); err != nil {
+		return err
+	}
+	if subject == "" {
+		return errors.New("subject cannot be empty")
+	}
+	return nil
+}
+
+// Validates member info (namespace + subject + role).
+//
+// Ensures the reference is valid and the role is one of the predefined roles.
+func validateMemberInfo(namespace string, info MemberInfo) error {
+	if err := validateMemberReference(namespace, info.Subject); err != nil {
+		return err
+	}
+	if !IsValidRole(info.Role) {
+		return errors.New("invalid role: must be admin, publisher, or reader")
+	}
+	return nil
+}
+
+// Validates an audit filter.
+//
+// Ensures the namespace filter follows naming conventions, a resource filter
+// comes with a namespace, the time range is ordered, and the limit is within
+// [MaxAuditLimit].
+func validateAuditFilter(filter AuditFilter) error {
+	if filter.Namespace != "" {
+		if err := validateName(filter.Namespace); err != nil {
+			return err
+		}
+	}
+	if filter.Resource != "" && filter.Namespace == "" {
+		return errors.New("resource filter requires a namespace")
+	}
+	if filter.Since < 0 || filter.Until < 0 {
+		return errors.New("time range must not be negative")
+	}
+	if filter.Until != 0 && filter.Until <= filter.Since {
+		return errors.New("time range end must be after its start")
+	}
+	if filter.Limit < 0 || filter.Limit > MaxAuditLimit {
+		return errors.New("limit must be between 0 and 1000")
+	}
+	return nil
+}
+
+// Validates a search query.
+//
+// Ensures the text is within [MaxSearchTextLength], the namespace facet is a
+// valid name, and the limit and offset are within range.
+func validateSearchQuery(query SearchQuery) error {
+	if len(query.Text) > MaxSearchTextLength {
+		return errors.New("search text must be at most 256 bytes")
+	}
+	if query.Namespace != "" {
+		if err := validateName(query.Namespace); err != nil {
+			return err
+		}
+	}
+	if query.Limit < 0 || query.Limit > MaxSearchLimit {
+		return errors.New("limit must be between 0 and 100")
+	}
+	if query.Offset < 0 {
+		return errors.New("offset must not be negative")
+	}
+	return nil
+}
+
+// Validates a webhook.
+//
+// Ensures the URL is an absolute HTTP or HTTPS URL and the secret is not
+// empty.
+func validateWebhook(hook Webhook) error {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("URL must be an absolute http or https URL")
+	}
+	if len(hook.Secret) == 0 {
+		return errors.New("secret must not be empty")
+	}
+	return nil
+}
+
+// Validates criteria for listing webhook deliveries.
+//
+// Ensures the limit is within [MaxDeliveryLimit].
+func validateDeliveryFilter(filter WebhookDeliveryFilter) error {
+	if filter.Limit < 0 || filter.Limit > MaxDeliveryLimit {
+		return errors.New("limit must be between 0 and 1000")
+	}
+	return nil
+}
@@ -222,3 +222,46 @@ func TestValidateChannelInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateMemberInfo(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		info      MemberInfo
+		wantErr   bool
+	}{
+		{
+			name:      "valid member info",
+			namespace: "my-namespace",
+			info:      MemberInfo{Subject: "alice", Role: RolePublisher},
+			wantErr:   false,
+		},
+		{
+			name:      "invalid namespace",
+			namespace: "My-Namespace",
+			info:      MemberInfo{Subject: "alice", Role: RolePublisher},
+			wantErr:   true,
+		},
+		{
+			name:      "empty subject",
+			namespace: "my-namespace",
+			info:      MemberInfo{Role: RolePublisher},
+			wantErr:   true,
+		},
+		{
+			name:      "unknown role",
+			namespace: "my-namespace",
+			info:      MemberInfo{Subject: "alice", Role: "owner"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMemberInfo(tt.namespace, tt.info)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateMemberInfo(%q, %+v) error = %v, wantErr %v", tt.namespace, tt.info, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,507 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+
+	// Number of deliveries returned when [WebhookDeliveryFilter.Limit] is zero.
+	DefaultDeliveryLimit = 100
+
+	// Maximum number of deliveries returned by one query.
+	MaxDeliveryLimit = 1000
+
+	// Number of deliveries waiting to be sent when [WebhookOptions.QueueSize]
+	// is zero.
+	DefaultWebhookQueueSize = 256
+
+	// How far the timestamp of a webhook request may be from the time it is
+	// verified by [VerifyWebhook].
+	DefaultWebhookTolerance = 5 * time.Minute
+
+	// Largest webhook payload accepted by [VerifyWebhook].
+	maxWebhookPayload = 1 << 20
+
+	// Headers of webhook requests
+	headerWebhookEvent     = "X-Crucible-Event"
+	headerWebhookDelivery  = "X-Crucible-Delivery"
+	headerWebhookTimestamp = "X-Crucible-Timestamp"
+	headerWebhookSignature = "X-Crucible-Signature"
+
+	// Prefix of webhook signatures, naming the algorithm
+	webhookSignaturePrefix = "sha256="
+
+	// Webhook error messages
+	errMsgInvalidWebhook   = "invalid webhook"
+	errMsgEncodeEvent      = "unable to encode event"
+	errMsgWebhookQueueFull = "webhook queue full, dropping event"
+	errMsgDeliverWebhook   = "unable to deliver event to webhook"
+	errMsgRecordDelivery   = "unable to record webhook delivery"
+	errMsgRetrieveDelivery = "unable to retrieve webhook deliveries"
+)
+
+// Endpoint that registry events are posted to.
+type Webhook struct {
+	URL    string      // HTTP or HTTPS URL receiving the events.
+	Secret []byte      // Key shared with the receiver to sign payloads.
+	Filter EventFilter // Events sent to the webhook; the zero value sends all.
+}
+
+// Posts registry events to webhooks.
+//
+// Implements [Subscriber]; subscribe it to a registry with
+// [SQLRegistry.Subscribe]. Every webhook has its own queue and background
+// worker posting its events in order, so neither the registry nor other
+// webhooks are held up by a slow webhook. Each event is posted to every
+// webhook whose filter it matches as a JSON body with the media type
+// [MediaTypeEvent], and these headers:
+//
+//   - X-Crucible-Event: the event type
+//   - X-Crucible-Delivery: the event identifier, the same for every attempt
+//   - X-Crucible-Timestamp: the Unix time the request was signed
+//   - X-Crucible-Signature: "sha256=" and the hex-encoded HMAC-SHA256 of the
+//     timestamp, a period and the body, keyed with the webhook secret
+//
+// Receivers check requests with [VerifyWebhook]. Requests failing with a
+// transport error, a 408, a 429 or a 5xx status are retried according to a
+// [RetryPolicy]; a Retry-After header is honored up to
+// [RetryPolicy.MaxBackoff]. Once an event is accepted or given up on, the
+// outcome is recorded in a delivery log, listed with
+// [WebhookDispatcher.ListDeliveries]. Events arriving while the queue of a
+// webhook is full are dropped and logged.
+//
+// Shares the database and schema of [SQLRegistry]. Thread-safe for concurrent
+// access.
+type WebhookDispatcher struct {
+	db      *sql.DB
+	logger  *slog.Logger
+	dialect *Dialect
+	client  *http.Client
+	retry   *RetryPolicy
+	workers []*webhookWorker   // One per webhook
+	mu      sync.RWMutex       // Protects closed against sends on the queues
+	closed  bool               // Whether the queues are closed
+	ctx     context.Context    // Context of deliveries, canceled to give up on them
+	cancel  context.CancelFunc // Cancels ctx
+	done    chan struct{}      // Closed when every worker has stopped
+}
+
+// Queue of the deliveries to one webhook.
+type webhookWorker struct {
+	hook  Webhook
+	queue chan webhookJob // Deliveries waiting to be sent
+}
+
+// Event waiting to be posted to a webhook.
+type webhookJob struct {
+	hook    Webhook
+	event   Event
+	payload []byte // Encoded event
+}
+
+// Options for creating a [WebhookDispatcher].
+//
+// The zero value (and a nil *WebhookOptions) creates a dispatcher for SQLite
+// using http.DefaultClient and the default retry policy.
+type WebhookOptions struct {
+
+	// SQL dialect of the database.
+	//
+	// Must match the dialect of the [SQLRegistry] sharing the database. Nil
+	// is [DialectSQLite].
+	Dialect *Dialect
+
+	// HTTP client used to post events.
+	//
+	// Nil uses http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Policy for retrying failed deliveries.
+	//
+	// Nil uses the defaults described in [RetryPolicy].
+	Retry *RetryPolicy
+
+	// Number of deliveries that can wait to be sent to each webhook.
+	//
+	// Zero uses [DefaultWebhookQueueSize].
+	QueueSize int
+}
+
+// Returns the dialect, or nil for SQLite.
+func (o *WebhookOptions) dialect() *Dialect {
+	if o == nil {
+		return nil
+	}
+	return o.Dialect
+}
+
+// Returns the HTTP client, applying the default.
+func (o *WebhookOptions) httpClient() *http.Client {
+	if o == nil || o.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return o.HTTPClient
+}
+
+// Returns the retry policy, or nil for the defaults.
+func (o *WebhookOptions) retry() *RetryPolicy {
+	if o == nil {
+		return nil
+	}
+	return o.Retry
+}
+
+// Returns the queue size, applying the default.
+func (o *WebhookOptions) queueSize() int {
+	if o == nil || o.QueueSize <= 0 {
+		return DefaultWebhookQueueSize
+	}
+	return o.QueueSize
+}
+
+// Creates a new webhook dispatcher.
+//
+// The caller is responsible for opening and closing the database, as with
+// [NewSQLRegistry], and for calling [WebhookDispatcher.Close] when done. The
+// dispatcher applies any pending schema migrations, as [NewSQLRegistry] does.
+// Returns [ErrorCodeBadRequest] if a webhook has no secret or its URL is not
+// an absolute HTTP or HTTPS URL.
+func NewWebhookDispatcher(ctx context.Context, db *sql.DB, hooks []Webhook, logger *slog.Logger) (*WebhookDispatcher, error) {
+	return NewWebhookDispatcherWithOptions(ctx, db, hooks, logger, nil)
+}
+
+// Creates a new webhook dispatcher using options.
+//
+// Same as [NewWebhookDispatcher], configured by the given options. Options
+// can be nil.
+func NewWebhookDispatcherWithOptions(ctx context.Context, db *sql.DB, hooks []Webhook, logger *slog.Logger, options *WebhookOptions) (*WebhookDispatcher, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	for _, hook := range hooks {
+		if err := validateWebhook(hook); err != nil {
+			return nil, &Error{Code: ErrorCodeBadRequest, Message: errMsgInvalidWebhook + ": " + err.Error()}
+		}
+	}
+
+	dialect := options.dialect()
+	if err := migrateSchema(ctx, db, dialect, logger); err != nil {
+		return nil, err
+	}
+
+	dctx, cancel := context.WithCancel(context.Background())
+	d := &WebhookDispatcher{
+		db:      db,
+		logger:  logger,
+		dialect: dialect,
+		client:  options.httpClient(),
+		retry:   options.retry(),
+		ctx:     dctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	for _, hook := range hooks {
+		w := &webhookWorker{hook: hook, queue: make(chan webhookJob, options.queueSize())}
+		d.workers = append(d.workers, w)
+		wg.Go(func() { d.run(w) })
+	}
+	go func() {
+		wg.Wait()
+		close(d.done)
+	}()
+
+	return d, nil
+}
+
+// Queues an event for every webhook whose filter it matches.
+//
+// Never blocks. Events are dropped if the queue is full or the dispatcher is
+// closed.
+func (d *WebhookDispatcher) HandleEvent(event Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return
+	}
+
+	var payload []byte
+	for _, w := range d.workers {
+		if !w.hook.Filter.Matches(event) {
+			continue
+		}
+
+		if payload == nil {
+			var err error
+			if payload, err = encodeEvent(event); err != nil {
+				d.logger.Error(errMsgEncodeEvent, "error", err, "event", event.ID, "type", event.Type)
+				return
+			}
+		}
+
+		select {
+		case w.queue <- webhookJob{hook: w.hook, event: event, payload: payload}:
+		default:
+			d.logger.Warn(errMsgWebhookQueueFull, "event", event.ID, "type", event.Type, "url", w.hook.URL)
+		}
+	}
+}
+
+// Stops accepting events and waits for queued deliveries to finish.
+//
+// If ctx ends first, the remaining deliveries are given up on and recorded
+// as failed, and the context error is returned once the workers have stopped.
+func (d *WebhookDispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, w := range d.workers {
+			close(w.queue)
+		}
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-d.done
+		return ctx.Err()
+	}
+}
+
+// Lists recorded deliveries, newest first.
+//
+// Returns [ErrorCodeBadRequest] if the limit is negative or above
+// [MaxDeliveryLimit].
+func (d *WebhookDispatcher) ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) (*WebhookDeliveryList, error) {
+	if err := validateDeliveryFilter(filter); err != nil {
+		return nil, &Error{Code: ErrorCodeBadRequest, Message: err.Error()}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultDeliveryLimit
+	}
+
+	failed := 0
+	if filter.Failed {
+		failed = 1
+	}
+
+	rows, err := d.db.QueryContext(ctx, d.dialect.rebind(sqlWebhookDeliveriesList),
+		filter.EventID, filter.EventID,
+		filter.URL, filter.URL,
+		failed,
+		filter.Limit,
+	)
+	if err != nil {
+		return nil, logError(d.logger, ErrorCodeInternalError, errMsgRetrieveDelivery, err)
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var w WebhookDelivery
+		if err := rows.Scan(&w.ID, &w.EventID, &w.EventType, &w.URL, &w.Attempts, &w.StatusCode, &w.Error, &w.CreatedAt, &w.CompletedAt); err != nil {
+			return nil, logError(d.logger, ErrorCodeInternalError, errMsgRetrieveDelivery, err)
+		}
+		w.Delivered = w.Error == ""
+		deliveries = append(deliveries, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logError(d.logger, ErrorCodeInternalError, errMsgRetrieveDelivery, err)
+	}
+
+	return &WebhookDeliveryList{Deliveries: deliveries}, nil
+}
+
+// Posts the queued events of a webhook until its queue is closed and drained.
+func (d *WebhookDispatcher) run(w *webhookWorker) {
+	for job := range w.queue {
+		delivery := d.deliver(d.ctx, job)
+		d.record(context.WithoutCancel(d.ctx), &delivery)
+	}
+}
+
+// Posts an event to a webhook, retrying as configured.
+//
+// Returns the outcome of the last attempt.
+func (d *WebhookDispatcher) deliver(ctx context.Context, job webhookJob) WebhookDelivery {
+	delivery := WebhookDelivery{
+		EventID:   job.event.ID,
+		EventType: job.event.Type,
+		URL:       job.hook.URL,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	for attempt := 1; ; attempt++ {
+		status, wait, err := d.post(ctx, job)
+		delivery.Attempts = attempt
+		delivery.StatusCode = status
+		delivery.Error = ""
+		if err == nil {
+			break
+		}
+		delivery.Error = err.Error()
+
+		if attempt >= d.retry.maxAttempts() || !retryableDelivery(status) {
+			break
+		}
+		if sleep(ctx, d.retry.wait(attempt, wait)) != nil {
+			break
+		}
+	}
+
+	delivery.CompletedAt = time.Now().Unix()
+	delivery.Delivered = delivery.Error == ""
+	if !delivery.Delivered {
+		d.logger.Warn(errMsgDeliverWebhook, "error", delivery.Error, "event", job.event.ID, "type", job.event.Type, "url", job.hook.URL, "attempts", delivery.Attempts)
+	}
+	return delivery
+}
+
+// Sends one signed request posting an event to a webhook.
+//
+// Returns the response status, or zero if no response was received, and the
+// delay requested by a Retry-After header.
+func (d *WebhookDispatcher) post(ctx context.Context, job webhookJob) (int, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.hook.URL, bytes.NewReader(job.payload))
+	if err != nil {
+		return 0, 0, fmt.Errorf("create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", string(MediaTypeEvent)+"+json")
+	req.Header.Set(headerWebhookEvent, string(job.event.Type))
+	req.Header.Set(headerWebhookDelivery, job.event.ID)
+	req.Header.Set(headerWebhookTimestamp, timestamp)
+	req.Header.Set(headerWebhookSignature, signWebhook(job.hook.Secret, timestamp, job.payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("execute request: %w", err)
+	}
+	defer discard(resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, retryAfter(resp), fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+	return resp.StatusCode, 0, nil
+}
+
+// Records the outcome of a delivery in the delivery log.
+//
+// Failures are logged; the delivery itself is over either way.
+func (d *WebhookDispatcher) record(ctx context.Context, delivery *WebhookDelivery) {
+	args := []any{
+		delivery.EventID,
+		string(delivery.EventType),
+		delivery.URL,
+		delivery.Attempts,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.CreatedAt,
+		delivery.CompletedAt,
+	}
+
+	if d.dialect.useReturning() {
+		if err := d.db.QueryRowContext(ctx, d.dialect.insertReturningID(sqlWebhookDeliveriesInsert), args...).Scan(&delivery.ID); err != nil {
+			logError(d.logger, ErrorCodeInternalError, errMsgRecordDelivery, err, "event", delivery.EventID, "url", delivery.URL)
+		}
+		return
+	}
+
+	result, err := d.db.ExecContext(ctx, d.dialect.rebind(sqlWebhookDeliveriesInsert), args...)
+	if err != nil {
+		logError(d.logger, ErrorCodeInternalError, errMsgRecordDelivery, err, "event", delivery.EventID, "url", delivery.URL)
+		return
+	}
+	delivery.ID, _ = result.LastInsertId()
+}
+
+// Whether a delivery may be retried after a response with the given status.
+//
+// A zero status means no response was received.
+func retryableDelivery(status int) bool {
+	switch {
+	case status == 0:
+		return true
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return true
+	default:
+		return status >= 500
+	}
+}
+
+// Verifies a webhook request and decodes the event it carries.
+//
+// Checks the signature of the request against the secret of the webhook and
+// that its timestamp is within [DefaultWebhookTolerance] of now, so captured
+// requests cannot be replayed later. Returns [ErrWebhookSignature] if the
+// request was not signed with the secret or was altered, and
+// [ErrWebhookExpired] if it is too old or too far in the future. Reads and
+// closes the request body.
+func VerifyWebhook(req *http.Request, secret []byte, now time.Time) (*Event, error) {
+	defer req.Body.Close()
+
+	payload, err := io.ReadAll(io.LimitReader(req.Body, maxWebhookPayload))
+	if err != nil {
+		return nil, fmt.Errorf("read webhook payload: %w", err)
+	}
+
+	timestamp := req.Header.Get(headerWebhookTimestamp)
+	signature, ok := strings.CutPrefix(req.Header.Get(headerWebhookSignature), webhookSignaturePrefix)
+	if !ok {
+		return nil, ErrWebhookSignature
+	}
+
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return nil, ErrWebhookSignature
+	}
+	want, _ := hex.DecodeString(strings.TrimPrefix(signWebhook(secret, timestamp, payload), webhookSignaturePrefix))
+	if !hmac.Equal(got, want) {
+		return nil, ErrWebhookSignature
+	}
+
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrWebhookSignature
+	}
+	if age := now.Sub(time.Unix(sent, 0)); age > DefaultWebhookTolerance || age < -DefaultWebhookTolerance {
+		return nil, ErrWebhookExpired
+	}
+
+	event, err := decodeEvent(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("decode webhook payload: %w", err)
+	}
+	return event, nil
+}
+
+// Returns the signature header of a webhook payload sent at a timestamp.
+func signWebhook(secret []byte, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,277 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookDispatcher_Deliver(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	secret := []byte("secret")
+	var mu sync.Mutex
+	var received []Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event, err := VerifyWebhook(r, secret, time.Now())
+		if err != nil {
+			t.Errorf("VerifyWebhook() error = %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got := r.Header.Get("X-Crucible-Event"); got != string(event.Type) {
+			t.Errorf("X-Crucible-Event = %q, want %q", got, event.Type)
+		}
+		if got := r.Header.Get("X-Crucible-Delivery"); got != event.ID {
+			t.Errorf("X-Crucible-Delivery = %q, want %q", got, event.ID)
+		}
+		mu.Lock()
+		received = append(received, *event)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	dispatcher, err := NewWebhookDispatcher(ctx, registry.db, []Webhook{{
+		URL:    server.URL,
+		Secret: secret,
+		Filter: EventFilter{Types: []EventType{EventChannelMoved}},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("NewWebhookDispatcher() error = %v", err)
+	}
+	registry.Subscribe(dispatcher)
+
+	_, _ = registry.CreateNamespace(ctx, NamespaceInfo{Name: "test-ns"})
+	_, _ = registry.CreateResource(ctx, "test-ns", ResourceInfo{Name: "app", Type: "widget"})
+	_, _ = registry.CreateVersion(ctx, "test-ns", "app", VersionInfo{String: "1.0.0"})
+	_, _ = registry.CreateChannel(ctx, "test-ns", "app", ChannelInfo{Name: "stable", Version: "1.0.0"})
+
+	if err := dispatcher.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 event, got %d: %+v", len(received), received)
+	}
+	if received[0].Channel != "stable" || received[0].Version != "1.0.0" {
+		t.Errorf("unexpected event: %+v", received[0])
+	}
+
+	list, err := dispatcher.ListDeliveries(ctx, WebhookDeliveryFilter{})
+	if err != nil {
+		t.Fatalf("ListDeliveries() error = %v", err)
+	}
+	if len(list.Deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(list.Deliveries))
+	}
+	delivery := list.Deliveries[0]
+	if !delivery.Delivered || delivery.Attempts != 1 || delivery.StatusCode != http.StatusOK {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+	if delivery.EventID != received[0].ID || delivery.EventType != EventChannelMoved || delivery.URL != server.URL {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+
+	// Closed dispatchers drop events
+	dispatcher.HandleEvent(received[0])
+}
+
+func TestWebhookDispatcher_Retry(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	dispatcher, err := NewWebhookDispatcherWithOptions(ctx, registry.db, []Webhook{{URL: server.URL, Secret: []byte("secret")}}, nil, &WebhookOptions{
+		Retry: &RetryPolicy{InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewWebhookDispatcherWithOptions() error = %v", err)
+	}
+
+	dispatcher.HandleEvent(Event{ID: "event-1", Type: EventNamespaceCreated, Namespace: "test-ns"})
+	if err := dispatcher.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	list, err := dispatcher.ListDeliveries(ctx, WebhookDeliveryFilter{EventID: "event-1"})
+	if err != nil {
+		t.Fatalf("ListDeliveries() error = %v", err)
+	}
+	if len(list.Deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(list.Deliveries))
+	}
+	if delivery := list.Deliveries[0]; !delivery.Delivered || delivery.Attempts != 3 {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+}
+
+func TestWebhookDispatcher_SlowWebhook(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer slow.Close()
+
+	delivered := make(chan struct{}, 1)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer fast.Close()
+
+	ctx := context.Background()
+	hooks := []Webhook{
+		{URL: slow.URL, Secret: []byte("secret")},
+		{URL: fast.URL, Secret: []byte("secret")},
+	}
+	dispatcher, err := NewWebhookDispatcherWithOptions(ctx, registry.db, hooks, nil, &WebhookOptions{
+		Retry: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewWebhookDispatcherWithOptions() error = %v", err)
+	}
+
+	dispatcher.HandleEvent(Event{ID: "event-1", Type: EventNamespaceCreated})
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a slow webhook not to delay other webhooks")
+	}
+	close(release)
+
+	// Retry-After is capped at MaxBackoff, so closing does not wait an hour
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := dispatcher.Close(closeCtx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	list, err := dispatcher.ListDeliveries(ctx, WebhookDeliveryFilter{Failed: true})
+	if err != nil {
+		t.Fatalf("ListDeliveries() error = %v", err)
+	}
+	if len(list.Deliveries) != 1 || list.Deliveries[0].URL != slow.URL || list.Deliveries[0].Attempts != 2 {
+		t.Errorf("unexpected deliveries: %+v", list.Deliveries)
+	}
+}
+
+func TestWebhookDispatcher_Failed(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	dispatcher, err := NewWebhookDispatcher(ctx, registry.db, []Webhook{{URL: server.URL, Secret: []byte("secret")}}, nil)
+	if err != nil {
+		t.Fatalf("NewWebhookDispatcher() error = %v", err)
+	}
+
+	dispatcher.HandleEvent(Event{ID: "event-1", Type: EventNamespaceCreated})
+	dispatcher.HandleEvent(Event{ID: "event-2", Type: EventNamespaceCreated})
+	if err := dispatcher.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("expected client errors not to be retried, got %d attempts", attempts)
+	}
+
+	list, err := dispatcher.ListDeliveries(ctx, WebhookDeliveryFilter{Failed: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("ListDeliveries() error = %v", err)
+	}
+	if len(list.Deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(list.Deliveries))
+	}
+	delivery := list.Deliveries[0]
+	if delivery.Delivered || delivery.EventID != "event-2" || delivery.StatusCode != http.StatusBadRequest || delivery.Error == "" {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+}
+
+func TestNewWebhookDispatcher_InvalidWebhook(t *testing.T) {
+	registry, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name string
+		hook Webhook
+	}{
+		{"relative URL", Webhook{URL: "/hooks", Secret: []byte("secret")}},
+		{"unsupported scheme", Webhook{URL: "ftp://example.com/hooks", Secret: []byte("secret")}},
+		{"no secret", Webhook{URL: "https://example.com/hooks"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebhookDispatcher(context.Background(), registry.db, []Webhook{tt.hook}, nil)
+			if regErr, ok := err.(*Error); !ok || regErr.Code != ErrorCodeBadRequest {
+				t.Errorf("expected bad request, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	secret := []byte("secret")
+	payload, _ := encodeEvent(Event{ID: "event-1", Type: EventChannelMoved, Channel: "stable"})
+	now := time.Unix(1700000000, 0)
+
+	request := func(body []byte, sent time.Time, key []byte) *http.Request {
+		timestamp := strconv.FormatInt(sent.Unix(), 10)
+		req := httptest.NewRequest(http.MethodPost, "/hooks", bytes.NewReader(body))
+		req.Header.Set("X-Crucible-Timestamp", timestamp)
+		req.Header.Set("X-Crucible-Signature", signWebhook(key, timestamp, payload))
+		return req
+	}
+
+	event, err := VerifyWebhook(request(payload, now, secret), secret, now)
+	if err != nil {
+		t.Fatalf("VerifyWebhook() error = %v", err)
+	}
+	if event.ID != "event-1" || event.Channel != "stable" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	if _, err := VerifyWebhook(request(payload, now, []byte("other")), secret, now); err != ErrWebhookSignature {
+		t.Errorf("wrong secret: expected ErrWebhookSignature, got %v", err)
+	}
+	tampered := bytes.Replace(payload, []byte("stable"), []byte("latest"), 1)
+	if _, err := VerifyWebhook(request(tampered, now, secret), secret, now); err != ErrWebhookSignature {
+		t.Errorf("tampered payload: expected ErrWebhookSignature, got %v", err)
+	}
+	if _, err := VerifyWebhook(request(payload, now.Add(-10*time.Minute), secret), secret, now); err != ErrWebhookExpired {
+		t.Errorf("old request: expected ErrWebhookExpired, got %v", err)
+	}
+
+	req := request(payload, now, secret)
+	req.Header.Del("X-Crucible-Signature")
+	if _, err := VerifyWebhook(req, secret, now); err != ErrWebhookSignature {
+		t.Errorf("unsigned request: expected ErrWebhookSignature, got %v", err)
+	}
+}